@@ -18,9 +18,12 @@ package main
 
 import (
 	"encoding/json"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/minio/minio-xl/pkg/probe"
@@ -28,13 +31,38 @@ import (
 
 type accessLogHandler struct {
 	http.Handler
-	accessLogFile *os.File
+	accessLogFile io.Writer
+	sample        float64 // fraction of successful requests logged, all of them if zero
+	mutex         sync.Mutex
+	random        *rand.Rand
+}
+
+// statusRecorder - response writer remembering the status a response was written with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(p)
 }
 
 // LogMessage is a serializable json log message
 type LogMessage struct {
+	RequestID     string
 	StartTime     time.Time
 	Duration      time.Duration
+	StatusCode    int
 	StatusMessage string // human readable http status message
 	ContentLength string // human readable content length
 
@@ -59,17 +87,40 @@ type LogMessage struct {
 }
 
 func (h *accessLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	message, perr := getLogMessage(w, req)
+	startTime := time.Now().UTC()
+	recorder := &statusRecorder{ResponseWriter: w}
+	h.Handler.ServeHTTP(recorder, req)
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if !h.sampled(recorder.status) {
+		return
+	}
+
+	message, perr := getLogMessage(w, req, startTime, recorder.status)
 	fatalIf(perr.Trace(), "Unable to extract http message.", nil)
+	h.mutex.Lock()
 	_, err := h.accessLogFile.Write(message)
+	h.mutex.Unlock()
 	fatalIf(probe.NewError(err), "Writing to log file failed.", nil)
+}
 
-	h.Handler.ServeHTTP(w, req)
+// sampled - whether a request answered with status is logged, errors always are
+func (h *accessLogHandler) sampled(status int) bool {
+	if status >= http.StatusBadRequest || h.sample <= 0 || h.sample >= 1 {
+		return true
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.random.Float64() < h.sample
 }
 
-func getLogMessage(w http.ResponseWriter, req *http.Request) ([]byte, *probe.Error) {
+func getLogMessage(w http.ResponseWriter, req *http.Request, startTime time.Time, status int) ([]byte, *probe.Error) {
 	logMessage := &LogMessage{
-		StartTime: time.Now().UTC(),
+		RequestID:     w.Header().Get(requestIDHeader),
+		StartTime:     startTime,
+		StatusCode:    status,
+		StatusMessage: http.StatusText(status),
 	}
 	// store lower level details
 	logMessage.HTTP.ResponseHeaders = w.Header()
@@ -102,7 +153,7 @@ func getLogMessage(w http.ResponseWriter, req *http.Request) ([]byte, *probe.Err
 	}
 
 	// logMessage.HTTP.Request = req
-	logMessage.Duration = time.Now().UTC().Sub(logMessage.StartTime)
+	logMessage.Duration = time.Now().UTC().Sub(startTime)
 	js, err := json.Marshal(logMessage)
 	if err != nil {
 		return nil, probe.NewError(err)
@@ -111,10 +162,23 @@ func getLogMessage(w http.ResponseWriter, req *http.Request) ([]byte, *probe.Err
 	return js, nil
 }
 
-// AccessLogHandler logs requests
-func AccessLogHandler(h http.Handler) http.Handler {
+// AccessLogHandler logs requests once served, sample is the fraction of successful requests
+// logged while requests failing with 4xx or 5xx are always logged
+func AccessLogHandler(sample float64) MiddlewareHandler {
 	file, err := os.OpenFile("access.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
 	fatalIf(probe.NewError(err), "Unable to open access log.", nil)
 
-	return &accessLogHandler{Handler: h, accessLogFile: file}
+	return newAccessLogHandler(file, sample)
+}
+
+// newAccessLogHandler - handler logging sampled requests to accessLogFile
+func newAccessLogHandler(accessLogFile io.Writer, sample float64) MiddlewareHandler {
+	return func(h http.Handler) http.Handler {
+		return &accessLogHandler{
+			Handler:       h,
+			accessLogFile: accessLogFile,
+			sample:        sample,
+			random:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+	}
 }
@@ -0,0 +1,79 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+type AccessLogHandlerSuite struct{}
+
+var _ = Suite(&AccessLogHandlerSuite{})
+
+func (s *AccessLogHandlerSuite) TestSample(c *C) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			writeErrorResponse(w, r, NoSuchKey, r.URL.Path)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("ok"))
+		}
+	})
+	var accessLog bytes.Buffer
+	sampled := newAccessLogHandler(&accessLog, 0.1)(handler)
+
+	// 4000 successes at 10% are logged 400 times on average, the tolerance is about five standard deviations
+	const requests = 4000
+	for _, path := range []string{"/object", "/missing", "/broken"} {
+		request, err := http.NewRequest("GET", path, nil)
+		c.Assert(err, IsNil)
+		for i := 0; i < requests; i++ {
+			w := httptest.NewRecorder()
+			sampled.ServeHTTP(w, request)
+		}
+	}
+
+	logged := make(map[int]int)
+	scanner := bufio.NewScanner(&accessLog)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message LogMessage
+		c.Assert(json.Unmarshal(scanner.Bytes(), &message), IsNil)
+		c.Assert(message.StatusMessage, Equals, http.StatusText(message.StatusCode))
+		logged[message.StatusCode]++
+	}
+	c.Assert(scanner.Err(), IsNil)
+	c.Assert(logged[http.StatusNotFound], Equals, requests)
+	c.Assert(logged[http.StatusInternalServerError], Equals, requests)
+	c.Assert(logged[http.StatusOK] > 300 && logged[http.StatusOK] < 500, Equals, true, Commentf("%d of %d successes logged", logged[http.StatusOK], requests))
+
+	// every request is logged without sampling
+	accessLog.Reset()
+	request, err := http.NewRequest("GET", "/object", nil)
+	c.Assert(err, IsNil)
+	newAccessLogHandler(&accessLog, 1)(handler).ServeHTTP(httptest.NewRecorder(), request)
+	newAccessLogHandler(&accessLog, 1)(handler).ServeHTTP(httptest.NewRecorder(), request)
+	c.Assert(bytes.Count(accessLog.Bytes(), []byte("\n")), Equals, 2)
+}
@@ -0,0 +1,391 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// adminPathPrefix - admin API path prefix, '_' is not allowed in bucket names hence this
+// never collides with a bucket
+const adminPathPrefix = "/_minio/admin"
+
+// StorageInfoHandler - GET storage statistics
+// ----------
+// This implementation returns disk usage of the export path along with bucket and
+// object counts as JSON, only for authenticated requests
+func (api CloudStorageAPI) StorageInfoHandler(w http.ResponseWriter, req *http.Request) {
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	storageInfo, err := api.ObjectAPI.GetStorageInfo()
+	if err != nil {
+		errorIf(err.Trace(), "GetStorageInfo failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	encodedSuccessResponse, e := json.Marshal(StorageInfoResponse{
+		Total:   storageInfo.Total,
+		Free:    storageInfo.Free,
+		Used:    storageInfo.Used,
+		Buckets: storageInfo.Buckets,
+		Objects: storageInfo.Objects,
+	})
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding storage info failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
+
+// MaintenanceModeHandler - GET, PUT or DELETE maintenance mode
+// ----------
+// PUT puts the server into maintenance mode where all mutating requests are rejected
+// with ServiceUnavailable, DELETE brings it back to normal. All methods reply with
+// the current state as JSON, only for authenticated requests
+func (api CloudStorageAPI) MaintenanceModeHandler(w http.ResponseWriter, req *http.Request) {
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	switch req.Method {
+	case "PUT":
+		setMaintenanceMode(true)
+	case "DELETE":
+		setMaintenanceMode(false)
+	}
+	encodedSuccessResponse, e := json.Marshal(MaintenanceModeResponse{
+		Enabled: isMaintenanceMode(),
+	})
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding maintenance mode failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
+
+// BucketCompressionHandler - GET, PUT or DELETE bucket compression
+// ----------
+// PUT enables at rest compression for objects created in the bucket from now on, the
+// algorithm is taken from 'algorithm' query, either gzip (default) or zstd. DELETE disables it,
+// objects already stored compressed stay readable. Range reads of compressed objects decompress
+// everything before the range. All methods reply with the current setting as JSON, only for
+// authenticated requests
+func (api CloudStorageAPI) BucketCompressionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	var err *probe.Error
+	switch req.Method {
+	case "PUT":
+		algorithm := req.URL.Query().Get("algorithm")
+		if algorithm == "" {
+			algorithm = fs.CompressionGzip
+		}
+		err = api.ObjectAPI.SetBucketCompression(bucket, algorithm)
+	case "DELETE":
+		err = api.ObjectAPI.SetBucketCompression(bucket, "")
+	}
+	if err != nil {
+		errorIf(err.Trace(), "SetBucketCompression failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidCompression, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	encodedSuccessResponse, e := json.Marshal(BucketCompressionResponse{
+		Bucket:      bucket,
+		Compression: bucketMetadata.Compression,
+	})
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding bucket compression failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
+
+// BucketEncryptionHandler - GET, PUT or DELETE bucket encryption
+// ----------
+// PUT enables server side encryption for objects created in the bucket from now on, the
+// algorithm is taken from 'algorithm' query and defaults to AES256. DELETE disables it,
+// objects already stored encrypted stay readable. All methods reply with the current
+// setting as JSON, only for authenticated requests
+func (api CloudStorageAPI) BucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	var err *probe.Error
+	switch req.Method {
+	case "PUT":
+		algorithm := req.URL.Query().Get("algorithm")
+		if algorithm == "" {
+			algorithm = fs.EncryptionAES256
+		}
+		err = api.ObjectAPI.SetBucketEncryption(bucket, algorithm)
+	case "DELETE":
+		err = api.ObjectAPI.SetBucketEncryption(bucket, "")
+	}
+	if err != nil {
+		errorIf(err.Trace(), "SetBucketEncryption failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidEncryption, req.URL.Path)
+		case fs.InvalidMasterKey:
+			writeErrorResponse(w, req, MissingMasterKey, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	encodedSuccessResponse, e := json.Marshal(BucketEncryptionResponse{
+		Bucket:     bucket,
+		Encryption: bucketMetadata.Encryption,
+	})
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding bucket encryption failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
+
+// BucketRetentionHandler - GET, PUT or DELETE bucket retention
+// ----------
+// PUT locks objects created in the bucket from now on for the duration taken from
+// 'retention' query, DELETE stops locking new objects. Objects already locked stay
+// locked until their own retention passes. All methods reply with the current setting
+// as JSON, only for authenticated requests
+func (api CloudStorageAPI) BucketRetentionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	var err *probe.Error
+	switch req.Method {
+	case "PUT":
+		retention, e := time.ParseDuration(req.URL.Query().Get("retention"))
+		if e != nil || retention <= 0 {
+			writeErrorResponse(w, req, InvalidRetention, req.URL.Path)
+			return
+		}
+		err = api.ObjectAPI.SetBucketRetention(bucket, retention)
+	case "DELETE":
+		err = api.ObjectAPI.SetBucketRetention(bucket, 0)
+	}
+	if err != nil {
+		errorIf(err.Trace(), "SetBucketRetention failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidRetention, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	response := BucketRetentionResponse{Bucket: bucket}
+	if bucketMetadata.Retention > 0 {
+		response.Retention = bucketMetadata.Retention.String()
+	}
+	encodedSuccessResponse, e := json.Marshal(response)
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding bucket retention failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
+
+// BucketNotificationHandler - GET, PUT or DELETE bucket notification
+// ----------
+// PUT posts events of objects created in or removed from the bucket from now on to the
+// webhook url taken from 'webhook' query, DELETE stops posting them. All methods reply
+// with the current setting as JSON, only for authenticated requests
+func (api CloudStorageAPI) BucketNotificationHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	var err *probe.Error
+	switch req.Method {
+	case "PUT":
+		webhook := req.URL.Query().Get("webhook")
+		if webhook == "" {
+			writeErrorResponse(w, req, InvalidNotification, req.URL.Path)
+			return
+		}
+		err = api.ObjectAPI.SetBucketNotification(bucket, webhook)
+	case "DELETE":
+		err = api.ObjectAPI.SetBucketNotification(bucket, "")
+	}
+	if err != nil {
+		errorIf(err.Trace(), "SetBucketNotification failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidNotification, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	encodedSuccessResponse, e := json.Marshal(BucketNotificationResponse{
+		Bucket:  bucket,
+		Webhook: bucketMetadata.Notification,
+	})
+	if e != nil {
+		errorIf(probe.NewError(e), "Encoding bucket notification failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	w.Header().Set("Content-Type", "application/json")
+	// write response
+	w.Write(encodedSuccessResponse)
+}
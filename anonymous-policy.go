@@ -0,0 +1,69 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// anonymousReadAll - anonymous read policy entry naming every bucket
+const anonymousReadAll = "*"
+
+// anonymousReadPolicy - buckets whose objects may be listed and read without a signature
+// whatever their ACL, writes still need a signature unless the bucket ACL allows them
+type anonymousReadPolicy struct {
+	all     bool
+	buckets map[string]struct{}
+}
+
+// parseAnonymousReadPolicy - parse comma separated bucket names, '*' names every bucket
+func parseAnonymousReadPolicy(value string) (anonymousReadPolicy, *probe.Error) {
+	policy := anonymousReadPolicy{buckets: make(map[string]struct{})}
+	if strings.TrimSpace(value) == "" {
+		return policy, nil
+	}
+	for _, bucket := range strings.Split(value, ",") {
+		bucket = strings.TrimSpace(bucket)
+		if bucket == anonymousReadAll {
+			policy.all = true
+			continue
+		}
+		if !fs.IsValidBucket(bucket) {
+			return anonymousReadPolicy{}, probe.NewError(errInvalidArgument).Trace(bucket)
+		}
+		policy.buckets[bucket] = struct{}{}
+	}
+	return policy, nil
+}
+
+// allowsRead - true if anonymous reads are allowed on bucket
+func (p anonymousReadPolicy) allowsRead(bucket string) bool {
+	if p.all {
+		return true
+	}
+	_, ok := p.buckets[bucket]
+	return ok
+}
+
+// isAnonymousReadDenied - true if an unsigned read request on bucket is denied, neither the
+// bucket ACL nor the anonymous read policy allows it
+func (api CloudStorageAPI) isAnonymousReadDenied(bucket string) bool {
+	return api.ObjectAPI.IsPrivateBucket(bucket) && !api.AnonymousRead.allowsRead(bucket)
+}
@@ -194,9 +194,144 @@ type CompleteMultipartUploadResponse struct {
 	ETag     string
 }
 
+// CopyObjectResponse container for copy object response
+type CopyObjectResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult" json:"-"`
+
+	LastModified string
+	ETag         string
+}
+
+// Tag - key and value of an object tag
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// TagSet - tags of an object, as sent and returned by object tagging requests
+type TagSet struct {
+	Tag []Tag
+}
+
+// ObjectTaggingRequest - format for put object tagging request
+type ObjectTaggingRequest struct {
+	TagSet TagSet
+}
+
+// ObjectTaggingResponse - format for get object tagging response
+type ObjectTaggingResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ Tagging" json:"-"`
+	TagSet  TagSet
+}
+
+// VersioningConfigurationRequest - format for put bucket versioning request
+type VersioningConfigurationRequest struct {
+	Status string
+}
+
+// VersioningConfigurationResponse - format for get bucket versioning response, buckets
+// versioning was never enabled on have no status
+type VersioningConfigurationResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ VersioningConfiguration" json:"-"`
+	Status  string   `xml:",omitempty"`
+}
+
+// ServerSideEncryptionByDefault - algorithm objects created in a bucket are encrypted with
+type ServerSideEncryptionByDefault struct {
+	SSEAlgorithm string
+}
+
+// ServerSideEncryptionRule - rule of a bucket encryption configuration
+type ServerSideEncryptionRule struct {
+	ApplyServerSideEncryptionByDefault ServerSideEncryptionByDefault
+}
+
+// ServerSideEncryptionConfigurationRequest - format for put bucket encryption request
+type ServerSideEncryptionConfigurationRequest struct {
+	Rules []ServerSideEncryptionRule `xml:"Rule"`
+}
+
+// ServerSideEncryptionConfigurationResponse - format for get bucket encryption response
+type ServerSideEncryptionConfigurationResponse struct {
+	XMLName xml.Name                   `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ServerSideEncryptionConfiguration" json:"-"`
+	Rules   []ServerSideEncryptionRule `xml:"Rule"`
+}
+
+// StorageInfoResponse - format for storage info admin response
+type StorageInfoResponse struct {
+	Total   int64 `json:"total"`
+	Free    int64 `json:"free"`
+	Used    int64 `json:"used"`
+	Buckets int64 `json:"buckets"`
+	Objects int64 `json:"objects"`
+}
+
+// MaintenanceModeResponse - format for maintenance mode admin response
+type MaintenanceModeResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// BucketCompressionResponse - format for bucket compression admin response
+type BucketCompressionResponse struct {
+	Bucket      string `json:"bucket"`
+	Compression string `json:"compression"`
+}
+
+// BucketEncryptionResponse - format for bucket encryption admin response
+type BucketEncryptionResponse struct {
+	Bucket     string `json:"bucket"`
+	Encryption string `json:"encryption"`
+}
+
+// BucketRetentionResponse - format for bucket retention admin response
+type BucketRetentionResponse struct {
+	Bucket    string `json:"bucket"`
+	Retention string `json:"retention"` // default retention of new objects, empty if disabled
+}
+
+// BucketNotificationResponse - format for bucket notification admin response
+type BucketNotificationResponse struct {
+	Bucket  string `json:"bucket"`
+	Webhook string `json:"webhook"` // url object events are posted to, empty if disabled
+}
+
+// NotificationEvent - format for object events posted to bucket webhooks, resembles S3 event notifications
+type NotificationEvent struct {
+	Records []NotificationEventRecord `json:"Records"`
+}
+
+// NotificationEventRecord - a single object event
+type NotificationEventRecord struct {
+	EventVersion string              `json:"eventVersion"`
+	EventSource  string              `json:"eventSource"`
+	AwsRegion    string              `json:"awsRegion"`
+	EventTime    string              `json:"eventTime"`
+	EventName    string              `json:"eventName"`
+	S3           NotificationEventS3 `json:"s3"`
+}
+
+// NotificationEventS3 - bucket and object of an event
+type NotificationEventS3 struct {
+	SchemaVersion string                  `json:"s3SchemaVersion"`
+	Bucket        NotificationEventBucket `json:"bucket"`
+	Object        NotificationEventObject `json:"object"`
+}
+
+// NotificationEventBucket - bucket of an event
+type NotificationEventBucket struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn"`
+}
+
+// NotificationEventObject - object of an event, size and etag are empty for removed objects
+type NotificationEventObject struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size,omitempty"`
+	ETag string `json:"eTag,omitempty"`
+}
+
 // List of not implemented bucket queries
 var notimplementedBucketResourceNames = map[string]bool{
-	"policy":         true,
 	"cors":           true,
 	"lifecycle":      true,
 	"location":       true,
@@ -206,7 +341,6 @@ var notimplementedBucketResourceNames = map[string]bool{
 	"tagging":        true,
 	"versions":       true,
 	"requestPayment": true,
-	"versioning":     true,
 	"website":        true,
 }
 
@@ -69,9 +69,37 @@ const (
 	InvalidPart
 	InvalidPartOrder
 	AuthorizationHeaderMalformed
+	AuthorizationQueryParametersError
 	MalformedPOSTRequest
 	BucketNotEmpty
 	RootPathFull
+	InvalidCopySource
+	InvalidCopyDest
+	InvalidMetadataDirective
+	ServiceUnavailable
+	InvalidCompression
+	InvalidEncryption
+	MissingMasterKey
+	InvalidRetention
+	InvalidNotification
+	InvalidStorageClass
+	TooManyMultipartUploads
+	InvalidResponseOverride
+	AnonymousResponseOverride
+	InvalidTrailer
+	PreconditionFailed
+	InvalidTag
+	NoSuchBucketPolicy
+	MalformedPolicy
+	SlowDown
+	InvalidFolderObject
+	RequestBodyTooLarge
+	NoSuchVersion
+	InvalidVersionID
+	IllegalVersioningConfiguration
+	NoSuchEncryptionConfiguration
+	OperationAborted
+	KeyTooLongError
 )
 
 // APIError code to Error structure map
@@ -96,6 +124,21 @@ var errorCodeResponse = map[int]APIError{
 		Description:    "Argument partNumberMarker must be an integer.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	InvalidCopySource: {
+		Code:           "InvalidArgument",
+		Description:    "Copy Source must mention the source bucket and key: sourcebucket/sourcekey.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidMetadataDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown metadata directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidCopyDest: {
+		Code:           "InvalidRequest",
+		Description:    "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	AccessDenied: {
 		Code:           "AccessDenied",
 		Description:    "Access Denied.",
@@ -213,7 +256,12 @@ var errorCodeResponse = map[int]APIError{
 	},
 	AuthorizationHeaderMalformed: {
 		Code:           "AuthorizationHeaderMalformed",
-		Description:    "The authorization header is malformed; the region is wrong; expecting 'milkyway'.",
+		Description:    "The authorization header is malformed; the region is wrong.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	AuthorizationQueryParametersError: {
+		Code:           "AuthorizationQueryParametersError",
+		Description:    "Error parsing the X-Amz-Credential parameter; the region is wrong.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
 	MalformedPOSTRequest: {
@@ -231,6 +279,126 @@ var errorCodeResponse = map[int]APIError{
 		Description:    "Root path has reached its minimum free disk threshold. Please delete few objects to proceed.",
 		HTTPStatusCode: http.StatusInternalServerError,
 	},
+	ServiceUnavailable: {
+		Code:           "ServiceUnavailable",
+		Description:    "Server is in maintenance mode, retry later.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	InvalidCompression: {
+		Code:           "InvalidArgument",
+		Description:    "Unsupported compression algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidEncryption: {
+		Code:           "InvalidArgument",
+		Description:    "Unsupported encryption algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	MissingMasterKey: {
+		Code:           "InvalidRequest",
+		Description:    "Server side encryption requires a master key to be configured.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidRetention: {
+		Code:           "InvalidArgument",
+		Description:    "Object lock retention should be a time in the future or a positive duration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidNotification: {
+		Code:           "InvalidArgument",
+		Description:    "Notification webhook should be an absolute http or https url.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidStorageClass: {
+		Code:           "InvalidStorageClass",
+		Description:    "The storage class you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	TooManyMultipartUploads: {
+		Code:           "SlowDown",
+		Description:    "Too many multipart uploads in progress on this bucket, complete or abort some of them first.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	SlowDown: {
+		Code:           "SlowDown",
+		Description:    "Too many uploads in progress on this server, please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	InvalidFolderObject: {
+		Code:           "InvalidArgument",
+		Description:    "Objects ending in a slash are kept as empty folders, which cannot have data or replace an object of the same name.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	RequestBodyTooLarge: {
+		Code:           "MaxMessageLengthExceeded",
+		Description:    "Your request was too big.",
+		HTTPStatusCode: http.StatusRequestEntityTooLarge,
+	},
+	NoSuchVersion: {
+		Code:           "NoSuchVersion",
+		Description:    "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	InvalidVersionID: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid version id specified",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	IllegalVersioningConfiguration: {
+		Code:           "IllegalVersioningConfigurationException",
+		Description:    "The versioning configuration specified in the request is invalid, the status should be Enabled or Suspended.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	NoSuchEncryptionConfiguration: {
+		Code:           "ServerSideEncryptionConfigurationNotFoundError",
+		Description:    "The server side encryption configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	InvalidResponseOverride: {
+		Code:           "InvalidArgument",
+		Description:    "Response header overrides should be valid values of their header.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	AnonymousResponseOverride: {
+		Code:           "InvalidRequest",
+		Description:    "Request specific response headers cannot be used for anonymous GET requests.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidTrailer: {
+		Code:           "InvalidArgument",
+		Description:    "The x-amz-trailer header declares an unsupported checksum algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	PreconditionFailed: {
+		Code:           "PreconditionFailed",
+		Description:    "At least one of the pre-conditions you specified did not hold.",
+		HTTPStatusCode: http.StatusPreconditionFailed,
+	},
+	InvalidTag: {
+		Code:           "InvalidTag",
+		Description:    "The tag provided was not a valid tag. Objects carry at most 10 tags, with keys of up to 128 and values of up to 256 characters.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	NoSuchBucketPolicy: {
+		Code:           "NoSuchBucketPolicy",
+		Description:    "The bucket policy does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	MalformedPolicy: {
+		Code:           "MalformedPolicy",
+		Description:    "Policy has invalid or unsupported elements. Statements allow or deny s3:GetObject and s3:PutObject on objects of the bucket, without conditions.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	OperationAborted: {
+		Code:           "OperationAborted",
+		Description:    "A conflicting conditional operation is currently in progress against this resource. Try again.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	KeyTooLongError: {
+		Code:           "KeyTooLongError",
+		Description:    "Your key is too long.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 }
 
 // errorCodeError provides errorCode to Error. It returns empty if the code provided is unknown
@@ -240,15 +408,15 @@ func getErrorCode(code int) APIError {
 
 // getErrorResponse gets in standard error and resource value and
 // provides a encodable populated response values
-func getErrorResponse(err APIError, resource string) APIErrorResponse {
+func getErrorResponse(err APIError, resource, requestID string) APIErrorResponse {
 	var data = APIErrorResponse{}
 	data.Code = err.Code
 	data.Message = err.Description
 	if resource != "" {
 		data.Resource = resource
 	}
+	data.RequestID = requestID
 	// TODO implement this in future
-	data.RequestID = "3L137"
 	data.HostID = "3L137"
 
 	return data
@@ -20,9 +20,12 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/xml"
+	"mime"
 	"net/http"
+	"net/url"
 	"runtime"
 	"strconv"
+	"time"
 
 	"github.com/minio/minio/pkg/fs"
 )
@@ -42,10 +45,94 @@ func generateRequestID() []byte {
 	return alpha
 }
 
+// requestIDHeader - response header carrying the id assigned to each request
+const requestIDHeader = "X-Amz-Request-Id"
+
+// retainUntilHeader - time until which an object is locked, as named by S3 object lock
+const retainUntilHeader = "X-Amz-Object-Lock-Retain-Until-Date"
+
+// storageClassHeader - storage class an object is requested with and reported in
+const storageClassHeader = "X-Amz-Storage-Class"
+
+// taggingHeader, taggingCountHeader - URL query encoded tags an object is requested with, and
+// the number of tags it carries as reported on GET and HEAD
+const (
+	taggingHeader      = "X-Amz-Tagging"
+	taggingCountHeader = "X-Amz-Tagging-Count"
+)
+
+// versionIDHeader - version id of an object in a bucket versioning was ever enabled on
+const versionIDHeader = "X-Amz-Version-Id"
+
+// serverSideEncryptionHeader - algorithm an object is stored encrypted with, as named by S3
+const serverSideEncryptionHeader = "X-Amz-Server-Side-Encryption"
+
+// responseOverrideHeaders - query parameters of a GET overriding response headers, as named by S3
+var responseOverrideHeaders = map[string]string{
+	"response-content-type":        "Content-Type",
+	"response-content-language":    "Content-Language",
+	"response-expires":             "Expires",
+	"response-cache-control":       "Cache-Control",
+	"response-content-disposition": "Content-Disposition",
+	"response-content-encoding":    "Content-Encoding",
+}
+
+// getResponseOverrides - response headers overridden by the query of a GET. Values are checked
+// against the syntax of their header and may not carry control characters, which would let a
+// presigned url inject headers of its own. False if any value is not valid.
+func getResponseOverrides(values url.Values) (map[string]string, bool) {
+	overrides := make(map[string]string)
+	for param, header := range responseOverrideHeaders {
+		paramValues, ok := values[param]
+		if !ok {
+			continue
+		}
+		if len(paramValues) != 1 || !isValidHeaderValue(paramValues[0]) {
+			return nil, false
+		}
+		value := paramValues[0]
+		switch header {
+		case "Content-Type", "Content-Disposition":
+			if _, _, err := mime.ParseMediaType(value); err != nil {
+				return nil, false
+			}
+		case "Expires":
+			if _, err := http.ParseTime(value); err != nil {
+				return nil, false
+			}
+		}
+		overrides[header] = value
+	}
+	return overrides, true
+}
+
+// isValidHeaderValue - true if value is not empty and carries no control characters
+func isValidHeaderValue(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if (value[i] < ' ' && value[i] != '\t') || value[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// getRequestID - returns the id assigned to this request, assigns a new one if none is set yet
+func getRequestID(w http.ResponseWriter) string {
+	requestID := w.Header().Get(requestIDHeader)
+	if requestID == "" {
+		requestID = string(generateRequestID())
+		w.Header().Set(requestIDHeader, requestID)
+	}
+	return requestID
+}
+
 // Write http common headers
 func setCommonHeaders(w http.ResponseWriter, contentLength int) {
 	// set unique request ID for each reply
-	w.Header().Set("X-Amz-Request-Id", string(generateRequestID()))
+	getRequestID(w)
 	w.Header().Set("Server", ("Minio/" + minioReleaseTag + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"))
 	w.Header().Set("Accept-Ranges", "bytes")
 	w.Header().Set("Connection", "close")
@@ -62,8 +149,8 @@ func encodeErrorResponse(response interface{}) []byte {
 	return bytesBuffer.Bytes()
 }
 
-// Write object header
-func setObjectHeaders(w http.ResponseWriter, metadata fs.ObjectMetadata, contentRange *httpRange) {
+// Write object header, overrides replace the object headers of the same name
+func setObjectHeaders(w http.ResponseWriter, metadata fs.ObjectMetadata, contentRange *httpRange, overrides map[string]string) {
 	// set common headers
 	if contentRange != nil {
 		if contentRange.length > 0 {
@@ -77,9 +164,27 @@ func setObjectHeaders(w http.ResponseWriter, metadata fs.ObjectMetadata, content
 	// set object headers
 	lastModified := metadata.Created.Format(http.TimeFormat)
 	// object related headers
-	w.Header().Set("Content-Type", "application/octet-stream")
+	contentType := metadata.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("ETag", "\""+metadata.Md5+"\"")
 	w.Header().Set("Last-Modified", lastModified)
+	if !metadata.RetainUntil.IsZero() {
+		w.Header().Set(retainUntilHeader, metadata.RetainUntil.Format(time.RFC3339))
+	}
+	if metadata.StorageClass != "" {
+		w.Header().Set(storageClassHeader, metadata.StorageClass)
+	}
+	if metadata.TagCount > 0 {
+		w.Header().Set(taggingCountHeader, strconv.Itoa(metadata.TagCount))
+	}
+	setVersionIDHeader(w, metadata)
+	setServerSideEncryptionHeader(w, metadata)
+	for header, value := range overrides {
+		w.Header().Set(header, value)
+	}
 
 	// set content range
 	if contentRange != nil {
@@ -90,6 +195,40 @@ func setObjectHeaders(w http.ResponseWriter, metadata fs.ObjectMetadata, content
 	}
 }
 
+// setVersionIDHeader - set the version id of an object written or read, objects of buckets
+// versioning was never enabled on have none
+func setVersionIDHeader(w http.ResponseWriter, metadata fs.ObjectMetadata) {
+	if metadata.VersionID != "" {
+		w.Header().Set(versionIDHeader, metadata.VersionID)
+	}
+}
+
+// setServerSideEncryptionHeader - advertise the encryption of an object written or read, objects
+// stored as is have none
+func setServerSideEncryptionHeader(w http.ResponseWriter, metadata fs.ObjectMetadata) {
+	if metadata.Encryption != "" {
+		w.Header().Set(serverSideEncryptionHeader, metadata.Encryption)
+	}
+}
+
+// Write not modified response headers
+func setNotModifiedHeaders(w http.ResponseWriter, metadata fs.ObjectMetadata) {
+	getRequestID(w)
+	w.Header().Set("Server", ("Minio/" + minioReleaseTag + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"))
+	w.Header().Set("ETag", "\""+metadata.Md5+"\"")
+	w.Header().Set("Last-Modified", metadata.Created.Format(http.TimeFormat))
+}
+
+// isObjectNotModified - verify conditional request headers against object metadata
+func isObjectNotModified(req *http.Request, metadata fs.ObjectMetadata) bool {
+	var ifModifiedSince time.Time
+	if date := req.Header.Get("If-Modified-Since"); date != "" {
+		// invalid dates are ignored, as per RFC 7232
+		ifModifiedSince, _ = http.ParseTime(date)
+	}
+	return fs.CheckPreconditions(metadata, req.Header.Get("If-None-Match"), ifModifiedSince)
+}
+
 func encodeSuccessResponse(response interface{}) []byte {
 	var bytesBuffer bytes.Buffer
 	e := xml.NewEncoder(&bytesBuffer)
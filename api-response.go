@@ -18,7 +18,9 @@ package main
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio/pkg/fs"
 )
 
@@ -110,7 +112,10 @@ func generateListObjectsResponse(bucket string, objects []fs.ObjectMetadata, buc
 		content.LastModified = object.Created.Format(rfcFormat)
 		content.ETag = "\"" + object.Md5 + "\""
 		content.Size = object.Size
-		content.StorageClass = "STANDARD"
+		content.StorageClass = object.StorageClass
+		if content.StorageClass == "" {
+			content.StorageClass = fs.StorageClassStandard
+		}
 		content.Owner = owner
 		contents = append(contents, content)
 	}
@@ -141,6 +146,14 @@ func generateInitiateMultipartUploadResponse(bucket, key, uploadID string) Initi
 	}
 }
 
+// generateCopyObjectResponse
+func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectResponse {
+	return CopyObjectResponse{
+		LastModified: lastModified.Format(rfcFormat),
+		ETag:         "\"" + etag + "\"",
+	}
+}
+
 // generateCompleteMultipartUploadResponse
 func generateCompleteMultpartUploadResponse(bucket, key, location, etag string) CompleteMultipartUploadResponse {
 	return CompleteMultipartUploadResponse{
@@ -169,13 +182,13 @@ func generateListPartsResponse(objectMetadata fs.ObjectResourcesMetadata) ListPa
 	listPartsResponse.IsTruncated = objectMetadata.IsTruncated
 	listPartsResponse.NextPartNumberMarker = objectMetadata.NextPartNumberMarker
 
-	listPartsResponse.Part = make([]*Part, len(objectMetadata.Part))
+	listPartsResponse.Part = make([]*Part, 0, len(objectMetadata.Part))
 	for _, part := range objectMetadata.Part {
 		newPart := &Part{}
 		newPart.PartNumber = part.PartNumber
 		newPart.ETag = "\"" + part.ETag + "\""
 		newPart.Size = part.Size
-		newPart.LastModified = part.LastModified.Format(rfcFormat)
+		newPart.LastModified = part.LastModified.UTC().Format(rfcFormat)
 		listPartsResponse.Part = append(listPartsResponse.Part, newPart)
 	}
 	return listPartsResponse
@@ -203,6 +216,9 @@ func generateListMultipartUploadsResponse(bucket string, metadata fs.BucketMulti
 		newUpload.Initiated = upload.Initiated.Format(rfcFormat)
 		listMultipartUploadsResponse.Upload = append(listMultipartUploadsResponse.Upload, newUpload)
 	}
+	for _, prefix := range metadata.CommonPrefixes {
+		listMultipartUploadsResponse.CommonPrefixes = append(listMultipartUploadsResponse.CommonPrefixes, &CommonPrefix{Prefix: prefix})
+	}
 	return listMultipartUploadsResponse
 }
 
@@ -222,7 +238,7 @@ func writeSuccessNoContent(w http.ResponseWriter) {
 func writeErrorResponse(w http.ResponseWriter, req *http.Request, errorType int, resource string) {
 	error := getErrorCode(errorType)
 	// generate error response
-	errorResponse := getErrorResponse(error, resource)
+	errorResponse := getErrorResponse(error, resource, getRequestID(w))
 	encodedErrorResponse := encodeErrorResponse(errorResponse)
 	// set common headers
 	setCommonHeaders(w, len(encodedErrorResponse))
@@ -234,3 +250,15 @@ func writeErrorResponse(w http.ResponseWriter, req *http.Request, errorType int,
 		w.Write(encodedErrorResponse)
 	}
 }
+
+// writeProbeErrorResponse - write the S3 error response of a failed object layer operation,
+// code, message and status as mapped by fs.APIError
+func writeProbeErrorResponse(w http.ResponseWriter, err *probe.Error, resource string) {
+	code, httpStatus, message := fs.APIError(err)
+	errorResponse := getErrorResponse(APIError{Code: code, Description: message, HTTPStatusCode: httpStatus}, resource, getRequestID(w))
+	encodedErrorResponse := encodeErrorResponse(errorResponse)
+	setCommonHeaders(w, len(encodedErrorResponse))
+	w.WriteHeader(httpStatus)
+	// bodies of HEAD responses are dropped by the server
+	w.Write(encodedErrorResponse)
+}
@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type APIResponseSuite struct{}
+
+var _ = Suite(&APIResponseSuite{})
+
+func (s *APIResponseSuite) TestWriteProbeErrorResponse(c *C) {
+	testCases := []struct {
+		err        error
+		resource   string
+		code       string
+		message    string
+		httpStatus int
+	}{
+		{fs.BucketNotFound{Bucket: "bucket"}, "/bucket", "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound},
+		{fs.ObjectNotFound{Bucket: "bucket", Object: "object"}, "/bucket/object", "NoSuchKey", "The specified key does not exist.", http.StatusNotFound},
+		{fs.BadDigest{Md5: "md5"}, "/bucket/object", "BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest},
+		{fs.ObjectLocked{Bucket: "bucket", Object: "object"}, "/bucket/object", "AccessDenied", "Access Denied.", http.StatusForbidden},
+		{fs.OperationAborted{Bucket: "bucket", Object: "object"}, "/bucket/object", "OperationAborted", "A conflicting conditional operation is currently in progress against this resource. Try again.", http.StatusConflict},
+		{errors.New("unknown error"), "/", "InternalError", "We encountered an internal error, please try again.", http.StatusInternalServerError},
+	}
+	for _, testCase := range testCases {
+		w := httptest.NewRecorder()
+		w.Header().Set(requestIDHeader, "3L137REQUEST")
+		writeProbeErrorResponse(w, probe.NewError(testCase.err), testCase.resource)
+		c.Assert(w.Code, Equals, testCase.httpStatus, Commentf("%T", testCase.err))
+		c.Assert(w.Header().Get(requestIDHeader), Equals, "3L137REQUEST")
+		c.Assert(w.Header().Get("Content-Length"), Equals, strconv.Itoa(w.Body.Len()))
+
+		var response APIErrorResponse
+		c.Assert(xml.Unmarshal(w.Body.Bytes(), &response), IsNil)
+		c.Assert(response.XMLName.Local, Equals, "Error")
+		c.Assert(response.Code, Equals, testCase.code)
+		c.Assert(response.Message, Equals, testCase.message)
+		c.Assert(response.Resource, Equals, testCase.resource)
+		c.Assert(response.RequestID, Equals, "3L137REQUEST")
+	}
+
+	// SDKs parse the elements by name
+	w := httptest.NewRecorder()
+	w.Header().Set(requestIDHeader, "3L137REQUEST")
+	writeProbeErrorResponse(w, probe.NewError(fs.BucketNotFound{Bucket: "bucket"}), "/bucket")
+	c.Assert(w.Body.String(), Equals, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist.</Message>"+
+		"<Resource>/bucket</Resource><RequestId>3L137REQUEST</RequestId><HostId>3L137</HostId></Error>")
+
+	// responses without a request id are assigned one, reported in the body as well
+	w = httptest.NewRecorder()
+	writeProbeErrorResponse(w, probe.NewError(fs.BucketNotFound{Bucket: "bucket"}), "/bucket")
+	var response APIErrorResponse
+	c.Assert(xml.Unmarshal(w.Body.Bytes(), &response), IsNil)
+	c.Assert(response.RequestID, Not(Equals), "")
+	c.Assert(response.RequestID, Equals, w.Header().Get(requestIDHeader))
+}
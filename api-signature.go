@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -26,6 +27,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
 	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio/pkg/fs"
 )
@@ -35,6 +37,28 @@ const (
 	iso8601Format    = "20060102T150405Z"
 )
 
+// defaultRegions - regions signatures may be scoped to unless configured, us-east-1 is
+// what most clients sign with when no region is set
+var defaultRegions = []string{fs.DefaultRegion, "us-east-1"}
+
+// parseRegions - parse comma separated region names
+func parseRegions(value string) ([]string, *probe.Error) {
+	var regions []string
+	for _, region := range strings.Split(value, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" || strings.ContainsAny(region, "/ ") {
+			return nil, probe.NewError(errInvalidArgument).Trace(value)
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// serverRegion - region the server reports itself in, the first region signatures may be scoped to
+func serverRegion() string {
+	return globalRegions[0]
+}
+
 // getCredentialsFromAuth parse credentials tag from authorization value
 func getCredentialsFromAuth(authValue string) ([]string, *probe.Error) {
 	if authValue == "" {
@@ -68,44 +92,53 @@ func getCredentialsFromAuth(authValue string) ([]string, *probe.Error) {
 	return credentialElements, nil
 }
 
-// verify if authHeader value has valid region
-func isValidRegion(authHeaderValue string) *probe.Error {
-	credentialElements, err := getCredentialsFromAuth(authHeaderValue)
-	if err != nil {
-		return err.Trace()
+// isValidRegion - true if signatures may be scoped to region
+func isValidRegion(region string) bool {
+	for _, validRegion := range globalRegions {
+		if region == validRegion {
+			return true
+		}
 	}
-	region := credentialElements[2]
-	if region != "milkyway" {
-		return probe.NewError(errInvalidRegion)
+	return false
+}
+
+// verifyCredentialScope - verify region and service of credential elements
+// <access-key>/<date>/<region>/<service>/aws4_request
+func verifyCredentialScope(credentialElements []string) *probe.Error {
+	if !isValidRegion(credentialElements[2]) {
+		return probe.NewError(errInvalidRegion).Trace(credentialElements[2])
+	}
+	if credentialElements[3] != "s3" || credentialElements[4] != "aws4_request" {
+		return probe.NewError(errInvalidService).Trace(credentialElements[3], credentialElements[4])
 	}
 	return nil
 }
 
-// stripAccessKeyID - strip only access key id from auth header
-func stripAccessKeyID(authHeaderValue string) (string, *probe.Error) {
-	if err := isValidRegion(authHeaderValue); err != nil {
-		return "", err.Trace()
-	}
+// stripAccessKeyID - strip only access key id and region from auth header
+func stripAccessKeyID(authHeaderValue string) (string, string, *probe.Error) {
 	credentialElements, err := getCredentialsFromAuth(authHeaderValue)
 	if err != nil {
-		return "", err.Trace()
+		return "", "", err.Trace()
+	}
+	if err := verifyCredentialScope(credentialElements); err != nil {
+		return "", "", err.Trace()
 	}
 	accessKeyID := credentialElements[0]
 	if !isValidAccessKey(accessKeyID) {
-		return "", probe.NewError(errAccessKeyIDInvalid)
+		return "", "", probe.NewError(errAccessKeyIDInvalid)
 	}
-	return accessKeyID, nil
+	return accessKeyID, credentialElements[2], nil
 }
 
 // initSignatureV4 initializing signature verification
 func initSignatureV4(req *http.Request) (*fs.Signature, *probe.Error) {
 	// strip auth from authorization header
 	authHeaderValue := req.Header.Get("Authorization")
-	accessKeyID, err := stripAccessKeyID(authHeaderValue)
+	accessKeyID, region, err := stripAccessKeyID(authHeaderValue)
 	if err != nil {
 		return nil, err.Trace()
 	}
-	config, err := loadConfigV2()
+	config, err := getServerConfig()
 	if err != nil {
 		return nil, err.Trace()
 	}
@@ -118,6 +151,9 @@ func initSignatureV4(req *http.Request) (*fs.Signature, *probe.Error) {
 			SecretAccessKey: config.Credentials.SecretAccessKey,
 			Signature:       signature,
 			SignedHeaders:   signedHeaders,
+			ClockSkew:       globalClockSkew,
+			Region:          region,
+			SigningKeys:     globalSigningKeys,
 			Request:         req,
 		}
 		return signature, nil
@@ -125,6 +161,73 @@ func initSignatureV4(req *http.Request) (*fs.Signature, *probe.Error) {
 	return nil, probe.NewError(errAccessKeyIDInvalid)
 }
 
+// doesClaimedPayloadSignatureMatch - verify signature against the payload hash the client claims
+// in X-Amz-Content-Sha256 without reading the body, the body is verified against the signature
+// once it is read. True if there is no claim to verify.
+func doesClaimedPayloadSignatureMatch(signature *fs.Signature) (bool, *probe.Error) {
+	claimedPayload := signature.Request.Header.Get("X-Amz-Content-Sha256")
+	if claimedPayload == "" {
+		return true, nil
+	}
+	return signature.DoesSignatureMatch(claimedPayload)
+}
+
+// verifyPayloadSignature - verify the signature v4 of a request against its payload read in full
+// as body. Writes the error response and returns false if the request is rejected, requests not
+// signed with signature v4 are left to the caller.
+func verifyPayloadSignature(w http.ResponseWriter, req *http.Request, body []byte) bool {
+	if !isRequestSignatureV4(req) {
+		return true
+	}
+	signature, err := initSignatureV4(req)
+	if err != nil {
+		errorIf(err.Trace(), "Initializing signature v4 failed.", requestFields(w))
+		writeErrorResponse(w, req, signatureV4ErrorCode(err), req.URL.Path)
+		return false
+	}
+	ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sha256.Sum256(body)))
+	if err != nil {
+		errorIf(err.Trace(), "Unable to verify signature.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return false
+	}
+	if !ok {
+		writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		return false
+	}
+	return true
+}
+
+// verifyClaimedPayloadSignature - reject a bad signature before the body is read, clients
+// waiting on Expect: 100-continue never send the body then. Writes the error response and
+// returns false if the request is rejected. Chunked requests are verified chunk by chunk instead.
+func verifyClaimedPayloadSignature(w http.ResponseWriter, req *http.Request, signature *fs.Signature) bool {
+	if signature == nil || isRequestChunkedV4(req) {
+		return true
+	}
+	ok, err := doesClaimedPayloadSignatureMatch(signature)
+	if err != nil {
+		errorIf(err.Trace(), "Unable to verify signature.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return false
+	}
+	if !ok {
+		writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		return false
+	}
+	return true
+}
+
 func extractHTTPFormValues(reader *multipart.Reader) (io.Reader, map[string]string, *probe.Error) {
 	/// HTML Form values
 	formValues := make(map[string]string)
@@ -206,11 +309,14 @@ func initPostPresignedPolicyV4(formValues map[string]string) (*fs.Signature, *pr
 	if len(credentialElements) != 5 {
 		return nil, probe.NewError(errCredentialTagMalformed)
 	}
+	if err := verifyCredentialScope(credentialElements); err != nil {
+		return nil, err.Trace()
+	}
 	accessKeyID := credentialElements[0]
 	if !isValidAccessKey(accessKeyID) {
 		return nil, probe.NewError(errAccessKeyIDInvalid)
 	}
-	config, perr := loadConfigV2()
+	config, perr := getServerConfig()
 	if perr != nil {
 		return nil, perr.Trace()
 	}
@@ -220,6 +326,8 @@ func initPostPresignedPolicyV4(formValues map[string]string) (*fs.Signature, *pr
 			SecretAccessKey: config.Credentials.SecretAccessKey,
 			Signature:       formValues["X-Amz-Signature"],
 			PresignedPolicy: formValues["Policy"],
+			Region:          credentialElements[2],
+			SigningKeys:     globalSigningKeys,
 		}
 		return signature, nil
 	}
@@ -232,11 +340,14 @@ func initPresignedSignatureV4(req *http.Request) (*fs.Signature, *probe.Error) {
 	if len(credentialElements) != 5 {
 		return nil, probe.NewError(errCredentialTagMalformed)
 	}
+	if err := verifyCredentialScope(credentialElements); err != nil {
+		return nil, err.Trace()
+	}
 	accessKeyID := credentialElements[0]
 	if !isValidAccessKey(accessKeyID) {
 		return nil, probe.NewError(errAccessKeyIDInvalid)
 	}
-	config, err := loadConfigV2()
+	config, err := getServerConfig()
 	if err != nil {
 		return nil, err.Trace()
 	}
@@ -249,6 +360,8 @@ func initPresignedSignatureV4(req *http.Request) (*fs.Signature, *probe.Error) {
 			Signature:       signature,
 			SignedHeaders:   signedHeaders,
 			Presigned:       true,
+			Region:          credentialElements[2],
+			SigningKeys:     globalSigningKeys,
 			Request:         req,
 		}
 		return signature, nil
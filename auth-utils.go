@@ -52,6 +52,11 @@ func generateAccessKeyID() ([]byte, *probe.Error) {
 	return alpha, nil
 }
 
+// isValidSecretKey - validate secret key
+func isValidSecretKey(secretAccessKey string) bool {
+	return len(secretAccessKey) == minioSecretID
+}
+
 // generateSecretAccessKey - generate random base64 numeric value from a random seed.
 func generateSecretAccessKey() ([]byte, *probe.Error) {
 	rb := make([]byte, minioSecretID)
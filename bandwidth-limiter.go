@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bandwidthBurst - bytes a throttled stream moves at once at most, small enough to keep low
+// rates smooth and large enough to keep the number of waits low at high ones
+const bandwidthBurst = 32 * 1024
+
+// tokenBucket - allows rate bytes per second, in bursts of up to burst bytes
+type tokenBucket struct {
+	mutex  *sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket - full token bucket of rate bytes per second
+func newTokenBucket(rate int64) *tokenBucket {
+	burst := bandwidthBurst
+	if rate < int64(burst) {
+		burst = int(rate)
+	}
+	return &tokenBucket{
+		mutex:  &sync.Mutex{},
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait - take n bytes out of the bucket, sleeping until they are due. Bytes taken ahead of
+// time are owed by the next caller, so that concurrent callers share the rate in turn.
+func (b *tokenBucket) wait(n int) {
+	b.mutex.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mutex.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// throttledStream - token buckets a stream is throttled by, all of them have to allow its bytes
+type throttledStream []*tokenBucket
+
+// chunk - bytes the stream may move at once
+func (s throttledStream) chunk() int {
+	chunk := bandwidthBurst
+	for _, bucket := range s {
+		if bucket.burst < chunk {
+			chunk = bucket.burst
+		}
+	}
+	return chunk
+}
+
+func (s throttledStream) wait(n int) {
+	for _, bucket := range s {
+		bucket.wait(n)
+	}
+}
+
+// throttledReader - reader of a throttled upload
+type throttledReader struct {
+	reader io.Reader
+	stream throttledStream
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	if chunk := r.stream.chunk(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.reader.Read(p)
+	r.stream.wait(n)
+	return n, err
+}
+
+// throttledWriter - writer of a throttled download
+type throttledWriter struct {
+	writer io.Writer
+	stream throttledStream
+}
+
+func (w throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	chunk := w.stream.chunk()
+	for len(p) > 0 {
+		n := chunk
+		if len(p) < n {
+			n = len(p)
+		}
+		w.stream.wait(n)
+		m, err := w.writer.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// clientBandwidth - token bucket shared by the requests in progress of a client address
+type clientBandwidth struct {
+	bucket   *tokenBucket
+	requests int
+}
+
+// bandwidthLimiter - throttles object uploads and downloads, each request to a rate of its own
+// and all requests of a client address together to another one. A nil limiter throttles nothing.
+type bandwidthLimiter struct {
+	requestRate int64 // bytes per second of a single request, unlimited if zero
+	clientRate  int64 // bytes per second of all requests of a client address, unlimited if zero
+	mutex       *sync.Mutex
+	clients     map[string]*clientBandwidth
+}
+
+// newBandwidthLimiter - limiter of the given rates in bytes per second, nil if both are unlimited
+func newBandwidthLimiter(requestRate, clientRate int64) *bandwidthLimiter {
+	if requestRate <= 0 && clientRate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		requestRate: requestRate,
+		clientRate:  clientRate,
+		mutex:       &sync.Mutex{},
+		clients:     make(map[string]*clientBandwidth),
+	}
+}
+
+// clientAddress - address requests are aggregated by, the host of the remote address
+func clientAddress(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// acquire - token buckets a request is throttled by. Release has to be called once the request
+// is done, client buckets are dropped along with the last request of their client.
+func (l *bandwidthLimiter) acquire(req *http.Request) (stream throttledStream, release func()) {
+	if l.requestRate > 0 {
+		stream = append(stream, newTokenBucket(l.requestRate))
+	}
+	if l.clientRate <= 0 {
+		return stream, func() {}
+	}
+	client := clientAddress(req)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	bandwidth, ok := l.clients[client]
+	if !ok {
+		bandwidth = &clientBandwidth{bucket: newTokenBucket(l.clientRate)}
+		l.clients[client] = bandwidth
+	}
+	bandwidth.requests++
+	stream = append(stream, bandwidth.bucket)
+	return stream, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		bandwidth.requests--
+		if bandwidth.requests == 0 {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// reader - throttle the upload of req read from reader
+func (l *bandwidthLimiter) reader(req *http.Request, reader io.Reader) (io.Reader, func()) {
+	if l == nil {
+		return reader, func() {}
+	}
+	stream, release := l.acquire(req)
+	return throttledReader{reader: reader, stream: stream}, release
+}
+
+// writer - throttle the download of req written to writer
+func (l *bandwidthLimiter) writer(req *http.Request, writer io.Writer) (io.Writer, func()) {
+	if l == nil {
+		return writer, func() {}
+	}
+	stream, release := l.acquire(req)
+	return throttledWriter{writer: writer, stream: stream}, release
+}
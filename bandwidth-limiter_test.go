@@ -0,0 +1,112 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type BandwidthLimiterSuite struct{}
+
+var _ = Suite(&BandwidthLimiterSuite{})
+
+func (s *BandwidthLimiterSuite) TestThrottledTransfers(c *C) {
+	// 64KB/s lets the first 32KB through right away and the next 64KB in a second
+	const rate = 64 * 1024
+	api := CloudStorageAPI{
+		ObjectAPI: fs.NewMemoryFS(),
+		Anonymous: true,
+		Bandwidth: newBandwidthLimiter(rate, 0),
+	}
+	server := httptest.NewServer(getCloudStorageAPIHandler(api))
+	defer server.Close()
+
+	do := func(method, path string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		c.Assert(err, IsNil)
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	response := do("PUT", "/bandwidth", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = do("POST", "/bandwidth/object?uploads", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	newMultipartUpload := InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&newMultipartUpload), IsNil)
+
+	part := bytes.Repeat([]byte("a"), 96*1024)
+	start := time.Now()
+	response = do("PUT", "/bandwidth/object?uploadId="+newMultipartUpload.UploadID+"&partNumber=1", part)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(time.Since(start) >= 900*time.Millisecond, Equals, true)
+
+	response = do("PUT", "/bandwidth/download", part)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	start = time.Now()
+	response = do("GET", "/bandwidth/download", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, part)
+	c.Assert(time.Since(start) >= 900*time.Millisecond, Equals, true)
+}
+
+func (s *BandwidthLimiterSuite) TestClientBandwidth(c *C) {
+	c.Assert(newBandwidthLimiter(0, 0), IsNil)
+
+	limiter := newBandwidthLimiter(0, 64*1024)
+	first := &http.Request{RemoteAddr: "10.0.0.1:40000"}
+	second := &http.Request{RemoteAddr: "10.0.0.1:40001"}
+	other := &http.Request{RemoteAddr: "10.0.0.2:40000"}
+
+	firstStream, releaseFirst := limiter.acquire(first)
+	secondStream, releaseSecond := limiter.acquire(second)
+	otherStream, releaseOther := limiter.acquire(other)
+	c.Assert(len(firstStream), Equals, 1)
+	c.Assert(firstStream[0] == secondStream[0], Equals, true)
+	c.Assert(firstStream[0] == otherStream[0], Equals, false)
+	c.Assert(len(limiter.clients), Equals, 2)
+
+	// both requests of a client draw from the same 64KB/s, the first 32KB are let through right away
+	done := make(chan struct{})
+	start := time.Now()
+	for _, stream := range []throttledStream{firstStream, secondStream} {
+		go func(stream throttledStream) {
+			stream.wait(32 * 1024)
+			done <- struct{}{}
+		}(stream)
+	}
+	<-done
+	<-done
+	c.Assert(time.Since(start) >= 400*time.Millisecond, Equals, true)
+
+	releaseFirst()
+	c.Assert(len(limiter.clients), Equals, 2)
+	releaseSecond()
+	releaseOther()
+	c.Assert(len(limiter.clients), Equals, 0)
+}
@@ -0,0 +1,220 @@
+// +build browser
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// browserBuild - the bucket browser is compiled in, it is only ever served along with --browser
+const browserBuild = true
+
+// browserMaxKeys - objects and prefixes listed on a page of the bucket browser
+const browserMaxKeys = 1000
+
+// browserEntry - row of a page of the bucket browser, a bucket, a prefix or an object
+type browserEntry struct {
+	Name     string
+	Link     string
+	Size     int64
+	Modified time.Time
+	IsObject bool
+}
+
+// browserPage - page of the bucket browser
+type browserPage struct {
+	Title   string
+	Parent  string // link to the page above, empty on the list of buckets
+	Entries []browserEntry
+	Next    string // link to the rest of a truncated listing, empty on its last page
+}
+
+var browserTemplate = template.Must(template.New("browser").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Minio Browser - {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 1em; text-align: left; }
+td.size { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Parent}}<p><a href="{{.Parent}}">Up</a></p>{{end}}
+<table>
+<tr><th>Name</th><th>Size</th><th>Last Modified</th></tr>
+{{range .Entries}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td>{{if .IsObject}}<td class="size">{{.Size}}</td><td>{{.Modified.UTC.Format "2006-01-02 15:04:05"}}</td>{{else}}<td></td><td>{{if not .Modified.IsZero}}{{.Modified.UTC.Format "2006-01-02 15:04:05"}}{{end}}</td>{{end}}</tr>
+{{else}}<tr><td colspan="3">Empty</td></tr>
+{{end}}</table>
+{{if .Next}}<p><a href="{{.Next}}">More</a></p>{{end}}
+</body>
+</html>
+`))
+
+// browserLink - escaped link to the page or the download of name in the bucket browser
+func browserLink(name string) string {
+	return (&url.URL{Path: browserPathPrefix + "/" + name}).EscapedPath()
+}
+
+// serveBrowser - serve the list of buckets, a page of the listing of a prefix of a bucket, or
+// the download of an object. Prefixes and buckets end in a slash, objects do not.
+func serveBrowser(api CloudStorageAPI, w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, browserPathPrefix), "/")
+	if name == "" {
+		serveBrowserBuckets(api, w, req)
+		return
+	}
+	bucket, object := name, ""
+	if i := strings.Index(name, "/"); i >= 0 {
+		bucket, object = name[:i], name[i+1:]
+	}
+	if object == "" || strings.HasSuffix(object, "/") {
+		serveBrowserObjects(api, w, req, bucket, object)
+		return
+	}
+	serveBrowserDownload(api, w, req, bucket, object)
+}
+
+// writeBrowserError - answer a failed browser request, objects and buckets which cannot be
+// found are reported as such and every other failure is logged
+func writeBrowserError(w http.ResponseWriter, req *http.Request, err *probe.Error, msg string) {
+	switch err.ToGoError().(type) {
+	case fs.BucketNameInvalid, fs.BucketNotFound, fs.ObjectNameInvalid, fs.ObjectNotFound:
+		http.NotFound(w, req)
+	default:
+		errorIf(err.Trace(), msg, requestFields(w))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// writeBrowserPage - render page of the bucket browser
+func writeBrowserPage(w http.ResponseWriter, req *http.Request, page browserPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if req.Method == "HEAD" {
+		return
+	}
+	if e := browserTemplate.Execute(w, page); e != nil {
+		errorIf(probe.NewError(e), "Rendering browser page failed.", requestFields(w))
+	}
+}
+
+// serveBrowserBuckets - list every bucket of the server
+func serveBrowserBuckets(api CloudStorageAPI, w http.ResponseWriter, req *http.Request) {
+	buckets, err := api.ObjectAPI.ListBuckets()
+	if err != nil {
+		writeBrowserError(w, req, err, "Listing buckets for the browser failed.")
+		return
+	}
+	page := browserPage{Title: "Buckets"}
+	for _, bucket := range buckets {
+		page.Entries = append(page.Entries, browserEntry{
+			Name:     bucket.Name + "/",
+			Link:     browserLink(bucket.Name + "/"),
+			Modified: bucket.Created,
+		})
+	}
+	writeBrowserPage(w, req, page)
+}
+
+// serveBrowserObjects - list a page of the objects and prefixes right below prefix of bucket,
+// starting after the marker query parameter
+func serveBrowserObjects(api CloudStorageAPI, w http.ResponseWriter, req *http.Request, bucket, prefix string) {
+	resources := fs.BucketResourcesMetadata{
+		Prefix:    prefix,
+		Marker:    req.URL.Query().Get("marker"),
+		Delimiter: "/",
+		Maxkeys:   browserMaxKeys,
+	}
+	objects, resources, err := api.ObjectAPI.ListObjects(bucket, resources)
+	if err != nil {
+		writeBrowserError(w, req, err, "Listing objects for the browser failed.")
+		return
+	}
+	page := browserPage{Title: bucket + "/" + prefix}
+	if prefix == "" {
+		page.Parent = browserLink("")
+	} else {
+		parent := path.Dir(strings.TrimSuffix(prefix, "/"))
+		if parent == "." {
+			page.Parent = browserLink(bucket + "/")
+		} else {
+			page.Parent = browserLink(bucket + "/" + parent + "/")
+		}
+	}
+	// the listing is resumed after whichever of the objects and prefixes comes last
+	var last string
+	for _, commonPrefix := range resources.CommonPrefixes {
+		page.Entries = append(page.Entries, browserEntry{
+			Name: strings.TrimPrefix(commonPrefix, prefix),
+			Link: browserLink(bucket + "/" + commonPrefix),
+		})
+		if commonPrefix > last {
+			last = commonPrefix
+		}
+	}
+	for _, object := range objects {
+		page.Entries = append(page.Entries, browserEntry{
+			Name:     strings.TrimPrefix(object.Object, prefix),
+			Link:     browserLink(bucket + "/" + object.Object),
+			Size:     object.Size,
+			Modified: object.Created,
+			IsObject: true,
+		})
+		if object.Object > last {
+			last = object.Object
+		}
+	}
+	if resources.IsTruncated && last != "" {
+		page.Next = browserLink(bucket+"/"+prefix) + "?marker=" + url.QueryEscape(last)
+	}
+	writeBrowserPage(w, req, page)
+}
+
+// serveBrowserDownload - download object of bucket as an attachment named after it
+func serveBrowserDownload(api CloudStorageAPI, w http.ResponseWriter, req *http.Request, bucket, object string) {
+	metadata, err := api.ObjectAPI.GetObjectMetadata(bucket, object)
+	if err != nil {
+		writeBrowserError(w, req, err, "Downloading object from the browser failed.")
+		return
+	}
+	metadata.ContentType = api.ContentTypes.contentType(object, metadata.ContentType)
+	setObjectHeaders(w, metadata, nil, nil)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(object)}))
+	if req.Method == "HEAD" {
+		return
+	}
+	writer, release := api.Bandwidth.writer(req, w)
+	defer release()
+	if _, err = api.ObjectAPI.GetObject(writer, bucket, object, 0, 0); err != nil {
+		errorIf(err.Trace(), "Downloading object from the browser failed.", requestFields(w))
+	}
+}
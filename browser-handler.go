@@ -0,0 +1,89 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// browserPathPrefix - path the bucket browser is served under, not a valid bucket name so it
+// never shadows a bucket
+const browserPathPrefix = "/_minio/browser"
+
+// browserRealm - realm browsers are asked to log in to
+const browserRealm = "minio"
+
+type browserHandler struct {
+	handler http.Handler
+	api     CloudStorageAPI
+}
+
+// BrowserHandler serves the bucket browser ahead of signature verification, as browsers log in
+// with basic auth which would be taken for a signature. Paths of the browser are not found unless
+// it is compiled in and enabled with --browser.
+func BrowserHandler(api CloudStorageAPI) MiddlewareHandler {
+	return func(h http.Handler) http.Handler {
+		return browserHandler{handler: h, api: api}
+	}
+}
+
+// isBrowserRequest - true if the request is for a page or a download of the bucket browser
+func isBrowserRequest(req *http.Request) bool {
+	return req.URL.Path == browserPathPrefix || strings.HasPrefix(req.URL.Path, browserPathPrefix+"/")
+}
+
+// isBrowserAuthorized - true if the request logs in with the access key and secret key of the
+// server, the browser has no other users
+func isBrowserAuthorized(req *http.Request) bool {
+	accessKeyID, secretAccessKey, ok := req.BasicAuth()
+	if !ok {
+		return false
+	}
+	config, err := getServerConfig()
+	if err != nil {
+		errorIf(err.Trace(), "Loading credentials for the browser failed.", nil)
+		return false
+	}
+	// both keys are compared in full, so that timing tells nothing about either
+	accessKeyMatch := subtle.ConstantTimeCompare([]byte(accessKeyID), []byte(config.Credentials.AccessKeyID))
+	secretKeyMatch := subtle.ConstantTimeCompare([]byte(secretAccessKey), []byte(config.Credentials.SecretAccessKey))
+	return accessKeyMatch&secretKeyMatch == 1
+}
+
+func (h browserHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !isBrowserRequest(req) {
+		h.handler.ServeHTTP(w, req)
+		return
+	}
+	if !browserBuild || !h.api.Browser {
+		http.NotFound(w, req)
+		return
+	}
+	if !isBrowserAuthorized(req) {
+		w.Header().Set("WWW-Authenticate", "Basic realm=\""+browserRealm+"\"")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	serveBrowser(h.api, w, req)
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type BrowserHandlerSuite struct{}
+
+var _ = Suite(&BrowserHandlerSuite{})
+
+func (s *BrowserHandlerSuite) TestBrowserIndex(c *C) {
+	conf := newConfigV2()
+	conf.Credentials.AccessKeyID = string(mustGenerateAccessKeyID())
+	conf.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+	setServerConfig(conf)
+	defer setServerConfig(nil)
+
+	memory := fs.NewMemoryFS()
+	c.Assert(memory.MakeBucket("browsed", "private"), IsNil)
+	data := []byte("hello world")
+	_, perr := memory.CreateObject("browsed", "folder/object", "", int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(perr, IsNil)
+
+	get := func(browser bool, path, accessKeyID, secretAccessKey string) (*http.Response, string) {
+		server := httptest.NewServer(getCloudStorageAPIHandler(CloudStorageAPI{ObjectAPI: memory, Browser: browser}))
+		defer server.Close()
+		request, err := http.NewRequest("GET", server.URL+path, nil)
+		c.Assert(err, IsNil)
+		if accessKeyID != "" {
+			request.SetBasicAuth(accessKeyID, secretAccessKey)
+		}
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, IsNil)
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		return response, string(body)
+	}
+	accessKeyID, secretAccessKey := conf.Credentials.AccessKeyID, conf.Credentials.SecretAccessKey
+
+	// not found unless enabled, even when logged in
+	response, _ := get(false, browserPathPrefix+"/", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	response, _ = get(false, browserPathPrefix+"/browsed/folder/object", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	if !browserBuild {
+		response, _ = get(true, browserPathPrefix+"/", accessKeyID, secretAccessKey)
+		c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+		return
+	}
+
+	// enabled browsers need the access keys of the server
+	response, _ = get(true, browserPathPrefix+"/", "", "")
+	c.Assert(response.StatusCode, Equals, http.StatusUnauthorized)
+	c.Assert(response.Header.Get("WWW-Authenticate"), Equals, "Basic realm=\"minio\"")
+	response, _ = get(true, browserPathPrefix+"/", accessKeyID, "wrong")
+	c.Assert(response.StatusCode, Equals, http.StatusUnauthorized)
+
+	response, body := get(true, browserPathPrefix+"/", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(strings.HasPrefix(response.Header.Get("Content-Type"), "text/html"), Equals, true)
+	c.Assert(strings.Contains(body, browserPathPrefix+"/browsed/"), Equals, true)
+
+	response, body = get(true, browserPathPrefix+"/browsed/", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(strings.Contains(body, browserPathPrefix+"/browsed/folder/"), Equals, true)
+	response, body = get(true, browserPathPrefix+"/browsed/folder/", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(strings.Contains(body, browserPathPrefix+"/browsed/folder/object"), Equals, true)
+
+	response, body = get(true, browserPathPrefix+"/browsed/folder/object", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Disposition"), Equals, "attachment; filename=object")
+	c.Assert(body, Equals, string(data))
+
+	response, _ = get(true, browserPathPrefix+"/browsed/missing", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	response, _ = get(true, browserPathPrefix+"/missing/", accessKeyID, secretAccessKey)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+}
@@ -18,6 +18,9 @@ package main
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
 	"io/ioutil"
 	"net/http"
 
@@ -41,7 +44,7 @@ func (api CloudStorageAPI) ListMultipartUploadsHandler(w http.ResponseWriter, re
 
 	if !api.Anonymous {
 		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
+			if api.ObjectAPI.IsPrivateBucket(bucket) {
 				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 				return
 			}
@@ -57,14 +60,14 @@ func (api CloudStorageAPI) ListMultipartUploadsHandler(w http.ResponseWriter, re
 		resources.MaxUploads = maxObjectList
 	}
 
-	resources, err := api.Filesystem.ListMultipartUploads(bucket, resources)
+	resources, err := api.ObjectAPI.ListMultipartUploads(bucket, resources)
 	if err != nil {
-		errorIf(err.Trace(), "ListMultipartUploads failed.", nil)
+		errorIf(err.Trace(), "ListMultipartUploads failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNotFound:
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -89,7 +92,7 @@ func (api CloudStorageAPI) ListObjectsHandler(w http.ResponseWriter, req *http.R
 
 	if !api.Anonymous {
 		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
+			if api.isAnonymousReadDenied(bucket) {
 				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 				return
 			}
@@ -104,7 +107,7 @@ func (api CloudStorageAPI) ListObjectsHandler(w http.ResponseWriter, req *http.R
 		resources.Maxkeys = maxObjectList
 	}
 
-	objects, resources, err := api.Filesystem.ListObjects(bucket, resources)
+	objects, resources, err := api.ObjectAPI.ListObjects(bucket, resources)
 	if err == nil {
 		// generate response
 		response := generateListObjectsResponse(bucket, objects, resources)
@@ -125,8 +128,8 @@ func (api CloudStorageAPI) ListObjectsHandler(w http.ResponseWriter, req *http.R
 	case fs.ObjectNameInvalid:
 		writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 	default:
-		errorIf(err.Trace(), "ListObjects failed.", nil)
-		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		errorIf(err.Trace(), "ListObjects failed.", requestFields(w))
+		writeProbeErrorResponse(w, err, req.URL.Path)
 	}
 }
 
@@ -141,7 +144,7 @@ func (api CloudStorageAPI) ListBucketsHandler(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
-	buckets, err := api.Filesystem.ListBuckets()
+	buckets, err := api.ObjectAPI.ListBuckets()
 	if err == nil {
 		// generate response
 		response := generateListBucketsResponse(buckets)
@@ -152,7 +155,7 @@ func (api CloudStorageAPI) ListBucketsHandler(w http.ResponseWriter, req *http.R
 		w.Write(encodedSuccessResponse)
 		return
 	}
-	errorIf(err.Trace(), "ListBuckets failed.", nil)
+	errorIf(err.Trace(), "ListBuckets failed.", requestFields(w))
 	writeErrorResponse(w, req, InternalError, req.URL.Path)
 }
 
@@ -184,8 +187,8 @@ func (api CloudStorageAPI) PutBucketHandler(w http.ResponseWriter, req *http.Req
 			var err *probe.Error
 			signature, err = initSignatureV4(req)
 			if err != nil {
-				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				errorIf(err.Trace(), "Initializing signature v4 failed.", requestFields(w))
+				writeErrorResponse(w, req, signatureV4ErrorCode(err), req.URL.Path)
 				return
 			}
 		}
@@ -203,10 +206,15 @@ func (api CloudStorageAPI) PutBucketHandler(w http.ResponseWriter, req *http.Req
 			if err != nil {
 				sh := sha256.New()
 				sh.Write(locationBytes)
-				ok, perr := signature.DoesSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+				ok, perr := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
 				if perr != nil {
-					errorIf(perr.Trace(), "MakeBucket failed.", nil)
-					writeErrorResponse(w, req, InternalError, req.URL.Path)
+					errorIf(perr.Trace(), "MakeBucket failed.", requestFields(w))
+					switch perr.ToGoError().(type) {
+					case fs.RequestTimeTooSkewed:
+						writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+					default:
+						writeProbeErrorResponse(w, perr, req.URL.Path)
+					}
 					return
 				}
 				if !ok {
@@ -217,16 +225,16 @@ func (api CloudStorageAPI) PutBucketHandler(w http.ResponseWriter, req *http.Req
 		}
 	}
 
-	err := api.Filesystem.MakeBucket(bucket, getACLTypeString(aclType))
+	err := api.ObjectAPI.MakeBucket(bucket, getACLTypeString(aclType))
 	if err != nil {
-		errorIf(err.Trace(), "MakeBucket failed.", nil)
+		errorIf(err.Trace(), "MakeBucket failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
 		case fs.BucketExists:
 			writeErrorResponse(w, req, BucketAlreadyExists, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -255,14 +263,14 @@ func (api CloudStorageAPI) PostPolicyBucketHandler(w http.ResponseWriter, req *h
 	// files
 	reader, err := req.MultipartReader()
 	if err != nil {
-		errorIf(probe.NewError(err), "Unable to initialize multipart reader.", nil)
+		errorIf(probe.NewError(err), "Unable to initialize multipart reader.", requestFields(w))
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
 		return
 	}
 
 	fileBody, formValues, perr := extractHTTPFormValues(reader)
 	if perr != nil {
-		errorIf(perr.Trace(), "Unable to parse form values.", nil)
+		errorIf(perr.Trace(), "Unable to parse form values.", requestFields(w))
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
 		return
 	}
@@ -271,13 +279,13 @@ func (api CloudStorageAPI) PostPolicyBucketHandler(w http.ResponseWriter, req *h
 	object := formValues["Key"]
 	signature, perr := initPostPresignedPolicyV4(formValues)
 	if perr != nil {
-		errorIf(perr.Trace(), "Unable to initialize post policy presigned.", nil)
+		errorIf(perr.Trace(), "Unable to initialize post policy presigned.", requestFields(w))
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
 		return
 	}
 	var ok bool
 	if ok, perr = signature.DoesPolicySignatureMatch(formValues["X-Amz-Date"]); perr != nil {
-		errorIf(perr.Trace(), "Unable to verify signature.", nil)
+		errorIf(perr.Trace(), "Unable to verify signature.", requestFields(w))
 		writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
 		return
 	}
@@ -286,13 +294,13 @@ func (api CloudStorageAPI) PostPolicyBucketHandler(w http.ResponseWriter, req *h
 		return
 	}
 	if perr = applyPolicy(formValues); perr != nil {
-		errorIf(perr.Trace(), "Invalid request, policy doesn't match with the endpoint.", nil)
+		errorIf(perr.Trace(), "Invalid request, policy doesn't match with the endpoint.", requestFields(w))
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
 		return
 	}
-	metadata, perr := api.Filesystem.CreateObject(bucket, object, "", 0, fileBody, nil)
+	metadata, perr := api.ObjectAPI.CreateObject(bucket, object, "", 0, fileBody, nil)
 	if perr != nil {
-		errorIf(perr.Trace(), "CreateObject failed.", nil)
+		errorIf(perr.Trace(), "CreateObject failed.", requestFields(w))
 		switch perr.ToGoError().(type) {
 		case fs.RootPathFull:
 			writeErrorResponse(w, req, RootPathFull, req.URL.Path)
@@ -304,14 +312,20 @@ func (api CloudStorageAPI) PostPolicyBucketHandler(w http.ResponseWriter, req *h
 			writeErrorResponse(w, req, BadDigest, req.URL.Path)
 		case fs.SignatureDoesNotMatch:
 			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
 		case fs.IncompleteBody:
 			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
 		case fs.EntityTooLarge:
 			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
+		case fs.OperationAborted:
+			writeErrorResponse(w, req, OperationAborted, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
 		case fs.InvalidDigest:
 			writeErrorResponse(w, req, InvalidDigest, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, perr, req.URL.Path)
 		}
 		return
 	}
@@ -339,16 +353,16 @@ func (api CloudStorageAPI) PutBucketACLHandler(w http.ResponseWriter, req *http.
 		writeErrorResponse(w, req, NotImplemented, req.URL.Path)
 		return
 	}
-	err := api.Filesystem.SetBucketMetadata(bucket, map[string]string{"acl": getACLTypeString(aclType)})
+	err := api.ObjectAPI.SetBucketACL(bucket, fs.BucketACL(getACLTypeString(aclType)))
 	if err != nil {
-		errorIf(err.Trace(), "PutBucketACL failed.", nil)
+		errorIf(err.Trace(), "PutBucketACL failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
 		case fs.BucketNotFound:
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -372,21 +386,21 @@ func (api CloudStorageAPI) GetBucketACLHandler(w http.ResponseWriter, req *http.
 		}
 	}
 
-	bucketMetadata, err := api.Filesystem.GetBucketMetadata(bucket)
+	bucketACL, err := api.ObjectAPI.GetBucketACL(bucket)
 	if err != nil {
-		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		errorIf(err.Trace(), "GetBucketACL failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNotFound:
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
 	// generate response
-	response := generateAccessControlPolicyResponse(bucketMetadata.ACL)
+	response := generateAccessControlPolicyResponse(bucketACL)
 	encodedSuccessResponse := encodeSuccessResponse(response)
 	// write headers
 	setCommonHeaders(w, len(encodedSuccessResponse))
@@ -406,23 +420,23 @@ func (api CloudStorageAPI) HeadBucketHandler(w http.ResponseWriter, req *http.Re
 
 	if !api.Anonymous {
 		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
+			if api.isAnonymousReadDenied(bucket) {
 				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 				return
 			}
 		}
 	}
 
-	_, err := api.Filesystem.GetBucketMetadata(bucket)
+	_, err := api.ObjectAPI.GetBucketMetadata(bucket)
 	if err != nil {
-		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNotFound:
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -441,18 +455,370 @@ func (api CloudStorageAPI) DeleteBucketHandler(w http.ResponseWriter, req *http.
 		}
 	}
 
-	err := api.Filesystem.DeleteBucket(bucket)
+	err := api.ObjectAPI.DeleteBucket(bucket)
 	if err != nil {
-		errorIf(err.Trace(), "DeleteBucket failed.", nil)
+		errorIf(err.Trace(), "DeleteBucket failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNotFound:
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case fs.BucketNotEmpty:
 			writeErrorResponse(w, req, BucketNotEmpty, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// PutBucketPolicyHandler - PUT Bucket policy
+// ----------
+// This implementation of the PUT operation replaces the policy of a bucket, statements
+// allow or deny principals to get and put objects of the bucket.
+func (api CloudStorageAPI) PutBucketPolicyHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+	policyBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxBucketPolicySize+1))
+	if e != nil {
+		errorIf(probe.NewError(e), "Reading bucket policy failed.", requestFields(w))
+		writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+		return
+	}
+	if len(policyBytes) > maxBucketPolicySize {
+		writeErrorResponse(w, req, MalformedPolicy, req.URL.Path)
+		return
+	}
+
+	if !api.Anonymous && !verifyPayloadSignature(w, req, policyBytes) {
+		return
+	}
+
+	policy, err := fs.ParseBucketPolicy(bucket, policyBytes)
+	if err != nil {
+		errorIf(err.Trace(), "ParseBucketPolicy failed.", requestFields(w))
+		writeErrorResponse(w, req, MalformedPolicy, req.URL.Path)
+		return
+	}
+	if err := api.ObjectAPI.SetBucketPolicy(bucket, policy); err != nil {
+		errorIf(err.Trace(), "SetBucketPolicy failed.", requestFields(w))
+		writeBucketPolicyError(w, req, err)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketPolicyHandler - GET Bucket policy
+// ----------
+// This operation uses the policy subresource to return the policy of a bucket as JSON.
+func (api CloudStorageAPI) GetBucketPolicyHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	policy, err := api.ObjectAPI.GetBucketPolicy(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketPolicy failed.", requestFields(w))
+		writeBucketPolicyError(w, req, err)
+		return
+	}
+	policyBytes, e := json.Marshal(policy)
+	if e != nil {
+		errorIf(probe.NewError(e), "Unable to marshal bucket policy.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	setCommonHeaders(w, len(policyBytes))
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(policyBytes)
+}
+
+// DeleteBucketPolicyHandler - DELETE Bucket policy
+// ----------
+// This implementation of the DELETE operation removes the policy of a bucket, leaving access
+// to the bucket ACL.
+func (api CloudStorageAPI) DeleteBucketPolicyHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
 		}
+	}
+
+	if err := api.ObjectAPI.SetBucketPolicy(bucket, nil); err != nil {
+		errorIf(err.Trace(), "SetBucketPolicy failed.", requestFields(w))
+		writeBucketPolicyError(w, req, err)
 		return
 	}
 	writeSuccessNoContent(w)
 }
+
+// writeBucketPolicyError - write the error response of a failed bucket policy operation
+func writeBucketPolicyError(w http.ResponseWriter, req *http.Request, err *probe.Error) {
+	switch err.ToGoError().(type) {
+	case fs.BucketNameInvalid:
+		writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+	case fs.BucketNotFound:
+		writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+	case fs.BucketPolicyNotFound:
+		writeErrorResponse(w, req, NoSuchBucketPolicy, req.URL.Path)
+	default:
+		writeProbeErrorResponse(w, err, req.URL.Path)
+	}
+}
+
+// maxVersioningConfigurationSize - largest versioning configuration accepted, it carries a status only
+const maxVersioningConfigurationSize = 1024
+
+// PutBucketVersioningHandler - PUT Bucket versioning
+// ----------
+// This implementation of the PUT operation enables or suspends versioning of a bucket. Once
+// enabled versioning cannot be disabled, only suspended.
+func (api CloudStorageAPI) PutBucketVersioningHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+	configurationBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxVersioningConfigurationSize+1))
+	if e != nil {
+		errorIf(probe.NewError(e), "Reading bucket versioning failed.", requestFields(w))
+		writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+		return
+	}
+	if len(configurationBytes) > maxVersioningConfigurationSize {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+
+	if !api.Anonymous && !verifyPayloadSignature(w, req, configurationBytes) {
+		return
+	}
+
+	configuration := VersioningConfigurationRequest{}
+	if e := xml.Unmarshal(configurationBytes, &configuration); e != nil {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	if !fs.IsValidVersioning(configuration.Status) {
+		writeErrorResponse(w, req, IllegalVersioningConfiguration, req.URL.Path)
+		return
+	}
+	if err := api.ObjectAPI.SetBucketVersioning(bucket, configuration.Status); err != nil {
+		errorIf(err.Trace(), "SetBucketVersioning failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.NotImplemented:
+			writeErrorResponse(w, req, NotImplemented, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// GetBucketVersioningHandler - GET Bucket versioning
+// ----------
+// This implementation of the GET operation returns the versioning state of a bucket, without a
+// status if versioning was never enabled on it.
+func (api CloudStorageAPI) GetBucketVersioningHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	encodedSuccessResponse := encodeSuccessResponse(VersioningConfigurationResponse{Status: bucketMetadata.Versioning})
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	// write body
+	w.Write(encodedSuccessResponse)
+}
+
+// maxEncryptionConfigurationSize - largest encryption configuration accepted, it carries an algorithm only
+const maxEncryptionConfigurationSize = 1024
+
+// PutBucketEncryptionHandler - PUT Bucket encryption
+// ----------
+// This implementation of the PUT operation sets the algorithm objects created in a bucket are
+// encrypted with by default, from now on. Objects already stored are left as is.
+func (api CloudStorageAPI) PutBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+	configurationBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxEncryptionConfigurationSize+1))
+	if e != nil {
+		errorIf(probe.NewError(e), "Reading bucket encryption failed.", requestFields(w))
+		writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+		return
+	}
+	if len(configurationBytes) > maxEncryptionConfigurationSize {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+
+	if !api.Anonymous && !verifyPayloadSignature(w, req, configurationBytes) {
+		return
+	}
+
+	configuration := ServerSideEncryptionConfigurationRequest{}
+	if e := xml.Unmarshal(configurationBytes, &configuration); e != nil {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	// a bucket has a single default algorithm
+	if len(configuration.Rules) != 1 {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	algorithm := configuration.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm
+	if algorithm == "" {
+		writeErrorResponse(w, req, InvalidEncryption, req.URL.Path)
+		return
+	}
+	if err := api.ObjectAPI.SetBucketEncryption(bucket, algorithm); err != nil {
+		errorIf(err.Trace(), "SetBucketEncryption failed.", requestFields(w))
+		writeBucketEncryptionError(w, req, err)
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// GetBucketEncryptionHandler - GET Bucket encryption
+// ----------
+// This implementation of the GET operation returns the algorithm objects created in a bucket
+// are encrypted with by default, buckets without one have no encryption configuration.
+func (api CloudStorageAPI) GetBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", requestFields(w))
+		writeBucketEncryptionError(w, req, err)
+		return
+	}
+	if bucketMetadata.Encryption == "" {
+		writeErrorResponse(w, req, NoSuchEncryptionConfiguration, req.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeSuccessResponse(ServerSideEncryptionConfigurationResponse{
+		Rules: []ServerSideEncryptionRule{
+			{ApplyServerSideEncryptionByDefault: ServerSideEncryptionByDefault{SSEAlgorithm: bucketMetadata.Encryption}},
+		},
+	})
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	// write body
+	w.Write(encodedSuccessResponse)
+}
+
+// DeleteBucketEncryptionHandler - DELETE Bucket encryption
+// ----------
+// This implementation of the DELETE operation stops encrypting objects created in a bucket,
+// objects already stored encrypted stay readable.
+func (api CloudStorageAPI) DeleteBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	if err := api.ObjectAPI.SetBucketEncryption(bucket, ""); err != nil {
+		errorIf(err.Trace(), "SetBucketEncryption failed.", requestFields(w))
+		writeBucketEncryptionError(w, req, err)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// writeBucketEncryptionError - write the error response of a failed bucket encryption operation
+func writeBucketEncryptionError(w http.ResponseWriter, req *http.Request, err *probe.Error) {
+	switch err.ToGoError().(type) {
+	case fs.BucketNameInvalid:
+		writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+	case fs.BucketNotFound:
+		writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+	case fs.InvalidArgument:
+		writeErrorResponse(w, req, InvalidEncryption, req.URL.Path)
+	case fs.InvalidMasterKey:
+		writeErrorResponse(w, req, MissingMasterKey, req.URL.Path)
+	default:
+		writeProbeErrorResponse(w, err, req.URL.Path)
+	}
+}
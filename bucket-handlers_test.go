@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type BucketHandlersSuite struct{}
+
+var _ = Suite(&BucketHandlersSuite{})
+
+func (s *BucketHandlersSuite) TestBucketEncryption(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-encryption-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "export")
+	c.Assert(os.Mkdir(path, 0700), IsNil)
+	fs.SetFSMultipartsConfigPath(filepath.Join(root, "multiparts-session.json"))
+	fs.SetFSBucketsConfigPath(filepath.Join(root, "buckets.json"))
+	filesystem, perr := fs.New()
+	c.Assert(perr, IsNil)
+	filesystem.SetRootPath(path)
+	filesystem.SetMinFreeDisk(0)
+	c.Assert(filesystem.SetMasterKey(bytes.Repeat([]byte{'k'}, fs.MasterKeySize)), IsNil)
+	server := httptest.NewServer(getCloudStorageAPIHandler(CloudStorageAPI{ObjectAPI: filesystem, Anonymous: true}))
+	defer server.Close()
+
+	do := func(method, path string, body []byte) *http.Response {
+		request, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		c.Assert(err, IsNil)
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	response := do("PUT", "/encrypted", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = do("GET", "/encrypted?encryption", nil)
+	verifyError(c, response, "ServerSideEncryptionConfigurationNotFoundError", "The server side encryption configuration was not found.", http.StatusNotFound)
+
+	// kms keys are not supported, neither are configurations without a rule
+	response = do("PUT", "/encrypted?encryption", []byte("<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>"))
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+	response = do("PUT", "/encrypted?encryption", []byte("<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>"))
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+
+	response = do("PUT", "/encrypted?encryption", []byte("<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>"))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = do("GET", "/encrypted?encryption", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	configuration := ServerSideEncryptionConfigurationResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&configuration), IsNil)
+	c.Assert(len(configuration.Rules), Equals, 1)
+	c.Assert(configuration.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm, Equals, fs.EncryptionAES256)
+
+	// objects are encrypted without asking for it
+	response = do("PUT", "/encrypted/object", []byte("hello encryption"))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Server-Side-Encryption"), Equals, fs.EncryptionAES256)
+	stored, err := ioutil.ReadFile(filepath.Join(path, "encrypted", "object"))
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Contains(stored, []byte("hello encryption")), Equals, false)
+	_, err = os.Stat(filepath.Join(path, "encrypted", "object$sse"))
+	c.Assert(err, IsNil)
+
+	response = do("GET", "/encrypted/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Server-Side-Encryption"), Equals, fs.EncryptionAES256)
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello encryption")
+
+	// objects stored encrypted stay readable once encryption is removed
+	response = do("DELETE", "/encrypted?encryption", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	response = do("GET", "/encrypted?encryption", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	response = do("PUT", "/encrypted/plain", []byte("hello plain"))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Server-Side-Encryption"), Equals, "")
+	response = do("HEAD", "/encrypted/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Server-Side-Encryption"), Equals, fs.EncryptionAES256)
+}
@@ -0,0 +1,78 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio/pkg/fs"
+)
+
+// maxBucketPolicySize - largest bucket policy accepted, as for S3
+const maxBucketPolicySize = 20 * 1024
+
+// requestPrincipal - access key a request claims to be signed with, empty for anonymous
+// requests. Signatures are verified apart from bucket policies.
+func requestPrincipal(req *http.Request) string {
+	if isRequestSignatureV4(req) {
+		credentialElements, err := getCredentialsFromAuth(req.Header.Get("Authorization"))
+		if err != nil {
+			return ""
+		}
+		return credentialElements[0]
+	}
+	if isRequestPresignedSignatureV4(req) {
+		return strings.Split(req.URL.Query().Get("X-Amz-Credential"), "/")[0]
+	}
+	return ""
+}
+
+// bucketPolicyDecision - decision of the policy of bucket on the request taking action on
+// object, buckets without a policy make none
+func (api CloudStorageAPI) bucketPolicyDecision(req *http.Request, action, bucket, object string) fs.PolicyDecision {
+	policy, err := api.ObjectAPI.GetBucketPolicy(bucket)
+	if err != nil {
+		return fs.PolicyNoDecision
+	}
+	return policy.Evaluate(requestPrincipal(req), action, bucket, object)
+}
+
+// isObjectReadDenied - true if reading object is denied, either explicitly by the bucket policy
+// or, for an unsigned request the policy does not allow, by the bucket ACL
+func (api CloudStorageAPI) isObjectReadDenied(req *http.Request, bucket, object string) bool {
+	switch api.bucketPolicyDecision(req, fs.PolicyActionGetObject, bucket, object) {
+	case fs.PolicyDenied:
+		return true
+	case fs.PolicyAllowed:
+		return false
+	}
+	return isRequestRequiresACLCheck(req) && api.isAnonymousReadDenied(bucket)
+}
+
+// isObjectWriteDenied - true if writing object is denied, either explicitly by the bucket policy
+// or, for an unsigned request the policy does not allow, by the bucket ACL
+func (api CloudStorageAPI) isObjectWriteDenied(req *http.Request, bucket, object string) bool {
+	switch api.bucketPolicyDecision(req, fs.PolicyActionPutObject, bucket, object) {
+	case fs.PolicyDenied:
+		return true
+	case fs.PolicyAllowed:
+		return false
+	}
+	// anonymous writes are only allowed on public-read-write buckets
+	return isRequestRequiresACLCheck(req) && !api.ObjectAPI.IsPublicBucket(bucket)
+}
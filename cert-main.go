@@ -0,0 +1,235 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// generatedCertFile - file name of a generated certificate in its directory
+	generatedCertFile = "public.crt"
+	// generatedKeyFile - file name of a generated private key in its directory
+	generatedKeyFile = "private.key"
+	// generatedCertValidity - period a generated certificate is valid for
+	generatedCertValidity = 365 * 24 * time.Hour
+)
+
+// Manage certificates for the TLS options of minio server.
+var certCmd = cli.Command{
+	Name:   "cert",
+	Usage:  "Collection of commands managing TLS certificates.",
+	Action: mainCert,
+	Subcommands: []cli.Command{
+		certGenerateCmd,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.Name}} - {{.Usage}}
+
+USAGE:
+  {{.Name}} {{if .Flags}}[global flags] {{end}}command{{if .Flags}} [command flags]{{end}} [arguments...]
+
+COMMANDS:
+  {{range .Commands}}{{ .Name }}{{ "\t" }}{{.Usage}}
+  {{end}}
+`,
+}
+
+var certGenerateCmd = cli.Command{
+	Name:   "generate",
+	Usage:  "Generate a self-signed certificate and private key for evaluating TLS.",
+	Action: mainCertGenerate,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "hosts",
+			Value: "localhost,127.0.0.1",
+			Usage: "Comma separated host names and IP addresses the certificate is valid for.",
+		},
+		cli.StringFlag{
+			Name:  "out",
+			Value: ".",
+			Usage: "Directory " + generatedCertFile + " and " + generatedKeyFile + " are written to.",
+		},
+	},
+	CustomHelpTemplate: `NAME:
+   minio cert {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio cert {{.Name}} [--hosts HOST[,HOST...]] [--out DIR]
+
+FLAGS:
+  {{range .Flags}}{{.}}
+  {{end}}
+EXAMPLES:
+   1. Generate a certificate for this host and start minio server with it, clients have to trust public.crt.
+      $ minio cert {{.Name}} --hosts minio.example.com,192.168.1.101 --out /etc/minio
+      $ minio --cert /etc/minio/public.crt --key /etc/minio/private.key server /home/shared
+`,
+}
+
+// mainCert is the handle for "minio cert" command, provides sub-commands only
+func mainCert(ctx *cli.Context) {
+	if !ctx.Args().Present() || ctx.Args().First() == "help" {
+		cli.ShowAppHelp(ctx)
+	}
+}
+
+func mainCertGenerate(ctx *cli.Context) {
+	if ctx.Args().Present() {
+		cli.ShowCommandHelpAndExit(ctx, "generate", 1) // last argument is exit code
+	}
+	hosts := parseCertHosts(ctx.String("hosts"))
+	if len(hosts) == 0 {
+		fatalIf(probe.NewError(errInvalidArgument), "At least one host should be passed.", nil)
+	}
+	cert, err := generateCertificate(hosts, ctx.String("out"))
+	fatalIf(err.Trace(ctx.String("out")), "Generating certificate failed.", nil)
+	if globalJSONFlag {
+		Println(cert.JSON())
+	} else {
+		Println(cert)
+	}
+}
+
+// certMessage - generated certificate as printed by the cert command
+type certMessage struct {
+	Certificate string   `json:"certificate"`
+	Key         string   `json:"key"`
+	Hosts       []string `json:"hosts"`
+	NotAfter    string   `json:"notAfter"`
+}
+
+// String files written along with the hosts and period the certificate is valid for
+func (m certMessage) String() string {
+	return "Certificate: " + m.Certificate + "\n" +
+		"Key: " + m.Key + "\n" +
+		"Hosts: " + strings.Join(m.Hosts, ", ") + "\n" +
+		"Valid until: " + m.NotAfter
+}
+
+// JSON jsonified cert message
+func (m certMessage) JSON() string {
+	certJSONBytes, err := json.Marshal(m)
+	fatalIf(probe.NewError(err), "Unable to marshal into JSON.", nil)
+
+	return string(certJSONBytes)
+}
+
+// parseCertHosts - host names and IP addresses of a comma separated list, empty ones are dropped
+func parseCertHosts(hosts string) []string {
+	var parsed []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			parsed = append(parsed, host)
+		}
+	}
+	return parsed
+}
+
+// generateCertificate - write a self-signed certificate valid for hosts along with its private
+// key to dir, both readable by the owner only. Existing files are never overwritten.
+func generateCertificate(hosts []string, dir string) (certMessage, *probe.Error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return certMessage{}, probe.NewError(err)
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return certMessage{}, probe.NewError(err)
+	}
+	now := time.Now().UTC()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{"Minio"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(generatedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		// clients trust the certificate by adding it as a root of their own
+		IsCA: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return certMessage{}, probe.NewError(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return certMessage{}, probe.NewError(err)
+	}
+
+	certFile := filepath.Join(dir, generatedCertFile)
+	keyFile := filepath.Join(dir, generatedKeyFile)
+	for _, file := range []string{certFile, keyFile} {
+		if _, err := os.Stat(file); err == nil {
+			return certMessage{}, probe.NewError(os.ErrExist).Trace(file)
+		}
+	}
+	if err := writeNewFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})); err != nil {
+		return certMessage{}, err.Trace(keyFile)
+	}
+	if err := writeNewFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})); err != nil {
+		os.Remove(keyFile)
+		return certMessage{}, err.Trace(certFile)
+	}
+	return certMessage{
+		Certificate: certFile,
+		Key:         keyFile,
+		Hosts:       hosts,
+		NotAfter:    template.NotAfter.Format(time.RFC3339),
+	}, nil
+}
+
+// writeNewFile - write data to a file readable by the owner only, failing if it exists
+func writeNewFile(file string, data []byte) *probe.Error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(file)
+		return probe.NewError(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(file)
+		return probe.NewError(err)
+	}
+	return nil
+}
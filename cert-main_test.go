@@ -0,0 +1,75 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "gopkg.in/check.v1"
+)
+
+type CertMainSuite struct{}
+
+var _ = Suite(&CertMainSuite{})
+
+func (s *CertMainSuite) TestGenerateCertificate(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-cert-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	hosts := parseCertHosts("minio.example.com, 192.168.1.101,,localhost,::1")
+	c.Assert(hosts, DeepEquals, []string{"minio.example.com", "192.168.1.101", "localhost", "::1"})
+	cert, perr := generateCertificate(hosts, root)
+	c.Assert(perr, IsNil)
+	c.Assert(cert.Certificate, Equals, filepath.Join(root, "public.crt"))
+	c.Assert(cert.Key, Equals, filepath.Join(root, "private.key"))
+	if runtime.GOOS != "windows" {
+		for _, file := range []string{cert.Certificate, cert.Key} {
+			st, err := os.Stat(file)
+			c.Assert(err, IsNil)
+			c.Assert(st.Mode().Perm(), Equals, os.FileMode(0600))
+		}
+	}
+
+	// served as by minio server --cert --key, trusted by clients adding it to their roots
+	tlsCert, perr := loadServerCertificate(cloudServerConfig{CertFile: cert.Certificate, KeyFile: cert.Key, StrictCert: true})
+	c.Assert(perr, IsNil)
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsCert.Leaf)
+	for _, host := range hosts {
+		_, err = tlsCert.Leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		c.Assert(err, IsNil, Commentf("%s", host))
+	}
+	_, err = tlsCert.Leaf.Verify(x509.VerifyOptions{DNSName: "other.example.com", Roots: roots})
+	c.Assert(err, Not(IsNil))
+	_, err = tlsCert.Leaf.Verify(x509.VerifyOptions{DNSName: "192.168.1.102", Roots: roots})
+	c.Assert(err, Not(IsNil))
+
+	// existing files are never overwritten
+	certBytes, err := ioutil.ReadFile(cert.Certificate)
+	c.Assert(err, IsNil)
+	_, perr = generateCertificate([]string{"localhost"}, root)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(os.IsExist(perr.ToGoError()), Equals, true)
+	regenerated, err := ioutil.ReadFile(cert.Certificate)
+	c.Assert(err, IsNil)
+	c.Assert(regenerated, DeepEquals, certBytes)
+}
@@ -0,0 +1,141 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Environment variables configuring fault injection, which is meant for testing retries of
+// clients only and is compiled in by 'go build -tags chaos' only
+const (
+	chaosDelayPercentEnv = "MINIO_CHAOS_DELAY_PERCENT" // percentage of requests delayed
+	chaosDelayEnv        = "MINIO_CHAOS_DELAY"         // latency added to delayed requests
+	chaosJitterEnv       = "MINIO_CHAOS_JITTER"        // random latency added to delayed requests on top, up to this much
+	chaosErrorPercentEnv = "MINIO_CHAOS_ERROR_PERCENT" // percentage of requests failed with ServiceUnavailable
+)
+
+// chaosConfig - faults injected into requests
+type chaosConfig struct {
+	DelayPercent float64
+	Delay        time.Duration
+	Jitter       time.Duration
+	ErrorPercent float64
+}
+
+// loadChaosConfig - faults configured by the environment, nil if none are or if fault injection
+// is not compiled in
+func loadChaosConfig() (*chaosConfig, *probe.Error) {
+	conf := &chaosConfig{}
+	set := false
+	for _, percent := range []struct {
+		env   string
+		value *float64
+	}{{chaosDelayPercentEnv, &conf.DelayPercent}, {chaosErrorPercentEnv, &conf.ErrorPercent}} {
+		value := os.Getenv(percent.env)
+		if value == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, probe.NewError(err).Trace(percent.env, value)
+		}
+		if p < 0 || p > 100 {
+			return nil, probe.NewError(errInvalidArgument).Trace(percent.env, value)
+		}
+		*percent.value = p
+		set = true
+	}
+	for _, duration := range []struct {
+		env   string
+		value *time.Duration
+	}{{chaosDelayEnv, &conf.Delay}, {chaosJitterEnv, &conf.Jitter}} {
+		value := os.Getenv(duration.env)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, probe.NewError(err).Trace(duration.env, value)
+		}
+		if d < 0 {
+			return nil, probe.NewError(errInvalidArgument).Trace(duration.env, value)
+		}
+		*duration.value = d
+		set = true
+	}
+	if !set {
+		return nil, nil
+	}
+	if !chaosBuild {
+		log.Warn("Fault injection is not compiled in, " + chaosDelayPercentEnv + " and others are ignored.")
+		return nil, nil
+	}
+	return conf, nil
+}
+
+type chaosHandler struct {
+	handler http.Handler
+	conf    chaosConfig
+	mutex   *sync.Mutex
+	random  *rand.Rand
+}
+
+// ChaosHandler delays and fails random requests as configured, for clients to test their
+// retries against
+func ChaosHandler(conf chaosConfig) MiddlewareHandler {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	mutex := &sync.Mutex{}
+	return func(h http.Handler) http.Handler {
+		return chaosHandler{handler: h, conf: conf, mutex: mutex, random: random}
+	}
+}
+
+// chance - whether a request falls into percent of requests
+func (h chaosHandler) chance(percent float64) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.random.Float64()*100 < percent
+}
+
+// delay - latency of a delayed request, jitter included
+func (h chaosHandler) delay() time.Duration {
+	if h.conf.Jitter <= 0 {
+		return h.conf.Delay
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.conf.Delay + time.Duration(h.random.Int63n(int64(h.conf.Jitter)+1))
+}
+
+func (h chaosHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.chance(h.conf.DelayPercent) {
+		time.Sleep(h.delay())
+	}
+	if h.chance(h.conf.ErrorPercent) {
+		writeErrorResponse(w, r, ServiceUnavailable, r.URL.Path)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
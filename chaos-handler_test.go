@@ -0,0 +1,91 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ChaosHandlerSuite struct{}
+
+var _ = Suite(&ChaosHandlerSuite{})
+
+func (s *ChaosHandlerSuite) TestErrorRate(c *C) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request, err := http.NewRequest("GET", "/bucket/object", nil)
+	c.Assert(err, IsNil)
+	handler := ChaosHandler(chaosConfig{ErrorPercent: 20})(ok)
+
+	// 4000 requests at 20% fail 800 times on average, the tolerance is about four standard deviations
+	const requests = 4000
+	failed := 0
+	for i := 0; i < requests; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		switch w.Code {
+		case http.StatusOK:
+		case http.StatusServiceUnavailable:
+			failed++
+		default:
+			c.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+	c.Assert(failed > 700 && failed < 900, Equals, true, Commentf("%d of %d requests failed", failed, requests))
+
+	// delayed requests are served all the same
+	handler = ChaosHandler(chaosConfig{DelayPercent: 100, Delay: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})(ok)
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(w, request)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	c.Assert(time.Since(start) >= 20*time.Millisecond, Equals, true)
+}
+
+func (s *ChaosHandlerSuite) TestLoadChaosConfig(c *C) {
+	defer os.Unsetenv(chaosErrorPercentEnv)
+	defer os.Unsetenv(chaosDelayEnv)
+
+	// off unless configured
+	conf, perr := loadChaosConfig()
+	c.Assert(perr, IsNil)
+	c.Assert(conf, IsNil)
+
+	os.Setenv(chaosErrorPercentEnv, "101")
+	_, perr = loadChaosConfig()
+	c.Assert(perr, Not(IsNil))
+	os.Setenv(chaosErrorPercentEnv, "5")
+	os.Setenv(chaosDelayEnv, "soon")
+	_, perr = loadChaosConfig()
+	c.Assert(perr, Not(IsNil))
+
+	// and never active in builds without fault injection compiled in
+	os.Setenv(chaosDelayEnv, "100ms")
+	conf, perr = loadChaosConfig()
+	c.Assert(perr, IsNil)
+	if chaosBuild {
+		c.Assert(*conf, DeepEquals, chaosConfig{ErrorPercent: 5, Delay: 100 * time.Millisecond})
+	} else {
+		c.Assert(conf, IsNil)
+	}
+}
@@ -18,6 +18,8 @@ package main
 
 import (
 	"runtime"
+	"strconv"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/minio-xl/pkg/probe"
@@ -40,16 +42,25 @@ EXAMPLES:
    1. Configure new mongo logger.
       $ minio config {{.Name}} add mongo localhost:28710 mydb mylogger
 
-   2. Configure new syslog logger. NOTE: syslog logger is not supported on windows.
+   2. Configure new mongo logger buffering up to 5000 log entries, flushed every 5 seconds.
+      $ minio config {{.Name}} add mongo localhost:28710 mydb mylogger 5000 5s
+
+   3. Configure new syslog logger. NOTE: syslog logger is not supported on windows.
       $ minio config {{.Name}} add syslog localhost:554 udp
 
-   3. Configure new file logger. "/var/log" should be writable by user.
+   4. Configure new syslog logger over TLS with a custom CA and a client certificate.
+      $ minio config {{.Name}} add syslog localhost:6514 tcp+tls /etc/ssl/ca.crt /etc/ssl/client.crt /etc/ssl/client.key
+
+   5. Configure new file logger. "/var/log" should be writable by user.
       $ minio config {{.Name}} add file /var/log/minio.log
 
-   4. List currently configured logger.
+   6. Configure new file logger rotated at 100MB or every 24 hours, keeping 5 gzip compressed rotated files.
+      $ minio config {{.Name}} add file /var/log/minio.log 100 5 24h gzip
+
+   7. List currently configured logger.
       $ minio config {{.Name}} list
 
-   5. Remove/Reset a configured logger.
+   8. Remove/Reset a configured logger.
       $ minio config {{.Name}} remove mongo
 `,
 }
@@ -87,6 +98,8 @@ func mainConfigLogger(ctx *cli.Context) {
 			conf.MongoLogger.Addr = ""
 			conf.MongoLogger.DB = ""
 			conf.MongoLogger.Collection = ""
+			conf.MongoLogger.BufferSize = 0
+			conf.MongoLogger.FlushInterval = ""
 			err := saveConfig(conf)
 			fatalIf(err.Trace(), "Unable to save config.", nil)
 		}
@@ -96,11 +109,18 @@ func mainConfigLogger(ctx *cli.Context) {
 			}
 			conf.SyslogLogger.Network = ""
 			conf.SyslogLogger.Addr = ""
+			conf.SyslogLogger.CAFile = ""
+			conf.SyslogLogger.CertFile = ""
+			conf.SyslogLogger.KeyFile = ""
 			err := saveConfig(conf)
 			fatalIf(err.Trace(), "Unable to save config.", nil)
 		}
 		if args.Get(0) == "file" {
 			conf.FileLogger.Filename = ""
+			conf.FileLogger.MaxSize = 0
+			conf.FileLogger.MaxBackups = 0
+			conf.FileLogger.MaxAge = ""
+			conf.FileLogger.Compress = false
 			err := saveConfig(conf)
 			fatalIf(err.Trace(), "Unable to save config.", nil)
 		}
@@ -127,6 +147,18 @@ func enableLog2Mongo(conf *config, args cli.Args) {
 	conf.MongoLogger.Addr = args.Get(0)
 	conf.MongoLogger.DB = args.Get(1)
 	conf.MongoLogger.Collection = args.Get(2)
+	conf.MongoLogger.BufferSize = 0
+	conf.MongoLogger.FlushInterval = ""
+	if args.Get(3) != "" {
+		bufferSize, e := strconv.Atoi(args.Get(3))
+		fatalIf(probe.NewError(e), "Invalid mongo logger buffer size.", nil)
+		conf.MongoLogger.BufferSize = bufferSize
+	}
+	if args.Get(4) != "" {
+		_, e := time.ParseDuration(args.Get(4))
+		fatalIf(probe.NewError(e), "Invalid mongo logger flush interval.", nil)
+		conf.MongoLogger.FlushInterval = args.Get(4)
+	}
 
 	err := saveConfig(conf.configV2)
 	fatalIf(err.Trace(), "Unable to save mongo logging config.", nil)
@@ -145,6 +177,14 @@ func enableLog2Syslog(conf *config, args cli.Args) {
 	}
 	conf.SyslogLogger.Addr = args.Get(0)
 	conf.SyslogLogger.Network = args.Get(1)
+	conf.SyslogLogger.CAFile = ""
+	conf.SyslogLogger.CertFile = ""
+	conf.SyslogLogger.KeyFile = ""
+	if conf.SyslogLogger.Network == syslogTLSNetwork {
+		conf.SyslogLogger.CAFile = args.Get(2)
+		conf.SyslogLogger.CertFile = args.Get(3)
+		conf.SyslogLogger.KeyFile = args.Get(4)
+	}
 	err := saveConfig(conf.configV2)
 	fatalIf(err.Trace(), "Unable to save syslog config.", nil)
 }
@@ -162,6 +202,28 @@ func enableLog2File(conf *config, args cli.Args) {
 		conf.MongoLogger.Collection = ""
 	}
 	conf.FileLogger.Filename = args.Get(0)
+	conf.FileLogger.MaxSize = 0
+	conf.FileLogger.MaxBackups = 0
+	conf.FileLogger.MaxAge = ""
+	conf.FileLogger.Compress = false
+	if args.Get(1) != "" {
+		maxSize, e := strconv.Atoi(args.Get(1))
+		fatalIf(probe.NewError(e), "Invalid file logger max size.", nil)
+		conf.FileLogger.MaxSize = maxSize
+	}
+	if args.Get(2) != "" {
+		maxBackups, e := strconv.Atoi(args.Get(2))
+		fatalIf(probe.NewError(e), "Invalid file logger max backups.", nil)
+		conf.FileLogger.MaxBackups = maxBackups
+	}
+	if args.Get(3) != "" {
+		_, e := time.ParseDuration(args.Get(3))
+		fatalIf(probe.NewError(e), "Invalid file logger max age.", nil)
+		conf.FileLogger.MaxAge = args.Get(3)
+	}
+	if args.Get(4) == "gzip" {
+		conf.FileLogger.Compress = true
+	}
 	err := saveConfig(conf.configV2)
 	fatalIf(err.Trace(), "Unable to save file logging config.", nil)
 }
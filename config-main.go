@@ -32,6 +32,7 @@ var configCmd = cli.Command{
 	Subcommands: []cli.Command{
 		configLoggerCmd,
 		configVersionCmd,
+		configShowCmd,
 	},
 	CustomHelpTemplate: `NAME:
   {{.Name}} - {{.Usage}}
@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// secretKeyMask - replaces secret access key in printed config
+const secretKeyMask = "********"
+
+// Print effective config.
+var configShowCmd = cli.Command{
+	Name:   "show",
+	Usage:  "Print effective config with secrets redacted.",
+	Action: mainConfigShow,
+	CustomHelpTemplate: `NAME:
+   minio config {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio config {{.Name}}
+
+`,
+}
+
+func mainConfigShow(ctx *cli.Context) {
+	if ctx.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(ctx, "show", 1) // last argument is exit code
+	}
+
+	config, err := loadConfigV2()
+	fatalIf(err.Trace(), "Unable to load config", nil)
+
+	err = printConfig(config)
+	fatalIf(err.Trace(), "Unable to print config", nil)
+}
+
+// redactConfig - returns a copy of config with secrets masked
+func redactConfig(conf *configV2) *configV2 {
+	redacted := *conf
+	if redacted.Credentials.SecretAccessKey != "" {
+		redacted.Credentials.SecretAccessKey = secretKeyMask
+	}
+	if redacted.MasterKey != "" {
+		redacted.MasterKey = secretKeyMask
+	}
+	return &redacted
+}
+
+// printConfig - print redacted config as indented JSON, or as a single line with '--json'
+func printConfig(conf *configV2) *probe.Error {
+	var configBytes []byte
+	var e error
+	if globalJSONFlag {
+		configBytes, e = json.Marshal(redactConfig(conf))
+	} else {
+		configBytes, e = json.MarshalIndent(redactConfig(conf), "", "\t")
+	}
+	if e != nil {
+		return probe.NewError(e)
+	}
+	Println(string(configBytes))
+	return nil
+}
@@ -22,6 +22,8 @@ import (
 	"sync"
 
 	"path/filepath"
+
+	"github.com/fatih/color"
 )
 
 type logLevel int
@@ -168,3 +170,11 @@ func ProgramName() string {
 	_, progName := filepath.Split(os.Args[0])
 	return progName
 }
+
+// setColorOutput - turn off color on request and for json output, color package turns
+// it off by itself when stdout is not a terminal
+func setColorOutput(noColor bool) {
+	if noColor || globalJSONFlag {
+		color.NoColor = true
+	}
+}
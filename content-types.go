@@ -0,0 +1,102 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// defaultContentType - content type of objects whose extension is not mapped
+const defaultContentType = "application/octet-stream"
+
+// defaultContentTypes - content types of objects by extension, served when no content type is
+// stored with the object
+var defaultContentTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".csv":   "text/csv; charset=utf-8",
+	".gif":   "image/gif",
+	".gz":    "application/gzip",
+	".htm":   "text/html; charset=utf-8",
+	".html":  "text/html; charset=utf-8",
+	".ico":   "image/x-icon",
+	".jpeg":  "image/jpeg",
+	".jpg":   "image/jpeg",
+	".js":    "application/javascript",
+	".json":  "application/json",
+	".md":    "text/markdown; charset=utf-8",
+	".mjs":   "application/javascript",
+	".mp3":   "audio/mpeg",
+	".mp4":   "video/mp4",
+	".pdf":   "application/pdf",
+	".png":   "image/png",
+	".svg":   "image/svg+xml",
+	".ttf":   "font/ttf",
+	".txt":   "text/plain; charset=utf-8",
+	".wasm":  "application/wasm",
+	".webm":  "video/webm",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".xml":   "application/xml",
+	".zip":   "application/zip",
+}
+
+// contentTypes - content types of objects by lower case extension
+type contentTypes map[string]string
+
+// newContentTypes - default content types with overrides of the config applied, an override
+// with an empty content type unmaps its extension
+func newContentTypes(overrides map[string]string) (contentTypes, *probe.Error) {
+	types := make(contentTypes, len(defaultContentTypes)+len(overrides))
+	for ext, contentType := range defaultContentTypes {
+		types[ext] = contentType
+	}
+	for ext, contentType := range overrides {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 || strings.ContainsAny(ext, "/ ") {
+			return nil, probe.NewError(errInvalidContentType).Trace(ext)
+		}
+		ext = strings.ToLower(ext)
+		if contentType == "" {
+			delete(types, ext)
+			continue
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return nil, probe.NewError(errInvalidContentType).Trace(ext, contentType)
+		}
+		types[ext] = contentType
+	}
+	return types, nil
+}
+
+// contentType - content type object is served with, the stored one unless it is empty or
+// the default, else the one mapped to the extension of object, the defaults if types is nil
+func (types contentTypes) contentType(object, stored string) string {
+	if stored != "" && stored != defaultContentType {
+		return stored
+	}
+	if types == nil {
+		types = defaultContentTypes
+	}
+	if contentType, ok := types[strings.ToLower(path.Ext(object))]; ok {
+		return contentType
+	}
+	return defaultContentType
+}
@@ -0,0 +1,157 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// Object events posted to bucket webhooks, named as S3 event notifications
+const (
+	eventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	eventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	eventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	eventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+)
+
+// defaultEventQueueSize - events waiting for delivery, further events are dropped
+const defaultEventQueueSize = 1000
+
+// eventDeliveryTimeout - time a webhook has to accept an event
+const eventDeliveryTimeout = 10 * time.Second
+
+// eventDelivery - event waiting to be posted to a webhook
+type eventDelivery struct {
+	webhook string
+	event   NotificationEvent
+}
+
+// eventNotifier - posts object events to bucket webhooks one by one in the background, events
+// are dropped while the queue is full so that requests never wait on a slow webhook
+type eventNotifier struct {
+	queue  chan eventDelivery
+	client *http.Client
+}
+
+// newEventNotifier - start delivering events queued from now on
+func newEventNotifier(queueSize int) *eventNotifier {
+	notifier := &eventNotifier{
+		queue:  make(chan eventDelivery, queueSize),
+		client: &http.Client{Timeout: eventDeliveryTimeout},
+	}
+	go notifier.deliverEvents()
+	return notifier
+}
+
+// deliverEvents - post queued events in order, failures are logged and the event is dropped
+func (n *eventNotifier) deliverEvents() {
+	for delivery := range n.queue {
+		err := n.deliver(delivery)
+		errorIf(err.Trace(delivery.webhook), "Delivering event notification failed.", map[string]interface{}{
+			"event":  delivery.event.Records[0].EventName,
+			"bucket": delivery.event.Records[0].S3.Bucket.Name,
+			"object": delivery.event.Records[0].S3.Object.Key,
+		})
+	}
+}
+
+// deliver - post an event to its webhook as JSON, any status but 2xx fails
+func (n *eventNotifier) deliver(delivery eventDelivery) *probe.Error {
+	eventBytes, err := json.Marshal(delivery.event)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	response, err := n.client.Post(delivery.webhook, "application/json", bytes.NewReader(eventBytes))
+	if err != nil {
+		return probe.NewError(err)
+	}
+	defer response.Body.Close()
+	// drain the body so that the connection is reused
+	io.Copy(ioutil.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return probe.NewError(errEventNotDelivered).Trace(response.Status)
+	}
+	return nil
+}
+
+// notify - queue an event if bucket has a webhook, never blocks
+func (n *eventNotifier) notify(webhook, eventName string, object fs.ObjectMetadata) {
+	delivery := eventDelivery{
+		webhook: webhook,
+		event:   newNotificationEvent(eventName, object),
+	}
+	select {
+	case n.queue <- delivery:
+	default:
+		log.WithFields(map[string]interface{}{
+			"event":  eventName,
+			"bucket": object.Bucket,
+			"object": object.Object,
+		}).Warn("Event notification queue is full, event dropped.")
+	}
+}
+
+// newNotificationEvent - event of a single object
+func newNotificationEvent(eventName string, object fs.ObjectMetadata) NotificationEvent {
+	return NotificationEvent{
+		Records: []NotificationEventRecord{
+			{
+				EventVersion: "2.0",
+				EventSource:  "minio:s3",
+				AwsRegion:    serverRegion(),
+				EventTime:    time.Now().UTC().Format(rfcFormat),
+				EventName:    eventName,
+				S3: NotificationEventS3{
+					SchemaVersion: "1.0",
+					Bucket: NotificationEventBucket{
+						Name: object.Bucket,
+						ARN:  "arn:aws:s3:::" + object.Bucket,
+					},
+					Object: NotificationEventObject{
+						Key:  object.Object,
+						Size: object.Size,
+						ETag: object.Md5,
+					},
+				},
+			},
+		},
+	}
+}
+
+// notifyObjectEvent - queue an event for the bucket webhook of object, if there is any
+func (api CloudStorageAPI) notifyObjectEvent(eventName string, object fs.ObjectMetadata) {
+	if api.Notifier == nil {
+		return
+	}
+	bucketMetadata, err := api.ObjectAPI.GetBucketMetadata(object.Bucket)
+	if err != nil {
+		errorIf(err.Trace(object.Bucket), "GetBucketMetadata failed.", nil)
+		return
+	}
+	if bucketMetadata.Notification == "" {
+		return
+	}
+	api.Notifier.notify(bucketMetadata.Notification, eventName, object)
+}
@@ -25,7 +25,18 @@ var (
 	addressFlag = cli.StringFlag{
 		Name:  "address",
 		Value: ":9000",
-		Usage: "ADDRESS:PORT for cloud storage access.",
+		Usage: "Comma separated ADDRESS:PORT for cloud storage access, :PORT listens on all interfaces, or unix:PATH to listen on a unix domain socket.",
+	}
+
+	socketModeFlag = cli.StringFlag{
+		Name:  "socket-mode",
+		Value: "0660",
+		Usage: "Permissions of the unix domain socket file, in octal.",
+	}
+
+	profileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "ADDRESS:PORT to serve net/http/pprof profiles on, keep it private. Off by default.",
 	}
 
 	accessLogFlag = cli.BoolFlag{
@@ -34,6 +45,13 @@ var (
 		Usage: "Enable access logs for all incoming HTTP request.",
 	}
 
+	accessLogSampleFlag = cli.StringFlag{
+		Name:  "accesslog-sample",
+		Hide:  true,
+		Value: "1",
+		Usage: "Fraction of successful requests logged by --enable-accesslog, failed requests are always logged: [DEFAULT: 1].",
+	}
+
 	rateLimitFlag = cli.IntFlag{
 		Name:  "ratelimit",
 		Hide:  true,
@@ -44,7 +62,28 @@ var (
 	anonymousFlag = cli.BoolFlag{
 		Name:  "anonymous",
 		Hide:  true,
-		Usage: "Make server run in anonymous mode where all client connections are accepted.",
+		Usage: "Deprecated, use --anonymous-read. Make server run in anonymous mode where all client connections are accepted.",
+	}
+
+	anonymousReadFlag = cli.StringFlag{
+		Name:  "anonymous-read",
+		Usage: "Comma separated buckets whose objects anyone may list and read without signature, '*' for all. Writes still need a signature.",
+	}
+
+	allowIPsFlag = cli.StringFlag{
+		Name:  "allow-ips",
+		Value: ipFilterAll,
+		Usage: "Comma separated IPv4 and IPv6 CIDRs requests are accepted from, '*' for all. Others are denied with 403.",
+	}
+
+	denyIPsFlag = cli.StringFlag{
+		Name:  "deny-ips",
+		Usage: "Comma separated IPv4 and IPv6 CIDRs requests are denied from with 403, even if allowed.",
+	}
+
+	trustedProxiesFlag = cli.StringFlag{
+		Name:  "trusted-proxies",
+		Usage: "Comma separated CIDRs of proxies whose X-Forwarded-For or X-Real-IP is the client address logged, limited and filtered, ignored otherwise.",
 	}
 
 	certFlag = cli.StringFlag{
@@ -57,10 +96,55 @@ var (
 		Usage: "Provide your domain private key.",
 	}
 
+	strictCertFlag = cli.BoolFlag{
+		Name:  "strict-cert",
+		Usage: "Refuse to start with an expired or not yet valid certificate instead of warning.",
+	}
+
+	http2Flag = cli.BoolFlag{
+		Name:  "http2",
+		Usage: "Negotiate HTTP/2 with clients supporting it over TLS, HTTP/1.1 is served otherwise.",
+	}
+
+	browserFlag = cli.BoolFlag{
+		Name:  "browser",
+		Usage: "Serve a web UI for browsing buckets and downloading objects at /_minio/browser/, logging in with the access keys. Needs a build with the browser tag.",
+	}
+
+	credentialsFileFlag = cli.StringFlag{
+		Name:  "credentials-file",
+		Usage: "Write access keys to a file readable only by the owner, instead of printing the secret key.",
+	}
+
+	validateFlag = cli.BoolFlag{
+		Name:  "validate",
+		Usage: "Validate server path, certificates, config and loggers and exit without starting the server.",
+	}
+
+	configDirFlag = cli.StringFlag{
+		Name:  "config-dir",
+		Usage: "Path to configuration directory, overrides MINIO_CONFIG_DIR: [DEFAULT: ~/.minio].",
+	}
+
+	quietFlag = cli.BoolFlag{
+		Name:  "quiet",
+		Usage: "Suppress startup banner, along with access keys when written to --credentials-file.",
+	}
+
 	jsonFlag = cli.BoolFlag{
 		Name:  "json",
 		Usage: "Enable json formatted output.",
 	}
+
+	noColorFlag = cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Disable color theme, color is off already when output is not a terminal.",
+	}
+
+	debugFlag = cli.BoolFlag{
+		Name:  "debug",
+		Usage: "Log errors along with their call traces and system info, only their cause is logged without.",
+	}
 )
 
 // registerFlag registers a cli flag
@@ -46,6 +46,19 @@ type resourceHandler struct {
 	handler http.Handler
 }
 
+type requestIDHandler struct {
+	handler http.Handler
+}
+
+type maintenanceHandler struct {
+	handler http.Handler
+}
+
+type requestBodyLimitHandler struct {
+	handler http.Handler
+	limit   int64
+}
+
 func parseDate(req *http.Request) (time.Time, error) {
 	amzDate := req.Header.Get(http.CanonicalHeaderKey("x-amz-date"))
 	switch {
@@ -109,8 +122,10 @@ func (h timeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		duration := time.Since(date)
-		minutes := time.Duration(5) * time.Minute
-		if duration.Minutes() > minutes.Minutes() {
+		if duration < 0 {
+			duration = -duration
+		}
+		if duration > globalClockSkew {
 			writeErrorResponse(w, r, RequestTimeTooSkewed, r.URL.Path)
 			return
 		}
@@ -118,6 +133,60 @@ func (h timeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.handler.ServeHTTP(w, r)
 }
 
+// RequestIDHandler assigns a unique id to every request, the id is sent back
+// in response headers and error responses and is attached to all the log records
+func RequestIDHandler(h http.Handler) http.Handler {
+	return requestIDHandler{h}
+}
+
+func (h requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(requestIDHeader, string(generateRequestID()))
+	h.handler.ServeHTTP(w, r)
+}
+
+// MaintenanceModeHandler rejects all mutating requests with ServiceUnavailable while the
+// server is in maintenance mode, read requests and admin API are served as usual
+func MaintenanceModeHandler(h http.Handler) http.Handler {
+	return maintenanceHandler{h}
+}
+
+func (h maintenanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isMaintenanceMode() && !strings.HasPrefix(r.URL.Path, adminPathPrefix+separator) {
+		switch r.Method {
+		case "PUT", "POST", "DELETE":
+			writeErrorResponse(w, r, ServiceUnavailable, r.URL.Path)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// RequestBodyLimitHandler rejects requests with bodies larger than limit with RequestBodyTooLarge
+// before anything is read, and cuts off bodies of unknown length at limit. Parts are exempt, they
+// are limited by their own size.
+func RequestBodyLimitHandler(limit int64) MiddlewareHandler {
+	return func(h http.Handler) http.Handler {
+		return requestBodyLimitHandler{handler: h, limit: limit}
+	}
+}
+
+// isObjectPartRequest - true if the request uploads a part of a multipart upload
+func isObjectPartRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	return r.Method == "PUT" && query.Get("partNumber") != "" && query.Get("uploadId") != ""
+}
+
+func (h requestBodyLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isObjectPartRequest(r) {
+		if r.ContentLength > h.limit {
+			writeErrorResponse(w, r, RequestBodyTooLarge, r.URL.Path)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.limit)
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
 // CorsHandler handler for CORS (Cross Origin Resource Sharing)
 func CorsHandler(h http.Handler) http.Handler {
 	c := cors.New(cors.Options{
@@ -16,6 +16,55 @@
 
 package main
 
+import (
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
 var (
-	globalJSONFlag = false // Json flag set via command line
+	globalJSONFlag  = false               // Json flag set via command line
+	globalQuietFlag = false               // Quiet flag set via command line
+	globalDebugFlag = false               // Debug flag set via command line, errors are logged with their traces
+	globalClockSkew = fs.DefaultClockSkew // Allowed request clock skew set via server command line
+	globalRegions   = defaultRegions      // Regions signatures may be scoped to set via server command line
+
+	globalSigningKeys = fs.NewSigningKeyCache(fs.DefaultSigningKeyTTL) // Signing keys reused across requests, purged when credentials change
+
+	globalMaintenanceMode int32 // Non zero while all mutating requests are rejected, toggled via admin API
+
+	globalServerConfig atomic.Value // Config of the running server, swapped as a whole on reload
 )
+
+// isMaintenanceMode - true if the server is in maintenance mode
+func isMaintenanceMode() bool {
+	return atomic.LoadInt32(&globalMaintenanceMode) != 0
+}
+
+// setMaintenanceMode - enable or disable maintenance mode
+func setMaintenanceMode(enable bool) {
+	var mode int32
+	if enable {
+		mode = 1
+	}
+	atomic.StoreInt32(&globalMaintenanceMode, mode)
+}
+
+// getServerConfig - config of the running server, read from disk if the server did not set one
+func getServerConfig() (*configV2, *probe.Error) {
+	if conf, ok := globalServerConfig.Load().(*configV2); ok && conf != nil {
+		return conf, nil
+	}
+	return loadConfigV2()
+}
+
+// setServerConfig - swap in config of the running server, requests in progress keep the one
+// they started with. Signing keys derived from credentials which changed are dropped.
+func setServerConfig(conf *configV2) {
+	previous, _ := globalServerConfig.Load().(*configV2)
+	globalServerConfig.Store(conf)
+	if previous == nil || conf == nil || previous.Credentials != conf.Credentials {
+		globalSigningKeys.Purge()
+	}
+}
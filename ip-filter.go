@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// ipFilterAll - allow list entry naming every source address
+const ipFilterAll = "*"
+
+// ipFilter - source addresses requests are accepted from, a denied address is turned away even
+// if it is allowed as well. The client address is the remote address of the connection, or the
+// one forwarded by trusted proxies, see trusted-proxies.go.
+type ipFilter struct {
+	allowAll bool
+	allow    []*net.IPNet
+	deny     []*net.IPNet
+}
+
+// parseIPFilter - parse comma separated allowed and denied networks, nil if every address is
+// allowed and none is denied
+func parseIPFilter(allow, deny string) (*ipFilter, *probe.Error) {
+	filter := &ipFilter{}
+	var err *probe.Error
+	if strings.TrimSpace(allow) == ipFilterAll {
+		filter.allowAll = true
+	} else if filter.allow, err = parseNetworks(allow); err != nil {
+		return nil, err.Trace(allow)
+	}
+	if filter.deny, err = parseNetworks(deny); err != nil {
+		return nil, err.Trace(deny)
+	}
+	if filter.allowAll && len(filter.deny) == 0 {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// parseNetworks - parse comma separated IPv4 and IPv6 CIDRs, a bare address is a network of its own
+func parseNetworks(value string) ([]*net.IPNet, *probe.Error) {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(value, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, probe.NewError(errInvalidCIDR).Trace(cidr)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, probe.NewError(errInvalidCIDR).Trace(cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// containsIP - true if ip is in any of networks
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows - true if requests from ip are accepted
+func (f *ipFilter) allows(ip net.IP) bool {
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return f.allowAll || containsIP(f.allow, ip)
+}
+
+type ipFilterHandler struct {
+	handler http.Handler
+	filter  *ipFilter
+}
+
+// IPFilterHandler turns away requests from source addresses which are not allowed with
+// AccessDenied ahead of signature verification. Requests over a unix domain socket are
+// governed by the permissions of the socket file instead.
+func IPFilterHandler(filter *ipFilter) MiddlewareHandler {
+	return func(h http.Handler) http.Handler {
+		return ipFilterHandler{handler: h, filter: filter}
+	}
+}
+
+func (h ipFilterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// requests over a unix domain socket have no address
+	if ip := net.ParseIP(clientAddress(r)); ip != nil && !h.filter.allows(ip) {
+		writeErrorResponse(w, r, AccessDenied, r.URL.Path)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
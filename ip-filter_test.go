@@ -0,0 +1,110 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type IPFilterSuite struct{}
+
+var _ = Suite(&IPFilterSuite{})
+
+func (s *IPFilterSuite) TestParseIPFilter(c *C) {
+	// every address allowed and none denied needs no filter
+	filter, perr := parseIPFilter(ipFilterAll, "")
+	c.Assert(perr, IsNil)
+	c.Assert(filter, IsNil)
+
+	filter, perr = parseIPFilter("10.0.0.0/8, 192.168.1.7,2001:db8::/32,::1", "10.1.0.0/16")
+	c.Assert(perr, IsNil)
+	c.Assert(len(filter.allow), Equals, 4)
+	c.Assert(filter.allow[1].String(), Equals, "192.168.1.7/32")
+	c.Assert(filter.allow[3].String(), Equals, "::1/128")
+	c.Assert(len(filter.deny), Equals, 1)
+
+	for _, networks := range [][2]string{
+		{"10.0.0.0/33", ""},
+		{"*", "not-an-address"},
+		{"*", "10.0.0.0/8,*"},
+		{"2001:db8::/129", ""},
+	} {
+		_, perr = parseIPFilter(networks[0], networks[1])
+		c.Assert(perr, Not(IsNil))
+		c.Assert(perr.ToGoError(), Equals, errInvalidCIDR)
+	}
+}
+
+func (s *IPFilterSuite) TestIPFilter(c *C) {
+	filter, perr := parseIPFilter("10.0.0.0/8,2001:db8::/32", "10.1.0.0/16,2001:db8:bad::/48")
+	c.Assert(perr, IsNil)
+	proxies, perr := parseTrustedProxies("192.168.0.1,fd00::/8")
+	c.Assert(perr, IsNil)
+	api := CloudStorageAPI{
+		ObjectAPI:      fs.NewMemoryFS(),
+		Anonymous:      true,
+		IPFilter:       filter,
+		TrustedProxies: proxies,
+	}
+	handler := getCloudStorageAPIHandler(api)
+
+	do := func(remoteAddr string, forwardedFor ...string) int {
+		request, err := http.NewRequest("GET", "http://localhost/", nil)
+		c.Assert(err, IsNil)
+		request.RemoteAddr = remoteAddr
+		for _, hops := range forwardedFor {
+			request.Header.Add("X-Forwarded-For", hops)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// allowed networks, IPv4 and IPv6
+	c.Assert(do("10.2.3.4:5000"), Equals, http.StatusOK)
+	c.Assert(do("[2001:db8::1]:5000"), Equals, http.StatusOK)
+	// outside of allowed networks, or denied within them
+	c.Assert(do("172.16.0.1:5000"), Equals, http.StatusForbidden)
+	c.Assert(do("[2001:db9::1]:5000"), Equals, http.StatusForbidden)
+	c.Assert(do("10.1.2.3:5000"), Equals, http.StatusForbidden)
+	c.Assert(do("[2001:db8:bad::1]:5000"), Equals, http.StatusForbidden)
+
+	// X-Forwarded-For of clients which are not trusted proxies is ignored
+	c.Assert(do("172.16.0.1:5000", "10.2.3.4"), Equals, http.StatusForbidden)
+	c.Assert(do("10.1.2.3:5000", "10.2.3.4"), Equals, http.StatusForbidden)
+
+	// trusted proxies forward the client address, which is filtered instead of theirs
+	c.Assert(do("192.168.0.1:5000"), Equals, http.StatusForbidden)
+	c.Assert(do("192.168.0.1:5000", "10.2.3.4"), Equals, http.StatusOK)
+	c.Assert(do("192.168.0.1:5000", "172.16.0.1"), Equals, http.StatusForbidden)
+	c.Assert(do("192.168.0.1:5000", "10.1.2.3"), Equals, http.StatusForbidden)
+	c.Assert(do("[fd00::1]:5000", "2001:db8::1"), Equals, http.StatusOK)
+	// addresses made up by the client ahead of the ones added by trusted proxies are ignored
+	c.Assert(do("192.168.0.1:5000", "10.2.3.4, 172.16.0.1"), Equals, http.StatusForbidden)
+	c.Assert(do("192.168.0.1:5000", "172.16.0.1, 10.2.3.4"), Equals, http.StatusOK)
+	c.Assert(do("192.168.0.1:5000", "10.2.3.4", "fd00::2"), Equals, http.StatusOK)
+	c.Assert(do("192.168.0.1:5000", "garbage, 10.2.3.4"), Equals, http.StatusOK)
+	// malformed addresses added by trusted proxies tell nothing about the client, the proxy is filtered
+	c.Assert(do("192.168.0.1:5000", "10.2.3.4, garbage"), Equals, http.StatusForbidden)
+
+	// requests over a unix domain socket carry no address
+	c.Assert(do("@"), Equals, http.StatusOK)
+}
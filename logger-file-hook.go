@@ -17,34 +17,175 @@
 package main
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/minio/minio-xl/pkg/probe"
 )
 
+// rotatedTimeFormat - suffix of rotated log files, sorts in chronological order
+const rotatedTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// localFile hook writes log entries to a file, rotated once it grows past maxSize bytes
+// or is older than maxAge. Only maxBackups rotated files are retained, zero values disable.
 type localFile struct {
 	*os.File
+	mutex      *sync.Mutex
+	filename   string
+	size       int64
+	openedAt   time.Time
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
 }
 
-func log2File(filename string) *probe.Error {
-	fileHook, e := newFile(filename)
+func log2File(hooks logrus.LevelHooks, filename string, maxSize int64, maxAge time.Duration, maxBackups int, compress bool) *probe.Error {
+	fileHook, e := newFile(filename, maxSize, maxAge, maxBackups, compress)
 	if e != nil {
 		return probe.NewError(e)
 	}
-	log.Hooks.Add(fileHook)                 // Add a local file hook.
-	log.Formatter = &logrus.JSONFormatter{} // JSON formatted log.
-	log.Level = logrus.InfoLevel            // Minimum log level.
+	hooks.Add(fileHook) // Add a local file hook.
 	return nil
 }
 
-func newFile(filename string) (*localFile, error) {
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+func newFile(filename string, maxSize int64, maxAge time.Duration, maxBackups int, compress bool) (*localFile, error) {
+	l := &localFile{
+		mutex:      &sync.Mutex{},
+		filename:   filename,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open - open log file for appending, caller should hold the lock
+func (l *localFile) open() error {
+	file, err := os.OpenFile(l.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	st, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.File = file
+	l.size = st.Size()
+	l.openedAt = time.Now()
+	return nil
+}
+
+// shouldRotate - verify if writing n more bytes needs the log file to be rotated first
+func (l *localFile) shouldRotate(n int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.maxSize > 0 && l.size+n > l.maxSize {
+		return true
+	}
+	return l.maxAge > 0 && time.Since(l.openedAt) > l.maxAge
+}
+
+// rotate - move current log file aside, compress it if asked for and prune old
+// rotated files, caller should hold the lock
+func (l *localFile) rotate() error {
+	if err := l.File.Close(); err != nil {
+		return err
+	}
+	rotatedFilename := l.filename + "." + time.Now().UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(l.filename, rotatedFilename); err != nil {
+		// keep logging to the current file
+		if e := l.open(); e != nil {
+			return e
+		}
+		return err
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	if l.compress {
+		if err := compressFile(rotatedFilename); err != nil {
+			return err
+		}
+	}
+	return l.prune()
+}
+
+// prune - remove oldest rotated log files beyond maxBackups
+func (l *localFile) prune() error {
+	if l.maxBackups <= 0 {
+		return nil
+	}
+	rotated, err := l.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	if len(rotated) <= l.maxBackups {
+		return nil
+	}
+	sort.Strings(rotated)
+	for _, name := range rotated[:len(rotated)-l.maxBackups] {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotatedFiles - log files rotated off filename, other files sharing its prefix are left alone
+func (l *localFile) rotatedFiles() ([]string, error) {
+	matches, err := filepath.Glob(l.filename + ".*")
 	if err != nil {
 		return nil, err
 	}
-	return &localFile{file}, nil
+	var rotated []string
+	for _, name := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, l.filename+"."), ".gz")
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			rotated = append(rotated, name)
+		}
+	}
+	return rotated, nil
+}
+
+// compressFile - gzip filename into filename.gz and remove the original
+func compressFile(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(filename+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(dst)
+	if _, err = io.Copy(gzipWriter, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(filename)
 }
 
 func (l *localFile) Fire(entry *logrus.Entry) error {
@@ -52,11 +193,28 @@ func (l *localFile) Fire(entry *logrus.Entry) error {
 	if err != nil {
 		return fmt.Errorf("Unable to read entry, %v", err)
 	}
-	l.File.Write([]byte(line + "\n"))
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	data := []byte(line + "\n")
+	if l.shouldRotate(int64(len(data))) {
+		if err = l.rotate(); err != nil {
+			return fmt.Errorf("Unable to rotate log file, %v", err)
+		}
+	}
+	n, _ := l.File.Write(data)
+	l.size += int64(n)
 	l.File.Sync()
 	return nil
 }
 
+// Close - close the log file, no entries are written afterwards
+func (l *localFile) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.File.Close()
+}
+
 // Levels -
 func (l *localFile) Levels() []logrus.Level {
 	return []logrus.Level{
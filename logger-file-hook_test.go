@@ -0,0 +1,191 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	. "gopkg.in/check.v1"
+)
+
+type FileLoggerSuite struct{}
+
+var _ = Suite(&FileLoggerSuite{})
+
+func newTestEntry(message string) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.New())
+	entry.Logger.Formatter = &logrus.JSONFormatter{}
+	entry.Level = logrus.ErrorLevel
+	entry.Message = message
+	return entry
+}
+
+// testEntrySize - size of a log entry as written by the file logger
+func testEntrySize(c *C, entry *logrus.Entry) int64 {
+	line, err := entry.String()
+	c.Assert(err, IsNil)
+	return int64(len(line) + 1)
+}
+
+func (s *FileLoggerSuite) TestFileLoggerRotate(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-logger-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	entry := newTestEntry(strings.Repeat("a", 200))
+	lineSize := testEntrySize(c, entry)
+
+	// room for exactly 4 log entries
+	filename := filepath.Join(root, "minio.log")
+	hook, err := newFile(filename, 4*lineSize, 0, 2, false)
+	c.Assert(err, IsNil)
+	defer hook.Close()
+
+	for i := 0; i < 4; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	rotated, err := filepath.Glob(filename + ".*")
+	c.Assert(err, IsNil)
+	c.Assert(len(rotated), Equals, 0)
+
+	// writing past the threshold rotates
+	for i := 0; i < 4; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	rotated, err = filepath.Glob(filename + ".*")
+	c.Assert(err, IsNil)
+	c.Assert(len(rotated), Equals, 1)
+	st, err := os.Stat(filename)
+	c.Assert(err, IsNil)
+	c.Assert(st.Size(), Equals, 4*lineSize)
+
+	// only max backups are retained, oldest ones are pruned, other files are left alone
+	for _, name := range []string{filename + ".bak", filename + ".old"} {
+		c.Assert(ioutil.WriteFile(name, []byte("keep"), 0600), IsNil)
+	}
+	for i := 0; i < 20; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	newRotated, err := filepath.Glob(filename + ".*")
+	c.Assert(err, IsNil)
+	c.Assert(len(newRotated), Equals, 4)
+	for _, name := range newRotated {
+		c.Assert(name, Not(Equals), rotated[0])
+	}
+	for _, name := range []string{filename + ".bak", filename + ".old"} {
+		_, err = os.Stat(name)
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *FileLoggerSuite) TestFileLoggerRotateCompress(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-logger-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	entry := newTestEntry(strings.Repeat("b", 200))
+	lineSize := testEntrySize(c, entry)
+
+	filename := filepath.Join(root, "minio.log")
+	hook, err := newFile(filename, 2*lineSize, 0, 0, true)
+	c.Assert(err, IsNil)
+	defer hook.Close()
+
+	for i := 0; i < 3; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	rotated, err := filepath.Glob(filename + ".*")
+	c.Assert(err, IsNil)
+	c.Assert(len(rotated), Equals, 1)
+	c.Assert(strings.HasSuffix(rotated[0], ".gz"), Equals, true)
+
+	file, err := os.Open(rotated[0])
+	c.Assert(err, IsNil)
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(gzipReader)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(data), strings.Repeat("b", 200)), Equals, 2)
+}
+
+func (s *FileLoggerSuite) TestFileLoggerMaxAge(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-logger-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	filename := filepath.Join(root, "minio.log")
+	hook, err := newFile(filename, 0, time.Hour, 0, false)
+	c.Assert(err, IsNil)
+	defer hook.Close()
+
+	entry := newTestEntry("aged")
+	c.Assert(hook.Fire(entry), IsNil)
+	hook.openedAt = time.Now().Add(-2 * time.Hour)
+	c.Assert(hook.Fire(entry), IsNil)
+	rotated, err := filepath.Glob(filename + ".*")
+	c.Assert(err, IsNil)
+	c.Assert(len(rotated), Equals, 1)
+}
+
+func (s *FileLoggerSuite) TestFileLoggerConcurrent(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-logger-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	filename := filepath.Join(root, "minio.log")
+	hook, err := newFile(filename, 4096, 0, 0, false)
+	c.Assert(err, IsNil)
+	defer hook.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				hook.Fire(newTestEntry(strings.Repeat("c", 100)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// no log entry is lost or torn across rotations
+	files, err := filepath.Glob(filename + "*")
+	c.Assert(err, IsNil)
+	var lines int
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		c.Assert(err, IsNil)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			c.Assert(strings.Contains(line, strings.Repeat("c", 100)), Equals, true)
+			lines++
+		}
+	}
+	c.Assert(lines, Equals, 500)
+}
@@ -18,6 +18,9 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/minio/minio-xl/pkg/probe"
@@ -25,39 +28,137 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
-// mongoDB collection
+const (
+	// defaultMongoBufferSize - number of log records buffered before new ones are dropped
+	defaultMongoBufferSize = 1000
+
+	// defaultMongoFlushInterval - maximum time a log record waits in the buffer
+	defaultMongoFlushInterval = time.Second
+
+	// maxMongoBatchSize - maximum number of log records inserted at once
+	maxMongoBatchSize = 100
+)
+
+// mongoDB hook buffers log records and inserts them in batches from a background
+// goroutine, so that a slow mongodb never blocks the caller.
 type mongoDB struct {
-	c *mgo.Collection
+	// number of log records dropped, either due to a full buffer or failed inserts,
+	// kept first for 64bit alignment of atomic operations
+	dropped uint64
+
+	insert        func(docs ...interface{}) error
+	records       chan bson.M
+	done          chan struct{}
+	flushed       chan struct{}
+	flushInterval time.Duration
+
+	// session of the insert, closed once the final records are flushed
+	session *mgo.Session
 }
 
-func log2Mongo(url, db, collection string) *probe.Error {
-	mongoHook, e := newMongo(url, db, collection)
+func log2Mongo(hooks logrus.LevelHooks, url, db, collection string, bufferSize int, flushInterval time.Duration) *probe.Error {
+	mongoHook, e := newMongo(url, db, collection, bufferSize, flushInterval)
 	if e != nil {
 		return probe.NewError(e)
 	}
-	log.Hooks.Add(mongoHook)                // Add mongodb hook.
-	log.Formatter = &logrus.JSONFormatter{} // JSON formatted log.
-	log.Level = logrus.InfoLevel            // Minimum log level.
+	hooks.Add(mongoHook) // Add mongodb hook.
 	return nil
 }
 
 // newMongo -
-func newMongo(mgoEndpoint, db, collection string) (*mongoDB, error) {
+func newMongo(mgoEndpoint, db, collection string, bufferSize int, flushInterval time.Duration) (*mongoDB, error) {
 	session, err := mgo.Dial(mgoEndpoint)
 	if err != nil {
 		return nil, err
 	}
-	return &mongoDB{c: session.DB(db).C(collection)}, nil
+	h := newMongoHook(session.DB(db).C(collection).Insert, bufferSize, flushInterval)
+	h.session = session
+	return h, nil
 }
 
-// Fire - the log event
+// newMongoHook - start background flushing of log records through insert, zero values pick defaults
+func newMongoHook(insert func(docs ...interface{}) error, bufferSize int, flushInterval time.Duration) *mongoDB {
+	if bufferSize <= 0 {
+		bufferSize = defaultMongoBufferSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultMongoFlushInterval
+	}
+	h := &mongoDB{
+		insert:        insert,
+		records:       make(chan bson.M, bufferSize),
+		done:          make(chan struct{}),
+		flushed:       make(chan struct{}),
+		flushInterval: flushInterval,
+	}
+	go h.flushLoop()
+	return h
+}
+
+// flushLoop - insert buffered records once a batch fills up or flush interval elapses
+func (h *mongoDB) flushLoop() {
+	defer close(h.flushed)
+	ticker := time.NewTicker(h.flushInterval)
+	defer ticker.Stop()
+
+	var batch []interface{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.insert(batch...); err != nil {
+			atomic.AddUint64(&h.dropped, uint64(len(batch)))
+			fmt.Fprintf(os.Stderr, "Failed to send %d log entries to mongodb: %s\n", len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case record := <-h.records:
+			batch = append(batch, record)
+			if len(batch) >= maxMongoBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-h.done:
+			for len(h.records) > 0 {
+				batch = append(batch, <-h.records)
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// Close - stop background flushing, returns once records buffered so far are inserted
+func (h *mongoDB) Close() error {
+	close(h.done)
+	<-h.flushed
+	if h.session != nil {
+		h.session.Close()
+	}
+	return nil
+}
+
+// Dropped - number of log records which never made it to mongodb
+func (h *mongoDB) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Fire - the log event, queued without blocking. Dropped if the buffer is full.
 func (h *mongoDB) Fire(entry *logrus.Entry) error {
-	entry.Data["Level"] = entry.Level.String()
-	entry.Data["Time"] = entry.Time
-	entry.Data["Message"] = entry.Message
-	mgoErr := h.c.Insert(bson.M(entry.Data))
-	if mgoErr != nil {
-		return fmt.Errorf("Failed to send log entry to mongodb: %s", mgoErr)
+	record := make(bson.M, len(entry.Data)+3)
+	for k, v := range entry.Data {
+		record[k] = v
+	}
+	record["Level"] = entry.Level.String()
+	record["Time"] = entry.Time
+	record["Message"] = entry.Message
+	select {
+	case h.records <- record:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
 	}
 	return nil
 }
@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	. "gopkg.in/check.v1"
+)
+
+type MongoLoggerSuite struct{}
+
+var _ = Suite(&MongoLoggerSuite{})
+
+func (s *MongoLoggerSuite) TestMongoLoggerBatches(c *C) {
+	var mutex sync.Mutex
+	var inserted []interface{}
+	insert := func(docs ...interface{}) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		inserted = append(inserted, docs...)
+		return nil
+	}
+	hook := newMongoHook(insert, 10, 10*time.Millisecond)
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "batched"
+	for i := 0; i < 5; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	// entry itself is not modified
+	c.Assert(len(entry.Data), Equals, 0)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mutex.Lock()
+		n := len(inserted)
+		mutex.Unlock()
+		if n == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	c.Assert(len(inserted), Equals, 5)
+	c.Assert(hook.Dropped(), Equals, uint64(0))
+}
+
+func (s *MongoLoggerSuite) TestMongoLoggerClose(c *C) {
+	var mutex sync.Mutex
+	var inserted []interface{}
+	insert := func(docs ...interface{}) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		inserted = append(inserted, docs...)
+		return nil
+	}
+	// never flushed by the interval, only by closing
+	hook := newMongoHook(insert, 10, time.Hour)
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "closed"
+	for i := 0; i < 3; i++ {
+		c.Assert(hook.Fire(entry), IsNil)
+	}
+	c.Assert(hook.Close(), IsNil)
+	mutex.Lock()
+	defer mutex.Unlock()
+	c.Assert(len(inserted), Equals, 3)
+}
+
+func (s *MongoLoggerSuite) TestMongoLoggerHung(c *C) {
+	hung := make(chan struct{})
+	defer close(hung)
+	insert := func(docs ...interface{}) error {
+		<-hung
+		return nil
+	}
+	hook := newMongoHook(insert, 10, time.Millisecond)
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "hung"
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			hook.Fire(entry)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatal("logging blocked on a hung mongodb")
+	}
+	c.Assert(hook.Dropped() > 0, Equals, true)
+}
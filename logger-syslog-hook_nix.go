@@ -26,21 +26,29 @@ import (
 	"github.com/minio/minio-xl/pkg/probe"
 )
 
+// syslogWriter - implemented by *syslog.Writer and syslogTLSWriter
+type syslogWriter interface {
+	Crit(m string) error
+	Err(m string) error
+	Warning(m string) error
+	Info(m string) error
+	Debug(m string) error
+	Close() error
+}
+
 // syslogHook to send logs via syslog.
 type syslogHook struct {
-	writer        *syslog.Writer
+	writer        syslogWriter
 	syslogNetwork string
 	syslogRaddr   string
 }
 
-func log2Syslog(network, raddr string) *probe.Error {
+func log2Syslog(hooks logrus.LevelHooks, network, raddr string) *probe.Error {
 	syslogHook, e := newSyslog(network, raddr, syslog.LOG_ERR, "MINIO")
 	if e != nil {
 		return probe.NewError(e)
 	}
-	log.Hooks.Add(syslogHook)               // Add syslog hook.
-	log.Formatter = &logrus.JSONFormatter{} // JSON formatted log.
-	log.Level = logrus.InfoLevel            // Minimum log level.
+	hooks.Add(syslogHook) // Add syslog hook.
 	return nil
 }
 
@@ -74,6 +82,11 @@ func (hook *syslogHook) Fire(entry *logrus.Entry) error {
 	}
 }
 
+// Close - close the connection to the syslog server
+func (hook *syslogHook) Close() error {
+	return hook.writer.Close()
+}
+
 // Levels -
 func (hook *syslogHook) Levels() []logrus.Level {
 	return []logrus.Level{
@@ -18,8 +18,15 @@
 
 package main
 
-import "github.com/minio/minio-xl/pkg/probe"
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/minio/minio-xl/pkg/probe"
+)
 
-func log2Syslog(network, raddr string) *probe.Error {
+func log2Syslog(hooks logrus.LevelHooks, network, raddr string) *probe.Error {
+	return probe.NewError(errSysLogNotSupported)
+}
+
+func log2SyslogTLS(hooks logrus.LevelHooks, raddr, caFile, certFile, keyFile string) *probe.Error {
 	return probe.NewError(errSysLogNotSupported)
 }
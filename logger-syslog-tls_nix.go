@@ -0,0 +1,207 @@
+// +build !windows
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log/syslog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// syslogTLSTimeout - timeout for connecting and writing to the syslog server
+	syslogTLSTimeout = 10 * time.Second
+
+	// syslogTLSMinBackoff and syslogTLSMaxBackoff - bounds of the delay between reconnects
+	syslogTLSMinBackoff = time.Second
+	syslogTLSMaxBackoff = time.Minute
+)
+
+// syslogTLSWriter - writes RFC 5424 messages framed as per RFC 5425 over a TLS connection,
+// the connection is re-established lazily with an exponential backoff once lost.
+type syslogTLSWriter struct {
+	mutex     *sync.Mutex
+	raddr     string
+	tlsConfig *tls.Config
+	facility  syslog.Priority
+	tag       string
+	hostname  string
+
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+func log2SyslogTLS(hooks logrus.LevelHooks, raddr, caFile, certFile, keyFile string) *probe.Error {
+	tlsConfig, err := newSyslogTLSConfig(raddr, caFile, certFile, keyFile)
+	if err != nil {
+		return err.Trace(raddr, caFile, certFile, keyFile)
+	}
+	writer, e := newSyslogTLSWriter(raddr, tlsConfig, syslog.LOG_ERR, "MINIO")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	hooks.Add(&syslogHook{writer, syslogTLSNetwork, raddr}) // Add syslog hook.
+	return nil
+}
+
+// newSyslogTLSConfig - trust caFile if set, otherwise system roots. Client certificate is optional.
+func newSyslogTLSConfig(raddr, caFile, certFile, keyFile string) (*tls.Config, *probe.Error) {
+	host, _, e := net.SplitHostPort(raddr)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+	if caFile != "" {
+		caBytes, e := ioutil.ReadFile(caFile)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caBytes) {
+			return nil, probe.NewError(errInvalidSyslogCA)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		certificate, e := tls.LoadX509KeyPair(certFile, keyFile)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// newSyslogTLSWriter - connects to the syslog server, initial connection failure is reported back.
+func newSyslogTLSWriter(raddr string, tlsConfig *tls.Config, priority syslog.Priority, tag string) (*syslogTLSWriter, error) {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	w := &syslogTLSWriter{
+		mutex:     &sync.Mutex{},
+		raddr:     raddr,
+		tlsConfig: tlsConfig,
+		facility:  priority & 0xf8,
+		tag:       tag,
+		hostname:  hostname,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect - dial syslog server, caller should hold the lock
+func (w *syslogTLSWriter) connect() error {
+	dialer := &net.Dialer{Timeout: syslogTLSTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", w.raddr, w.tlsConfig)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	w.backoff = 0
+	return nil
+}
+
+// disconnect - close the connection and schedule next reconnect, caller should hold the lock
+func (w *syslogTLSWriter) disconnect() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	if w.backoff == 0 {
+		w.backoff = syslogTLSMinBackoff
+	} else if w.backoff *= 2; w.backoff > syslogTLSMaxBackoff {
+		w.backoff = syslogTLSMaxBackoff
+	}
+	w.retryAt = time.Now().Add(w.backoff)
+}
+
+// writeAndRetry - write a message, reconnect if the connection was lost. While backing off
+// messages are dropped instead of blocking the caller.
+func (w *syslogTLSWriter) writeAndRetry(severity syslog.Priority, msg string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			return errSyslogUnavailable
+		}
+		if err := w.connect(); err != nil {
+			w.disconnect()
+			return err
+		}
+	}
+	// RFC 5424 message with RFC 5425 octet counting framing
+	message := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", w.facility|severity,
+		time.Now().UTC().Format(time.RFC3339Nano), w.hostname, w.tag, os.Getpid(), msg)
+	w.conn.SetWriteDeadline(time.Now().Add(syslogTLSTimeout))
+	if _, err := fmt.Fprintf(w.conn, "%d %s", len(message), message); err != nil {
+		w.disconnect()
+		return err
+	}
+	return nil
+}
+
+// Crit - log a message with severity LOG_CRIT
+func (w *syslogTLSWriter) Crit(m string) error {
+	return w.writeAndRetry(syslog.LOG_CRIT, m)
+}
+
+// Err - log a message with severity LOG_ERR
+func (w *syslogTLSWriter) Err(m string) error {
+	return w.writeAndRetry(syslog.LOG_ERR, m)
+}
+
+// Warning - log a message with severity LOG_WARNING
+func (w *syslogTLSWriter) Warning(m string) error {
+	return w.writeAndRetry(syslog.LOG_WARNING, m)
+}
+
+// Info - log a message with severity LOG_INFO
+func (w *syslogTLSWriter) Info(m string) error {
+	return w.writeAndRetry(syslog.LOG_INFO, m)
+}
+
+// Debug - log a message with severity LOG_DEBUG
+func (w *syslogTLSWriter) Debug(m string) error {
+	return w.writeAndRetry(syslog.LOG_DEBUG, m)
+}
+
+// Close - close the connection, messages written afterwards reconnect
+func (w *syslogTLSWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
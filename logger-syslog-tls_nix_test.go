@@ -0,0 +1,147 @@
+// +build !windows
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	. "gopkg.in/check.v1"
+)
+
+type SyslogTLSSuite struct{}
+
+var _ = Suite(&SyslogTLSSuite{})
+
+// readSyslogFrame reads a single RFC 5425 octet counted frame
+func readSyslogFrame(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		return "", err
+	}
+	message := make([]byte, n)
+	if _, err = io.ReadFull(reader, message); err != nil {
+		return "", err
+	}
+	return string(message), nil
+}
+
+func (s *SyslogTLSSuite) TestSyslogTLS(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-syslog-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	certFile := filepath.Join(root, "public.crt")
+	keyFile := filepath.Join(root, "private.key")
+	key := writeTestKey(c, keyFile)
+	writeTestCertificate(c, certFile, key, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	c.Assert(err, IsNil)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	c.Assert(err, IsNil)
+	defer listener.Close()
+
+	conns := make(chan *bufio.Reader, 2)
+	closers := make(chan io.Closer, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if err = conn.(*tls.Conn).Handshake(); err != nil {
+				conn.Close()
+				continue
+			}
+			closers <- conn
+			conns <- bufio.NewReader(conn)
+		}
+	}()
+
+	// server certificate not trusted without CA
+	tlsConfig, perr := newSyslogTLSConfig(listener.Addr().String(), "", "", "")
+	c.Assert(perr, IsNil)
+	_, err = newSyslogTLSWriter(listener.Addr().String(), tlsConfig, syslog.LOG_ERR, "MINIO")
+	c.Assert(err, Not(IsNil))
+
+	tlsConfig, perr = newSyslogTLSConfig(listener.Addr().String(), certFile, "", "")
+	c.Assert(perr, IsNil)
+	writer, err := newSyslogTLSWriter(listener.Addr().String(), tlsConfig, syslog.LOG_ERR, "MINIO")
+	c.Assert(err, IsNil)
+
+	hook := &syslogHook{writer, syslogTLSNetwork, listener.Addr().String()}
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "syslog over tls"
+	c.Assert(hook.Fire(entry), IsNil)
+
+	var reader *bufio.Reader
+	select {
+	case reader = <-conns:
+	case <-time.After(5 * time.Second):
+		c.Fatal("syslog server never received a connection")
+	}
+	message, err := readSyslogFrame(reader)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(message, "<3>1 "), Equals, true)
+	c.Assert(strings.Contains(message, " MINIO "), Equals, true)
+	c.Assert(strings.Contains(message, "syslog over tls"), Equals, true)
+
+	// drop the connection, writes fail and further writes back off instead of blocking
+	(<-closers).Close()
+	for i := 0; i < 100; i++ {
+		if err = writer.Info("lost"); err != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(err, Not(IsNil))
+	c.Assert(writer.Info("dropped"), Equals, errSyslogUnavailable)
+
+	// reconnects once backoff elapsed
+	writer.mutex.Lock()
+	writer.retryAt = time.Now()
+	writer.mutex.Unlock()
+	c.Assert(writer.Warning("reconnected"), IsNil)
+	select {
+	case reader = <-conns:
+	case <-time.After(5 * time.Second):
+		c.Fatal("syslog server never received a reconnection")
+	}
+	message, err = readSyslogFrame(reader)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(message, "<4>1 "), Equals, true)
+	c.Assert(strings.Contains(message, "reconnected"), Equals, true)
+	(<-closers).Close()
+}
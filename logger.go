@@ -18,7 +18,10 @@ package main
 
 import (
 	"encoding/json"
+	"io"
+	"net/http"
 	"reflect"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/minio/minio-xl/pkg/probe"
@@ -26,8 +29,80 @@ import (
 
 type fields map[string]interface{}
 
-var log = logrus.New() // Default console logger.
+var log = newLogger() // Default console logger, along with the loggers enabled in config.
 
+// configuredHooks - hooks of the loggers enabled in config
+var configuredHooks = &loggerHooks{mutex: &sync.RWMutex{}, hooks: make(logrus.LevelHooks)}
+
+// newLogger - console logger firing the hooks of the loggers enabled in config
+func newLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.Hooks.Add(configuredHooks)
+	return logger
+}
+
+// loggerHooks - hooks replaced as a whole when config is reloaded, entries being logged
+// meanwhile go either to the old or the new ones
+type loggerHooks struct {
+	mutex *sync.RWMutex
+	hooks logrus.LevelHooks
+}
+
+// Fire - fire the hooks of the entry level
+func (h *loggerHooks) Fire(entry *logrus.Entry) error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.hooks.Fire(entry.Level, entry)
+}
+
+// Levels - all levels, hooks filter levels on their own
+func (h *loggerHooks) Levels() []logrus.Level {
+	return []logrus.Level{
+		logrus.PanicLevel,
+		logrus.FatalLevel,
+		logrus.ErrorLevel,
+		logrus.WarnLevel,
+		logrus.InfoLevel,
+		logrus.DebugLevel,
+	}
+}
+
+// replace - swap in new hooks and close the old ones, once no entry is being fired to them
+func (h *loggerHooks) replace(hooks logrus.LevelHooks) {
+	h.mutex.Lock()
+	old := h.hooks
+	h.hooks = hooks
+	h.mutex.Unlock()
+	closeHooks(old)
+}
+
+// closeHooks - close hooks holding files or connections, every hook once
+func closeHooks(hooks logrus.LevelHooks) {
+	closed := make(map[logrus.Hook]bool)
+	for _, levelHooks := range hooks {
+		for _, hook := range levelHooks {
+			if closed[hook] {
+				continue
+			}
+			closed[hook] = true
+			if closer, ok := hook.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+}
+
+// syslogTLSNetwork - syslog network name for syslog over TLS, as per RFC 5425
+const syslogTLSNetwork = "tcp+tls"
+
+// requestFields - log fields identifying the request being served
+func requestFields(w http.ResponseWriter) map[string]interface{} {
+	return map[string]interface{}{
+		"requestID": w.Header().Get(requestIDHeader),
+	}
+}
+
+// errorIf - log err along with fields, with its call trace and system info only in debug
 func errorIf(err *probe.Error, msg string, fields map[string]interface{}) {
 	if err == nil {
 		return
@@ -35,6 +110,12 @@ func errorIf(err *probe.Error, msg string, fields map[string]interface{}) {
 	if fields == nil {
 		fields = make(map[string]interface{})
 	}
+	var callTrace []probe.TracePoint
+	var sysInfo map[string]string
+	if globalDebugFlag {
+		callTrace = err.CallTrace
+		sysInfo = err.SysInfo
+	}
 	fields["Error"] = struct {
 		Cause     string             `json:"cause,omitempty"`
 		Type      string             `json:"type,omitempty"`
@@ -43,12 +124,13 @@ func errorIf(err *probe.Error, msg string, fields map[string]interface{}) {
 	}{
 		err.Cause.Error(),
 		reflect.TypeOf(err.Cause).String(),
-		err.CallTrace,
-		err.SysInfo,
+		callTrace,
+		sysInfo,
 	}
 	log.WithFields(fields).Error(msg)
 }
 
+// fatalIf - log err along with fields and exit, with the whole probe error only in debug
 func fatalIf(err *probe.Error, msg string, fields map[string]interface{}) {
 	if err == nil {
 		return
@@ -58,8 +140,10 @@ func fatalIf(err *probe.Error, msg string, fields map[string]interface{}) {
 	}
 
 	fields["error"] = err.ToGoError()
-	if jsonErr, e := json.Marshal(err); e == nil {
-		fields["probe"] = string(jsonErr)
+	if globalDebugFlag {
+		if jsonErr, e := json.Marshal(err); e == nil {
+			fields["probe"] = string(jsonErr)
+		}
 	}
 	log.WithFields(fields).Fatal(msg)
 }
@@ -46,3 +46,39 @@ func (s *LoggerSuite) TestLogger(c *C) {
 	c.Assert(ok, Equals, true)
 	c.Assert(msg.(map[string]interface{})["cause"], Equals, "Fake error")
 }
+
+func (s *LoggerSuite) TestTraceVerbosity(c *C) {
+	var buffer bytes.Buffer
+	log.Out = &buffer
+	log.Formatter = new(logrus.JSONFormatter)
+	defer func() { globalDebugFlag = false }()
+
+	logged := func() map[string]interface{} {
+		defer buffer.Reset()
+		var fields logrus.Fields
+		c.Assert(json.Unmarshal(buffer.Bytes(), &fields), IsNil)
+		return fields["Error"].(map[string]interface{})
+	}
+
+	// concise, only the cause
+	globalDebugFlag = false
+	errorIf(probe.NewError(errors.New("Fake error")).Trace("bucket"), "Failed with error.", nil)
+	concise := logged()
+	c.Assert(concise["cause"], Equals, "Fake error")
+	c.Assert(concise["type"], Equals, "*errors.errorString")
+	_, ok := concise["trace"]
+	c.Assert(ok, Equals, false)
+	_, ok = concise["sysinfo"]
+	c.Assert(ok, Equals, false)
+
+	// full, along with the call trace and system info
+	globalDebugFlag = true
+	errorIf(probe.NewError(errors.New("Fake error")).Trace("bucket"), "Failed with error.", nil)
+	full := logged()
+	c.Assert(full["cause"], Equals, "Fake error")
+	trace, ok := full["trace"].([]interface{})
+	c.Assert(ok, Equals, true)
+	c.Assert(len(trace), Equals, 2)
+	_, ok = full["sysinfo"]
+	c.Assert(ok, Equals, true)
+}
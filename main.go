@@ -93,15 +93,35 @@ func registerApp() *cli.App {
 	registerCommand(configCmd)
 	registerCommand(versionCmd)
 	registerCommand(updateCmd)
+	registerCommand(selfTestCmd)
+	registerCommand(scrubCmd)
+	registerCommand(multipartCmd)
+	registerCommand(certCmd)
 
 	// register all flags
 	registerFlag(addressFlag)
+	registerFlag(socketModeFlag)
+	registerFlag(profileFlag)
 	registerFlag(accessLogFlag)
+	registerFlag(accessLogSampleFlag)
 	registerFlag(rateLimitFlag)
 	registerFlag(anonymousFlag)
+	registerFlag(anonymousReadFlag)
+	registerFlag(allowIPsFlag)
+	registerFlag(denyIPsFlag)
+	registerFlag(trustedProxiesFlag)
 	registerFlag(certFlag)
 	registerFlag(keyFlag)
+	registerFlag(strictCertFlag)
+	registerFlag(http2Flag)
+	registerFlag(browserFlag)
+	registerFlag(credentialsFileFlag)
+	registerFlag(validateFlag)
+	registerFlag(configDirFlag)
 	registerFlag(jsonFlag)
+	registerFlag(noColorFlag)
+	registerFlag(quietFlag)
+	registerFlag(debugFlag)
 
 	// set up app
 	app := cli.NewApp()
@@ -160,6 +180,12 @@ func main() {
 	app := registerApp()
 	app.Before = func(c *cli.Context) error {
 		globalJSONFlag = c.GlobalBool("json")
+		globalQuietFlag = c.GlobalBool("quiet")
+		globalDebugFlag = c.GlobalBool("debug")
+		setColorOutput(c.GlobalBool("no-color"))
+		if configDir := c.GlobalString("config-dir"); configDir != "" {
+			customConfigPath = configDir
+		}
 		migrate()
 		return nil
 	}
@@ -0,0 +1,262 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// Manage incomplete multipart uploads of a path, without going through the S3 API.
+var multipartCmd = cli.Command{
+	Name:   "multipart",
+	Usage:  "Collection of commands managing incomplete multipart uploads.",
+	Action: mainMultipart,
+	Subcommands: []cli.Command{
+		multipartListCmd,
+		multipartAbortCmd,
+	},
+	CustomHelpTemplate: `NAME:
+  {{.Name}} - {{.Usage}}
+
+USAGE:
+  {{.Name}} {{if .Flags}}[global flags] {{end}}command{{if .Flags}} [command flags]{{end}} [arguments...]
+
+COMMANDS:
+  {{range .Commands}}{{ .Name }}{{ "\t" }}{{.Usage}}
+  {{end}}
+`,
+}
+
+var multipartListCmd = cli.Command{
+	Name:   "ls",
+	Usage:  "List incomplete multipart uploads under a path.",
+	Action: mainMultipartList,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "sort",
+			Value: string(fs.UploadsByKey),
+			Usage: "Order of listed uploads, by bucket and key or the oldest initiated first.",
+		},
+	},
+	CustomHelpTemplate: `NAME:
+   minio multipart {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio multipart {{.Name}} [--sort key|initiated] PATH
+
+FLAGS:
+  {{range .Flags}}{{.}}
+  {{end}}
+EXAMPLES:
+   1. List incomplete multipart uploads along with the time they were initiated at.
+      $ minio multipart {{.Name}} /home/shared
+
+   2. List incomplete multipart uploads as one JSON record per line.
+      $ minio --json multipart {{.Name}} /home/shared
+
+   3. List the oldest incomplete multipart uploads first, to abort the ones left behind.
+      $ minio multipart {{.Name}} --sort initiated /home/shared
+`,
+}
+
+var multipartAbortCmd = cli.Command{
+	Name:   "abort",
+	Usage:  "Abort an incomplete multipart upload under a path, removing its parts.",
+	Action: mainMultipartAbort,
+	CustomHelpTemplate: `NAME:
+   minio multipart {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio multipart {{.Name}} PATH BUCKET OBJECT UPLOADID
+
+EXAMPLES:
+   1. Abort an upload listed by 'minio multipart ls'.
+      $ minio multipart {{.Name}} /home/shared photos 2015/october.jpg Fr3LnkQxQJcR0Xh8lHUMnsKuTMdZ2QRHc2MVB1HORsd
+`,
+}
+
+// mainMultipart is the handle for "minio multipart" command, provides sub-commands only
+func mainMultipart(ctx *cli.Context) {
+	if !ctx.Args().Present() || ctx.Args().First() == "help" {
+		cli.ShowAppHelp(ctx)
+	}
+}
+
+func mainMultipartList(ctx *cli.Context) {
+	args := ctx.Args()
+	if !args.Present() || args.First() == "help" || len(args) > 1 {
+		cli.ShowCommandHelpAndExit(ctx, "ls", 1) // last argument is exit code
+	}
+	order, err := parseUploadOrder(ctx.String("sort"))
+	fatalIf(err.Trace(ctx.String("sort")), "Invalid order of multipart uploads.", nil)
+	uploads, err := listMultipartUploads(args.First(), order)
+	fatalIf(err.Trace(args.First()), "Listing multipart uploads failed.", nil)
+	for _, upload := range uploads {
+		if globalJSONFlag {
+			Println(upload.JSON())
+		} else {
+			Println(upload)
+		}
+	}
+}
+
+func mainMultipartAbort(ctx *cli.Context) {
+	args := ctx.Args()
+	if !args.Present() || args.First() == "help" || len(args) != 4 {
+		cli.ShowCommandHelpAndExit(ctx, "abort", 1) // last argument is exit code
+	}
+	upload := multipartUploadMessage{
+		Bucket:   args.Get(1),
+		Object:   args.Get(2),
+		UploadID: args.Get(3),
+	}
+	err := abortMultipartUpload(args.First(), upload)
+	fatalIf(err.Trace(args...), "Aborting multipart upload failed.", nil)
+	upload.Status = "aborted"
+	if globalJSONFlag {
+		Println(upload.JSON())
+	} else {
+		Println(upload)
+	}
+}
+
+// multipartUploadMessage - incomplete multipart upload as printed by the multipart commands
+type multipartUploadMessage struct {
+	Status    string `json:"status,omitempty"`
+	Bucket    string `json:"bucket"`
+	Object    string `json:"object"`
+	UploadID  string `json:"uploadId"`
+	Initiated string `json:"initiated,omitempty"`
+
+	initiated time.Time
+}
+
+// byUploadMessageInitiated is a sortable interface for multipartUploadMessage slice, oldest first
+type byUploadMessageInitiated []multipartUploadMessage
+
+func (b byUploadMessageInitiated) Len() int           { return len(b) }
+func (b byUploadMessageInitiated) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byUploadMessageInitiated) Less(i, j int) bool { return b[i].initiated.Before(b[j].initiated) }
+
+// String one line per upload, listed uploads lead with the time they were initiated at
+func (u multipartUploadMessage) String() string {
+	message := u.Bucket + "/" + u.Object + " " + u.UploadID
+	if u.Status != "" {
+		return "Upload " + message + " " + u.Status + "."
+	}
+	return "[" + u.Initiated + "] " + message
+}
+
+// JSON jsonified upload message
+func (u multipartUploadMessage) JSON() string {
+	uploadJSONBytes, err := json.Marshal(u)
+	fatalIf(probe.NewError(err), "Unable to marshal into JSON.", nil)
+
+	return string(uploadJSONBytes)
+}
+
+// openMultipartSessions - filesystem of path along with the multipart sessions found on disk,
+// corrupted sessions are quarantined as on server start
+func openMultipartSessions(path string) (fs.Filesystem, *probe.Error) {
+	filesystem, err := fs.New()
+	if err != nil {
+		return fs.Filesystem{}, err.Trace()
+	}
+	filesystem.SetRootPath(path)
+	quarantined, err := filesystem.RecoverMultipartSessions()
+	if err != nil {
+		return fs.Filesystem{}, err.Trace(path)
+	}
+	for _, sessionPath := range quarantined {
+		log.WithFields(map[string]interface{}{"path": sessionPath}).Warn("Corrupted multipart session quarantined.")
+	}
+	return filesystem, nil
+}
+
+// parseUploadOrder - order of listed uploads, key or initiated
+func parseUploadOrder(value string) (fs.UploadOrder, *probe.Error) {
+	switch order := fs.UploadOrder(value); order {
+	case fs.UploadsByKey, fs.UploadsByInitiated:
+		return order, nil
+	}
+	return "", probe.NewError(errInvalidArgument)
+}
+
+// listMultipartUploads - incomplete multipart uploads of every bucket under path, ordered by
+// bucket, object and upload id, or the oldest initiated first across buckets
+func listMultipartUploads(path string, order fs.UploadOrder) ([]multipartUploadMessage, *probe.Error) {
+	filesystem, err := openMultipartSessions(path)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	buckets, err := filesystem.ListBuckets()
+	if err != nil {
+		return nil, err.Trace(path)
+	}
+	var uploads []multipartUploadMessage
+	for _, bucket := range buckets {
+		resources := fs.BucketMultipartResourcesMetadata{MaxUploads: maxObjectList, Order: order}
+		for {
+			resources, err = filesystem.ListMultipartUploads(bucket.Name, resources)
+			if err != nil {
+				return nil, err.Trace(bucket.Name)
+			}
+			for _, upload := range resources.Upload {
+				uploads = append(uploads, multipartUploadMessage{
+					Bucket:    bucket.Name,
+					Object:    upload.Object,
+					UploadID:  upload.UploadID,
+					Initiated: upload.Initiated.UTC().Format(time.RFC3339),
+					initiated: upload.Initiated,
+				})
+			}
+			if !resources.IsTruncated {
+				break
+			}
+			resources = fs.BucketMultipartResourcesMetadata{
+				KeyMarker:       resources.NextKeyMarker,
+				UploadIDMarker:  resources.NextUploadIDMarker,
+				InitiatedMarker: resources.NextInitiatedMarker,
+				MaxUploads:      maxObjectList,
+				Order:           order,
+			}
+		}
+	}
+	if order == fs.UploadsByInitiated {
+		// uploads of every bucket are oldest first already, ties stay ordered by bucket
+		sort.Stable(byUploadMessageInitiated(uploads))
+	}
+	return uploads, nil
+}
+
+// abortMultipartUpload - abort an incomplete multipart upload under path, removing its parts
+func abortMultipartUpload(path string, upload multipartUploadMessage) *probe.Error {
+	filesystem, err := openMultipartSessions(path)
+	if err != nil {
+		return err.Trace()
+	}
+	if err := filesystem.AbortMultipartUpload(upload.Bucket, upload.Object, upload.UploadID); err != nil {
+		return err.Trace(upload.Bucket, upload.Object, upload.UploadID)
+	}
+	return nil
+}
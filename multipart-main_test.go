@@ -0,0 +1,141 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type MultipartMainSuite struct{}
+
+var _ = Suite(&MultipartMainSuite{})
+
+func (s *MultipartMainSuite) TestListAndAbort(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-multipart-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "export")
+	c.Assert(os.Mkdir(path, 0700), IsNil)
+	fs.SetFSMultipartsConfigPath(filepath.Join(root, "multiparts-session.json"))
+	fs.SetFSBucketsConfigPath(filepath.Join(root, "buckets.json"))
+
+	// plant sessions as left behind by clients which never completed their uploads
+	filesystem, perr := fs.New()
+	c.Assert(perr, IsNil)
+	filesystem.SetRootPath(path)
+	filesystem.SetMinFreeDisk(0)
+	c.Assert(filesystem.MakeBucket("bucket", ""), IsNil)
+	c.Assert(filesystem.MakeBucket("empty", ""), IsNil)
+	staleID, perr := filesystem.NewMultipartUpload("bucket", "dir/stale")
+	c.Assert(perr, IsNil)
+	_, perr = filesystem.CreateObjectPart(context.Background(), "bucket", "dir/stale", staleID, "", 1, int64(len("hello")), strings.NewReader("hello"), nil)
+	c.Assert(perr, IsNil)
+	otherID, perr := filesystem.NewMultipartUpload("bucket", "other")
+	c.Assert(perr, IsNil)
+
+	uploads, perr := listMultipartUploads(path, fs.UploadsByKey)
+	c.Assert(perr, IsNil)
+	c.Assert(len(uploads), Equals, 2)
+	c.Assert(uploads[0].Bucket, Equals, "bucket")
+	c.Assert(uploads[0].Object, Equals, "dir/stale")
+	c.Assert(uploads[0].UploadID, Equals, staleID)
+	c.Assert(uploads[0].Initiated, Not(Equals), "")
+	c.Assert(uploads[1].Object, Equals, "other")
+	c.Assert(uploads[1].UploadID, Equals, otherID)
+	c.Assert(uploads[0].String(), Equals, "["+uploads[0].Initiated+"] bucket/dir/stale "+staleID)
+
+	var upload map[string]string
+	c.Assert(json.Unmarshal([]byte(uploads[0].JSON()), &upload), IsNil)
+	c.Assert(upload, DeepEquals, map[string]string{
+		"bucket":    "bucket",
+		"object":    "dir/stale",
+		"uploadId":  staleID,
+		"initiated": uploads[0].Initiated,
+	})
+
+	// upload ids of other objects are refused
+	perr = abortMultipartUpload(path, multipartUploadMessage{Bucket: "bucket", Object: "dir/stale", UploadID: otherID})
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), FitsTypeOf, fs.InvalidUploadID{})
+
+	c.Assert(abortMultipartUpload(path, multipartUploadMessage{Bucket: "bucket", Object: "dir/stale", UploadID: staleID}), IsNil)
+	_, err = os.Stat(filepath.Join(path, "bucket", "dir", "stale$1"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(filepath.Join(path, "bucket", "dir", "stale$multiparts"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	uploads, perr = listMultipartUploads(path, fs.UploadsByKey)
+	c.Assert(perr, IsNil)
+	c.Assert(len(uploads), Equals, 1)
+	c.Assert(uploads[0].UploadID, Equals, otherID)
+}
+
+func (s *MultipartMainSuite) TestListOldestFirst(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-multipart-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "export")
+	c.Assert(os.Mkdir(path, 0700), IsNil)
+	fs.SetFSMultipartsConfigPath(filepath.Join(root, "multiparts-session.json"))
+	fs.SetFSBucketsConfigPath(filepath.Join(root, "buckets.json"))
+
+	filesystem, perr := fs.New()
+	c.Assert(perr, IsNil)
+	filesystem.SetRootPath(path)
+	filesystem.SetMinFreeDisk(0)
+	c.Assert(filesystem.MakeBucket("alpha", ""), IsNil)
+	c.Assert(filesystem.MakeBucket("beta", ""), IsNil)
+	// initiated in an order differing from the one of buckets and keys
+	var uploadIDs []string
+	for _, upload := range [][2]string{{"beta", "d"}, {"alpha", "z"}, {"beta", "c"}, {"alpha", "a"}} {
+		uploadID, perr := filesystem.NewMultipartUpload(upload[0], upload[1])
+		c.Assert(perr, IsNil)
+		uploadIDs = append(uploadIDs, uploadID)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	uploads, perr := listMultipartUploads(path, fs.UploadsByInitiated)
+	c.Assert(perr, IsNil)
+	var listed []string
+	for _, upload := range uploads {
+		listed = append(listed, upload.UploadID)
+	}
+	c.Assert(listed, DeepEquals, uploadIDs)
+
+	uploads, perr = listMultipartUploads(path, fs.UploadsByKey)
+	c.Assert(perr, IsNil)
+	listed = nil
+	for _, upload := range uploads {
+		listed = append(listed, upload.Bucket+"/"+upload.Object)
+	}
+	c.Assert(listed, DeepEquals, []string{"alpha/a", "alpha/z", "beta/c", "beta/d"})
+
+	order, perr := parseUploadOrder("initiated")
+	c.Assert(perr, IsNil)
+	c.Assert(order, Equals, fs.UploadsByInitiated)
+	_, perr = parseUploadOrder("size")
+	c.Assert(perr, Not(IsNil))
+}
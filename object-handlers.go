@@ -17,8 +17,15 @@
 package main
 
 import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/minio/minio-xl/pkg/probe"
@@ -29,6 +36,87 @@ const (
 	maxPartsList = 1000
 )
 
+// maxTaggingSize - size of the largest tag set accepted by PutObjectTaggingHandler, ten tags
+// of the longest keys and values fit in it
+const maxTaggingSize = 64 * 1024
+
+// defaultStorageClass - storage class of new objects requested with none
+func (api CloudStorageAPI) defaultStorageClass() string {
+	if api.StorageClass == "" {
+		return fs.StorageClassStandard
+	}
+	return api.StorageClass
+}
+
+// requestStorageClass - storage class a new object is requested with, defaultClass if the request
+// names none. False if the requested storage class is not valid.
+func requestStorageClass(req *http.Request, defaultClass string) (string, bool) {
+	storageClass := req.Header.Get(storageClassHeader)
+	if storageClass == "" {
+		return defaultClass, true
+	}
+	return storageClass, fs.IsValidStorageClass(storageClass)
+}
+
+// requestObjectTags - tags a new object is requested with in the URL query encoded x-amz-tagging
+// header, false if they do not parse, repeat a key or exceed the limits of S3
+func requestObjectTags(req *http.Request) (map[string]string, bool) {
+	tagging := req.Header.Get(taggingHeader)
+	if tagging == "" {
+		return nil, true
+	}
+	query, err := url.ParseQuery(tagging)
+	if err != nil {
+		return nil, false
+	}
+	tags := make(map[string]string, len(query))
+	for key, values := range query {
+		if len(values) != 1 {
+			return nil, false
+		}
+		tags[key] = values[0]
+	}
+	return tags, fs.CheckObjectTags(tags) == nil
+}
+
+// tagStorageClass - tag a new object with storageClass unless it is of that storage class already
+func (api CloudStorageAPI) tagStorageClass(metadata *fs.ObjectMetadata, storageClass string) *probe.Error {
+	if storageClass == metadata.StorageClass {
+		return nil
+	}
+	if err := api.ObjectAPI.SetObjectStorageClass(metadata.Bucket, metadata.Object, storageClass); err != nil {
+		return err.Trace(metadata.Bucket, metadata.Object, storageClass)
+	}
+	metadata.StorageClass = storageClass
+	return nil
+}
+
+// requestVersionID - version of an object a GET or HEAD asks for with versionId, false for the
+// current object
+func requestVersionID(req *http.Request) (string, bool) {
+	versionIDs, ok := req.URL.Query()["versionId"]
+	if !ok || len(versionIDs) == 0 {
+		return "", false
+	}
+	return versionIDs[0], true
+}
+
+// getObjectMetadata - metadata of the current object, or of the version the request asks for
+func (api CloudStorageAPI) getObjectMetadata(req *http.Request, bucket, object string) (fs.ObjectMetadata, *probe.Error) {
+	if versionID, ok := requestVersionID(req); ok {
+		return api.ObjectAPI.GetObjectVersionMetadata(bucket, object, versionID)
+	}
+	return api.ObjectAPI.GetObjectMetadata(bucket, object)
+}
+
+// getObject - write the current object, or the version the request asks for
+func (api CloudStorageAPI) getObject(req *http.Request, w io.Writer, bucket, object string, start, length int64) (int64, *probe.Error) {
+	if versionID, ok := requestVersionID(req); ok {
+		return api.ObjectAPI.GetObjectVersion(w, bucket, object, versionID, start, length)
+	}
+	return api.ObjectAPI.GetObject(w, bucket, object, start, length)
+}
+
 // GetObjectHandler - GET Object
 // ----------
 // This implementation of the GET operation retrieves object. To use GET,
@@ -40,17 +128,26 @@ func (api CloudStorageAPI) GetObjectHandler(w http.ResponseWriter, req *http.Req
 	object = vars["object"]
 
 	if !api.Anonymous {
-		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
-				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
-				return
-			}
+		if api.isObjectReadDenied(req, bucket, object) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
 		}
 	}
 
-	metadata, err := api.Filesystem.GetObjectMetadata(bucket, object)
+	overrides, ok := getResponseOverrides(req.URL.Query())
+	if !ok {
+		writeErrorResponse(w, req, InvalidResponseOverride, req.URL.Path)
+		return
+	}
+	// overrides are only honoured for signed requests, as for S3
+	if len(overrides) > 0 && !api.Anonymous && isRequestRequiresACLCheck(req) {
+		writeErrorResponse(w, req, AnonymousResponseOverride, req.URL.Path)
+		return
+	}
+
+	metadata, err := api.getObjectMetadata(req, bucket, object)
 	if err != nil {
-		errorIf(err.Trace(), "GetObject failed.", nil)
+		errorIf(err.Trace(), "GetObject failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -60,20 +157,32 @@ func (api CloudStorageAPI) GetObjectHandler(w http.ResponseWriter, req *http.Req
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 		case fs.ObjectNameInvalid:
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectVersionNotFound:
+			writeErrorResponse(w, req, NoSuchVersion, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidVersionID, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
+	if isObjectNotModified(req, metadata) {
+		setNotModifiedHeaders(w, metadata)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	var hrange *httpRange
 	hrange, err = getRequestedRange(req.Header.Get("Range"), metadata.Size)
 	if err != nil {
 		writeErrorResponse(w, req, InvalidRange, req.URL.Path)
 		return
 	}
-	setObjectHeaders(w, metadata, hrange)
-	if _, err = api.Filesystem.GetObject(w, bucket, object, hrange.start, hrange.length); err != nil {
-		errorIf(err.Trace(), "GetObject failed.", nil)
+	metadata.ContentType = api.ContentTypes.contentType(object, metadata.ContentType)
+	setObjectHeaders(w, metadata, hrange, overrides)
+	writer, release := api.Bandwidth.writer(req, w)
+	defer release()
+	if _, err = api.getObject(req, writer, bucket, object, hrange.start, hrange.length); err != nil {
+		errorIf(err.Trace(), "GetObject failed.", requestFields(w))
 		return
 	}
 }
@@ -88,15 +197,13 @@ func (api CloudStorageAPI) HeadObjectHandler(w http.ResponseWriter, req *http.Re
 	object = vars["object"]
 
 	if !api.Anonymous {
-		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
-				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
-				return
-			}
+		if api.isObjectReadDenied(req, bucket, object) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
 		}
 	}
 
-	metadata, err := api.Filesystem.GetObjectMetadata(bucket, object)
+	metadata, err := api.getObjectMetadata(req, bucket, object)
 	if err != nil {
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
@@ -107,12 +214,22 @@ func (api CloudStorageAPI) HeadObjectHandler(w http.ResponseWriter, req *http.Re
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 		case fs.ObjectNameInvalid:
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectVersionNotFound:
+			writeErrorResponse(w, req, NoSuchVersion, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidVersionID, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
-	setObjectHeaders(w, metadata, nil)
+	if isObjectNotModified(req, metadata) {
+		setNotModifiedHeaders(w, metadata)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	metadata.ContentType = api.ContentTypes.contentType(object, metadata.ContentType)
+	setObjectHeaders(w, metadata, nil, nil)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -125,12 +242,16 @@ func (api CloudStorageAPI) PutObjectHandler(w http.ResponseWriter, req *http.Req
 	bucket = vars["bucket"]
 	object = vars["object"]
 
+	if !api.Uploads.acquire() {
+		writeSlowDownResponse(w, req)
+		return
+	}
+	defer api.Uploads.release()
+
 	if !api.Anonymous {
-		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
-				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
-				return
-			}
+		if api.isObjectWriteDenied(req, bucket, object) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
 		}
 	}
 
@@ -168,16 +289,50 @@ func (api CloudStorageAPI) PutObjectHandler(w http.ResponseWriter, req *http.Req
 			var err *probe.Error
 			signature, err = initSignatureV4(req)
 			if err != nil {
-				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				errorIf(err.Trace(), "Initializing signature v4 failed.", requestFields(w))
+				writeErrorResponse(w, req, signatureV4ErrorCode(err), req.URL.Path)
 				return
 			}
 		}
 	}
 
-	metadata, err := api.Filesystem.CreateObject(bucket, object, md5, sizeInt64, req.Body, signature)
+	if !verifyClaimedPayloadSignature(w, req, signature) {
+		return
+	}
+
+	storageClass, ok := requestStorageClass(req, api.defaultStorageClass())
+	if !ok {
+		writeErrorResponse(w, req, InvalidStorageClass, req.URL.Path)
+		return
+	}
+	tags, ok := requestObjectTags(req)
+	if !ok {
+		writeErrorResponse(w, req, InvalidTag, req.URL.Path)
+		return
+	}
+
+	// optional object lock, retention of the bucket applies otherwise
+	var retainUntil time.Time
+	if retainUntilDate := req.Header.Get(retainUntilHeader); retainUntilDate != "" {
+		var e error
+		retainUntil, e = time.Parse(time.RFC3339, retainUntilDate)
+		if e != nil || !retainUntil.After(time.Now().UTC()) {
+			writeErrorResponse(w, req, InvalidRetention, req.URL.Path)
+			return
+		}
+	}
+
+	// optimistic concurrency, the object is only replaced as the client last saw it
+	preconditions := fs.WritePreconditions{
+		IfMatch:     req.Header.Get("If-Match"),
+		IfNoneMatch: req.Header.Get("If-None-Match"),
+	}
+
+	data, release := api.Bandwidth.reader(req, req.Body)
+	defer release()
+	metadata, err := api.ObjectAPI.CreateObjectIf(bucket, object, md5, sizeInt64, data, signature, preconditions)
 	if err != nil {
-		errorIf(err.Trace(), "CreateObject failed.", nil)
+		errorIf(err.Trace(), "CreateObject failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.RootPathFull:
 			writeErrorResponse(w, req, RootPathFull, req.URL.Path)
@@ -185,27 +340,183 @@ func (api CloudStorageAPI) PutObjectHandler(w http.ResponseWriter, req *http.Req
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
 		case fs.BadDigest:
 			writeErrorResponse(w, req, BadDigest, req.URL.Path)
 		case fs.MissingDateHeader:
 			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
 		case fs.SignatureDoesNotMatch:
 			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
 		case fs.IncompleteBody:
 			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
 		case fs.EntityTooLarge:
 			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
 		case fs.InvalidDigest:
 			writeErrorResponse(w, req, InvalidDigest, req.URL.Path)
+		case fs.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case fs.OperationAborted:
+			writeErrorResponse(w, req, OperationAborted, req.URL.Path)
+		case fs.PreconditionFailed:
+			writeErrorResponse(w, req, PreconditionFailed, req.URL.Path)
+		case fs.InvalidFolderObject:
+			writeErrorResponse(w, req, InvalidFolderObject, req.URL.Path)
 		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	// folders carry neither retention, storage class nor tags
+	if metadata.Mode.IsDir() {
+		api.notifyObjectEvent(eventObjectCreatedPut, metadata)
+		w.Header().Set("ETag", "\""+metadata.Md5+"\"")
+		writeSuccessResponse(w)
+		return
+	}
+	if !retainUntil.IsZero() {
+		if err := api.ObjectAPI.SetObjectRetention(bucket, object, retainUntil); err != nil {
+			errorIf(err.Trace(), "SetObjectRetention failed.", requestFields(w))
 			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
 		}
+	}
+	if err := api.tagStorageClass(&metadata, storageClass); err != nil {
+		errorIf(err.Trace(), "SetObjectStorageClass failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
 		return
 	}
+	if len(tags) > 0 {
+		if err := api.ObjectAPI.PutObjectTagging(bucket, object, tags); err != nil {
+			errorIf(err.Trace(), "PutObjectTagging failed.", requestFields(w))
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+		metadata.TagCount = len(tags)
+	}
+	api.notifyObjectEvent(eventObjectCreatedPut, metadata)
 	w.Header().Set("ETag", "\""+metadata.Md5+"\"")
+	setVersionIDHeader(w, metadata)
+	setServerSideEncryptionHeader(w, metadata)
 	writeSuccessResponse(w)
 }
 
+// CopyObjectHandler - Copy Object
+// ----------
+// This implementation of the PUT operation with 'x-amz-copy-source' header copies an existing
+// object to the requested key on the server side.
+func (api CloudStorageAPI) CopyObjectHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	if !api.Anonymous {
+		// Unauthorized copy requests are not supported
+		if isRequestRequiresACLCheck(req) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+	}
+
+	// 'x-amz-copy-source' is of style '/bucket/object' url encoded, '+' is a plus rather than a space
+	copySource, e := url.PathUnescape(req.Header.Get("X-Amz-Copy-Source"))
+	if e != nil {
+		writeErrorResponse(w, req, InvalidCopySource, req.URL.Path)
+		return
+	}
+	splits := strings.SplitN(strings.TrimPrefix(copySource, "/"), "/", 2)
+	if len(splits) != 2 || splits[0] == "" || splits[1] == "" {
+		writeErrorResponse(w, req, InvalidCopySource, req.URL.Path)
+		return
+	}
+	srcBucket, srcObject := splits[0], splits[1]
+
+	if !api.Anonymous {
+		// the copy is a write of the destination and a read of the source
+		if api.isObjectWriteDenied(req, bucket, object) || api.isObjectReadDenied(req, srcBucket, srcObject) {
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+			return
+		}
+		// copy requests do not carry any payload
+		if !verifyPayloadSignature(w, req, nil) {
+			return
+		}
+	}
+
+	// the copy keeps the storage class of its source unless another one is requested or the
+	// metadata replaced
+	storageClass, ok := requestStorageClass(req, "")
+	if !ok {
+		writeErrorResponse(w, req, InvalidStorageClass, req.URL.Path)
+		return
+	}
+	// replaced metadata is the tags requested, the copy carries none otherwise
+	replace := req.Header.Get("X-Amz-Metadata-Directive") == "REPLACE"
+	tags, ok := requestObjectTags(req)
+	if !ok {
+		writeErrorResponse(w, req, InvalidTag, req.URL.Path)
+		return
+	}
+
+	metadata, err := api.ObjectAPI.CopyObject(bucket, object, srcBucket, srcObject, req.Header.Get("X-Amz-Metadata-Directive"))
+	if err != nil {
+		errorIf(err.Trace(), "CopyObject failed.", requestFields(w))
+		switch err.ToGoError().(type) {
+		case fs.RootPathFull:
+			writeErrorResponse(w, req, RootPathFull, req.URL.Path)
+		case fs.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case fs.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case fs.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
+		case fs.InvalidArgument:
+			writeErrorResponse(w, req, InvalidMetadataDirective, req.URL.Path)
+		case fs.InvalidCopyRequest:
+			writeErrorResponse(w, req, InvalidCopyDest, req.URL.Path)
+		case fs.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case fs.OperationAborted:
+			writeErrorResponse(w, req, OperationAborted, req.URL.Path)
+		default:
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	if storageClass != "" {
+		if err := api.tagStorageClass(&metadata, storageClass); err != nil {
+			errorIf(err.Trace(), "SetObjectStorageClass failed.", requestFields(w))
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+	}
+	if replace && len(tags) > 0 {
+		if err := api.ObjectAPI.PutObjectTagging(bucket, object, tags); err != nil {
+			errorIf(err.Trace(), "PutObjectTagging failed.", requestFields(w))
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+		metadata.TagCount = len(tags)
+	}
+	api.notifyObjectEvent(eventObjectCreatedCopy, metadata)
+	response := generateCopyObjectResponse(metadata.Md5, metadata.Created)
+	encodedSuccessResponse := encodeSuccessResponse(response)
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	setVersionIDHeader(w, metadata)
+	setServerSideEncryptionHeader(w, metadata)
+	// write body
+	w.Write(encodedSuccessResponse)
+}
+
 /// Multipart CloudStorageAPI
 
 // NewMultipartUploadHandler - New multipart upload
@@ -217,18 +528,20 @@ func (api CloudStorageAPI) NewMultipartUploadHandler(w http.ResponseWriter, req
 
 	if !api.Anonymous {
 		// Unauthorized multipart uploads are not supported
-		if isRequestRequiresACLCheck(req) {
+		if isRequestRequiresACLCheck(req) || api.isObjectWriteDenied(req, bucket, object) {
 			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 			return
 		}
 	}
 
-	uploadID, err := api.Filesystem.NewMultipartUpload(bucket, object)
+	uploadID, err := api.ObjectAPI.NewMultipartUpload(bucket, object)
 	if err != nil {
-		errorIf(err.Trace(), "NewMultipartUpload failed.", nil)
+		errorIf(err.Trace(), "NewMultipartUpload failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.RootPathFull:
 			writeErrorResponse(w, req, RootPathFull, req.URL.Path)
+		case fs.TooManyMultipartUploads:
+			writeErrorResponse(w, req, TooManyMultipartUploads, req.URL.Path)
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
 		case fs.BucketNotFound:
@@ -237,8 +550,10 @@ func (api CloudStorageAPI) NewMultipartUploadHandler(w http.ResponseWriter, req
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 		case fs.ObjectNameInvalid:
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -257,8 +572,14 @@ func (api CloudStorageAPI) PutObjectPartHandler(w http.ResponseWriter, req *http
 	bucket := vars["bucket"]
 	object := vars["object"]
 
+	if !api.Uploads.acquire() {
+		writeSlowDownResponse(w, req)
+		return
+	}
+	defer api.Uploads.release()
+
 	if !api.Anonymous {
-		if isRequestRequiresACLCheck(req) {
+		if isRequestRequiresACLCheck(req) || api.isObjectWriteDenied(req, bucket, object) {
 			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 			return
 		}
@@ -278,6 +599,15 @@ func (api CloudStorageAPI) PutObjectPartHandler(w http.ResponseWriter, req *http
 		return
 	}
 
+	// for aws-chunked uploads Content-Length carries the chunk framing, size is the decoded length
+	if isRequestChunkedV4(req) {
+		size = req.Header.Get("X-Amz-Decoded-Content-Length")
+		if size == "" {
+			writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+			return
+		}
+	}
+
 	/// maximum Upload size for multipart objects in a single operation
 	if isMaxObjectSize(size) {
 		writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
@@ -314,33 +644,70 @@ func (api CloudStorageAPI) PutObjectPartHandler(w http.ResponseWriter, req *http
 			var err *probe.Error
 			signature, err = initSignatureV4(req)
 			if err != nil {
-				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				errorIf(err.Trace(), "Initializing signature v4 failed.", requestFields(w))
+				writeErrorResponse(w, req, signatureV4ErrorCode(err), req.URL.Path)
 				return
 			}
 		}
 	}
 
-	calculatedMD5, err := api.Filesystem.CreateObjectPart(bucket, object, uploadID, md5, partID, sizeInt64, req.Body, signature)
+	if !verifyClaimedPayloadSignature(w, req, signature) {
+		return
+	}
+
+	var data io.Reader = req.Body
+	if isRequestChunkedV4(req) {
+		var err *probe.Error
+		data, err = fs.NewChunkedReader(req.Body, req.Header.Get("X-Amz-Trailer"), signature)
+		if err != nil {
+			errorIf(err.Trace(), "Initializing chunked reader failed.", requestFields(w))
+			switch err.ToGoError().(type) {
+			case fs.InvalidTrailer:
+				writeErrorResponse(w, req, InvalidTrailer, req.URL.Path)
+			case fs.SignatureDoesNotMatch:
+				writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+			case fs.RequestTimeTooSkewed:
+				writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+			default:
+				writeProbeErrorResponse(w, err, req.URL.Path)
+			}
+			return
+		}
+		// chunk signatures are verified while reading
+		signature = nil
+	}
+	data, release := api.Bandwidth.reader(req, data)
+	defer release()
+
+	// a retry carrying the ETag of the part the client already sent in If-None-Match is not rewritten
+	calculatedMD5, err := api.ObjectAPI.CreateObjectPartIf(req.Context(), bucket, object, uploadID, md5, partID, sizeInt64, data, signature, req.Header.Get("If-None-Match"))
 	if err != nil {
-		errorIf(err.Trace(), "CreateObjectPart failed.", nil)
+		errorIf(err.Trace(), "CreateObjectPart failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.RootPathFull:
 			writeErrorResponse(w, req, RootPathFull, req.URL.Path)
 		case fs.InvalidUploadID:
 			writeErrorResponse(w, req, NoSuchUpload, req.URL.Path)
+		case fs.InvalidPart:
+			writeErrorResponse(w, req, InvalidPart, req.URL.Path)
 		case fs.BadDigest:
 			writeErrorResponse(w, req, BadDigest, req.URL.Path)
 		case fs.SignatureDoesNotMatch:
 			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
 		case fs.IncompleteBody:
 			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
 		case fs.EntityTooLarge:
 			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
 		case fs.InvalidDigest:
 			writeErrorResponse(w, req, InvalidDigest, req.URL.Path)
+		case fs.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -355,16 +722,16 @@ func (api CloudStorageAPI) AbortMultipartUploadHandler(w http.ResponseWriter, re
 	object := vars["object"]
 
 	if !api.Anonymous {
-		if isRequestRequiresACLCheck(req) {
+		if isRequestRequiresACLCheck(req) || api.isObjectWriteDenied(req, bucket, object) {
 			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 			return
 		}
 	}
 
 	objectResourcesMetadata := getObjectResources(req.URL.Query())
-	err := api.Filesystem.AbortMultipartUpload(bucket, object, objectResourcesMetadata.UploadID)
+	err := api.ObjectAPI.AbortMultipartUpload(bucket, object, objectResourcesMetadata.UploadID)
 	if err != nil {
-		errorIf(err.Trace(), "AbortMutlipartUpload failed.", nil)
+		errorIf(err.Trace(), "AbortMutlipartUpload failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -377,7 +744,7 @@ func (api CloudStorageAPI) AbortMultipartUploadHandler(w http.ResponseWriter, re
 		case fs.InvalidUploadID:
 			writeErrorResponse(w, req, NoSuchUpload, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -410,9 +777,9 @@ func (api CloudStorageAPI) ListObjectPartsHandler(w http.ResponseWriter, req *ht
 		objectResourcesMetadata.MaxParts = maxPartsList
 	}
 
-	objectResourcesMetadata, err := api.Filesystem.ListObjectParts(bucket, object, objectResourcesMetadata)
+	objectResourcesMetadata, err := api.ObjectAPI.ListObjectParts(bucket, object, objectResourcesMetadata)
 	if err != nil {
-		errorIf(err.Trace(), "ListObjectParts failed.", nil)
+		errorIf(err.Trace(), "ListObjectParts failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -425,7 +792,7 @@ func (api CloudStorageAPI) ListObjectPartsHandler(w http.ResponseWriter, req *ht
 		case fs.InvalidUploadID:
 			writeErrorResponse(w, req, NoSuchUpload, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
@@ -443,6 +810,12 @@ func (api CloudStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter,
 	bucket := vars["bucket"]
 	object := vars["object"]
 
+	if !api.Uploads.acquire() {
+		writeSlowDownResponse(w, req)
+		return
+	}
+	defer api.Uploads.release()
+
 	if !api.Anonymous {
 		if isRequestRequiresACLCheck(req) {
 			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
@@ -458,16 +831,16 @@ func (api CloudStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter,
 			var err *probe.Error
 			signature, err = initSignatureV4(req)
 			if err != nil {
-				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				errorIf(err.Trace(), "Initializing signature v4 failed.", requestFields(w))
+				writeErrorResponse(w, req, signatureV4ErrorCode(err), req.URL.Path)
 				return
 			}
 		}
 	}
 
-	metadata, err := api.Filesystem.CompleteMultipartUpload(bucket, object, objectResourcesMetadata.UploadID, req.Body, signature)
+	metadata, err := api.ObjectAPI.CompleteMultipartUpload(req.Context(), bucket, object, objectResourcesMetadata.UploadID, req.Body, signature)
 	if err != nil {
-		errorIf(err.Trace(), "CompleteMultipartUpload failed.", nil)
+		errorIf(err.Trace(), "CompleteMultipartUpload failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -477,6 +850,8 @@ func (api CloudStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter,
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 		case fs.ObjectNameInvalid:
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectNameTooLong:
+			writeErrorResponse(w, req, KeyTooLongError, req.URL.Path)
 		case fs.InvalidUploadID:
 			writeErrorResponse(w, req, NoSuchUpload, req.URL.Path)
 		case fs.InvalidPart:
@@ -485,19 +860,35 @@ func (api CloudStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter,
 			writeErrorResponse(w, req, InvalidPartOrder, req.URL.Path)
 		case fs.SignatureDoesNotMatch:
 			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		case fs.RequestTimeTooSkewed:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
 		case fs.IncompleteBody:
 			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
 		case fs.MalformedXML:
 			writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		case fs.EntityTooLarge:
+			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
+		case fs.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case fs.OperationAborted:
+			writeErrorResponse(w, req, OperationAborted, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
 		}
 		return
 	}
+	if err := api.tagStorageClass(&metadata, api.defaultStorageClass()); err != nil {
+		errorIf(err.Trace(), "SetObjectStorageClass failed.", requestFields(w))
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	api.notifyObjectEvent(eventObjectCreatedCompleteMultipartUpload, metadata)
 	response := generateCompleteMultpartUploadResponse(bucket, object, "", metadata.Md5)
 	encodedSuccessResponse := encodeSuccessResponse(response)
 	// write headers
 	setCommonHeaders(w, len(encodedSuccessResponse))
+	setVersionIDHeader(w, metadata)
+	setServerSideEncryptionHeader(w, metadata)
 	// write body
 	w.Write(encodedSuccessResponse)
 }
@@ -512,16 +903,17 @@ func (api CloudStorageAPI) DeleteObjectHandler(w http.ResponseWriter, req *http.
 
 	if !api.Anonymous {
 		if isRequestRequiresACLCheck(req) {
-			if api.Filesystem.IsPrivateBucket(bucket) {
+			// anonymous deletes are only allowed on public-read-write buckets
+			if !api.ObjectAPI.IsPublicBucket(bucket) {
 				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 				return
 			}
 		}
 	}
 
-	err := api.Filesystem.DeleteObject(bucket, object)
+	err := api.ObjectAPI.DeleteObject(bucket, object)
 	if err != nil {
-		errorIf(err.Trace(), "DeleteObject failed.", nil)
+		errorIf(err.Trace(), "DeleteObject failed.", requestFields(w))
 		switch err.ToGoError().(type) {
 		case fs.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -531,9 +923,157 @@ func (api CloudStorageAPI) DeleteObjectHandler(w http.ResponseWriter, req *http.
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
 		case fs.ObjectNameInvalid:
 			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case fs.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case fs.OperationAborted:
+			writeErrorResponse(w, req, OperationAborted, req.URL.Path)
 		default:
-			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			writeProbeErrorResponse(w, err, req.URL.Path)
+		}
+		return
+	}
+	api.notifyObjectEvent(eventObjectRemovedDelete, fs.ObjectMetadata{Bucket: bucket, Object: object})
+	writeSuccessNoContent(w)
+}
+
+// GetObjectTaggingHandler - GET Object tagging
+// ----------
+// This implementation of the GET operation returns the tags of an object, sorted by key.
+func (api CloudStorageAPI) GetObjectTaggingHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			if api.isAnonymousReadDenied(bucket) {
+				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+				return
+			}
+		}
+	}
+
+	tags, err := api.ObjectAPI.GetObjectTagging(bucket, object)
+	if err != nil {
+		errorIf(err.Trace(), "GetObjectTagging failed.", requestFields(w))
+		writeObjectTaggingError(w, req, err)
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	response := ObjectTaggingResponse{}
+	for _, key := range keys {
+		response.TagSet.Tag = append(response.TagSet.Tag, Tag{Key: key, Value: tags[key]})
+	}
+	encodedSuccessResponse := encodeSuccessResponse(response)
+	// write headers
+	setCommonHeaders(w, len(encodedSuccessResponse))
+	// write body
+	w.Write(encodedSuccessResponse)
+}
+
+// PutObjectTaggingHandler - PUT Object tagging
+// ----------
+// This implementation of the PUT operation replaces all the tags of an object by the tag set
+// in the request body.
+func (api CloudStorageAPI) PutObjectTaggingHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			// anonymous writes are only allowed on public-read-write buckets
+			if !api.ObjectAPI.IsPublicBucket(bucket) {
+				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+				return
+			}
+		}
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+	taggingBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxTaggingSize+1))
+	if e != nil {
+		errorIf(probe.NewError(e), "Reading object tagging failed.", requestFields(w))
+		writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+		return
+	}
+	if len(taggingBytes) > maxTaggingSize {
+		writeErrorResponse(w, req, InvalidTag, req.URL.Path)
+		return
+	}
+
+	if !api.Anonymous && !verifyPayloadSignature(w, req, taggingBytes) {
+		return
+	}
+
+	tagging := ObjectTaggingRequest{}
+	if e := xml.Unmarshal(taggingBytes, &tagging); e != nil {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	tags := make(map[string]string, len(tagging.TagSet.Tag))
+	for _, tag := range tagging.TagSet.Tag {
+		// every key is unique within a tag set
+		if _, ok := tags[tag.Key]; ok {
+			writeErrorResponse(w, req, InvalidTag, req.URL.Path)
+			return
+		}
+		tags[tag.Key] = tag.Value
+	}
+	if err := api.ObjectAPI.PutObjectTagging(bucket, object, tags); err != nil {
+		errorIf(err.Trace(), "PutObjectTagging failed.", requestFields(w))
+		writeObjectTaggingError(w, req, err)
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// DeleteObjectTaggingHandler - DELETE Object tagging
+// ----------
+// This implementation of the DELETE operation removes all the tags of an object.
+func (api CloudStorageAPI) DeleteObjectTaggingHandler(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	if !api.Anonymous {
+		if isRequestRequiresACLCheck(req) {
+			// anonymous writes are only allowed on public-read-write buckets
+			if !api.ObjectAPI.IsPublicBucket(bucket) {
+				writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+				return
+			}
 		}
 	}
+
+	if err := api.ObjectAPI.DeleteObjectTagging(bucket, object); err != nil {
+		errorIf(err.Trace(), "DeleteObjectTagging failed.", requestFields(w))
+		writeObjectTaggingError(w, req, err)
+		return
+	}
 	writeSuccessNoContent(w)
 }
+
+// writeObjectTaggingError - write the error response of a failed object tagging operation
+func writeObjectTaggingError(w http.ResponseWriter, req *http.Request, err *probe.Error) {
+	switch err.ToGoError().(type) {
+	case fs.BucketNameInvalid:
+		writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+	case fs.BucketNotFound:
+		writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+	case fs.ObjectNotFound, fs.ObjectNameInvalid:
+		writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+	case fs.InvalidTag:
+		writeErrorResponse(w, req, InvalidTag, req.URL.Path)
+	default:
+		writeProbeErrorResponse(w, err, req.URL.Path)
+	}
+}
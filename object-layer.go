@@ -0,0 +1,86 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+// ObjectLayer - storage backend the API handlers operate on, implemented by the filesystem
+// and by the in memory backend
+type ObjectLayer interface {
+	// Storage operations
+	GetStorageInfo() (fs.StorageInfo, *probe.Error)
+
+	// Bucket operations
+	MakeBucket(bucket, acl string) *probe.Error
+	DeleteBucket(bucket string) *probe.Error
+	EmptyBucket(bucket string) *probe.Error
+	ListBuckets() ([]fs.BucketMetadata, *probe.Error)
+	GetBucketMetadata(bucket string) (fs.BucketMetadata, *probe.Error)
+	ListObjects(bucket string, resources fs.BucketResourcesMetadata) ([]fs.ObjectMetadata, fs.BucketResourcesMetadata, *probe.Error)
+	SetBucketCompression(bucket, algorithm string) *probe.Error
+	SetBucketEncryption(bucket, algorithm string) *probe.Error
+	SetBucketRetention(bucket string, retention time.Duration) *probe.Error
+	SetBucketNotification(bucket, webhook string) *probe.Error
+	SetBucketVersioning(bucket, status string) *probe.Error
+
+	// Bucket ACL operations
+	GetBucketACL(bucket string) (fs.BucketACL, *probe.Error)
+	SetBucketACL(bucket string, acl fs.BucketACL) *probe.Error
+	IsPrivateBucket(bucket string) bool
+	IsPublicBucket(bucket string) bool
+
+	// Bucket policy operations
+	SetBucketPolicy(bucket string, policy *fs.BucketPolicy) *probe.Error
+	GetBucketPolicy(bucket string) (*fs.BucketPolicy, *probe.Error)
+
+	// Object operations
+	GetObject(w io.Writer, bucket, object string, start, length int64) (int64, *probe.Error)
+	GetObjectMetadata(bucket, object string) (fs.ObjectMetadata, *probe.Error)
+	GetObjectVersion(w io.Writer, bucket, object, versionID string, start, length int64) (int64, *probe.Error)
+	GetObjectVersionMetadata(bucket, object, versionID string) (fs.ObjectMetadata, *probe.Error)
+	CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *fs.Signature) (fs.ObjectMetadata, *probe.Error)
+	CreateObjectIf(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *fs.Signature, preconditions fs.WritePreconditions) (fs.ObjectMetadata, *probe.Error)
+	CopyObject(destBucket, destObject, srcBucket, srcObject string, metadataDirective string) (fs.ObjectMetadata, *probe.Error)
+	DeleteObject(bucket, object string) *probe.Error
+	SetObjectRetention(bucket, object string, retainUntil time.Time) *probe.Error
+	SetObjectStorageClass(bucket, object, storageClass string) *probe.Error
+	PutObjectTagging(bucket, object string, tags map[string]string) *probe.Error
+	GetObjectTagging(bucket, object string) (map[string]string, *probe.Error)
+	DeleteObjectTagging(bucket, object string) *probe.Error
+
+	// Multipart operations
+	ListMultipartUploads(bucket string, resources fs.BucketMultipartResourcesMetadata) (fs.BucketMultipartResourcesMetadata, *probe.Error)
+	NewMultipartUpload(bucket, object string) (string, *probe.Error)
+	CreateObjectPart(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *fs.Signature) (string, *probe.Error)
+	CreateObjectPartIf(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *fs.Signature, ifNoneMatch string) (string, *probe.Error)
+	CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, data io.Reader, signature *fs.Signature) (fs.ObjectMetadata, *probe.Error)
+	ListObjectParts(bucket, object string, resources fs.ObjectResourcesMetadata) (fs.ObjectResourcesMetadata, *probe.Error)
+	AbortMultipartUpload(bucket, object, uploadID string) *probe.Error
+}
+
+// both backends have to satisfy ObjectLayer
+var (
+	_ ObjectLayer = fs.Filesystem{}
+	_ ObjectLayer = fs.MemoryFS{}
+)
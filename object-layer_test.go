@@ -0,0 +1,877 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type ObjectLayerSuite struct {
+	server *httptest.Server
+}
+
+var _ = Suite(&ObjectLayerSuite{})
+
+func (s *ObjectLayerSuite) SetUpSuite(c *C) {
+	api := CloudStorageAPI{
+		ObjectAPI: fs.NewMemoryFS(),
+		Anonymous: true,
+	}
+	s.server = httptest.NewServer(getCloudStorageAPIHandler(api))
+}
+
+func (s *ObjectLayerSuite) TearDownSuite(c *C) {
+	s.server.Close()
+}
+
+func (s *ObjectLayerSuite) do(c *C, method, path string, body []byte) *http.Response {
+	request, err := http.NewRequest(method, s.server.URL+path, bytes.NewReader(body))
+	c.Assert(err, IsNil)
+	response, err := http.DefaultClient.Do(request)
+	c.Assert(err, IsNil)
+	return response
+}
+
+func (s *ObjectLayerSuite) TestBucketAndObject(c *C) {
+	response := s.do(c, "PUT", "/memory-bucket", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	response = s.do(c, "PUT", "/memory-bucket", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusConflict)
+
+	response = s.do(c, "PUT", "/memory-bucket/object", []byte("hello memory"))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Not(Equals), "")
+
+	response = s.do(c, "HEAD", "/memory-bucket/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.ContentLength, Equals, int64(len("hello memory")))
+
+	response = s.do(c, "GET", "/memory-bucket/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello memory")
+
+	response = s.do(c, "GET", "/memory-bucket", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	listObjects := ListObjectsResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&listObjects), IsNil)
+	c.Assert(len(listObjects.Contents), Equals, 1)
+	c.Assert(listObjects.Contents[0].Key, Equals, "object")
+
+	response = s.do(c, "GET", "/", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	listBuckets := ListBucketsResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&listBuckets), IsNil)
+	c.Assert(len(listBuckets.Buckets.Bucket), Equals, 1)
+	c.Assert(listBuckets.Buckets.Bucket[0].Name, Equals, "memory-bucket")
+
+	response = s.do(c, "DELETE", "/memory-bucket/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	response = s.do(c, "GET", "/memory-bucket/object", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	response = s.do(c, "DELETE", "/memory-bucket", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+}
+
+func (s *ObjectLayerSuite) TestMultipartUpload(c *C) {
+	response := s.do(c, "PUT", "/memory-multipart", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	response = s.do(c, "POST", "/memory-multipart/object?uploads", nil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	newMultipartUpload := InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&newMultipartUpload), IsNil)
+	c.Assert(newMultipartUpload.UploadID, Not(Equals), "")
+
+	response = s.do(c, "DELETE", "/memory-multipart/object?uploadId="+newMultipartUpload.UploadID, nil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+}
+
+// ObjectLayerConformanceSuite - runs the same tests against every ObjectLayer implementation
+type ObjectLayerConformanceSuite struct {
+	root string
+}
+
+var _ = Suite(&ObjectLayerConformanceSuite{})
+
+func (s *ObjectLayerConformanceSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "object-layer-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *ObjectLayerConformanceSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+func (s *ObjectLayerConformanceSuite) TestFilesystem(c *C) {
+	create := func() ObjectLayer {
+		configPath, err := ioutil.TempDir(s.root, "config-")
+		c.Assert(err, IsNil)
+		path, err := ioutil.TempDir(s.root, "fs-")
+		c.Assert(err, IsNil)
+		fs.SetFSMultipartsConfigPath(filepath.Join(configPath, "multiparts-session.json"))
+		fs.SetFSBucketsConfigPath(filepath.Join(configPath, "buckets.json"))
+		filesystem, perr := fs.New()
+		c.Assert(perr, IsNil)
+		filesystem.SetRootPath(path)
+		filesystem.SetMinFreeDisk(0)
+		return filesystem
+	}
+	objectLayerConformanceSuite(c, create)
+	testObjectLayerImmutableWindow(c, func() ObjectLayer {
+		filesystem := create().(fs.Filesystem)
+		filesystem.SetImmutableWindow(time.Hour)
+		return filesystem
+	})
+}
+
+func (s *ObjectLayerConformanceSuite) TestMemory(c *C) {
+	create := func() ObjectLayer {
+		return fs.NewMemoryFS()
+	}
+	objectLayerConformanceSuite(c, create)
+	testObjectLayerImmutableWindow(c, func() ObjectLayer {
+		memory := fs.NewMemoryFS()
+		memory.SetImmutableWindow(time.Hour)
+		return memory
+	})
+}
+
+func objectLayerConformanceSuite(c *C, create func() ObjectLayer) {
+	testObjectLayerMakeBucket(c, create)
+	testObjectLayerListBuckets(c, create)
+	testObjectLayerDeleteBucket(c, create)
+	testObjectLayerEmptyBucket(c, create)
+	testObjectLayerBucketACL(c, create)
+	testObjectLayerBucketPolicy(c, create)
+	testObjectLayerObject(c, create)
+	testObjectLayerListObjects(c, create)
+	testObjectLayerCopyObject(c, create)
+	testObjectLayerDeleteObject(c, create)
+	testObjectLayerEmptyObject(c, create)
+	testObjectLayerFolders(c, create)
+	testObjectLayerObjectNameLength(c, create)
+	testObjectLayerRetention(c, create)
+	testObjectLayerVersioning(c, create)
+	testObjectLayerTagging(c, create)
+	testObjectLayerMultipart(c, create)
+	testObjectLayerAbortMultipart(c, create)
+	testObjectLayerStorageInfo(c, create)
+}
+
+func testObjectLayerMakeBucket(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	err := objectAPI.MakeBucket("b", "")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNameInvalid{})
+	err = objectAPI.MakeBucket("bucket", "everyone")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidACL{})
+
+	err = objectAPI.MakeBucket("bucket", "")
+	c.Assert(err, IsNil)
+	err = objectAPI.MakeBucket("bucket", "")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketExists{})
+
+	metadata, err := objectAPI.GetBucketMetadata("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Name, Equals, "bucket")
+	c.Assert(metadata.ACL, Equals, fs.BucketPrivate)
+
+	_, err = objectAPI.GetBucketMetadata("missing")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+}
+
+func testObjectLayerListBuckets(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	buckets, err := objectAPI.ListBuckets()
+	c.Assert(err, IsNil)
+	c.Assert(len(buckets), Equals, 0)
+
+	c.Assert(objectAPI.MakeBucket("bucket-b", ""), IsNil)
+	c.Assert(objectAPI.MakeBucket("bucket-a", ""), IsNil)
+	buckets, err = objectAPI.ListBuckets()
+	c.Assert(err, IsNil)
+	c.Assert(len(buckets), Equals, 2)
+	c.Assert(buckets[0].Name, Equals, "bucket-a")
+	c.Assert(buckets[1].Name, Equals, "bucket-b")
+}
+
+func testObjectLayerDeleteBucket(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	err := objectAPI.DeleteBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	err = objectAPI.DeleteBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotEmpty{})
+
+	c.Assert(objectAPI.DeleteObject("bucket", "object"), IsNil)
+	c.Assert(objectAPI.DeleteBucket("bucket"), IsNil)
+	_, err = objectAPI.GetBucketMetadata("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+}
+
+func testObjectLayerEmptyBucket(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	err := objectAPI.EmptyBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+
+	c.Assert(objectAPI.MakeBucket("bucket", "public-read"), IsNil)
+	c.Assert(objectAPI.MakeBucket("other", ""), IsNil)
+	for _, object := range []string{"object", "dir/nested/object"} {
+		_, err = objectAPI.CreateObject("bucket", object, "", int64(len("data")), bytes.NewBufferString("data"), nil)
+		c.Assert(err, IsNil)
+	}
+	_, err = objectAPI.CreateObject("other", "kept", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	uploadID, err := objectAPI.NewMultipartUpload("bucket", "upload")
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CreateObjectPart(context.Background(), "bucket", "upload", uploadID, "", 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err, IsNil)
+	_, err = objectAPI.NewMultipartUpload("other", "other-upload")
+	c.Assert(err, IsNil)
+
+	c.Assert(objectAPI.EmptyBucket("bucket"), IsNil)
+	objects, _, err := objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(objects), Equals, 0)
+	uploads, err := objectAPI.ListMultipartUploads("bucket", fs.BucketMultipartResourcesMetadata{MaxUploads: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(uploads.Upload), Equals, 0)
+	_, err = objectAPI.CreateObjectPart(context.Background(), "bucket", "upload", uploadID, "", 2, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidUploadID{})
+
+	// the bucket remains along with its ACL, other buckets are left alone
+	acl, err := objectAPI.GetBucketACL("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(acl, Equals, fs.BucketPublicRead)
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("new data")), bytes.NewBufferString("new data"), nil)
+	c.Assert(err, IsNil)
+	_, err = objectAPI.GetObjectMetadata("other", "kept")
+	c.Assert(err, IsNil)
+	uploads, err = objectAPI.ListMultipartUploads("other", fs.BucketMultipartResourcesMetadata{MaxUploads: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(uploads.Upload), Equals, 1)
+
+	// nothing is removed while an object is locked
+	c.Assert(objectAPI.SetObjectRetention("bucket", "object", time.Now().UTC().Add(time.Hour)), IsNil)
+	_, err = objectAPI.CreateObject("bucket", "unlocked", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	err = objectAPI.EmptyBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectLocked{})
+	_, err = objectAPI.GetObjectMetadata("bucket", "unlocked")
+	c.Assert(err, IsNil)
+}
+
+func testObjectLayerBucketACL(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	c.Assert(objectAPI.IsPrivateBucket("bucket"), Equals, true)
+	c.Assert(objectAPI.IsPublicBucket("bucket"), Equals, false)
+
+	c.Assert(objectAPI.SetBucketACL("bucket", fs.BucketPublicReadWrite), IsNil)
+	acl, err := objectAPI.GetBucketACL("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(acl, Equals, fs.BucketPublicReadWrite)
+	c.Assert(objectAPI.IsPrivateBucket("bucket"), Equals, false)
+	c.Assert(objectAPI.IsPublicBucket("bucket"), Equals, true)
+
+	err = objectAPI.SetBucketACL("missing", fs.BucketPrivate)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+}
+
+func testObjectLayerBucketPolicy(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	_, err := objectAPI.GetBucketPolicy("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketPolicyNotFound{})
+
+	policy, err := fs.ParseBucketPolicy("bucket", []byte(`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"}]}`))
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.SetBucketPolicy("bucket", policy), IsNil)
+	stored, err := objectAPI.GetBucketPolicy("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(stored, DeepEquals, policy)
+	// the ACL is left as it was
+	c.Assert(objectAPI.IsPrivateBucket("bucket"), Equals, true)
+
+	c.Assert(objectAPI.SetBucketPolicy("bucket", nil), IsNil)
+	_, err = objectAPI.GetBucketPolicy("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketPolicyNotFound{})
+
+	err = objectAPI.SetBucketPolicy("missing", policy)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+}
+
+func testObjectLayerObject(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	data := "hello object layer"
+	md5Sum := md5.Sum([]byte(data))
+	md5Base64 := base64.StdEncoding.EncodeToString(md5Sum[:])
+
+	_, err := objectAPI.CreateObject("bucket", "object", md5Base64, int64(len(data)), bytes.NewBufferString(data), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	_, err = objectAPI.CreateObject("bucket", "object", "not-base64", int64(len(data)), bytes.NewBufferString(data), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidDigest{})
+	wrongSum := md5.Sum([]byte("other data"))
+	_, err = objectAPI.CreateObject("bucket", "object", base64.StdEncoding.EncodeToString(wrongSum[:]), int64(len(data)), bytes.NewBufferString(data), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BadDigest{})
+
+	metadata, err := objectAPI.CreateObject("bucket", "object", md5Base64, int64(len(data)), bytes.NewBufferString(data), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Md5, Equals, hex.EncodeToString(md5Sum[:]))
+	c.Assert(metadata.Size, Equals, int64(len(data)))
+
+	metadata, err = objectAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Size, Equals, int64(len(data)))
+	c.Assert(metadata.ContentType, Equals, "application/octet-stream")
+
+	var buffer bytes.Buffer
+	n, err := objectAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(data)))
+	c.Assert(buffer.String(), Equals, data)
+
+	buffer.Reset()
+	n, err = objectAPI.GetObject(&buffer, "bucket", "object", 6, 6)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(6))
+	c.Assert(buffer.String(), Equals, "object")
+
+	_, err = objectAPI.GetObject(&buffer, "bucket", "missing", 0, 0)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+	_, err = objectAPI.GetObjectMetadata("bucket", "missing")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+	_, err = objectAPI.GetObjectMetadata("bucket", "../object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameInvalid{})
+}
+
+func testObjectLayerListObjects(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	for _, object := range []string{"a", "b/c", "b/d", "e"} {
+		_, err := objectAPI.CreateObject("bucket", object, "", int64(len(object)), bytes.NewBufferString(object), nil)
+		c.Assert(err, IsNil)
+	}
+	objectNames := func(objects []fs.ObjectMetadata) []string {
+		var names []string
+		for _, object := range objects {
+			names = append(names, object.Object)
+		}
+		return names
+	}
+
+	objects, resources, err := objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objects), DeepEquals, []string{"a", "b/c", "b/d", "e"})
+	c.Assert(resources.IsTruncated, Equals, false)
+
+	objects, resources, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Delimiter: "/", Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objects), DeepEquals, []string{"a", "e"})
+	c.Assert(resources.CommonPrefixes, DeepEquals, []string{"b/"})
+
+	objects, _, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Prefix: "b/", Delimiter: "/", Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objects), DeepEquals, []string{"b/c", "b/d"})
+
+	objects, resources, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Maxkeys: 2})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objects), DeepEquals, []string{"a", "b/c"})
+	c.Assert(resources.IsTruncated, Equals, true)
+
+	objects, resources, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Marker: "b/c", Maxkeys: 2})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objects), DeepEquals, []string{"b/d", "e"})
+
+	_, _, err = objectAPI.ListObjects("missing", fs.BucketResourcesMetadata{Maxkeys: 1000})
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+}
+
+func testObjectLayerCopyObject(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("source", ""), IsNil)
+	c.Assert(objectAPI.MakeBucket("destination", ""), IsNil)
+	_, err := objectAPI.CreateObject("source", "object", "", int64(len("copied")), bytes.NewBufferString("copied"), nil)
+	c.Assert(err, IsNil)
+
+	metadata, err := objectAPI.CopyObject("destination", "copy", "source", "object", "")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Size, Equals, int64(len("copied")))
+	var buffer bytes.Buffer
+	_, err = objectAPI.GetObject(&buffer, "destination", "copy", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "copied")
+
+	_, err = objectAPI.CopyObject("source", "object", "source", "object", "COPY")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidCopyRequest{})
+	_, err = objectAPI.CopyObject("source", "object", "source", "object", "REPLACE")
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CopyObject("destination", "copy", "source", "object", "MOVE")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidArgument{})
+	_, err = objectAPI.CopyObject("destination", "copy", "source", "missing", "")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+}
+
+func testObjectLayerDeleteObject(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	err := objectAPI.DeleteObject("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.DeleteObject("bucket", "object"), IsNil)
+	_, err = objectAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+}
+
+func testObjectLayerEmptyObject(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	emptySum := md5.Sum(nil)
+	emptyETag := hex.EncodeToString(emptySum[:])
+
+	metadata, err := objectAPI.CreateObject("bucket", "put", base64.StdEncoding.EncodeToString(emptySum[:]), 0, bytes.NewReader(nil), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Md5, Equals, emptyETag)
+	c.Assert(metadata.Size, Equals, int64(0))
+
+	// completed out of no part, out of an empty part and out of empty parts
+	for i, partCount := range []int{0, 1, 2} {
+		object := fmt.Sprintf("multipart%d", i)
+		uploadID, err := objectAPI.NewMultipartUpload("bucket", object)
+		c.Assert(err, IsNil)
+		completedParts := fs.CompleteMultipartUpload{}
+		for partID := 1; partID <= partCount; partID++ {
+			etag, err := objectAPI.CreateObjectPart(context.Background(), "bucket", object, uploadID, "", partID, 0, bytes.NewReader(nil), nil)
+			c.Assert(err, IsNil)
+			c.Assert(etag, Equals, emptyETag)
+			completedParts.Part = append(completedParts.Part, fs.CompletePart{PartNumber: partID, ETag: etag})
+		}
+		completeBytes, e := xml.Marshal(completedParts)
+		c.Assert(e, IsNil)
+		metadata, err = objectAPI.CompleteMultipartUpload(context.Background(), "bucket", object, uploadID, bytes.NewReader(completeBytes), nil)
+		c.Assert(err, IsNil)
+		c.Assert(metadata.Md5, Equals, emptyETag)
+		c.Assert(metadata.Size, Equals, int64(0))
+	}
+
+	for _, object := range []string{"put", "multipart0", "multipart1", "multipart2"} {
+		metadata, err = objectAPI.GetObjectMetadata("bucket", object)
+		c.Assert(err, IsNil)
+		c.Assert(metadata.Md5, Equals, emptyETag)
+		c.Assert(metadata.Size, Equals, int64(0))
+		buffer := bytes.NewBufferString("untouched")
+		n, err := objectAPI.GetObject(buffer, "bucket", object, 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, int64(0))
+		c.Assert(buffer.String(), Equals, "untouched")
+	}
+}
+
+func testObjectLayerFolders(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	metadata, err := objectAPI.CreateObject("bucket", "folder/", "", 0, bytes.NewBufferString(""), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Size, Equals, int64(0))
+	c.Assert(metadata.Md5, Equals, "d41d8cd98f00b204e9800998ecf8427e")
+	_, err = objectAPI.CreateObject("bucket", "data/", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidFolderObject{})
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CreateObject("bucket", "object/", "", 0, bytes.NewBufferString(""), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidFolderObject{})
+
+	// folders are listed as common prefixes only, and survive the deletion of their objects
+	_, err = objectAPI.CreateObject("bucket", "folder/object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.DeleteObject("bucket", "folder/object"), IsNil)
+	objects, resources, err := objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Delimiter: "/", Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(objects), Equals, 1)
+	c.Assert(objects[0].Object, Equals, "object")
+	c.Assert(resources.CommonPrefixes, DeepEquals, []string{"folder/"})
+	objects, resources, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Prefix: "folder/", Delimiter: "/", Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(objects), Equals, 0)
+	c.Assert(len(resources.CommonPrefixes), Equals, 0)
+	c.Assert(objectAPI.DeleteBucket("bucket").ToGoError(), FitsTypeOf, fs.BucketNotEmpty{})
+
+	c.Assert(objectAPI.DeleteObject("bucket", "folder/"), IsNil)
+	_, resources, err = objectAPI.ListObjects("bucket", fs.BucketResourcesMetadata{Delimiter: "/", Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(resources.CommonPrefixes), Equals, 0)
+	err = objectAPI.DeleteObject("bucket", "folder/")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+}
+
+func testObjectLayerObjectNameLength(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	// names are limited in bytes, multi-byte characters count with every byte, directories of
+	// 200 bytes stay within the name limit of filesystems
+	longest := strings.Repeat(strings.Repeat("\u00e9", 100)+"/", 5) + strings.Repeat("\u00e9", 9) + "x"
+	tooLong := longest + "x"
+	c.Assert(len(longest), Equals, fs.MaxObjectNameLength)
+	c.Assert(len([]rune(tooLong)) < fs.MaxObjectNameLength, Equals, true)
+
+	_, err := objectAPI.CreateObject("bucket", longest, "", int64(len("longest")), bytes.NewBufferString("longest"), nil)
+	c.Assert(err, IsNil)
+	var buffer bytes.Buffer
+	_, err = objectAPI.GetObject(&buffer, "bucket", longest, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "longest")
+	_, err = objectAPI.CreateObject("bucket", tooLong, "", int64(len("too long")), bytes.NewBufferString("too long"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameTooLong{})
+	_, err = objectAPI.CopyObject("bucket", tooLong, "bucket", longest, "")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameTooLong{})
+
+	_, err = objectAPI.NewMultipartUpload("bucket", tooLong)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameTooLong{})
+	uploadID, err := objectAPI.NewMultipartUpload("bucket", longest)
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CreateObjectPart(context.Background(), "bucket", tooLong, uploadID, "", 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameTooLong{})
+	etag, err := objectAPI.CreateObjectPart(context.Background(), "bucket", longest, uploadID, "", 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err, IsNil)
+	completeBytes, e := xml.Marshal(fs.CompleteMultipartUpload{Part: []fs.CompletePart{{PartNumber: 1, ETag: etag}}})
+	c.Assert(e, IsNil)
+	_, err = objectAPI.CompleteMultipartUpload(context.Background(), "bucket", tooLong, uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameTooLong{})
+	_, err = objectAPI.CompleteMultipartUpload(context.Background(), "bucket", longest, uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+	buffer.Reset()
+	_, err = objectAPI.GetObject(&buffer, "bucket", longest, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "part")
+}
+
+func testObjectLayerRetention(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	_, err := objectAPI.CreateObject("bucket", "object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+
+	err = objectAPI.SetObjectRetention("bucket", "object", time.Now().UTC().Add(-time.Hour))
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidArgument{})
+	c.Assert(objectAPI.SetObjectRetention("bucket", "object", time.Now().UTC().Add(time.Hour)), IsNil)
+
+	err = objectAPI.DeleteObject("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectLocked{})
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("new data")), bytes.NewBufferString("new data"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectLocked{})
+	// the retention cannot be removed by its name on disk
+	err = objectAPI.DeleteObject("bucket", "object$lock")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameInvalid{})
+	_, err = objectAPI.CreateObject("bucket", "object$lock", "", int64(len("0")), bytes.NewBufferString("0"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNameInvalid{})
+	err = objectAPI.DeleteObject("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectLocked{})
+
+	c.Assert(objectAPI.SetBucketRetention("bucket", time.Hour), IsNil)
+	metadata, err := objectAPI.CreateObject("bucket", "retained", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.RetainUntil.After(time.Now().UTC()), Equals, true)
+	err = objectAPI.DeleteObject("bucket", "retained")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectLocked{})
+}
+
+func testObjectLayerVersioning(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	err := objectAPI.SetBucketVersioning("bucket", "Disabled")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidArgument{})
+	err = objectAPI.SetBucketVersioning("missing", fs.VersioningEnabled)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+
+	// objects written before versioning is enabled are the null version
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("null")), bytes.NewBufferString("null"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.SetBucketVersioning("bucket", fs.VersioningEnabled), IsNil)
+	metadata, err := objectAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.VersionID, Equals, fs.NullVersionID)
+
+	// replaced and removed objects are kept as previous versions
+	first, err := objectAPI.CreateObject("bucket", "object", "", int64(len("first")), bytes.NewBufferString("first"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(fs.IsValidVersionID(first.VersionID), Equals, true)
+	c.Assert(first.VersionID, Not(Equals), fs.NullVersionID)
+	second, err := objectAPI.CreateObject("bucket", "object", "", int64(len("second")), bytes.NewBufferString("second"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(second.VersionID, Not(Equals), first.VersionID)
+	c.Assert(objectAPI.DeleteObject("bucket", "object"), IsNil)
+	_, err = objectAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+	for versionID, data := range map[string]string{fs.NullVersionID: "null", first.VersionID: "first", second.VersionID: "second"} {
+		var buffer bytes.Buffer
+		_, err = objectAPI.GetObjectVersion(&buffer, "bucket", "object", versionID, 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(buffer.String(), Equals, data)
+		metadata, err = objectAPI.GetObjectVersionMetadata("bucket", "object", versionID)
+		c.Assert(err, IsNil)
+		c.Assert(metadata.VersionID, Equals, versionID)
+		c.Assert(metadata.Size, Equals, int64(len(data)))
+	}
+	_, err = objectAPI.GetObjectVersionMetadata("bucket", "object", strings.Repeat("0", 32))
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectVersionNotFound{})
+	_, err = objectAPI.GetObjectVersionMetadata("bucket", "object", "../object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidArgument{})
+
+	// while suspended objects are the null version, replacing the null version kept before
+	c.Assert(objectAPI.SetBucketVersioning("bucket", fs.VersioningSuspended), IsNil)
+	for _, data := range []string{"suspended", "replaced"} {
+		metadata, err = objectAPI.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewBufferString(data), nil)
+		c.Assert(err, IsNil)
+		c.Assert(metadata.VersionID, Equals, fs.NullVersionID)
+	}
+	var buffer bytes.Buffer
+	_, err = objectAPI.GetObjectVersion(&buffer, "bucket", "object", fs.NullVersionID, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "replaced")
+	buffer.Reset()
+	_, err = objectAPI.GetObjectVersion(&buffer, "bucket", "object", first.VersionID, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "first")
+
+	// previous versions keep a bucket from being deleted until it is emptied
+	c.Assert(objectAPI.DeleteObject("bucket", "object"), IsNil)
+	err = objectAPI.DeleteBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotEmpty{})
+	c.Assert(objectAPI.EmptyBucket("bucket"), IsNil)
+	c.Assert(objectAPI.DeleteBucket("bucket"), IsNil)
+}
+
+func testObjectLayerImmutableWindow(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+
+	// objects written by PUT are not immutable
+	_, err := objectAPI.CreateObject("bucket", "object", "", int64(len("put")), bytes.NewBufferString("put"), nil)
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("put again")), bytes.NewBufferString("put again"), nil)
+	c.Assert(err, IsNil)
+
+	uploadID, err := objectAPI.NewMultipartUpload("bucket", "object")
+	c.Assert(err, IsNil)
+	md5Sum := md5.Sum([]byte("part"))
+	etag, err := objectAPI.CreateObjectPart(context.Background(), "bucket", "object", uploadID, base64.StdEncoding.EncodeToString(md5Sum[:]), 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err, IsNil)
+	completeBytes, e := xml.Marshal(fs.CompleteMultipartUpload{Part: []fs.CompletePart{{PartNumber: 1, ETag: etag}}})
+	c.Assert(e, IsNil)
+	_, err = objectAPI.CompleteMultipartUpload(context.Background(), "bucket", "object", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+
+	// a completed object may be neither replaced nor removed within its window
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("overwrite")), bytes.NewBufferString("overwrite"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.OperationAborted{})
+	_, err = objectAPI.CopyObject("bucket", "object", "bucket", "object", "REPLACE")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.OperationAborted{})
+	err = objectAPI.DeleteObject("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.OperationAborted{})
+	var buffer bytes.Buffer
+	_, err = objectAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "part")
+
+	// the bucket may not be emptied within the window of any of its objects
+	err = objectAPI.EmptyBucket("bucket")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.OperationAborted{})
+	buffer.Reset()
+	_, err = objectAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "part")
+
+	// other objects and buckets are not affected
+	_, err = objectAPI.CopyObject("bucket", "copy", "bucket", "object", "")
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.DeleteObject("bucket", "copy"), IsNil)
+	c.Assert(objectAPI.MakeBucket("bucket2", ""), IsNil)
+	_, err = objectAPI.CopyObject("bucket2", "copy", "bucket", "object", "")
+	c.Assert(err, IsNil)
+	c.Assert(objectAPI.EmptyBucket("bucket2"), IsNil)
+}
+
+func testObjectLayerTagging(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	_, err := objectAPI.GetObjectTagging("bucket", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+	_, err = objectAPI.GetObjectTagging("missing", "object")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.BucketNotFound{})
+	_, err = objectAPI.CreateObject("bucket", "object", "", int64(len("data")), bytes.NewBufferString("data"), nil)
+	c.Assert(err, IsNil)
+
+	tags, err := objectAPI.GetObjectTagging("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(len(tags), Equals, 0)
+	c.Assert(objectAPI.PutObjectTagging("bucket", "object", map[string]string{"project": "minio", "empty": ""}), IsNil)
+	tags, err = objectAPI.GetObjectTagging("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, map[string]string{"project": "minio", "empty": ""})
+	metadata, err := objectAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.TagCount, Equals, 2)
+
+	// limits of S3, the tags stay as they were
+	tooMany := make(map[string]string)
+	for i := 0; i <= fs.MaxObjectTags; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	for _, invalid := range []map[string]string{
+		tooMany,
+		{"": "value"},
+		{strings.Repeat("k", fs.MaxTagKeyLength+1): "value"},
+		{"key": strings.Repeat("v", fs.MaxTagValueLength+1)},
+		{"aws:reserved": "value"},
+	} {
+		err = objectAPI.PutObjectTagging("bucket", "object", invalid)
+		c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidTag{})
+	}
+	// lengths are in characters, not bytes
+	c.Assert(fs.CheckObjectTags(map[string]string{strings.Repeat("\u00e9", fs.MaxTagKeyLength): strings.Repeat("\u00e9", fs.MaxTagValueLength)}), IsNil)
+	tags, err = objectAPI.GetObjectTagging("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(len(tags), Equals, 2)
+
+	// copies keep the tags of their source, new objects carry none
+	metadata, err = objectAPI.CopyObject("bucket", "copy", "bucket", "object", "")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.TagCount, Equals, 2)
+	tags, err = objectAPI.GetObjectTagging("bucket", "copy")
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, map[string]string{"project": "minio", "empty": ""})
+	metadata, err = objectAPI.CreateObject("bucket", "object", "", int64(len("new data")), bytes.NewBufferString("new data"), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.TagCount, Equals, 0)
+	tags, err = objectAPI.GetObjectTagging("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(len(tags), Equals, 0)
+
+	c.Assert(objectAPI.DeleteObjectTagging("bucket", "copy"), IsNil)
+	tags, err = objectAPI.GetObjectTagging("bucket", "copy")
+	c.Assert(err, IsNil)
+	c.Assert(len(tags), Equals, 0)
+	metadata, err = objectAPI.GetObjectMetadata("bucket", "copy")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.TagCount, Equals, 0)
+	err = objectAPI.DeleteObjectTagging("bucket", "missing")
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.ObjectNotFound{})
+}
+
+func testObjectLayerMultipart(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	uploadID, err := objectAPI.NewMultipartUpload("bucket", "object")
+	c.Assert(err, IsNil)
+
+	_, err = objectAPI.CreateObjectPart(context.Background(), "bucket", "object", "invalid-upload-id", "", 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidUploadID{})
+
+	completedParts := fs.CompleteMultipartUpload{}
+	for i, part := range []string{"first part ", "second part"} {
+		md5Sum := md5.Sum([]byte(part))
+		etag, err := objectAPI.CreateObjectPart(context.Background(), "bucket", "object", uploadID, base64.StdEncoding.EncodeToString(md5Sum[:]), i+1, int64(len(part)), bytes.NewBufferString(part), nil)
+		c.Assert(err, IsNil)
+		c.Assert(etag, Equals, hex.EncodeToString(md5Sum[:]))
+		completedParts.Part = append(completedParts.Part, fs.CompletePart{PartNumber: i + 1, ETag: etag})
+	}
+
+	uploads, err := objectAPI.ListMultipartUploads("bucket", fs.BucketMultipartResourcesMetadata{MaxUploads: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(uploads.Upload), Equals, 1)
+	c.Assert(uploads.Upload[0].Object, Equals, "object")
+	c.Assert(uploads.Upload[0].UploadID, Equals, uploadID)
+
+	parts, err := objectAPI.ListObjectParts("bucket", "object", fs.ObjectResourcesMetadata{UploadID: uploadID, MaxParts: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(parts.Part), Equals, 2)
+	c.Assert(parts.Part[0].PartNumber, Equals, 1)
+	c.Assert(parts.Part[1].PartNumber, Equals, 2)
+	c.Assert(parts.Part[0].LastModified.IsZero(), Equals, false)
+
+	completeBytes, e := xml.Marshal(completedParts)
+	c.Assert(e, IsNil)
+	metadata, err := objectAPI.CompleteMultipartUpload(context.Background(), "bucket", "object", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Size, Equals, int64(len("first part second part")))
+
+	var buffer bytes.Buffer
+	_, err = objectAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "first part second part")
+
+	uploads, err = objectAPI.ListMultipartUploads("bucket", fs.BucketMultipartResourcesMetadata{MaxUploads: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(uploads.Upload), Equals, 0)
+}
+
+func testObjectLayerAbortMultipart(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket", ""), IsNil)
+	uploadID, err := objectAPI.NewMultipartUpload("bucket", "object")
+	c.Assert(err, IsNil)
+	_, err = objectAPI.CreateObjectPart(context.Background(), "bucket", "object", uploadID, "", 1, int64(len("part")), bytes.NewBufferString("part"), nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(objectAPI.AbortMultipartUpload("bucket", "object", uploadID), IsNil)
+	err = objectAPI.AbortMultipartUpload("bucket", "object", uploadID)
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidUploadID{})
+	_, err = objectAPI.ListObjectParts("bucket", "object", fs.ObjectResourcesMetadata{UploadID: uploadID})
+	c.Assert(err.ToGoError(), FitsTypeOf, fs.InvalidUploadID{})
+}
+
+func testObjectLayerStorageInfo(c *C, create func() ObjectLayer) {
+	objectAPI := create()
+	c.Assert(objectAPI.MakeBucket("bucket-a", ""), IsNil)
+	c.Assert(objectAPI.MakeBucket("bucket-b", ""), IsNil)
+	for _, object := range []string{"a", "b/c"} {
+		_, err := objectAPI.CreateObject("bucket-a", object, "", int64(len(object)), bytes.NewBufferString(object), nil)
+		c.Assert(err, IsNil)
+	}
+	storageInfo, err := objectAPI.GetStorageInfo()
+	c.Assert(err, IsNil)
+	c.Assert(storageInfo.Buckets, Equals, int64(2))
+	c.Assert(storageInfo.Objects, Equals, int64(2))
+}
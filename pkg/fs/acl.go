@@ -16,6 +16,8 @@
 
 package fs
 
+import "github.com/minio/minio-xl/pkg/probe"
+
 // IsPrivateBucket - is private bucket
 func (fs Filesystem) IsPrivateBucket(bucket string) bool {
 	fs.lock.Lock()
@@ -33,7 +35,7 @@ func (fs Filesystem) IsPublicBucket(bucket string) bool {
 	defer fs.lock.Unlock()
 	bucketMetadata, ok := fs.buckets.Metadata[bucket]
 	if !ok {
-		return true
+		return false
 	}
 	return bucketMetadata.ACL.IsPublicReadWrite()
 }
@@ -44,7 +46,24 @@ func (fs Filesystem) IsReadOnlyBucket(bucket string) bool {
 	defer fs.lock.Unlock()
 	bucketMetadata, ok := fs.buckets.Metadata[bucket]
 	if !ok {
-		return true
+		return false
 	}
 	return bucketMetadata.ACL.IsPublicRead()
 }
+
+// GetBucketACL - get canned acl of a bucket
+func (fs Filesystem) GetBucketACL(bucket string) (BucketACL, *probe.Error) {
+	bucketMetadata, err := fs.GetBucketMetadata(bucket)
+	if err != nil {
+		return "", err.Trace(bucket)
+	}
+	return bucketMetadata.ACL, nil
+}
+
+// SetBucketACL - set canned acl of a bucket, persisted in buckets metadata
+func (fs Filesystem) SetBucketACL(bucket string, acl BucketACL) *probe.Error {
+	if err := fs.SetBucketMetadata(bucket, map[string]string{"acl": acl.String()}); err != nil {
+		return err.Trace(bucket, acl.String())
+	}
+	return nil
+}
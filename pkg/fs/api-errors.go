@@ -0,0 +1,112 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"net/http"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// APIError - S3 error code, http status and message for an error returned by filesystem,
+// unknown errors are reported as InternalError.
+//   - http://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+func APIError(err *probe.Error) (code string, httpStatus int, message string) {
+	if err == nil {
+		return "", http.StatusOK, ""
+	}
+	switch err.ToGoError().(type) {
+	case MissingDateHeader, RequestTimeTooSkewed:
+		return "RequestTimeTooSkewed", http.StatusForbidden, "The difference between the request time and the server's time is too large."
+	case MissingExpiresQuery:
+		return "AuthorizationQueryParametersError", http.StatusBadRequest, "X-Amz-Expires must be present in a presigned request."
+	case ExpiredPresignedRequest:
+		return "AccessDenied", http.StatusForbidden, "Request has expired."
+	case SignatureDoesNotMatch:
+		return "SignatureDoesNotMatch", http.StatusForbidden, "The request signature we calculated does not match the signature you provided."
+	case InvalidArgument, InvalidACL:
+		return "InvalidArgument", http.StatusBadRequest, "Invalid argument."
+	case OperationNotPermitted, ObjectLocked:
+		return "AccessDenied", http.StatusForbidden, "Access Denied."
+	case RootPathFull:
+		return "RootPathFull", http.StatusInternalServerError, "Root path has reached its minimum free disk threshold. Please delete few objects to proceed."
+	case TooManyMultipartUploads:
+		return "SlowDown", http.StatusServiceUnavailable, "Too many multipart uploads in progress on this bucket, complete or abort some of them first."
+	case OperationAborted:
+		return "OperationAborted", http.StatusConflict, "A conflicting conditional operation is currently in progress against this resource. Try again."
+	case BucketNotFound:
+		return "NoSuchBucket", http.StatusNotFound, "The specified bucket does not exist."
+	case BucketNotEmpty:
+		return "BucketNotEmpty", http.StatusConflict, "The bucket you tried to delete is not empty."
+	case BucketExists:
+		return "BucketAlreadyExists", http.StatusConflict, "The requested bucket name is not available."
+	case BucketPolicyNotFound:
+		return "NoSuchBucketPolicy", http.StatusNotFound, "The bucket policy does not exist."
+	case BucketNameInvalid:
+		return "InvalidBucketName", http.StatusBadRequest, "The specified bucket is not valid."
+	case ObjectNotFound, ObjectNameInvalid:
+		return "NoSuchKey", http.StatusNotFound, "The specified key does not exist."
+	case ObjectNameTooLong:
+		return "KeyTooLongError", http.StatusBadRequest, "Your key is too long."
+	case ObjectVersionNotFound:
+		return "NoSuchVersion", http.StatusNotFound, "The specified version does not exist."
+	case PreconditionFailed:
+		return "PreconditionFailed", http.StatusPreconditionFailed, "At least one of the pre-conditions you specified did not hold."
+	case BadDigest:
+		return "BadDigest", http.StatusBadRequest, "The Content-MD5 you specified did not match what we received."
+	case InvalidDigest:
+		return "InvalidDigest", http.StatusBadRequest, "The Content-MD5 you specified is not valid."
+	case EntityTooLarge:
+		return "EntityTooLarge", http.StatusBadRequest, "Your proposed upload exceeds the maximum allowed object size."
+	case IncompleteBody:
+		return "IncompleteBody", http.StatusBadRequest, "You did not provide the number of bytes specified by the Content-Length HTTP header."
+	case InvalidFolderObject:
+		return "InvalidArgument", http.StatusBadRequest, "Objects ending in a slash are kept as empty folders, which cannot have data or replace an object of the same name."
+	case InvalidCopyRequest:
+		return "InvalidRequest", http.StatusBadRequest, "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata."
+	case InvalidRange:
+		return "InvalidRange", http.StatusRequestedRangeNotSatisfiable, "The requested range cannot be satisfied."
+	case InvalidUploadID:
+		return "NoSuchUpload", http.StatusNotFound, "The specified multipart upload does not exist."
+	case InvalidPart:
+		return "InvalidPart", http.StatusBadRequest, "One or more of the specified parts could not be found."
+	case InvalidPartOrder:
+		return "InvalidPartOrder", http.StatusBadRequest, "The list of parts was not in ascending order. The parts list must be specified in order by part number."
+	case MalformedXML:
+		return "MalformedXML", http.StatusBadRequest, "The XML you provided was not well-formed or did not validate against our published schema."
+	case InvalidTag:
+		return "InvalidTag", http.StatusBadRequest, "The tag provided was not a valid tag."
+	case MalformedPolicy:
+		return "MalformedPolicy", http.StatusBadRequest, "Policy has invalid or unsupported elements."
+	case MissingPOSTPolicy:
+		return "MalformedPOSTRequest", http.StatusBadRequest, "The body of your POST request is not well-formed multipart/form-data."
+	case InvalidTrailer:
+		return "InvalidArgument", http.StatusBadRequest, "The x-amz-trailer header declares an unsupported checksum algorithm."
+	case InvalidMasterKey:
+		return "InvalidRequest", http.StatusBadRequest, "Server side encryption requires a valid master key to be configured."
+	case InvalidWriteBufferSize:
+		return "InternalError", http.StatusInternalServerError, "The write buffer size configured on the server is out of bounds."
+	case InvalidFileMode:
+		return "InternalError", http.StatusInternalServerError, "The file or directory mode configured on the server is not usable."
+	case ObjectCorrupted:
+		return "InternalError", http.StatusInternalServerError, "The stored object was found corrupted."
+	case NotImplemented, APINotImplemented:
+		return "NotImplemented", http.StatusNotImplemented, "A header you provided implies functionality that is not implemented."
+	default:
+		return "InternalError", http.StatusInternalServerError, "We encountered an internal error, please try again."
+	}
+}
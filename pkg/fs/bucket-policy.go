@@ -0,0 +1,264 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Please read for more information - http://docs.aws.amazon.com/AmazonS3/latest/dev/access-policy-language-overview.html
+//
+// Only a subset of the policy language is supported, statements allow or deny principals
+// actions on objects of the bucket. Conditions and the Not* elements are rejected rather than
+// ignored, as ignoring them would grant more than the policy says.
+
+// PolicyActionGetObject, PolicyActionPutObject - actions bucket policies may allow or deny
+const (
+	PolicyActionGetObject = "s3:GetObject"
+	PolicyActionPutObject = "s3:PutObject"
+)
+
+// supportedPolicyActions - every action statements may name, patterns have to match one of them
+var supportedPolicyActions = []string{PolicyActionGetObject, PolicyActionPutObject}
+
+const (
+	// policyAnyone - principal matching every request, including anonymous ones
+	policyAnyone = "*"
+	// policyResourcePrefix - prefix of every resource, followed by bucket/object
+	policyResourcePrefix = "arn:aws:s3:::"
+	// policyEffectAllow, policyEffectDeny - effects of a statement
+	policyEffectAllow = "Allow"
+	policyEffectDeny  = "Deny"
+)
+
+// PolicyDecision - outcome of evaluating a bucket policy for a request
+type PolicyDecision int
+
+// PolicyNoDecision, PolicyAllowed, PolicyDenied - no statement applies to the request which
+// is left to the bucket ACL, a statement allows it, or a statement explicitly denies it
+const (
+	PolicyNoDecision PolicyDecision = iota
+	PolicyAllowed
+	PolicyDenied
+)
+
+// policyValues - values of a statement element, given either as a string or as an array
+type policyValues []string
+
+// UnmarshalJSON - accept a single string as an array of one
+func (v *policyValues) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*v = policyValues{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// PolicyPrincipal - principals a statement applies to, "*" for everyone or access keys
+type PolicyPrincipal struct {
+	AWS policyValues `json:"AWS"`
+}
+
+// UnmarshalJSON - accept "*" as well as {"AWS": ...}, other kinds of principals are rejected
+func (p *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var anyone string
+	if err := json.Unmarshal(data, &anyone); err == nil {
+		if anyone != policyAnyone {
+			return errors.New("principal should be \"*\" or {\"AWS\": ...}")
+		}
+		p.AWS = policyValues{policyAnyone}
+		return nil
+	}
+	var principal struct {
+		AWS policyValues `json:"AWS"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&principal); err != nil {
+		return err
+	}
+	p.AWS = principal.AWS
+	return nil
+}
+
+// PolicyStatement - statement of a bucket policy
+type PolicyStatement struct {
+	Sid       string          `json:"Sid,omitempty"`
+	Effect    string          `json:"Effect"`
+	Principal PolicyPrincipal `json:"Principal"`
+	Action    policyValues    `json:"Action"`
+	Resource  policyValues    `json:"Resource"`
+}
+
+// BucketPolicy - bucket policy as set by PUT Bucket policy
+type BucketPolicy struct {
+	Version   string            `json:"Version,omitempty"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// matchPolicyPattern - true if value matches pattern, where '*' matches any run of characters
+// and '?' any single one. The last '*' is backtracked to only, which keeps matching linear in
+// the length of value for every pattern.
+func matchPolicyPattern(pattern, value string) bool {
+	p, v := []rune(pattern), []rune(value)
+	pi, vi := 0, 0
+	star, starMatch := -1, 0
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			star, starMatch = pi, vi
+			pi++
+		case star >= 0:
+			// the last '*' takes one more character
+			starMatch++
+			pi, vi = star+1, starMatch
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchesPrincipal - true if the statement applies to principal, empty for anonymous requests
+func (s PolicyStatement) matchesPrincipal(principal string) bool {
+	for _, value := range s.Principal.AWS {
+		if value == policyAnyone || (principal != "" && value == principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAction - true if the statement names action, actions are case insensitive
+func (s PolicyStatement) matchesAction(action string) bool {
+	for _, pattern := range s.Action {
+		if matchPolicyPattern(strings.ToLower(pattern), strings.ToLower(action)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResource - true if the statement names resource
+func (s PolicyStatement) matchesResource(resource string) bool {
+	for _, pattern := range s.Resource {
+		if matchPolicyPattern(pattern, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate - verify the statement is supported for a policy of bucket
+func (s PolicyStatement) validate(bucket string) *probe.Error {
+	if s.Effect != policyEffectAllow && s.Effect != policyEffectDeny {
+		return probe.NewError(MalformedPolicy{Reason: "Invalid effect: " + s.Effect})
+	}
+	if len(s.Principal.AWS) == 0 {
+		return probe.NewError(MalformedPolicy{Reason: "Statement has no principal"})
+	}
+	for _, principal := range s.Principal.AWS {
+		if principal == "" {
+			return probe.NewError(MalformedPolicy{Reason: "Invalid principal in policy"})
+		}
+	}
+	if len(s.Action) == 0 {
+		return probe.NewError(MalformedPolicy{Reason: "Statement has no action"})
+	}
+	for _, pattern := range s.Action {
+		supported := false
+		for _, action := range supportedPolicyActions {
+			if matchPolicyPattern(strings.ToLower(pattern), strings.ToLower(action)) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return probe.NewError(MalformedPolicy{Reason: "Policy has an unsupported action: " + pattern})
+		}
+	}
+	if len(s.Resource) == 0 {
+		return probe.NewError(MalformedPolicy{Reason: "Statement has no resource"})
+	}
+	// actions are on objects, so resources name objects of the bucket the policy is set on
+	for _, resource := range s.Resource {
+		if !strings.HasPrefix(resource, policyResourcePrefix+bucket+"/") {
+			return probe.NewError(MalformedPolicy{Reason: "Policy has invalid resource: " + resource})
+		}
+	}
+	return nil
+}
+
+// ParseBucketPolicy - parse and verify a policy to be set on bucket
+func ParseBucketPolicy(bucket string, policyBytes []byte) (*BucketPolicy, *probe.Error) {
+	policy := &BucketPolicy{}
+	decoder := json.NewDecoder(bytes.NewReader(policyBytes))
+	decoder.DisallowUnknownFields()
+	if e := decoder.Decode(policy); e != nil {
+		return nil, probe.NewError(MalformedPolicy{Reason: e.Error()})
+	}
+	if policy.Version != "" && policy.Version != "2012-10-17" && policy.Version != "2008-10-17" {
+		return nil, probe.NewError(MalformedPolicy{Reason: "Invalid policy version: " + policy.Version})
+	}
+	if len(policy.Statement) == 0 {
+		return nil, probe.NewError(MalformedPolicy{Reason: "Policy has no statement"})
+	}
+	for _, statement := range policy.Statement {
+		if err := statement.validate(bucket); err != nil {
+			return nil, err.Trace(bucket)
+		}
+	}
+	return policy, nil
+}
+
+// Evaluate - decision of the policy on principal taking action on object of bucket, an explicit
+// deny overrides every allow. Anonymous requests have an empty principal matched by "*" only.
+// A nil policy makes no decision.
+func (p *BucketPolicy) Evaluate(principal, action, bucket, object string) PolicyDecision {
+	if p == nil {
+		return PolicyNoDecision
+	}
+	resource := policyResourcePrefix + bucket + "/" + object
+	decision := PolicyNoDecision
+	for _, statement := range p.Statement {
+		if !statement.matchesPrincipal(principal) || !statement.matchesAction(action) || !statement.matchesResource(resource) {
+			continue
+		}
+		if statement.Effect == policyEffectDeny {
+			return PolicyDenied
+		}
+		decision = PolicyAllowed
+	}
+	return decision
+}
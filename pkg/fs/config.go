@@ -45,6 +45,7 @@ func userCurrent() (*user.User, *probe.Error) {
 	return user, nil
 }
 
+// getFSBucketsConfigPath - path of the buckets metadata config, custom path if one is set
 func getFSBucketsConfigPath() (string, *probe.Error) {
 	if customBucketsConfigPath != "" {
 		return customBucketsConfigPath, nil
@@ -57,6 +58,7 @@ func getFSBucketsConfigPath() (string, *probe.Error) {
 	return fsBucketsConfigPath, nil
 }
 
+// getFSMultipartsSessionConfigPath - path of the multiparts session config, custom path if one is set
 func getFSMultipartsSessionConfigPath() (string, *probe.Error) {
 	if customMultipartsConfigPath != "" {
 		return customMultipartsConfigPath, nil
@@ -69,8 +71,21 @@ func getFSMultipartsSessionConfigPath() (string, *probe.Error) {
 	return fsMultipartsConfigPath, nil
 }
 
+// getFSUsageConfigPath - path of the bucket usage config, custom path if one is set
+func getFSUsageConfigPath() (string, *probe.Error) {
+	if customUsageConfigPath != "" {
+		return customUsageConfigPath, nil
+	}
+	u, err := userCurrent()
+	if err != nil {
+		return "", err.Trace()
+	}
+	fsUsageConfigPath := filepath.Join(u.HomeDir, ".minio", "usage.json")
+	return fsUsageConfigPath, nil
+}
+
 // internal variable only accessed via get/set methods
-var customMultipartsConfigPath, customBucketsConfigPath string
+var customMultipartsConfigPath, customBucketsConfigPath, customUsageConfigPath string
 
 // SetFSBucketsConfigPath - set custom fs buckets config path
 func SetFSBucketsConfigPath(configPath string) {
@@ -82,6 +97,11 @@ func SetFSMultipartsConfigPath(configPath string) {
 	customMultipartsConfigPath = configPath
 }
 
+// SetFSUsageConfigPath - set custom bucket usage config path
+func SetFSUsageConfigPath(configPath string) {
+	customUsageConfigPath = configPath
+}
+
 // SaveMultipartsSession - save multiparts
 func SaveMultipartsSession(multiparts *Multiparts) *probe.Error {
 	fsMultipartsConfigPath, err := getFSMultipartsSessionConfigPath()
@@ -114,6 +134,22 @@ func SaveBucketsMetadata(buckets *Buckets) *probe.Error {
 	return nil
 }
 
+// saveUsage - save usage of all buckets
+func saveUsage(usage *Usage) *probe.Error {
+	fsUsageConfigPath, err := getFSUsageConfigPath()
+	if err != nil {
+		return err.Trace()
+	}
+	qc, err := quick.New(usage)
+	if err != nil {
+		return err.Trace()
+	}
+	if err := qc.Save(fsUsageConfigPath); err != nil {
+		return err.Trace()
+	}
+	return nil
+}
+
 // loadMultipartsSession load multipart session file
 func loadMultipartsSession() (*Multiparts, *probe.Error) {
 	fsMultipartsConfigPath, err := getFSMultipartsSessionConfigPath()
@@ -151,3 +187,22 @@ func loadBucketsMetadata() (*Buckets, *probe.Error) {
 	}
 	return qc.Data().(*Buckets), nil
 }
+
+// loadUsage load bucket usage file
+func loadUsage() (*Usage, *probe.Error) {
+	fsUsageConfigPath, err := getFSUsageConfigPath()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	usage := &Usage{}
+	usage.Version = "1"
+	usage.Buckets = make(map[string]BucketUsage)
+	qc, err := quick.New(usage)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if err := qc.Load(fsUsageConfigPath); err != nil {
+		return nil, err.Trace()
+	}
+	return qc.Data().(*Usage), nil
+}
@@ -18,6 +18,8 @@ package fs
 
 import (
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -55,9 +57,30 @@ func (b BucketACL) IsPublicReadWrite() bool {
 
 // BucketMetadata - name and create date
 type BucketMetadata struct {
-	Name    string
-	Created time.Time
-	ACL     BucketACL
+	Name         string
+	Created      time.Time
+	ACL          BucketACL
+	Compression  string        // compression algorithm for new objects, empty if disabled
+	Encryption   string        // encryption algorithm for new objects, empty if disabled
+	Retention    time.Duration // default retention of new objects, not locked if zero
+	Notification string        // webhook url object events are posted to, empty if disabled
+	Policy       *BucketPolicy // bucket policy evaluated before the ACL, none if nil
+	Versioning   string        // versioning state, empty if versioning was never enabled
+}
+
+// StorageInfo - disk usage and object counts of the root path
+type StorageInfo struct {
+	Total   int64
+	Free    int64
+	Used    int64
+	Buckets int64
+	Objects int64
+}
+
+// BucketUsage - objects of a bucket and the bytes their data takes on disk
+type BucketUsage struct {
+	Objects int64 `json:"objects"`
+	Size    int64 `json:"size"`
 }
 
 // ObjectMetadata - object key and its relevant metadata
@@ -65,11 +88,17 @@ type ObjectMetadata struct {
 	Bucket string
 	Object string
 
-	ContentType string
-	Created     time.Time
-	Mode        os.FileMode
-	Md5         string
-	Size        int64
+	ContentType  string
+	Created      time.Time
+	Mode         os.FileMode
+	Md5          string
+	Size         int64
+	Compression  string    // compression algorithm the object is stored with, empty if stored as is
+	Encryption   string    // encryption algorithm the object is stored with, empty if stored as is
+	RetainUntil  time.Time // object may not be replaced or removed before, zero if not locked
+	StorageClass string    // storage class the object was requested with
+	TagCount     int       // tags the object carries
+	VersionID    string    // version id of the object, empty if versioning was never enabled on its bucket
 }
 
 // PartMetadata - various types of individual part resources
@@ -103,19 +132,31 @@ type UploadMetadata struct {
 	Initiated    time.Time
 }
 
+// UploadOrder - order incomplete multipart uploads are listed in
+type UploadOrder string
+
+// different orders of listed multipart uploads
+const (
+	UploadsByKey       = UploadOrder("key")       // by key and upload id, the order of S3, also if empty
+	UploadsByInitiated = UploadOrder("initiated") // oldest first, ties by key and upload id
+)
+
 // BucketMultipartResourcesMetadata - various types of bucket resources for inprogress multipart uploads
 type BucketMultipartResourcesMetadata struct {
-	KeyMarker          string
-	UploadIDMarker     string
-	NextKeyMarker      string
-	NextUploadIDMarker string
-	EncodingType       string
-	MaxUploads         int
-	IsTruncated        bool
-	Upload             []*UploadMetadata
-	Prefix             string
-	Delimiter          string
-	CommonPrefixes     []string
+	KeyMarker           string
+	UploadIDMarker      string
+	InitiatedMarker     time.Time // only used when listing uploads by initiated time
+	NextKeyMarker       string
+	NextUploadIDMarker  string
+	NextInitiatedMarker time.Time
+	EncodingType        string
+	MaxUploads          int
+	IsTruncated         bool
+	Upload              []*UploadMetadata
+	Prefix              string
+	Delimiter           string
+	CommonPrefixes      []string
+	Order               UploadOrder
 }
 
 // BucketResourcesMetadata - various types of bucket resources
@@ -182,17 +223,55 @@ func IsValidBucket(bucket string) bool {
 	return match
 }
 
+// MaxObjectNameLength - longest object name in bytes of its UTF-8 encoding, multi-byte characters
+// count with every one of their bytes as they do in S3
+const MaxObjectNameLength = 1024
+
 // IsValidObjectName - verify object name in accordance with
 //   - http://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
 func IsValidObjectName(object string) bool {
+	return isValidObjectPrefix(object) && !isReservedObjectName(object)
+}
+
+// isReservedObjectName - true if any path segment of an object name ends like the files kept
+// next to objects, '\' is treated as a separator as well
+func isReservedObjectName(object string) bool {
+	for _, name := range strings.Split(strings.Replace(object, "\\", "/", -1), "/") {
+		if isSidecarFile(name) || isVersionsDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidObjectPrefix - verify a prefix of object names, which may end like the files kept next
+// to objects as long as it is otherwise valid
+func isValidObjectPrefix(object string) bool {
 	if strings.TrimSpace(object) == "" {
 		return true
 	}
-	if len(object) > 1024 || len(object) == 0 {
+	if len(object) > MaxObjectNameLength || len(object) == 0 {
 		return false
 	}
 	if !utf8.ValidString(object) {
 		return false
 	}
-	return true
+	if strings.ContainsRune(object, 0) {
+		return false
+	}
+	return !isEscapingPath(object)
+}
+
+// isEscapingPath - verify if object name after cleaning is absolute or would
+// resolve outside of the bucket directory, '\' is treated as a separator as well
+func isEscapingPath(object string) bool {
+	if filepath.VolumeName(object) != "" || filepath.IsAbs(object) {
+		return true
+	}
+	object = strings.Replace(object, "\\", "/", -1)
+	if path.IsAbs(object) {
+		return true
+	}
+	object = path.Clean(object)
+	return object == ".." || strings.HasPrefix(object, "../")
 }
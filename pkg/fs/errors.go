@@ -16,7 +16,11 @@
 
 package fs
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"time"
+)
 
 // MissingDateHeader date header missing
 type MissingDateHeader struct{}
@@ -39,6 +43,13 @@ func (e ExpiredPresignedRequest) Error() string {
 	return "Presigned request already expired"
 }
 
+// RequestTimeTooSkewed request time differs from server time more than allowed
+type RequestTimeTooSkewed struct{}
+
+func (e RequestTimeTooSkewed) Error() string {
+	return "The difference between the request time and the server's time is too large"
+}
+
 // SignatureDoesNotMatch invalid signature
 type SignatureDoesNotMatch struct {
 	SignatureSent       string
@@ -56,6 +67,44 @@ func (e InvalidArgument) Error() string {
 	return "Invalid argument"
 }
 
+// InvalidMasterKey master key is missing or does not open the data key of an object
+type InvalidMasterKey struct{}
+
+func (e InvalidMasterKey) Error() string {
+	return "Master key is missing or invalid"
+}
+
+// InvalidWriteBufferSize write buffer size out of bounds
+type InvalidWriteBufferSize struct {
+	Size int
+}
+
+func (e InvalidWriteBufferSize) Error() string {
+	return fmt.Sprintf("Write buffer size %d out of bounds, should be between %d and %d", e.Size, MinWriteBufferSize, MaxWriteBufferSize)
+}
+
+// InvalidFileMode file or directory mode not usable by the server
+type InvalidFileMode struct {
+	Mode os.FileMode
+	Dir  bool
+}
+
+func (e InvalidFileMode) Error() string {
+	if e.Dir {
+		return fmt.Sprintf("Invalid directory mode %#o, should only hold permission bits including 0700", uint32(e.Mode))
+	}
+	return fmt.Sprintf("Invalid file mode %#o, should only hold permission bits including 0600", uint32(e.Mode))
+}
+
+// InvalidTrailer trailing header declared in x-amz-trailer is not a supported checksum
+type InvalidTrailer struct {
+	Trailer string
+}
+
+func (e InvalidTrailer) Error() string {
+	return "Unsupported trailing checksum: " + e.Trailer
+}
+
 // UnsupportedFilesystem unsupported filesystem type
 type UnsupportedFilesystem struct {
 	Type string
@@ -74,6 +123,16 @@ func (e RootPathFull) Error() string {
 	return "Root path " + e.Path + " reached its minimum free disk threshold."
 }
 
+// TooManyMultipartUploads bucket reached its limit of active multipart sessions
+type TooManyMultipartUploads struct {
+	Bucket string
+	Limit  int
+}
+
+func (e TooManyMultipartUploads) Error() string {
+	return fmt.Sprintf("Bucket %s reached its limit of %d active multipart uploads.", e.Bucket, e.Limit)
+}
+
 // BucketNotFound bucket does not exist
 type BucketNotFound struct {
 	Bucket string
@@ -92,6 +151,15 @@ func (e BucketNotEmpty) Error() string {
 	return "Bucket not empty: " + e.Bucket
 }
 
+// BucketPolicyNotFound bucket has no policy
+type BucketPolicyNotFound struct {
+	Bucket string
+}
+
+func (e BucketPolicyNotFound) Error() string {
+	return "Bucket policy not found: " + e.Bucket
+}
+
 // ObjectNotFound object does not exist
 type ObjectNotFound struct {
 	Bucket string
@@ -102,6 +170,17 @@ func (e ObjectNotFound) Error() string {
 	return "Object not found: " + e.Bucket + "#" + e.Object
 }
 
+// ObjectVersionNotFound version of an object does not exist
+type ObjectVersionNotFound struct {
+	Bucket    string
+	Object    string
+	VersionID string
+}
+
+func (e ObjectVersionNotFound) Error() string {
+	return "Object version not found: " + e.Bucket + "#" + e.Object + "?versionId=" + e.VersionID
+}
+
 // ObjectCorrupted object found to be corrupted
 type ObjectCorrupted struct {
 	Object string
@@ -244,9 +323,15 @@ type EntityTooLarge struct {
 	MaxSize string
 }
 
+// InvalidCopyRequest - copy of an object to itself without replacing its metadata
+type InvalidCopyRequest GenericObjectError
+
 // ObjectNameInvalid - object name provided is invalid
 type ObjectNameInvalid GenericObjectError
 
+// ObjectNameTooLong - name of an object written is longer than MaxObjectNameLength bytes
+type ObjectNameTooLong GenericObjectError
+
 // InvalidDigest - md5 in request header invalid
 type InvalidDigest DigestError
 
@@ -292,6 +377,16 @@ func (e ObjectNameInvalid) Error() string {
 	return "Object name invalid: " + e.Bucket + "#" + e.Object
 }
 
+// Return string an error formatted as the given text
+func (e ObjectNameTooLong) Error() string {
+	return fmt.Sprintf("Object name of %d bytes in %s is longer than %d bytes", len(e.Object), e.Bucket, MaxObjectNameLength)
+}
+
+// Return string an error formatted as the given text
+func (e InvalidCopyRequest) Error() string {
+	return e.Bucket + "#" + e.Object + " cannot be copied to itself without replacing its metadata"
+}
+
 // Return string an error formatted as the given text
 func (e EntityTooLarge) Error() string {
 	return e.Bucket + "#" + e.Object + "with " + e.Size + "reached maximum allowed size limit " + e.MaxSize
@@ -325,6 +420,68 @@ func (e OperationNotPermitted) Error() string {
 	return "Operation " + e.Op + " not permitted for reason: " + e.Reason
 }
 
+// ObjectLocked - object may not be replaced or removed before its retention passes
+type ObjectLocked struct {
+	Bucket      string
+	Object      string
+	RetainUntil time.Time
+}
+
+func (e ObjectLocked) Error() string {
+	return "Object " + e.Bucket + "#" + e.Object + " is locked until " + e.RetainUntil.Format(time.RFC3339)
+}
+
+// OperationAborted - object may be neither replaced nor removed before the immutability window
+// after the completion of its upload passes
+type OperationAborted struct {
+	Bucket string
+	Object string
+	Until  time.Time
+}
+
+func (e OperationAborted) Error() string {
+	return "Object " + e.Bucket + "#" + e.Object + " is immutable until " + e.Until.Format(time.RFC3339)
+}
+
+// PreconditionFailed - current object does not meet the conditions a write was requested under
+type PreconditionFailed struct {
+	Bucket string
+	Object string
+}
+
+func (e PreconditionFailed) Error() string {
+	return "Object " + e.Bucket + "#" + e.Object + " does not meet the preconditions of the write"
+}
+
+// InvalidFolderObject - object ending in a slash which cannot be created as a folder marker
+type InvalidFolderObject struct {
+	Bucket string
+	Object string
+	Reason string
+}
+
+func (e InvalidFolderObject) Error() string {
+	return "Object " + e.Bucket + "#" + e.Object + " cannot be created as a folder: " + e.Reason
+}
+
+// InvalidTag - object tags beyond the limits of S3
+type InvalidTag struct {
+	Reason string
+}
+
+func (e InvalidTag) Error() string {
+	return "Invalid tag: " + e.Reason
+}
+
+// MalformedPolicy - bucket policy which is not valid JSON or not supported
+type MalformedPolicy struct {
+	Reason string
+}
+
+func (e MalformedPolicy) Error() string {
+	return "Malformed policy: " + e.Reason
+}
+
 // InvalidRange - invalid range
 type InvalidRange struct {
 	Start  int64
@@ -346,6 +503,16 @@ func (e InvalidUploadID) Error() string {
 	return "Invalid upload id " + e.UploadID
 }
 
+// UnsupportedMultipartSession multipart session written by a later server in a layout unknown to this one
+type UnsupportedMultipartSession struct {
+	UploadID string
+	Version  string
+}
+
+func (e UnsupportedMultipartSession) Error() string {
+	return "Multipart session " + e.UploadID + " of version " + e.Version + " is not supported, it was written by a later server"
+}
+
 // InvalidPart One or more of the specified parts could not be found
 type InvalidPart struct{}
 
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// atomicTempDir - directory under the root path temp files of atomic writes are created in,
+// apart from any bucket such that no object is ever taken for one of them
+const atomicTempDir = ".minio.tmp"
+
+// atomicTempMarker - temp files of atomic writes are named <file>$tmp<random digits>
+const atomicTempMarker = "$tmp"
+
+// DefaultAtomicTempAge - temp files younger than this are left alone by the startup sweep,
+// their write may still be in progress
+const DefaultAtomicTempAge = time.Hour
+
+// atomicFile - file written to a temp file and renamed over its destination on Close
+type atomicFile struct {
+	*os.File
+	file string
+}
+
+// createAtomicFile - create a temp file of the given modes for an atomic write of filePath,
+// creating parent directories if they don't exist. The temp file is created in the temp
+// directory of the modes, next to filePath if they have none.
+func createAtomicFile(filePath string, modes fileModes) (*atomicFile, error) {
+	if err := modes.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return nil, err
+	}
+	tempDir := modes.tempDir
+	if tempDir == "" {
+		tempDir = filepath.Dir(filePath)
+	} else if err := modes.mkdirAll(tempDir); err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(tempDir, filepath.Base(filePath)+atomicTempMarker)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(f.Name(), modes.file); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &atomicFile{File: f, file: filePath}, nil
+}
+
+// Close - close the temp file and rename it to its destination
+func (f *atomicFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), f.file)
+}
+
+// CloseAndPurge - close and remove the temp file, leaving the destination untouched
+func (f *atomicFile) CloseAndPurge() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return os.Remove(f.Name())
+}
+
+// RemoveAtomicTempFiles - remove temp files older than age of atomic writes which were
+// interrupted by a crash, only the temp directory is looked into. Returns the list of removed files.
+func (fs Filesystem) RemoveAtomicTempFiles(age time.Duration) ([]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	tempDir := filepath.Join(fs.path, atomicTempDir)
+	files, err := ioutil.ReadDir(tempDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, probe.NewError(err)
+	}
+	var tempPaths []string
+	for _, file := range files {
+		if file.Mode().IsRegular() && time.Since(file.ModTime()) >= age {
+			tempPaths = append(tempPaths, filepath.Join(tempDir, file.Name()))
+		}
+	}
+
+	var removed []string
+	for _, tempPath := range tempPaths {
+		if err := removeFile(tempPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return removed, probe.NewError(err)
+		}
+		removed = append(removed, tempPath)
+	}
+	return removed, nil
+}
@@ -18,6 +18,7 @@ package fs
 
 import (
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -31,10 +32,74 @@ import (
 func (fs Filesystem) ListObjects(bucket string, resources BucketResourcesMetadata) ([]ObjectMetadata, BucketResourcesMetadata, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
+	if !fs.portableKeys {
+		return fs.listObjects(bucket, resources)
+	}
+	return fs.listPortableObjects(bucket, resources)
+}
+
+// listPortableObjects - list objects by their portable keys. Names on disk escape upper case
+// runes, so that they sort apart from their keys, as '^46;oo' for 'Foo' after 'Zoo'. Everything
+// under the prefix is listed by name on disk and sorted by key before the marker and max keys
+// apply. Names which are no portable keys are not listed. Caller holds the lock.
+func (fs Filesystem) listPortableObjects(bucket string, resources BucketResourcesMetadata) ([]ObjectMetadata, BucketResourcesMetadata, *probe.Error) {
+	requested := resources
+	resources.Prefix = fs.diskKey(resources.Prefix)
+	resources.Delimiter = fs.diskKey(resources.Delimiter)
+	resources.Marker = ""
+	resources.Maxkeys = math.MaxInt32
+	objects, listed, err := fs.listObjects(bucket, resources)
+	if err != nil {
+		return nil, requested, err.Trace()
+	}
+
+	type listEntry struct {
+		key    string
+		object *ObjectMetadata
+	}
+	var entries []listEntry
+	for i := range objects {
+		if key, ok := fs.keyOfDiskName(objects[i].Object); ok {
+			objects[i].Object = key
+			entries = append(entries, listEntry{key: key, object: &objects[i]})
+		}
+	}
+	for _, commonPrefix := range listed.CommonPrefixes {
+		if key, ok := fs.keyOfDiskName(commonPrefix); ok {
+			entries = append(entries, listEntry{key: key})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	// common prefixes do not count against max keys, as in listObjects
+	var metadataList []ObjectMetadata
+	resources = requested
+	for _, entry := range entries {
+		if entry.key <= requested.Marker {
+			continue
+		}
+		if len(metadataList) == requested.Maxkeys {
+			resources.IsTruncated = true
+			if requested.Delimiter != "" && len(metadataList) > 0 {
+				resources.NextMarker = metadataList[len(metadataList)-1].Object
+			}
+			break
+		}
+		if entry.object == nil {
+			resources.CommonPrefixes = append(resources.CommonPrefixes, entry.key)
+			continue
+		}
+		metadataList = append(metadataList, *entry.object)
+	}
+	return metadataList, resources, nil
+}
+
+// listObjects - list objects by their names on disk, caller holds the lock
+func (fs Filesystem) listObjects(bucket string, resources BucketResourcesMetadata) ([]ObjectMetadata, BucketResourcesMetadata, *probe.Error) {
 	if !IsValidBucket(bucket) {
 		return nil, resources, probe.NewError(BucketNameInvalid{Bucket: bucket})
 	}
-	if resources.Prefix != "" && IsValidObjectName(resources.Prefix) == false {
+	if resources.Prefix != "" && isValidObjectPrefix(resources.Prefix) == false {
 		return nil, resources, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: resources.Prefix})
 	}
 
@@ -129,6 +194,14 @@ func (fs Filesystem) ListObjects(bucket string, resources BucketResourcesMetadat
 			if strings.HasSuffix(fp, "$multiparts") {
 				return nil
 			}
+			// previous versions of objects are not listed
+			if fl.IsDir() && isVersionsDir(fp) {
+				return ErrSkipDir
+			}
+			// files next to objects do not count against max keys
+			if isSidecarFile(fp) {
+				return nil
+			}
 			// if file pointer equals to rootPrefix - discard it
 			if fp == p.root {
 				return nil
@@ -259,6 +332,10 @@ func (fs Filesystem) ListObjects(bucket string, resources BucketResourcesMetadat
 			}
 			break
 		}
+		// files kept next to objects and previous versions are not objects
+		if isSidecarFile(content.Prefix) || isVersionsDir(content.Prefix) {
+			continue
+		}
 		if content.Prefix > resources.Marker {
 			var err *probe.Error
 			metadata, resources, err = fs.filterObjects(bucket, content, resources)
@@ -18,12 +18,15 @@ package fs
 
 import (
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/minio/minio-xl/pkg/probe"
-	"github.com/minio/minio/pkg/disk"
 )
 
 /// Bucket Operations
@@ -54,13 +57,84 @@ func (fs Filesystem) DeleteBucket(bucket string) *probe.Error {
 		return probe.NewError(err)
 	}
 	delete(fs.buckets.Metadata, bucket)
+	fs.usage.remove(bucket)
 	if err := SaveBucketsMetadata(fs.buckets); err != nil {
 		return err.Trace(bucket)
 	}
 	return nil
 }
 
-// ListBuckets - Get service
+// EmptyBucket - remove every object, version and multipart session of a bucket, keeping the
+// bucket along with its ACL and settings. Nothing is removed while any object is locked or
+// within the immutability window of its completion.
+func (fs Filesystem) EmptyBucket(bucket string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	st, err := os.Stat(bucketDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	if _, ok := fs.buckets.Metadata[bucket]; !ok {
+		return probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	if err := fs.checkBucketImmutable(bucket, bucketDir); err != nil {
+		return err.Trace()
+	}
+	if err := checkBucketRetention(bucket, bucketDir); err != nil {
+		return err.Trace()
+	}
+	for object, session := range fs.multiparts.ActiveSession {
+		if session.Bucket == bucket {
+			delete(fs.multiparts.ActiveSession, object)
+		}
+	}
+	if err := SaveMultipartsSession(fs.multiparts); err != nil {
+		return err.Trace(bucket)
+	}
+	fs.usage.remove(bucket)
+
+	// the bucket directory is swapped for an empty one, hidden names are not listed as buckets
+	emptiedDir := filepath.Join(fs.path, "."+bucket+"$empty"+strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := os.Rename(bucketDir, emptiedDir); err != nil {
+		// renaming fails on windows while files of the bucket are open, remove them one by one
+		return emptyDir(bucketDir)
+	}
+	if err := fs.modes.mkdir(bucketDir); err != nil {
+		return probe.NewError(err)
+	}
+	// the modification time of the bucket directory is its creation time
+	if err := os.Chtimes(bucketDir, st.ModTime(), st.ModTime()); err != nil {
+		return probe.NewError(err)
+	}
+	if err := os.RemoveAll(emptiedDir); err != nil {
+		return probe.NewError(err).Trace(emptiedDir)
+	}
+	return nil
+}
+
+// emptyDir - remove everything under dir, keeping dir itself
+func emptyDir(dir string) *probe.Error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return probe.NewError(err)
+		}
+	}
+	return nil
+}
+
+// ListBuckets - Get service, lists every directory under the root path which is a valid
+// bucket along with its creation time, sorted by name
 func (fs Filesystem) ListBuckets() ([]BucketMetadata, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
@@ -76,11 +150,13 @@ func (fs Filesystem) ListBuckets() ([]BucketMetadata, *probe.Error) {
 			// if files found ignore them
 			continue
 		}
-		if file.IsDir() {
+		if strings.HasPrefix(file.Name(), ".") {
+			// hidden directories such as the quarantine directory are not buckets
+			continue
+		}
+		if !IsValidBucket(file.Name()) {
 			// if directories found with odd names, skip them too
-			if !IsValidBucket(file.Name()) {
-				continue
-			}
+			continue
 		}
 		metadata := BucketMetadata{
 			Name:    file.Name(),
@@ -88,6 +164,7 @@ func (fs Filesystem) ListBuckets() ([]BucketMetadata, *probe.Error) {
 		}
 		metadataList = append(metadataList, metadata)
 	}
+	sort.Sort(byBucketMetadataName(metadataList))
 	return metadataList, nil
 }
 
@@ -96,7 +173,7 @@ func (fs Filesystem) MakeBucket(bucket, acl string) *probe.Error {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 
-	stfs, err := disk.Stat(fs.path)
+	stfs, err := fs.statDisk()
 	if err != nil {
 		return probe.NewError(err)
 	}
@@ -126,7 +203,7 @@ func (fs Filesystem) MakeBucket(bucket, acl string) *probe.Error {
 	}
 
 	// make bucket
-	err = os.Mkdir(bucketDir, 0700)
+	err = fs.modes.mkdir(bucketDir)
 	if err != nil {
 		return probe.NewError(err)
 	}
@@ -216,3 +293,201 @@ func (fs Filesystem) SetBucketMetadata(bucket string, metadata map[string]string
 	}
 	return nil
 }
+
+// SetBucketCompression - set compression algorithm for objects created in the bucket from
+// now on, empty algorithm disables compression. Objects already stored are left as is.
+func (fs Filesystem) SetBucketCompression(bucket, algorithm string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidCompression(algorithm) {
+		return probe.NewError(InvalidArgument{})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Compression = algorithm
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// SetBucketEncryption - set encryption algorithm for objects created in the bucket from
+// now on, empty algorithm disables encryption. Objects already stored are left as is.
+func (fs Filesystem) SetBucketEncryption(bucket, algorithm string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidEncryption(algorithm) {
+		return probe.NewError(InvalidArgument{})
+	}
+	if algorithm != "" && fs.masterKey == nil {
+		return probe.NewError(InvalidMasterKey{})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Encryption = algorithm
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// IsValidNotification - verify notification webhook url, empty string stands for no notification
+func IsValidNotification(webhook string) bool {
+	if webhook == "" {
+		return true
+	}
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// SetBucketNotification - set webhook url events of objects created in or removed from the
+// bucket are posted to, empty url disables notifications
+func (fs Filesystem) SetBucketNotification(bucket, webhook string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidNotification(webhook) {
+		return probe.NewError(InvalidArgument{})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Notification = webhook
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// SetBucketRetention - set default retention of objects created in the bucket from now on,
+// zero disables it. Objects already locked stay locked until their own retention passes.
+func (fs Filesystem) SetBucketRetention(bucket string, retention time.Duration) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if retention < 0 {
+		return probe.NewError(InvalidArgument{})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Retention = retention
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// SetBucketPolicy - set policy of a bucket, nil removes it
+func (fs Filesystem) SetBucketPolicy(bucket string, policy *BucketPolicy) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Policy = policy
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// GetBucketPolicy - get policy of a bucket, BucketPolicyNotFound if it has none
+func (fs Filesystem) GetBucketPolicy(bucket string) (*BucketPolicy, *probe.Error) {
+	bucketMetadata, err := fs.GetBucketMetadata(bucket)
+	if err != nil {
+		return nil, err.Trace(bucket)
+	}
+	if bucketMetadata.Policy == nil {
+		return nil, probe.NewError(BucketPolicyNotFound{Bucket: bucket})
+	}
+	return bucketMetadata.Policy, nil
+}
@@ -0,0 +1,229 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// checksumSuffix - suffix of the file next to an object stored as is carrying its md5sum,
+// compressed and encrypted objects carry their own md5sum
+const checksumSuffix = "$md5"
+
+// isChecksumFile - true if the file carries the md5sum of an object
+func isChecksumFile(name string) bool {
+	return strings.HasSuffix(name, checksumSuffix)
+}
+
+// readObjectChecksum - md5sum recorded when an object stored as is was written, empty for
+// objects written before checksums were recorded
+func readObjectChecksum(objectPath string) (string, *probe.Error) {
+	checksumBytes, err := ioutil.ReadFile(objectPath + checksumSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", probe.NewError(err)
+	}
+	return strings.TrimSpace(string(checksumBytes)), nil
+}
+
+// removeObjectChecksum - remove md5sum left over by an object which is now gone or stored otherwise
+func removeObjectChecksum(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + checksumSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// finishObjectChecksum - record md5sum of an object stored as is, for compressed or encrypted
+// objects any md5sum left over by a previous version is removed
+func finishObjectChecksum(objectPath, md5Sum string, storedAsIs bool, modes fileModes) *probe.Error {
+	if !storedAsIs {
+		return removeObjectChecksum(objectPath)
+	}
+	file, err := createAtomicFile(objectPath+checksumSuffix, modes)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if _, err := file.Write([]byte(md5Sum)); err != nil {
+		file.CloseAndPurge()
+		return probe.NewError(err)
+	}
+	if err := file.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// fileMD5Sum - md5sum of the data of a file as stored
+func fileMD5Sum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyObjectChecksum - recompute md5sum of an object and compare it with the recorded one,
+// checked is false for objects which carry no md5sum to compare with
+func verifyObjectChecksum(objectPath string) (checked bool, ok bool, err *probe.Error) {
+	encryption, err := readObjectEncryption(objectPath)
+	if err != nil {
+		return false, false, err.Trace(objectPath)
+	}
+	if encryption != nil {
+		// sealed data is verified against the md5sum of the sealed data
+		md5Sum, e := fileMD5Sum(objectPath)
+		if e != nil {
+			return false, false, probe.NewError(e)
+		}
+		return true, md5Sum == encryption.ETag, nil
+	}
+	compression, err := readObjectCompression(objectPath)
+	if err != nil {
+		return false, false, err.Trace(objectPath)
+	}
+	if compression != nil {
+		file, e := os.Open(objectPath)
+		if e != nil {
+			return false, false, probe.NewError(e)
+		}
+		defer file.Close()
+		reader, e := newObjectReader(file, 0, compression)
+		if e != nil {
+			return true, false, nil
+		}
+		h := md5.New()
+		// gzip and zstd verify their own checksum at the end, corrupted data fails to decompress
+		if _, e := io.Copy(h, reader); e != nil {
+			return true, false, nil
+		}
+		return true, hex.EncodeToString(h.Sum(nil)) == compression.MD5Sum, nil
+	}
+	expectedMD5Sum, err := readObjectChecksum(objectPath)
+	if err != nil {
+		return false, false, err.Trace(objectPath)
+	}
+	// objects which were not hashed have no md5sum to compare with
+	if expectedMD5Sum == "" || isUnhashedETag(expectedMD5Sum) {
+		return false, false, nil
+	}
+	md5Sum, e := fileMD5Sum(objectPath)
+	if e != nil {
+		return false, false, probe.NewError(e)
+	}
+	return true, md5Sum == expectedMD5Sum, nil
+}
+
+// quarantineObject - move an object along with the files next to it and its previous versions under
+// the quarantine directory
+func (fs Filesystem) quarantineObject(bucket, object string) *probe.Error {
+	objectPath := filepath.Join(fs.path, bucket, object)
+	quarantinePath := filepath.Join(fs.path, quarantineDir, bucket, object)
+	if err := fs.modes.mkdirAll(filepath.Dir(quarantinePath)); err != nil {
+		return probe.NewError(err)
+	}
+	for _, suffix := range []string{checksumSuffix, compressionSuffix, encryptionSuffix, retentionSuffix, storageClassSuffix, taggingSuffix, versionIDSuffix, versionsSuffix} {
+		if err := os.Rename(objectPath+suffix, quarantinePath+suffix); err != nil && !os.IsNotExist(err) {
+			return probe.NewError(err)
+		}
+	}
+	usage := objectUsage(objectPath)
+	if err := os.Rename(objectPath, quarantinePath); err != nil {
+		return probe.NewError(err)
+	}
+	fs.usage.update(bucket, usage, BucketUsage{})
+	return nil
+}
+
+// ScrubObjects - recompute md5sum of every object under the root path and compare it with
+// the one recorded when the object was written, objects written before checksums were
+// recorded are skipped. Corrupted objects are moved under the quarantine directory if
+// asked to. Returns the list of corrupted objects.
+func (fs Filesystem) ScrubObjects(quarantine bool) ([]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	type objectKey struct {
+		bucket, object string
+	}
+	var corruptedObjects []objectKey
+	var scrubErr *probe.Error
+	scrubObject := func(fp string, fl os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fl.IsDir() && (fl.Name() == quarantineDir || fl.Name() == atomicTempDir || isVersionsDir(fl.Name())) {
+			return ErrSkipDir
+		}
+		if !fl.Mode().IsRegular() {
+			return nil
+		}
+		if isSidecarFile(fl.Name()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(fs.path, fp)
+		if err != nil {
+			return err
+		}
+		splits := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+		if len(splits) != 2 {
+			// not inside a bucket, ignore
+			return nil
+		}
+		checked, ok, perr := verifyObjectChecksum(fp)
+		if perr != nil {
+			scrubErr = perr.Trace(fp)
+			return perr.ToGoError()
+		}
+		if checked && !ok {
+			corruptedObjects = append(corruptedObjects, objectKey{splits[0], splits[1]})
+		}
+		return nil
+	}
+	if err := WalkUnsorted(fs.path, scrubObject); err != nil {
+		if scrubErr != nil {
+			return nil, scrubErr
+		}
+		return nil, probe.NewError(err)
+	}
+
+	var corrupted []string
+	for _, corruptedObject := range corruptedObjects {
+		if quarantine {
+			if err := fs.quarantineObject(corruptedObject.bucket, corruptedObject.object); err != nil {
+				return corrupted, err.Trace(corruptedObject.bucket, corruptedObject.object)
+			}
+		}
+		corrupted = append(corrupted, filepath.Join(fs.path, corruptedObject.bucket, corruptedObject.object))
+	}
+	return corrupted, nil
+}
@@ -86,9 +86,34 @@ func delimiter(object, delimiter string) string {
 	return delimitedStr
 }
 
+// byBucketMetadataName is a sortable interface for BucketMetadata slice
+type byBucketMetadataName []BucketMetadata
+
+func (b byBucketMetadataName) Len() int           { return len(b) }
+func (b byBucketMetadataName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byBucketMetadataName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
 // byObjectMetadataKey is a sortable interface for UploadMetadata slice
 type byUploadMetadataKey []*UploadMetadata
 
 func (b byUploadMetadataKey) Len() int           { return len(b) }
 func (b byUploadMetadataKey) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
 func (b byUploadMetadataKey) Less(i, j int) bool { return b[i].Object < b[j].Object }
+
+// byUploadMetadataInitiated is a sortable interface for UploadMetadata slice, oldest first
+type byUploadMetadataInitiated []*UploadMetadata
+
+func (b byUploadMetadataInitiated) Len() int           { return len(b) }
+func (b byUploadMetadataInitiated) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byUploadMetadataInitiated) Less(i, j int) bool { return initiatedBefore(b[i], b[j]) }
+
+// initiatedBefore - true if upload a was initiated before upload b, ties broken by key and upload id
+func initiatedBefore(a, b *UploadMetadata) bool {
+	if !a.Initiated.Equal(b.Initiated) {
+		return a.Initiated.Before(b.Initiated)
+	}
+	if a.Object != b.Object {
+		return a.Object < b.Object
+	}
+	return a.UploadID < b.UploadID
+}
@@ -0,0 +1,195 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Compression algorithms objects may be stored with
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionSuffix - suffix of the file next to an object stored compressed carrying its
+// algorithm, original size and md5sum, objects stored as is have none. It is named after gzip
+// which was the only algorithm at first, objects of every algorithm share it.
+const compressionSuffix = "$gzip"
+
+// objectCompression - compression details of an object, written by the server along with
+// the compressed data and never taken from the data itself
+type objectCompression struct {
+	Algorithm string `json:"algorithm"`
+	Size      int64  `json:"size"` // original size of the object
+	MD5Sum    string `json:"md5"`  // md5sum of the original data
+}
+
+// IsValidCompression - verify compression algorithm, empty string stands for no compression
+func IsValidCompression(algorithm string) bool {
+	switch algorithm {
+	case "", CompressionGzip, CompressionZstd:
+		return true
+	}
+	return false
+}
+
+// isCompressionFile - true if the file carries the compression details of an object
+func isCompressionFile(name string) bool {
+	return strings.HasSuffix(name, compressionSuffix)
+}
+
+// compressedWriter - compresses everything written to an object file
+type compressedWriter struct {
+	algorithm string
+	writer    io.WriteCloser
+	size      int64
+}
+
+// newCompressedWriter - compress with algorithm, which is either gzip or zstd
+func newCompressedWriter(writer io.Writer, algorithm string) (*compressedWriter, error) {
+	if algorithm == CompressionZstd {
+		// a single encoder compresses synchronously, an object failing midway leaves nothing running
+		zstdWriter, err := zstd.NewWriter(writer, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &compressedWriter{algorithm: algorithm, writer: zstdWriter}, nil
+	}
+	return &compressedWriter{algorithm: CompressionGzip, writer: gzip.NewWriter(writer)}, nil
+}
+
+func (w *compressedWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close - flush compressed data
+func (w *compressedWriter) Close() error {
+	return w.writer.Close()
+}
+
+// readObjectCompression - compression details of an object, nil for objects stored as is
+func readObjectCompression(objectPath string) (*objectCompression, *probe.Error) {
+	compressionBytes, err := ioutil.ReadFile(objectPath + compressionSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, probe.NewError(err)
+	}
+	compression := &objectCompression{}
+	if err := json.Unmarshal(compressionBytes, compression); err != nil || compression.Algorithm == "" || !IsValidCompression(compression.Algorithm) {
+		return nil, probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	return compression, nil
+}
+
+// writeObjectCompression - record compression details of an object, nil removes them
+func writeObjectCompression(objectPath string, compression *objectCompression, modes fileModes) *probe.Error {
+	if compression == nil {
+		return removeObjectCompression(objectPath)
+	}
+	compressionBytes, err := json.Marshal(compression)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	file, err := createAtomicFile(objectPath+compressionSuffix, modes)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if _, err := file.Write(compressionBytes); err != nil {
+		file.CloseAndPurge()
+		return probe.NewError(err)
+	}
+	if err := file.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// removeObjectCompression - remove compression details left over by an object which is now
+// gone or stored otherwise
+func removeObjectCompression(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + compressionSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// finishObjectCompression - record original size and md5sum of an object stored compressed,
+// for objects stored otherwise any compression details left over by a previous version are removed
+func finishObjectCompression(compressed *compressedWriter, objectPath, md5Sum string, modes fileModes) *probe.Error {
+	if compressed == nil {
+		return removeObjectCompression(objectPath)
+	}
+	return writeObjectCompression(objectPath, &objectCompression{
+		Algorithm: compressed.algorithm,
+		Size:      compressed.size,
+		MD5Sum:    md5Sum,
+	}, modes)
+}
+
+// newObjectReader - reader of the original object data starting at offset 'start', objects
+// stored compressed are decompressed. Neither gzip nor zstd streams can be entered midway, a range
+// read of a compressed object decompresses and discards all of the data before 'start', reading
+// the tail of a large compressed object costs as much as reading all of it.
+func newObjectReader(file *os.File, start int64, compression *objectCompression) (io.Reader, error) {
+	if compression != nil {
+		var reader io.Reader
+		switch compression.Algorithm {
+		case CompressionZstd:
+			// decoding is synchronous with a single goroutine, nothing is left running to close
+			zstdReader, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			reader = zstdReader
+		default:
+			gzipReader, err := gzip.NewReader(file)
+			if err != nil {
+				return nil, err
+			}
+			reader = gzipReader
+		}
+		if _, err := io.CopyN(ioutil.Discard, reader, start); err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+	if _, err := file.Seek(start, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// bucketCompression - compression algorithm configured for a bucket, caller holds the lock
+func (fs Filesystem) bucketCompression(bucket string) string {
+	if bucketMetadata, ok := fs.buckets.Metadata[bucket]; ok && bucketMetadata != nil {
+		return bucketMetadata.Compression
+	}
+	return ""
+}
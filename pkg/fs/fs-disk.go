@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/disk"
+)
+
+const (
+	// DefaultDiskRetries - retries of a disk stat failing with a transient error
+	DefaultDiskRetries = 3
+	// DefaultDiskRetryBackoff - wait before the first retry, doubled on every further retry
+	DefaultDiskRetryBackoff = 100 * time.Millisecond
+)
+
+// diskStat - stat of the filesystem holding a path, replaced in tests
+var diskStat = disk.Stat
+
+// diskWriter - writer of the data of a part to its file, replaced in tests
+var diskWriter = func(file *os.File) io.Writer { return file }
+
+// diskFullRetryInterval - wait between retries of a write which found the disk full
+var diskFullRetryInterval = time.Second
+
+// isTransientDiskError - errors network filesystems like NFS return intermittently
+func isTransientDiskError(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	switch err {
+	case syscall.ESTALE, syscall.EIO:
+		return true
+	}
+	return false
+}
+
+// isDiskFullError - errors of writes to a disk out of space
+func isDiskFullError(err error) bool {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	errno, ok := err.(syscall.Errno)
+	if !ok {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// ERROR_HANDLE_DISK_FULL, ERROR_DISK_FULL
+		return errno == 39 || errno == 112
+	}
+	return errno == syscall.ENOSPC
+}
+
+// statDisk - stat of root path, transient errors are retried with backoff
+func (fs Filesystem) statDisk() (disk.StatFS, error) {
+	backoff := fs.diskRetryBackoff
+	for retry := 0; ; retry++ {
+		stfs, err := diskStat(fs.path)
+		if err == nil || retry >= fs.diskRetries || !isTransientDiskError(err) {
+			return stfs, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// diskFullWriter - writer retrying writes which found the disk full until space is reclaimed,
+// for up to the configured disk full wait. The filesystem lock is released while waiting, such
+// that deletes reclaiming space can proceed.
+type diskFullWriter struct {
+	fs     Filesystem
+	ctx    context.Context
+	writer io.Writer
+	waited bool // the filesystem lock was released at least once
+}
+
+func (w *diskFullWriter) Write(p []byte) (int, error) {
+	var deadline time.Time
+	written := 0
+	for {
+		n, err := w.writer.Write(p[written:])
+		written += n
+		if err == nil || !isDiskFullError(err) || w.fs.diskFullWait <= 0 {
+			return written, err
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(w.fs.diskFullWait)
+		}
+		if !time.Now().Before(deadline) {
+			return written, err
+		}
+		w.waited = true
+		w.fs.lock.Unlock()
+		select {
+		case <-w.ctx.Done():
+			w.fs.lock.Lock()
+			return written, err
+		case <-time.After(diskFullRetryInterval):
+		}
+		w.fs.lock.Lock()
+	}
+}
+
+// diskError - error of a write, writes which found the disk full fail as the root path being full
+func (fs Filesystem) diskError(err error) *probe.Error {
+	if isDiskFullError(err) {
+		return probe.NewError(RootPathFull{Path: fs.path})
+	}
+	return probe.NewError(err)
+}
@@ -0,0 +1,346 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// EncryptionAES256 - objects are stored encrypted with AES-256-GCM, named after S3 server side encryption
+const EncryptionAES256 = "AES256"
+
+// MasterKeySize - size of the master key in bytes
+const MasterKeySize = 32
+
+const (
+	// encryptionSuffix - suffix of the file next to an object carrying its sealed data key
+	encryptionSuffix = "$sse"
+	// encryptionChunkSize - objects are sealed in chunks of this size, the last chunk may be shorter
+	encryptionChunkSize = 64 * 1024
+	// encryptionOverhead - authentication tag appended to every sealed chunk
+	encryptionOverhead = 16
+)
+
+// objectEncryption - content of the file next to an encrypted object
+type objectEncryption struct {
+	Algorithm string `json:"algorithm"`
+	SealedKey []byte `json:"sealedKey"` // data key sealed with the master key, prefixed with its nonce
+	ETag      string `json:"etag"`      // md5sum of the sealed data, as plain md5sum is not exposed
+}
+
+// IsValidEncryption - verify encryption algorithm, empty string stands for no encryption
+func IsValidEncryption(algorithm string) bool {
+	switch algorithm {
+	case "", EncryptionAES256:
+		return true
+	}
+	return false
+}
+
+// isEncryptionFile - true if the file carries the data key of an object
+func isEncryptionFile(name string) bool {
+	return strings.HasSuffix(name, encryptionSuffix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealDataKey - seal data key with the master key, the random nonce is prepended to the result
+func sealDataKey(masterKey, dataKey []byte) ([]byte, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+// openDataKey - open data key sealed by sealDataKey, fails if the master key differs
+func openDataKey(masterKey, sealedKey []byte) ([]byte, *probe.Error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, probe.NewError(InvalidMasterKey{})
+	}
+	if len(sealedKey) < aead.NonceSize() {
+		return nil, probe.NewError(InvalidMasterKey{})
+	}
+	dataKey, err := aead.Open(nil, sealedKey[:aead.NonceSize()], sealedKey[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, probe.NewError(InvalidMasterKey{})
+	}
+	return dataKey, nil
+}
+
+// chunkNonce - nonce of the n-th chunk, data keys are never reused across objects so a
+// counter is enough. The final chunk is marked so that truncated objects fail to open.
+func chunkNonce(sequence uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	if final {
+		nonce[0] = 1
+	}
+	binary.BigEndian.PutUint64(nonce[4:], sequence)
+	return nonce
+}
+
+// encryptedWriter - seals everything written to it in chunks with a random data key
+type encryptedWriter struct {
+	writer   io.Writer
+	etag     hash.Hash
+	aead     cipher.AEAD
+	buffer   []byte
+	sequence uint64
+	dataKey  []byte
+}
+
+func newEncryptedWriter(writer io.Writer) (*encryptedWriter, error) {
+	dataKey := make([]byte, MasterKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	etag := md5.New()
+	return &encryptedWriter{
+		writer:  io.MultiWriter(writer, etag),
+		etag:    etag,
+		aead:    aead,
+		buffer:  make([]byte, 0, encryptionChunkSize),
+		dataKey: dataKey,
+	}, nil
+}
+
+func (w *encryptedWriter) sealChunk(final bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.sequence, final), w.buffer, nil)
+	w.sequence++
+	w.buffer = w.buffer[:0]
+	_, err := w.writer.Write(sealed)
+	return err
+}
+
+func (w *encryptedWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		// a full chunk is sealed only once more data shows up, the last one is left to Finish()
+		if len(w.buffer) == encryptionChunkSize {
+			if err := w.sealChunk(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buffer[len(w.buffer):encryptionChunkSize], p)
+		w.buffer = w.buffer[:len(w.buffer)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Finish - seal the final chunk and write encryption details next to the object, the returned
+// file has to be closed right before the object itself to move it into place
+func (w *encryptedWriter) Finish(masterKey []byte, objectPath string, modes fileModes) (*atomicFile, *probe.Error) {
+	if err := w.sealChunk(true); err != nil {
+		return nil, probe.NewError(err)
+	}
+	sealedKey, err := sealDataKey(masterKey, w.dataKey)
+	if err != nil {
+		return nil, probe.NewError(InvalidMasterKey{})
+	}
+	return createObjectEncryption(objectPath, &objectEncryption{
+		Algorithm: EncryptionAES256,
+		SealedKey: sealedKey,
+		ETag:      hex.EncodeToString(w.etag.Sum(nil)),
+	}, modes)
+}
+
+// ETag - md5sum of the sealed data, valid after Finish()
+func (w *encryptedWriter) ETag() string {
+	return hex.EncodeToString(w.etag.Sum(nil))
+}
+
+// decryptedReader - opens sealed chunks of an object starting at an arbitrary offset
+type decryptedReader struct {
+	file      *os.File
+	aead      cipher.AEAD
+	remaining int64 // sealed bytes not read yet
+	sequence  uint64
+	chunk     []byte
+}
+
+// encryptedObjectSize - size of the object sealed into a file of the given size
+func encryptedObjectSize(fileSize int64) int64 {
+	sealedChunkSize := int64(encryptionChunkSize + encryptionOverhead)
+	chunks := (fileSize + sealedChunkSize - 1) / sealedChunkSize
+	return fileSize - chunks*encryptionOverhead
+}
+
+func newDecryptedReader(file *os.File, dataKey []byte, start int64) (io.Reader, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	st, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	sequence := start / encryptionChunkSize
+	offset := sequence * (encryptionChunkSize + encryptionOverhead)
+	if _, err := file.Seek(offset, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	r := &decryptedReader{
+		file:      file,
+		aead:      aead,
+		remaining: st.Size() - offset,
+		sequence:  uint64(sequence),
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, start%encryptionChunkSize); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *decryptedReader) Read(p []byte) (int, error) {
+	if len(r.chunk) == 0 {
+		if r.remaining <= 0 {
+			return 0, io.EOF
+		}
+		sealed := make([]byte, encryptionChunkSize+encryptionOverhead)
+		if r.remaining < int64(len(sealed)) {
+			sealed = sealed[:r.remaining]
+		}
+		if _, err := io.ReadFull(r.file, sealed); err != nil {
+			return 0, err
+		}
+		r.remaining -= int64(len(sealed))
+		chunk, err := r.aead.Open(sealed[:0], chunkNonce(r.sequence, r.remaining == 0), sealed, nil)
+		if err != nil {
+			return 0, ObjectCorrupted{Object: r.file.Name()}
+		}
+		r.sequence++
+		r.chunk = chunk
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// readObjectEncryption - encryption details of an object, nil for objects stored as is
+func readObjectEncryption(objectPath string) (*objectEncryption, *probe.Error) {
+	encryptionBytes, err := ioutil.ReadFile(objectPath + encryptionSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, probe.NewError(err)
+	}
+	encryption := &objectEncryption{}
+	if err := json.Unmarshal(encryptionBytes, encryption); err != nil {
+		return nil, probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	return encryption, nil
+}
+
+// createObjectEncryption - write encryption details of an object, the caller renames the returned
+// file into place right before the object itself
+func createObjectEncryption(objectPath string, encryption *objectEncryption, modes fileModes) (*atomicFile, *probe.Error) {
+	encryptionBytes, err := json.Marshal(encryption)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	file, err := createAtomicFile(objectPath+encryptionSuffix, modes)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	if _, err := file.Write(encryptionBytes); err != nil {
+		file.CloseAndPurge()
+		return nil, probe.NewError(err)
+	}
+	return file, nil
+}
+
+// removeObjectEncryption - remove encryption details left over by an object which is now stored as is
+func removeObjectEncryption(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + encryptionSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// finishObjectEncryption - seal the final chunk of an encrypted object and move its encryption details
+// into place, for objects stored as is any encryption details left over by a previous version are removed
+func (fs Filesystem) finishObjectEncryption(encrypted *encryptedWriter, objectPath string) *probe.Error {
+	if encrypted == nil {
+		return removeObjectEncryption(objectPath)
+	}
+	encryptionFile, err := encrypted.Finish(fs.masterKey, objectPath, fs.modes)
+	if err != nil {
+		return err.Trace()
+	}
+	if err := encryptionFile.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// copyObjectEncryption - copy encryption details along with sealed data of an object
+func copyObjectEncryption(srcPath, destPath string, modes fileModes) *probe.Error {
+	encryption, err := readObjectEncryption(srcPath)
+	if err != nil {
+		return err.Trace()
+	}
+	if encryption == nil {
+		return removeObjectEncryption(destPath)
+	}
+	encryptionFile, err := createObjectEncryption(destPath, encryption, modes)
+	if err != nil {
+		return err.Trace()
+	}
+	if err := encryptionFile.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// bucketEncryption - encryption algorithm configured for a bucket, caller holds the lock
+func (fs Filesystem) bucketEncryption(bucket string) string {
+	if bucketMetadata, ok := fs.buckets.Metadata[bucket]; ok && bucketMetadata != nil {
+		return bucketMetadata.Encryption
+	}
+	return ""
+}
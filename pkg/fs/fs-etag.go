@@ -0,0 +1,77 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Parts uploaded without a Content-MD5 header nor a signed payload are not hashed once parts
+// skip md5sums, their ETag is made of the size and modification time of the part file instead.
+// Objects completed out of such parts only are not hashed either, when stored as is. This saves
+// the CPU spent on md5sums for high throughput ingest, at the cost of S3 compatibility: such
+// ETags are not the md5sum of the data, clients comparing them against their own md5sum fail,
+// and scrubbing has no md5sum to verify such objects against. Parts and objects keep their
+// ETags when parts stop skipping md5sums.
+
+// unhashedETag - ETag of a part or object which was not hashed, the dash keeps it apart from
+// md5sums and tells clients, as for multipart objects of S3, that it is not one
+func unhashedETag(size int64, modTime time.Time) string {
+	return fmt.Sprintf("%x-%x", modTime.UnixNano(), size)
+}
+
+// isUnhashedETag - true if etag was made of size and modification time rather than an md5sum
+func isUnhashedETag(etag string) bool {
+	return strings.Contains(strings.Trim(etag, "\""), "-")
+}
+
+// fileUnhashedETag - ETag of the file at filePath as if it was not hashed
+func fileUnhashedETag(filePath string) (string, error) {
+	st, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	return unhashedETag(st.Size(), st.ModTime()), nil
+}
+
+// skipsPartMD5 - true if a part of size uploaded with expectedMD5Sum and signature is not hashed,
+// the md5sum is needed to verify Content-MD5 and the sha256sum to verify a signed payload. Empty
+// parts cost nothing to hash and keep the ETag of empty content.
+func (fs Filesystem) skipsPartMD5(expectedMD5Sum string, size int64, signature *Signature) bool {
+	if !fs.skipPartMD5 || strings.TrimSpace(expectedMD5Sum) != "" || size == 0 {
+		return false
+	}
+	// requests signed with an UNSIGNED-PAYLOAD have everything but their payload verified
+	return signature == nil || signature.Request.Header.Get("X-Amz-Content-Sha256") == UnsignedPayload
+}
+
+// skipsObjectMD5 - true if an object completed out of parts is not hashed, which takes every
+// part to be unhashed as well and at least one part
+func (fs Filesystem) skipsObjectMD5(parts *CompleteMultipartUpload) bool {
+	if !fs.skipPartMD5 || len(parts.Part) == 0 {
+		return false
+	}
+	for _, part := range parts.Part {
+		if !isUnhashedETag(part.ETag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// folderSuffix - suffix of the keys of folders, which tools such as the AWS console create as
+// empty objects
+const folderSuffix = "/"
+
+// folderMarker - name of the empty file inside the directory of a folder created explicitly, the
+// directory is then never empty and survives the deletion of the objects in it
+const folderMarker = "$folder"
+
+// isFolderMarkerFile - true if the file marks the directory it is in as a folder
+func isFolderMarkerFile(name string) bool {
+	return strings.HasSuffix(name, folderMarker)
+}
+
+// createFolderMarker - mark the directory at folderPath as a folder, if it is not yet
+func createFolderMarker(folderPath string, modes fileModes) *probe.Error {
+	markerPath := filepath.Join(folderPath, folderMarker)
+	if _, err := os.Stat(markerPath); err == nil {
+		return nil
+	}
+	file, err := createAtomicFile(markerPath, modes)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if err := file.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// removeFolderMarker - unmark the directory at folderPath, if it is marked as a folder
+func removeFolderMarker(folderPath string) *probe.Error {
+	if err := os.Remove(filepath.Join(folderPath, folderMarker)); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// isFolderObject - true if object is a folder, kept as a directory listed as a common prefix
+func isFolderObject(object string) bool {
+	return strings.HasSuffix(object, folderSuffix)
+}
+
+// createFolderObject - create the marked directory of a folder at folderPath, caller holds the
+// lock. Folders are empty, their md5sum and payload hash are those of no data.
+func (fs Filesystem) createFolderObject(bucket, object, folderPath, expectedMD5Sum string, size int64, signature *Signature, preconditions WritePreconditions) (ObjectMetadata, *probe.Error) {
+	if !fs.folderObjects {
+		return ObjectMetadata{}, probe.NewError(InvalidFolderObject{Bucket: bucket, Object: object, Reason: "folders are disabled"})
+	}
+	if size != 0 {
+		return ObjectMetadata{}, probe.NewError(InvalidFolderObject{Bucket: bucket, Object: object, Reason: "folders cannot have data"})
+	}
+	md5Sum := hex.EncodeToString(md5.New().Sum(nil))
+	if expectedMD5Sum != "" {
+		if err := isMD5SumEqual(expectedMD5Sum, md5Sum); err != nil {
+			return ObjectMetadata{}, probe.NewError(BadDigest{Md5: expectedMD5Sum, Bucket: bucket, Object: object})
+		}
+	}
+	if signature != nil {
+		ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sha256.Sum256(nil)))
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+		if !ok {
+			return ObjectMetadata{}, probe.NewError(SignatureDoesNotMatch{})
+		}
+	}
+	st, e := os.Stat(folderPath)
+	if e == nil && !st.IsDir() {
+		// an object of the same name without the slash is in the way
+		return ObjectMetadata{}, probe.NewError(InvalidFolderObject{Bucket: bucket, Object: object, Reason: "an object of the same name exists"})
+	}
+	if preconditions != (WritePreconditions{}) && !preconditions.hold(md5Sum, e == nil) {
+		return ObjectMetadata{}, probe.NewError(PreconditionFailed{Bucket: bucket, Object: object})
+	}
+	if e := fs.modes.mkdirAll(folderPath); e != nil {
+		return ObjectMetadata{}, fs.diskError(e).Trace(bucket, object)
+	}
+	if err := createFolderMarker(folderPath, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if st, e = os.Stat(folderPath); e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	return ObjectMetadata{
+		Bucket:       bucket,
+		Object:       object,
+		Created:      st.ModTime(),
+		ContentType:  "application/octet-stream",
+		Md5:          md5Sum,
+		Mode:         st.Mode(),
+		StorageClass: StorageClassStandard,
+	}, nil
+}
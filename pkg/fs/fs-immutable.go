@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// recentUploads - objects completed by multipart uploads lately, which may be neither replaced
+// nor removed until their immutability window passes. Windows are kept in memory only, a
+// restart ends them early.
+type recentUploads struct {
+	mutex     *sync.Mutex
+	immutable map[string]time.Time // end of the window of an object by its path
+}
+
+func newRecentUploads() *recentUploads {
+	return &recentUploads{
+		mutex:     &sync.Mutex{},
+		immutable: make(map[string]time.Time),
+	}
+}
+
+// add - keep the object at objectPath immutable until the given time, windows passed by now
+// are dropped along the way
+func (r *recentUploads) add(objectPath string, until time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now().UTC()
+	for path, end := range r.immutable {
+		if !now.Before(end) {
+			delete(r.immutable, path)
+		}
+	}
+	r.immutable[objectPath] = until
+}
+
+// until - end of the window of the object at objectPath, zero if it is not immutable
+func (r *recentUploads) until(objectPath string) time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	end, ok := r.immutable[objectPath]
+	if !ok {
+		return time.Time{}
+	}
+	if !time.Now().UTC().Before(end) {
+		delete(r.immutable, objectPath)
+		return time.Time{}
+	}
+	return end
+}
+
+// under - an object whose path starts with prefix and which is still immutable along with the
+// end of its window, an empty path if there is none
+func (r *recentUploads) under(prefix string) (string, time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now().UTC()
+	for path, end := range r.immutable {
+		if strings.HasPrefix(path, prefix) && now.Before(end) {
+			return path, end
+		}
+	}
+	return "", time.Time{}
+}
+
+// SetImmutableWindow - set how long an object completed by a multipart upload may be neither
+// replaced nor removed, keeping producers racing for a key from overwriting each other. Zero
+// disables the window.
+func (fs *Filesystem) SetImmutableWindow(window time.Duration) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.immutableWindow = window
+}
+
+// markImmutable - start the immutability window of an object just completed
+func (fs Filesystem) markImmutable(objectPath string) {
+	if fs.immutableWindow <= 0 {
+		return
+	}
+	fs.recentUploads.add(objectPath, time.Now().UTC().Add(fs.immutableWindow))
+}
+
+// checkImmutable - fail with OperationAborted while an object is within the immutability
+// window of its completion
+func (fs Filesystem) checkImmutable(bucket, object, objectPath string) *probe.Error {
+	if until := fs.recentUploads.until(objectPath); !until.IsZero() {
+		return probe.NewError(OperationAborted{Bucket: bucket, Object: object, Until: until})
+	}
+	return nil
+}
+
+// checkBucketImmutable - fail with OperationAborted while any object of the bucket at bucketDir
+// is within the immutability window of its completion
+func (fs Filesystem) checkBucketImmutable(bucket, bucketDir string) *probe.Error {
+	objectPath, until := fs.recentUploads.under(bucketDir + string(os.PathSeparator))
+	if objectPath == "" {
+		return nil
+	}
+	object, e := filepath.Rel(bucketDir, objectPath)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	return probe.NewError(OperationAborted{Bucket: bucket, Object: filepath.ToSlash(object), Until: until})
+}
@@ -0,0 +1,144 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Portable keys are stored on disk such that keys which differ in case only never collide on
+// case-insensitive filesystems, and such that keys which only differ in their Unicode
+// normalization are the same key. Keys are normalized to NFC and every rune a case-insensitive
+// filesystem could confuse with another is escaped as '^' followed by its code point in hex and
+// ';', as in '^46;oo' for 'Foo'. Runes without case and the lowest lower case rune of every set
+// of runes folding onto each other are stored as is, so that 'foo' is stored as 'foo'.
+const (
+	keyEscape    = '^'
+	keyEscapeEnd = ';'
+)
+
+// keptRune - whether r is stored as is on disk, runes with a lower case as 'İ' never are
+func keptRune(r rune) bool {
+	if r == keyEscape || unicode.ToLower(r) != r {
+		return false
+	}
+	kept := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if isCanonicalFold(f, kept) {
+			kept = f
+		}
+	}
+	return kept == r
+}
+
+// isCanonicalFold - whether f is kept over kept among runes folding onto each other, lower case
+// runes over others and lower runes over higher ones
+func isCanonicalFold(f, kept rune) bool {
+	fLower, keptLower := unicode.ToLower(f) == f, unicode.ToLower(kept) == kept
+	if fLower != keptLower {
+		return fLower
+	}
+	return f < kept
+}
+
+// normalizeObjectKey - NFC form of an object key
+func normalizeObjectKey(object string) string {
+	return norm.NFC.String(object)
+}
+
+// encodeObjectKey - name of an object key on disk
+func encodeObjectKey(object string) string {
+	object = normalizeObjectKey(object)
+	var encoded []byte
+	for i, r := range object {
+		if keptRune(r) {
+			if encoded != nil {
+				encoded = append(encoded, object[i:i+utf8.RuneLen(r)]...)
+			}
+			continue
+		}
+		if encoded == nil {
+			encoded = append(make([]byte, 0, len(object)+8), object[:i]...)
+		}
+		encoded = append(encoded, keyEscape)
+		encoded = strconv.AppendInt(encoded, int64(r), 16)
+		encoded = append(encoded, keyEscapeEnd)
+	}
+	if encoded == nil {
+		return object
+	}
+	return string(encoded)
+}
+
+// decodeObjectKey - object key of a name on disk, names which are not the encoding of their key
+// as files put there by other means are not portable keys
+func decodeObjectKey(name string) (object string, ok bool) {
+	if strings.IndexRune(name, keyEscape) < 0 {
+		return name, encodeObjectKey(name) == name
+	}
+	var decoded []rune
+	for rest := name; rest != ""; {
+		r, size := utf8.DecodeRuneInString(rest)
+		rest = rest[size:]
+		if r != keyEscape {
+			decoded = append(decoded, r)
+			continue
+		}
+		end := strings.IndexRune(rest, keyEscapeEnd)
+		if end < 0 {
+			return name, false
+		}
+		code, err := strconv.ParseInt(rest[:end], 16, 32)
+		if err != nil {
+			return name, false
+		}
+		decoded = append(decoded, rune(code))
+		rest = rest[end+1:]
+	}
+	object = string(decoded)
+	return object, encodeObjectKey(object) == name
+}
+
+// objectKey - key an object is known by, normalized if keys are portable
+func (fs Filesystem) objectKey(object string) string {
+	if !fs.portableKeys {
+		return object
+	}
+	return normalizeObjectKey(object)
+}
+
+// diskKey - name of an object key on disk
+func (fs Filesystem) diskKey(object string) string {
+	if !fs.portableKeys {
+		return object
+	}
+	return encodeObjectKey(object)
+}
+
+// keyOfDiskName - object key of a name on disk, names which cannot be told as portable keys
+// are ignored
+func (fs Filesystem) keyOfDiskName(name string) (string, bool) {
+	if !fs.portableKeys {
+		return name, true
+	}
+	return decodeObjectKey(name)
+}
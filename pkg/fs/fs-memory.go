@@ -0,0 +1,1346 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// MemoryFS - keeps buckets, objects and multipart sessions in memory, for tests and ephemeral
+// nodes. Names are validated and errors reported the same way as by Filesystem. Data is kept
+// as is, compression of a bucket is recorded but not applied and encryption is not available
+// as there is no master key.
+type MemoryFS struct {
+	maxParts        int
+	maxSessions     int           // active multipart sessions allowed per bucket, unlimited if zero
+	maxObjectSize   int64         // maximum size of an object, unlimited if zero
+	immutableWindow time.Duration // how long objects completed by multipart uploads are immutable
+	recentUploads   *recentUploads
+	lock            *sync.Mutex
+	buckets         map[string]*memoryBucket
+}
+
+// memoryBucket - bucket along with its objects, their previous versions, its folders and active
+// multipart sessions
+type memoryBucket struct {
+	metadata   BucketMetadata
+	objects    map[string]*memoryObject
+	versions   map[string]map[string]*memoryObject // previous versions of an object by version id
+	folders    map[string]bool                     // keys of the folders created explicitly
+	multiparts map[string]*memoryMultipart
+}
+
+// memoryObject - object data along with its metadata
+type memoryObject struct {
+	metadata ObjectMetadata
+	data     []byte
+	tags     map[string]string
+}
+
+// memoryMultipart - multipart session along with the data of its parts
+type memoryMultipart struct {
+	session *MultipartSession
+	parts   map[int][]byte
+}
+
+// NewMemoryFS - instantiate a new empty in memory backend
+func NewMemoryFS() MemoryFS {
+	return MemoryFS{
+		maxParts:      DefaultMaxParts,
+		maxSessions:   DefaultMaxMultipartSessions,
+		recentUploads: newRecentUploads(),
+		lock:          new(sync.Mutex),
+		buckets:       make(map[string]*memoryBucket),
+	}
+}
+
+// SetMaxParts - set maximum part number allowed per multipart upload
+func (fs *MemoryFS) SetMaxParts(maxParts int) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxParts = maxParts
+}
+
+// SetMaxMultipartSessions - set active multipart sessions allowed per bucket, zero disables the limit
+func (fs *MemoryFS) SetMaxMultipartSessions(maxSessions int) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxSessions = maxSessions
+}
+
+// SetMaxObjectSize - set maximum size of an object, zero disables the limit
+func (fs *MemoryFS) SetMaxObjectSize(maxObjectSize int64) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxObjectSize = maxObjectSize
+}
+
+// SetImmutableWindow - set how long an object completed by a multipart upload may be neither
+// replaced nor removed, as for Filesystem. Zero disables the window.
+func (fs *MemoryFS) SetImmutableWindow(window time.Duration) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.immutableWindow = window
+}
+
+// markImmutable - start the immutability window of an object just completed
+func (fs MemoryFS) markImmutable(bucket, object string) {
+	if fs.immutableWindow <= 0 {
+		return
+	}
+	fs.recentUploads.add(bucket+"/"+object, time.Now().UTC().Add(fs.immutableWindow))
+}
+
+// checkImmutable - fail with OperationAborted while an object is within the immutability
+// window of its completion
+func (fs MemoryFS) checkImmutable(bucket, object string) *probe.Error {
+	if until := fs.recentUploads.until(bucket + "/" + object); !until.IsZero() {
+		return probe.NewError(OperationAborted{Bucket: bucket, Object: object, Until: until})
+	}
+	return nil
+}
+
+// checkBucketImmutable - fail with OperationAborted while any object of a bucket is within the
+// immutability window of its completion
+func (fs MemoryFS) checkBucketImmutable(bucket string) *probe.Error {
+	key, until := fs.recentUploads.under(bucket + "/")
+	if key == "" {
+		return nil
+	}
+	return probe.NewError(OperationAborted{Bucket: bucket, Object: strings.TrimPrefix(key, bucket+"/"), Until: until})
+}
+
+// isObjectTooLarge - verify size against configured maximum object size
+func (fs MemoryFS) isObjectTooLarge(size int64) bool {
+	return fs.maxObjectSize > 0 && size > fs.maxObjectSize
+}
+
+// getBucket - validate bucket name and look the bucket up, caller holds the lock
+func (fs MemoryFS) getBucket(bucket string) (*memoryBucket, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return nil, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	return b, nil
+}
+
+// decodeMD5Sum - hex encoded md5sum out of base64 encoded Content-MD5, empty stays empty
+func decodeMD5Sum(expectedMD5Sum string) (string, *probe.Error) {
+	if strings.TrimSpace(expectedMD5Sum) == "" {
+		return "", nil
+	}
+	expectedMD5SumBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(expectedMD5Sum))
+	if err != nil {
+		return "", probe.NewError(InvalidDigest{Md5: expectedMD5Sum})
+	}
+	return hex.EncodeToString(expectedMD5SumBytes), nil
+}
+
+// readVerified - read size bytes of data verifying them against md5sum and signature
+func readVerified(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature) ([]byte, *probe.Error) {
+	var buffer bytes.Buffer
+	h := md5.New()
+	sh := sha256.New()
+	mw := io.MultiWriter(&buffer, h, sh)
+	if size > 0 {
+		if _, err := io.CopyN(mw, data, size); err != nil {
+			return nil, probe.NewError(err)
+		}
+	} else {
+		if _, err := io.Copy(mw, data); err != nil {
+			return nil, probe.NewError(err)
+		}
+	}
+	if expectedMD5Sum != "" {
+		if err := isMD5SumEqual(expectedMD5Sum, hex.EncodeToString(h.Sum(nil))); err != nil {
+			return nil, probe.NewError(BadDigest{Md5: expectedMD5Sum, Bucket: bucket, Object: object})
+		}
+	}
+	if signature != nil {
+		ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+		if err != nil {
+			return nil, err.Trace()
+		}
+		if !ok {
+			return nil, probe.NewError(SignatureDoesNotMatch{})
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// checkRetention - fail with ObjectLocked while an object is locked, caller holds the lock
+func (b *memoryBucket) checkRetention(bucket, object string) *probe.Error {
+	o, ok := b.objects[object]
+	if !ok || o.metadata.RetainUntil.IsZero() {
+		return nil
+	}
+	if time.Now().UTC().Before(o.metadata.RetainUntil) {
+		return probe.NewError(ObjectLocked{Bucket: bucket, Object: object, RetainUntil: o.metadata.RetainUntil})
+	}
+	return nil
+}
+
+// newVersionID - version id of an object about to be written, a new one while versioning is
+// enabled, the null version while it is suspended and empty if it was never enabled
+func (b *memoryBucket) newVersionID() (string, *probe.Error) {
+	switch b.metadata.Versioning {
+	case VersioningEnabled:
+		versionID, e := newVersionID()
+		if e != nil {
+			return "", probe.NewError(e)
+		}
+		return versionID, nil
+	case VersioningSuspended:
+		return NullVersionID, nil
+	}
+	return "", nil
+}
+
+// keepVersion - keep the current object as a previous version before it is replaced or removed,
+// returns false if it was not kept. As for Filesystem objects are not kept in buckets versioning
+// was never enabled on, and the null version is not kept while versioning is suspended.
+func (b *memoryBucket) keepVersion(object string) bool {
+	if b.metadata.Versioning == "" {
+		return false
+	}
+	if b.metadata.Versioning == VersioningSuspended {
+		delete(b.versions[object], NullVersionID)
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return false
+	}
+	if o.metadata.VersionID == "" {
+		o.metadata.VersionID = NullVersionID
+	}
+	if b.metadata.Versioning == VersioningSuspended && o.metadata.VersionID == NullVersionID {
+		return false
+	}
+	if b.versions[object] == nil {
+		b.versions[object] = make(map[string]*memoryObject)
+	}
+	b.versions[object][o.metadata.VersionID] = o
+	delete(b.objects, object)
+	return true
+}
+
+// putObject - store object data, locked for the default retention of the bucket, the object
+// replaced is kept as a previous version in versioned buckets
+func (b *memoryBucket) putObject(bucket, object, contentType string, data []byte) (ObjectMetadata, *probe.Error) {
+	versionID, err := b.newVersionID()
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	b.keepVersion(object)
+	md5Sum := md5.Sum(data)
+	metadata := ObjectMetadata{
+		Bucket:      bucket,
+		Object:      object,
+		ContentType: contentType,
+		Created:     time.Now().UTC(),
+		Md5:         hex.EncodeToString(md5Sum[:]),
+		Size:        int64(len(data)),
+		// a new object is of the standard storage class until tagged otherwise
+		StorageClass: StorageClassStandard,
+		VersionID:    versionID,
+	}
+	if b.metadata.Retention > 0 {
+		metadata.RetainUntil = metadata.Created.Add(b.metadata.Retention)
+	}
+	b.objects[object] = &memoryObject{metadata: metadata, data: data}
+	return metadata, nil
+}
+
+// hasPrefix - true if any object or folder is kept under the prefix, caller holds the lock
+func (b *memoryBucket) hasPrefix(prefix string) bool {
+	for name := range b.objects {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for name := range b.folders {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// putFolder - create a folder, kept apart from the objects and only listed as a common prefix
+// as for Filesystem. Folders are empty, their md5sum and payload hash are those of no data.
+func (b *memoryBucket) putFolder(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature, preconditions WritePreconditions) (ObjectMetadata, *probe.Error) {
+	if size != 0 {
+		return ObjectMetadata{}, probe.NewError(InvalidFolderObject{Bucket: bucket, Object: object, Reason: "folders cannot have data"})
+	}
+	if _, err := readVerified(bucket, object, expectedMD5Sum, 0, io.LimitReader(data, 0), signature); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if _, ok := b.objects[strings.TrimSuffix(object, folderSuffix)]; ok {
+		// an object of the same name without the slash is in the way
+		return ObjectMetadata{}, probe.NewError(InvalidFolderObject{Bucket: bucket, Object: object, Reason: "an object of the same name exists"})
+	}
+	md5Sum := md5.Sum(nil)
+	if !preconditions.hold(hex.EncodeToString(md5Sum[:]), b.hasPrefix(object)) {
+		return ObjectMetadata{}, probe.NewError(PreconditionFailed{Bucket: bucket, Object: object})
+	}
+	b.folders[object] = true
+	return ObjectMetadata{
+		Bucket:       bucket,
+		Object:       object,
+		ContentType:  "application/octet-stream",
+		Created:      time.Now().UTC(),
+		Md5:          hex.EncodeToString(md5Sum[:]),
+		StorageClass: StorageClassStandard,
+	}, nil
+}
+
+/// Bucket Operations
+
+// GetStorageInfo - get memory used by objects along with bucket and object counts
+func (fs MemoryFS) GetStorageInfo() (StorageInfo, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	storageInfo := StorageInfo{Buckets: int64(len(fs.buckets))}
+	for _, b := range fs.buckets {
+		storageInfo.Objects += int64(len(b.objects))
+		for _, o := range b.objects {
+			storageInfo.Used += int64(len(o.data))
+		}
+	}
+	return storageInfo, nil
+}
+
+// MakeBucket - PUT Bucket
+func (fs MemoryFS) MakeBucket(bucket, acl string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidBucketACL(acl) {
+		return probe.NewError(InvalidACL{ACL: acl})
+	}
+	if _, ok := fs.buckets[bucket]; ok {
+		return probe.NewError(BucketExists{Bucket: bucket})
+	}
+	if strings.TrimSpace(acl) == "" {
+		acl = "private"
+	}
+	fs.buckets[bucket] = &memoryBucket{
+		metadata: BucketMetadata{
+			Name:    bucket,
+			Created: time.Now().UTC(),
+			ACL:     BucketACL(acl),
+		},
+		objects:    make(map[string]*memoryObject),
+		versions:   make(map[string]map[string]*memoryObject),
+		folders:    make(map[string]bool),
+		multiparts: make(map[string]*memoryMultipart),
+	}
+	return nil
+}
+
+// DeleteBucket - delete bucket
+func (fs MemoryFS) DeleteBucket(bucket string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	if len(b.objects) > 0 || len(b.versions) > 0 || len(b.folders) > 0 || len(b.multiparts) > 0 {
+		return probe.NewError(BucketNotEmpty{Bucket: bucket})
+	}
+	delete(fs.buckets, bucket)
+	return nil
+}
+
+// EmptyBucket - remove every object, previous version, folder and multipart session of a bucket,
+// keeping the bucket along with its ACL and settings. Nothing is removed while any object or
+// version is locked or any object is within the immutability window of its completion.
+func (fs MemoryFS) EmptyBucket(bucket string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	if err := fs.checkBucketImmutable(bucket); err != nil {
+		return err.Trace()
+	}
+	for object := range b.objects {
+		if err := b.checkRetention(bucket, object); err != nil {
+			return err.Trace()
+		}
+	}
+	for object, versions := range b.versions {
+		for _, o := range versions {
+			if time.Now().UTC().Before(o.metadata.RetainUntil) {
+				return probe.NewError(ObjectLocked{Bucket: bucket, Object: object, RetainUntil: o.metadata.RetainUntil})
+			}
+		}
+	}
+	b.objects = make(map[string]*memoryObject)
+	b.versions = make(map[string]map[string]*memoryObject)
+	b.folders = make(map[string]bool)
+	b.multiparts = make(map[string]*memoryMultipart)
+	return nil
+}
+
+// ListBuckets - Get service
+func (fs MemoryFS) ListBuckets() ([]BucketMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	var names []string
+	for name := range fs.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var metadataList []BucketMetadata
+	for _, name := range names {
+		metadataList = append(metadataList, fs.buckets[name].metadata)
+	}
+	return metadataList, nil
+}
+
+// GetBucketMetadata - get bucket metadata
+func (fs MemoryFS) GetBucketMetadata(bucket string) (BucketMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return BucketMetadata{}, err.Trace(bucket)
+	}
+	return b.metadata, nil
+}
+
+// SetBucketCompression - record compression algorithm of a bucket, data is kept as is
+func (fs MemoryFS) SetBucketCompression(bucket, algorithm string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidCompression(algorithm) {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Compression = algorithm
+	return nil
+}
+
+// SetBucketEncryption - only disabling encryption is possible, there is no master key
+func (fs MemoryFS) SetBucketEncryption(bucket, algorithm string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidEncryption(algorithm) {
+		return probe.NewError(InvalidArgument{})
+	}
+	if algorithm != "" {
+		return probe.NewError(InvalidMasterKey{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Encryption = algorithm
+	return nil
+}
+
+// SetBucketRetention - set default retention of objects created in the bucket from now on,
+// zero disables it
+func (fs MemoryFS) SetBucketRetention(bucket string, retention time.Duration) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if retention < 0 {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Retention = retention
+	return nil
+}
+
+// SetBucketVersioning - enable or suspend versioning of a bucket, as for Filesystem
+func (fs MemoryFS) SetBucketVersioning(bucket, status string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidVersioning(status) {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Versioning = status
+	return nil
+}
+
+// SetBucketNotification - set webhook url object events of the bucket are posted to, empty
+// url disables notifications
+func (fs MemoryFS) SetBucketNotification(bucket, webhook string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidNotification(webhook) {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Notification = webhook
+	return nil
+}
+
+// SetBucketPolicy - set policy of a bucket, nil removes it
+func (fs MemoryFS) SetBucketPolicy(bucket string, policy *BucketPolicy) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	b.metadata.Policy = policy
+	return nil
+}
+
+// GetBucketPolicy - get policy of a bucket, BucketPolicyNotFound if it has none
+func (fs MemoryFS) GetBucketPolicy(bucket string) (*BucketPolicy, *probe.Error) {
+	bucketMetadata, err := fs.GetBucketMetadata(bucket)
+	if err != nil {
+		return nil, err.Trace(bucket)
+	}
+	if bucketMetadata.Policy == nil {
+		return nil, probe.NewError(BucketPolicyNotFound{Bucket: bucket})
+	}
+	return bucketMetadata.Policy, nil
+}
+
+// GetBucketACL - get canned acl of a bucket
+func (fs MemoryFS) GetBucketACL(bucket string) (BucketACL, *probe.Error) {
+	bucketMetadata, err := fs.GetBucketMetadata(bucket)
+	if err != nil {
+		return "", err.Trace(bucket)
+	}
+	return bucketMetadata.ACL, nil
+}
+
+// SetBucketACL - set canned acl of a bucket
+func (fs MemoryFS) SetBucketACL(bucket string, acl BucketACL) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidBucketACL(acl.String()) {
+		return probe.NewError(InvalidACL{ACL: acl.String()})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	if strings.TrimSpace(acl.String()) == "" {
+		acl = BucketPrivate
+	}
+	b.metadata.ACL = acl
+	return nil
+}
+
+// IsPrivateBucket - is private bucket
+func (fs MemoryFS) IsPrivateBucket(bucket string) bool {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return true
+	}
+	return b.metadata.ACL.IsPrivate()
+}
+
+// IsPublicBucket - is public bucket
+func (fs MemoryFS) IsPublicBucket(bucket string) bool {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return false
+	}
+	return b.metadata.ACL.IsPublicReadWrite()
+}
+
+// IsReadOnlyBucket - is read only bucket
+func (fs MemoryFS) IsReadOnlyBucket(bucket string) bool {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return false
+	}
+	return b.metadata.ACL.IsPublicRead()
+}
+
+// ListObjects - GET bucket (list objects)
+func (fs MemoryFS) ListObjects(bucket string, resources BucketResourcesMetadata) ([]ObjectMetadata, BucketResourcesMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return nil, resources, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if resources.Prefix != "" && isValidObjectPrefix(resources.Prefix) == false {
+		return nil, resources, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: resources.Prefix})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return nil, resources, err.Trace(bucket)
+	}
+
+	var names []string
+	for name := range b.objects {
+		if strings.HasPrefix(name, resources.Prefix) && name > resources.Marker {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	commonPrefixes := make(map[string]bool)
+	// folders are not objects, they only show as the common prefixes of their keys
+	if resources.Delimiter != "" {
+		for name := range b.folders {
+			if !strings.HasPrefix(name, resources.Prefix) || name <= resources.Marker {
+				continue
+			}
+			if i := strings.Index(name[len(resources.Prefix):], resources.Delimiter); i >= 0 {
+				commonPrefixes[name[:len(resources.Prefix)+i+len(resources.Delimiter)]] = true
+			}
+		}
+	}
+	var metadataList []ObjectMetadata
+	for _, name := range names {
+		// keys with delimiter after the prefix roll up into common prefixes
+		if resources.Delimiter != "" {
+			if i := strings.Index(name[len(resources.Prefix):], resources.Delimiter); i >= 0 {
+				commonPrefixes[name[:len(resources.Prefix)+i+len(resources.Delimiter)]] = true
+				continue
+			}
+		}
+		if len(metadataList) == resources.Maxkeys {
+			resources.IsTruncated = true
+			if resources.Delimiter != "" {
+				resources.NextMarker = metadataList[len(metadataList)-1].Object
+			}
+			break
+		}
+		metadataList = append(metadataList, b.objects[name].metadata)
+	}
+	resources.CommonPrefixes = sortedCommonPrefixes(commonPrefixes)
+	return metadataList, resources, nil
+}
+
+/// Object Operations
+
+// GetObject - GET object
+func (fs MemoryFS) GetObject(w io.Writer, bucket, object string, start, length int64) (int64, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return 0, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return 0, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return 0, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return 0, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return o.read(w, start, length)
+}
+
+// read - write the data of an object starting at offset 'start', length bytes of it unless
+// length is zero
+func (o *memoryObject) read(w io.Writer, start, length int64) (int64, *probe.Error) {
+	if start < 0 || start > int64(len(o.data)) {
+		return 0, probe.NewError(InvalidRange{Start: start, Length: length})
+	}
+	data := o.data[start:]
+	if length > 0 {
+		if length > int64(len(data)) {
+			length = int64(len(data))
+		}
+		data = data[:length]
+	}
+	n, err := w.Write(data)
+	if err != nil {
+		return int64(n), probe.NewError(err)
+	}
+	return int64(n), nil
+}
+
+// GetObjectMetadata - HEAD object
+func (fs MemoryFS) GetObjectMetadata(bucket, object string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	metadata := o.metadata
+	// objects written before versioning was enabled are the null version
+	if b.metadata.Versioning != "" && metadata.VersionID == "" {
+		metadata.VersionID = NullVersionID
+	}
+	return metadata, nil
+}
+
+// getObjectVersion - a version of an object, the current object if it is the version asked for,
+// caller holds the lock
+func (fs MemoryFS) getObjectVersion(bucket, object, versionID string) (*memoryObject, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return nil, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !IsValidVersionID(versionID) {
+		return nil, probe.NewError(InvalidArgument{})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return nil, err.Trace(bucket)
+	}
+	if o, ok := b.objects[object]; ok {
+		currentID := o.metadata.VersionID
+		if currentID == "" {
+			currentID = NullVersionID
+		}
+		if currentID == versionID {
+			return o, nil
+		}
+	}
+	if o, ok := b.versions[object][versionID]; ok {
+		return o, nil
+	}
+	return nil, probe.NewError(ObjectVersionNotFound{Bucket: bucket, Object: object, VersionID: versionID})
+}
+
+// GetObjectVersion - GET a version of an object, the current object or a previous version
+func (fs MemoryFS) GetObjectVersion(w io.Writer, bucket, object, versionID string, start, length int64) (int64, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	o, err := fs.getObjectVersion(bucket, object, versionID)
+	if err != nil {
+		return 0, err.Trace(bucket, object, versionID)
+	}
+	return o.read(w, start, length)
+}
+
+// GetObjectVersionMetadata - HEAD a version of an object, the current object or a previous version
+func (fs MemoryFS) GetObjectVersionMetadata(bucket, object, versionID string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	o, err := fs.getObjectVersion(bucket, object, versionID)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object, versionID)
+	}
+	metadata := o.metadata
+	metadata.VersionID = versionID
+	return metadata, nil
+}
+
+// CreateObject - PUT object
+func (fs MemoryFS) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature) (ObjectMetadata, *probe.Error) {
+	return fs.CreateObjectIf(bucket, object, expectedMD5Sum, size, data, signature, WritePreconditions{})
+}
+
+// CreateObjectIf - PUT object only if the current object meets preconditions, as for Filesystem
+func (fs MemoryFS) CreateObjectIf(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature, preconditions WritePreconditions) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if fs.isObjectTooLarge(size) {
+		return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(size, 10),
+			MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+		})
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket)
+	}
+	if err := validateObjectName(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	expectedMD5Sum, err = decodeMD5Sum(expectedMD5Sum)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// locked objects may not be replaced
+	if err := b.checkRetention(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// objects ending in a slash are folders
+	if isFolderObject(object) {
+		return b.putFolder(bucket, object, expectedMD5Sum, size, data, signature, preconditions)
+	}
+	current, exists := b.objects[object]
+	var etag string
+	if exists {
+		etag = current.metadata.Md5
+	}
+	if !preconditions.hold(etag, exists) {
+		return ObjectMetadata{}, probe.NewError(PreconditionFailed{Bucket: bucket, Object: object})
+	}
+	objectData, err := readVerified(bucket, object, expectedMD5Sum, size, data, signature)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	metadata, err := b.putObject(bucket, object, "application/octet-stream", objectData)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	return metadata, nil
+}
+
+// CopyObject - copy object, metadataDirective is either "COPY" (default) or "REPLACE" as
+// for Filesystem. Copying an object to itself is allowed only with "REPLACE".
+func (fs MemoryFS) CopyObject(destBucket, destObject, srcBucket, srcObject string, metadataDirective string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if metadataDirective == "" {
+		metadataDirective = "COPY"
+	}
+	if metadataDirective != "COPY" && metadataDirective != "REPLACE" {
+		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+	}
+	src, err := fs.getBucket(srcBucket)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(srcBucket)
+	}
+	dest, err := fs.getBucket(destBucket)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket)
+	}
+	if !IsValidObjectName(srcObject) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: srcBucket, Object: srcObject})
+	}
+	if err := validateObjectName(destBucket, destObject); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	o, ok := src.objects[srcObject]
+	if !ok {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: srcBucket, Object: srcObject})
+	}
+	// locked objects may not be replaced, not even their metadata
+	if err := dest.checkRetention(destBucket, destObject); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(destBucket, destObject); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if srcBucket == destBucket && srcObject == destObject {
+		if metadataDirective != "REPLACE" {
+			return ObjectMetadata{}, probe.NewError(InvalidCopyRequest{Bucket: destBucket, Object: destObject})
+		}
+		// metadata only update, the object keeps its data and its version
+		o.metadata.Created = time.Now().UTC()
+		o.metadata.StorageClass = StorageClassStandard
+		o.metadata.TagCount = 0
+		o.tags = nil
+		return o.metadata, nil
+	}
+	// object data is never modified in place, sharing it is safe
+	metadata, err := dest.putObject(destBucket, destObject, o.metadata.ContentType, o.data)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// the copy keeps the storage class and the tags of its source unless they are replaced
+	if metadataDirective == "COPY" {
+		metadata.StorageClass = o.metadata.StorageClass
+		metadata.TagCount = len(o.tags)
+		dest.objects[destObject].metadata = metadata
+		dest.objects[destObject].tags = o.tags
+	}
+	return metadata, nil
+}
+
+// DeleteObject - delete an object
+func (fs MemoryFS) DeleteObject(bucket, object string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return err.Trace(bucket)
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	// folders holding objects are kept as their prefix, like directories by Filesystem
+	if isFolderObject(object) {
+		if !b.hasPrefix(object) {
+			return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		delete(b.folders, object)
+		return nil
+	}
+	if _, ok := b.objects[object]; !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	// locked objects may not be removed
+	if err := b.checkRetention(bucket, object); err != nil {
+		return err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object); err != nil {
+		return err.Trace()
+	}
+	// the object removed is kept as a previous version in versioned buckets
+	b.keepVersion(object)
+	delete(b.objects, object)
+	return nil
+}
+
+// SetObjectRetention - lock an object until the given time, retention of an object already
+// locked is only ever extended
+func (fs MemoryFS) SetObjectRetention(bucket, object string, retainUntil time.Time) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !retainUntil.After(time.Now().UTC()) {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	if retainUntil.After(o.metadata.RetainUntil) {
+		o.metadata.RetainUntil = retainUntil.UTC()
+	}
+	return nil
+}
+
+// SetObjectStorageClass - tag an object with the storage class it was requested with
+func (fs MemoryFS) SetObjectStorageClass(bucket, object, storageClass string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !IsValidStorageClass(storageClass) {
+		return probe.NewError(InvalidArgument{})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	o.metadata.StorageClass = storageClass
+	return nil
+}
+
+// getObject - look an object up whose tags are accessed, caller holds the lock
+func (fs MemoryFS) getObject(bucket, object string) (*memoryObject, *probe.Error) {
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return nil, err.Trace(bucket)
+	}
+	if !IsValidObjectName(object) {
+		return nil, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	o, ok := b.objects[object]
+	if !ok {
+		return nil, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return o, nil
+}
+
+// PutObjectTagging - replace all the tags of an object, tags may be changed on locked objects
+func (fs MemoryFS) PutObjectTagging(bucket, object string, tags map[string]string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if err := CheckObjectTags(tags); err != nil {
+		return err.Trace(bucket, object)
+	}
+	o, err := fs.getObject(bucket, object)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	// tags are replaced as a whole, never modified in place
+	o.tags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		o.tags[key] = value
+	}
+	o.metadata.TagCount = len(o.tags)
+	return nil
+}
+
+// GetObjectTagging - tags of an object, empty if it has none
+func (fs MemoryFS) GetObjectTagging(bucket, object string) (map[string]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	o, err := fs.getObject(bucket, object)
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	tags := make(map[string]string, len(o.tags))
+	for key, value := range o.tags {
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// DeleteObjectTagging - remove all the tags of an object
+func (fs MemoryFS) DeleteObjectTagging(bucket, object string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	o, err := fs.getObject(bucket, object)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	o.tags = nil
+	o.metadata.TagCount = 0
+	return nil
+}
+
+/// Multipart Operations
+
+// getMultipart - look the active multipart session of an object up, caller holds the lock
+func (fs MemoryFS) getMultipart(bucket, object, uploadID string) (*memoryBucket, *memoryMultipart, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return nil, nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return nil, nil, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	b, ok := fs.buckets[bucket]
+	if !ok {
+		return nil, nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+	}
+	m, ok := b.multiparts[object]
+	if !ok || m.session.UploadID != uploadID {
+		return nil, nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+	}
+	return b, m, nil
+}
+
+// ListMultipartUploads - list incomplete multipart sessions for a given BucketMultipartResourcesMetadata
+func (fs MemoryFS) ListMultipartUploads(bucket string, resources BucketMultipartResourcesMetadata) (BucketMultipartResourcesMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return BucketMultipartResourcesMetadata{}, err.Trace(bucket)
+	}
+	sessions := make(map[string]*MultipartSession)
+	for object, multipart := range b.multiparts {
+		sessions[object] = multipart.session
+	}
+	return listUploads(sessions, resources), nil
+}
+
+// NewMultipartUpload - initiate a new multipart session
+func (fs MemoryFS) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return "", probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if err := validateObjectName(bucket, object); err != nil {
+		return "", err.Trace()
+	}
+	b, err := fs.getBucket(bucket)
+	if err != nil {
+		return "", err.Trace(bucket)
+	}
+	sessions := len(b.multiparts)
+	if _, ok := b.multiparts[object]; ok {
+		// a new session replaces the one of object
+		sessions--
+	}
+	if fs.maxSessions > 0 && sessions >= fs.maxSessions {
+		return "", probe.NewError(TooManyMultipartUploads{Bucket: bucket, Limit: fs.maxSessions})
+	}
+	uploadID := newUploadID(bucket, object)
+	b.multiparts[object] = &memoryMultipart{
+		session: &MultipartSession{
+			Version:   multipartSessionVersion,
+			Bucket:    bucket,
+			UploadID:  uploadID,
+			Initiated: time.Now().UTC(),
+		},
+		parts: make(map[int][]byte),
+	}
+	return uploadID, nil
+}
+
+// CreateObjectPart - create a part in a multipart session
+func (fs MemoryFS) CreateObjectPart(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *Signature) (string, *probe.Error) {
+	return fs.CreateObjectPartIf(ctx, bucket, object, uploadID, expectedMD5Sum, partID, size, data, signature, "")
+}
+
+// CreateObjectPartIf - create a part in a multipart session unless it is a retry of an uploaded part
+func (fs MemoryFS) CreateObjectPartIf(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *Signature, ifNoneMatch string) (string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if partID <= 0 {
+		return "", probe.NewError(errors.New("invalid part id, cannot be zero or less than zero"))
+	}
+	if partID > fs.maxParts {
+		return "", probe.NewError(InvalidPart{})
+	}
+	if err := validateObjectName(bucket, object); err != nil {
+		return "", err.Trace()
+	}
+	_, m, err := fs.getMultipart(bucket, object, uploadID)
+	if err != nil {
+		return "", err.Trace(bucket, object)
+	}
+	expectedMD5Sum, err = decodeMD5Sum(expectedMD5Sum)
+	if err != nil {
+		return "", err.Trace()
+	}
+	// retried upload of an identical part, verify the payload but do not replace it
+	for _, part := range m.session.Parts {
+		if part.PartNumber == partID && isRetriedPart(part, ifNoneMatch, expectedMD5Sum, size) {
+			if _, err := readVerified(bucket, object, part.ETag, size, contextReader{ctx: ctx, reader: data}, signature); err != nil {
+				return "", err.Trace(bucket, object)
+			}
+			return part.ETag, nil
+		}
+	}
+	partData, err := readVerified(bucket, object, expectedMD5Sum, size, contextReader{ctx: ctx, reader: data}, signature)
+	if err != nil {
+		return "", err.Trace(bucket, object)
+	}
+	md5Sum := md5.Sum(partData)
+	partMetadata := &PartMetadata{
+		PartNumber:   partID,
+		LastModified: time.Now().UTC(),
+		ETag:         hex.EncodeToString(md5Sum[:]),
+		Size:         int64(len(partData)),
+	}
+	// a part uploaded again replaces the previous one
+	replaced := false
+	for i, part := range m.session.Parts {
+		if part.PartNumber == partID {
+			m.session.Parts[i] = partMetadata
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		m.session.Parts = append(m.session.Parts, partMetadata)
+		m.session.TotalParts++
+	}
+	sort.Sort(partNumber(m.session.Parts))
+	m.parts[partID] = partData
+	return partMetadata.ETag, nil
+}
+
+// CompleteMultipartUpload - complete a multipart upload and store the object
+func (fs MemoryFS) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, data io.Reader, signature *Signature) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if err := validateObjectName(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	b, m, err := fs.getMultipart(bucket, object, uploadID)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	// locked objects may not be replaced
+	if err := b.checkRetention(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// never read more than maxCompleteMultipartUploadSize, one byte more tells the body is too large
+	partBytes, e := ioutil.ReadAll(io.LimitReader(contextReader{ctx: ctx, reader: data}, maxCompleteMultipartUploadSize+1))
+	if e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	if int64(len(partBytes)) > maxCompleteMultipartUploadSize {
+		return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(int64(len(partBytes)), 10),
+			MaxSize:            strconv.FormatInt(maxCompleteMultipartUploadSize, 10),
+		})
+	}
+	if signature != nil {
+		sh := sha256.New()
+		sh.Write(partBytes)
+		ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+		if !ok {
+			return ObjectMetadata{}, probe.NewError(SignatureDoesNotMatch{})
+		}
+	}
+	parts := &CompleteMultipartUpload{}
+	if err := xml.Unmarshal(partBytes, parts); err != nil {
+		return ObjectMetadata{}, probe.NewError(MalformedXML{})
+	}
+	if !sort.IsSorted(completedParts(parts.Part)) {
+		return ObjectMetadata{}, probe.NewError(InvalidPartOrder{})
+	}
+
+	var objectData []byte
+	for _, part := range parts.Part {
+		partData, ok := m.parts[part.PartNumber]
+		if !ok {
+			return ObjectMetadata{}, probe.NewError(InvalidPart{})
+		}
+		// complete multi part request header md5sum per part is hex encoded
+		recvMD5Bytes, e := hex.DecodeString(strings.Trim(part.ETag, "\""))
+		if e != nil {
+			return ObjectMetadata{}, probe.NewError(InvalidDigest{Md5: part.ETag})
+		}
+		calcMD5Bytes := md5.Sum(partData)
+		if !bytes.Equal(recvMD5Bytes, calcMD5Bytes[:]) {
+			return ObjectMetadata{}, probe.NewError(BadDigest{Md5: part.ETag})
+		}
+		objectData = append(objectData, partData...)
+	}
+	if fs.isObjectTooLarge(int64(len(objectData))) {
+		return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(int64(len(objectData)), 10),
+			MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+		})
+	}
+	delete(b.multiparts, object)
+	metadata, err := b.putObject(bucket, object, "application/octet-stream", objectData)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	fs.markImmutable(bucket, object)
+	return metadata, nil
+}
+
+// ListObjectParts - list parts from incomplete multipart session for a given ObjectResourcesMetadata
+func (fs MemoryFS) ListObjectParts(bucket, object string, resources ObjectResourcesMetadata) (ObjectResourcesMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	_, m, err := fs.getMultipart(bucket, object, resources.UploadID)
+	if err != nil {
+		return ObjectResourcesMetadata{}, err.Trace(bucket, object)
+	}
+	objectResourcesMetadata := resources
+	objectResourcesMetadata.Bucket = bucket
+	objectResourcesMetadata.Object = object
+	startPartNumber := objectResourcesMetadata.PartNumberMarker
+	if startPartNumber == 0 {
+		startPartNumber = 1
+	}
+	var parts []*PartMetadata
+	for _, part := range m.session.Parts {
+		if part.PartNumber < startPartNumber {
+			continue
+		}
+		if objectResourcesMetadata.MaxParts > 0 && len(parts) >= objectResourcesMetadata.MaxParts {
+			objectResourcesMetadata.IsTruncated = true
+			objectResourcesMetadata.NextPartNumberMarker = part.PartNumber
+			break
+		}
+		partMetadata := *part
+		parts = append(parts, &partMetadata)
+	}
+	objectResourcesMetadata.Part = parts
+	return objectResourcesMetadata, nil
+}
+
+// AbortMultipartUpload - abort an incomplete multipart session
+func (fs MemoryFS) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	b, _, err := fs.getMultipart(bucket, object, uploadID)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	delete(b.multiparts, object)
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// DefaultFileMode - permissions of objects, parts and the files next to them, private to the
+// server user
+const DefaultFileMode os.FileMode = 0600
+
+// DefaultDirMode - permissions of buckets and the directories of objects, private to the server user
+const DefaultDirMode os.FileMode = 0700
+
+// fileModes - permissions of the files and directories created under the root path, applied
+// regardless of the process umask, along with the directory temp files are created in
+type fileModes struct {
+	file    os.FileMode
+	dir     os.FileMode
+	tempDir string
+}
+
+// defaultFileModes - file modes of a new filesystem
+var defaultFileModes = fileModes{file: DefaultFileMode, dir: DefaultDirMode}
+
+// validateFileModes - modes may only hold permission bits, and the server has to be able to
+// read and write its files as well as list and enter its directories
+func validateFileModes(fileMode, dirMode os.FileMode) *probe.Error {
+	if fileMode&^os.ModePerm != 0 || fileMode&0600 != 0600 {
+		return probe.NewError(InvalidFileMode{Mode: fileMode})
+	}
+	if dirMode&^os.ModePerm != 0 || dirMode&0700 != 0700 {
+		return probe.NewError(InvalidFileMode{Mode: dirMode, Dir: true})
+	}
+	return nil
+}
+
+// mkdir - create a directory of the configured mode
+func (m fileModes) mkdir(dirPath string) error {
+	if err := os.Mkdir(dirPath, m.dir); err != nil {
+		return err
+	}
+	return os.Chmod(dirPath, m.dir)
+}
+
+// mkdirAll - create a directory along with its missing parents, all of them of the configured mode
+func (m fileModes) mkdirAll(dirPath string) error {
+	fi, err := os.Stat(dirPath)
+	if err == nil {
+		if !fi.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: dirPath, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+	if parent := filepath.Dir(dirPath); parent != dirPath {
+		if err := m.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	if err := m.mkdir(dirPath); err != nil {
+		// created in the meantime by a concurrent write
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
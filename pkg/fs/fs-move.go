@@ -0,0 +1,199 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// renameFile - rename a file, replaced in tests to simulate renames between filesystems
+var renameFile = os.Rename
+
+// isCrossDevice - true if err is a rename failing as source and destination are on different
+// filesystems
+func isCrossDevice(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == errCrossDevice
+}
+
+// moveFile - rename srcPath to destPath, copying it over and removing it if they are on
+// different filesystems. The copy keeps the modification time of the source.
+func (fs Filesystem) moveFile(srcPath, destPath string) error {
+	err := renameFile(srcPath, destPath)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	st, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+	file, err := createAtomicFile(destPath, fs.modes)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.copyBuffered(file, srcFile, -1); err != nil {
+		file.CloseAndPurge()
+		return err
+	}
+	file.File.Sync()
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(destPath, st.ModTime(), st.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(srcPath)
+}
+
+// movedSuffixes - suffixes of the files next to an object which go along with it when it is moved
+var movedSuffixes = []string{encryptionSuffix, compressionSuffix, checksumSuffix, retentionSuffix, storageClassSuffix, taggingSuffix, versionIDSuffix}
+
+// moveObjectFiles - move the files next to an object already moved from srcPath to destPath along
+// with it, the object replaced must not keep any of its own. On failure the files moved so far
+// and the object itself are moved back, so that the source is left as it was.
+func (fs Filesystem) moveObjectFiles(srcPath, destPath string) error {
+	var moved []string
+	rollback := func() {
+		for _, suffix := range moved {
+			fs.moveFile(destPath+suffix, srcPath+suffix)
+		}
+		fs.moveFile(destPath, srcPath)
+	}
+	for _, suffix := range movedSuffixes {
+		if _, e := os.Stat(srcPath + suffix); os.IsNotExist(e) {
+			if e := os.Remove(destPath + suffix); e != nil && !os.IsNotExist(e) {
+				rollback()
+				return e
+			}
+			continue
+		}
+		if e := fs.moveFile(srcPath+suffix, destPath+suffix); e != nil {
+			rollback()
+			return e
+		}
+		moved = append(moved, suffix)
+	}
+	return nil
+}
+
+// MoveObject - move an object along with its encryption details, checksum, retention, storage
+// class and tags, replacing any object at the destination. Objects are renamed without copying their
+// data unless source and destination are on different filesystems. In versioned buckets the object
+// replaced is kept as a previous version and the object moved is given a new version id. Moving an
+// object onto itself leaves it as is.
+func (fs Filesystem) MoveObject(srcBucket, srcObject, destBucket, destObject string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	for _, bucket := range []string{srcBucket, destBucket} {
+		// check bucket name valid
+		if !IsValidBucket(bucket) {
+			return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		}
+		// check bucket exists
+		if _, err := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(err) {
+			return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+	}
+	// verify object path legal
+	if !IsValidObjectName(srcObject) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: srcBucket, Object: srcObject})
+	}
+	if err := validateObjectName(destBucket, destObject); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+
+	srcObject, destObject = fs.objectKey(srcObject), fs.objectKey(destObject)
+	srcMetadata, err := fs.getObjectMetadata(srcBucket, srcObject)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(srcBucket, srcObject)
+	}
+	if srcMetadata.Mode.IsDir() {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: srcBucket, Object: srcObject})
+	}
+
+	srcPath := filepath.Join(fs.path, srcBucket, fs.diskKey(srcObject))
+	destPath := filepath.Join(fs.path, destBucket, fs.diskKey(destObject))
+	if srcPath == destPath {
+		return srcMetadata, nil
+	}
+	// prefixes of other objects cannot be replaced by an object
+	if st, e := os.Stat(destPath); e == nil && st.IsDir() {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: destBucket, Object: destObject})
+	}
+	// locked objects may neither be removed nor replaced
+	if err := checkObjectRetention(srcBucket, srcObject, srcPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := checkObjectRetention(destBucket, destObject, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(srcBucket, srcObject, srcPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(destBucket, destObject, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if e := fs.modes.mkdirAll(filepath.Dir(destPath)); e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+
+	// the object replaced is kept as a previous version in versioned buckets
+	versionID, err := fs.newObjectVersionID(destBucket)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	srcUsage, destUsage := objectUsage(srcPath), objectUsage(destPath)
+	if _, err := fs.keepObjectVersion(destBucket, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	// the data goes first, the source is never left without the files next to it
+	if e := fs.moveFile(srcPath, destPath); e != nil {
+		return ObjectMetadata{}, fs.diskError(e).Trace(destBucket, destObject)
+	}
+	if e := fs.moveObjectFiles(srcPath, destPath); e != nil {
+		return ObjectMetadata{}, fs.diskError(e).Trace(destBucket, destObject)
+	}
+	// the version id moved along is that of the source, the object is a new version at the destination
+	if err := writeObjectVersionID(destPath, versionID, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	fs.usage.update(srcBucket, srcUsage, BucketUsage{})
+	fs.usage.update(destBucket, destUsage, objectUsage(destPath))
+	// directories left empty by the source go along with it
+	if err := deleteObjectPath(filepath.Join(fs.path, srcBucket), filepath.Dir(srcPath), srcBucket, srcObject); err != nil {
+		return ObjectMetadata{}, err.Trace(srcBucket, srcObject)
+	}
+	if err := fs.lockNewObject(destBucket, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+
+	destMetadata, err := fs.getObjectMetadata(destBucket, destObject)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	destMetadata.VersionID = versionID
+	return destMetadata, nil
+}
@@ -0,0 +1,324 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// quarantineDir - directory under the root path where corrupted multipart sessions are moved to
+const quarantineDir = ".minio.quarantine"
+
+// DefaultOrphanPartAge - orphaned parts younger than this are left alone, their upload may
+// still be about to record them or to write its session file
+const DefaultOrphanPartAge = 24 * time.Hour
+
+// errEmptySession - session file decoded fine but carries no upload id
+var errEmptySession = errors.New("multipart session is empty")
+
+// multipartSessionVersion - layout of multipart sessions written by this server, sessions
+// written before they were versioned carry no version and share the layout of version 1
+const multipartSessionVersion = "1"
+
+// UnmarshalJSON - decode a multipart session of this or an earlier version, fields missing from
+// earlier versions are filled in. Sessions of a later version are rejected rather than appended
+// to in this layout, which would drop the fields unknown to this server.
+func (s *MultipartSession) UnmarshalJSON(data []byte) error {
+	// decode through a type of the same fields without this method
+	type multipartSession MultipartSession
+	session := multipartSession{}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return err
+	}
+	if session.Version == "" {
+		session.Version = multipartSessionVersion
+	}
+	if isNewerSessionVersion(session.Version) {
+		return UnsupportedMultipartSession{UploadID: session.UploadID, Version: session.Version}
+	}
+	if session.Parts == nil {
+		session.Parts = []*PartMetadata{}
+	}
+	if session.TotalParts < len(session.Parts) {
+		session.TotalParts = len(session.Parts)
+	}
+	*s = MultipartSession(session)
+	return nil
+}
+
+// isNewerSessionVersion - true if version is not one this server knows the layout of
+func isNewerSessionVersion(version string) bool {
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return true
+	}
+	current, _ := strconv.Atoi(multipartSessionVersion)
+	return v > current
+}
+
+// readMultipartSession - read the latest valid session from a '$multiparts' file
+func readMultipartSession(sessionPath string) (*MultipartSession, error) {
+	sessionFile, err := os.Open(sessionPath)
+	if err != nil {
+		return nil, err
+	}
+	defer sessionFile.Close()
+
+	var session *MultipartSession
+	decoder := json.NewDecoder(sessionFile)
+	for {
+		decodedSession := new(MultipartSession)
+		if err := decoder.Decode(decodedSession); err != nil {
+			if err == io.EOF {
+				break
+			}
+			// a session appended by a later server is not a partial write
+			if _, ok := err.(UnsupportedMultipartSession); ok {
+				return nil, err
+			}
+			// a partially written trailing session is recoverable from the previous one
+			if session != nil {
+				break
+			}
+			return nil, err
+		}
+		session = decodedSession
+	}
+	if session == nil || session.UploadID == "" {
+		return nil, errEmptySession
+	}
+	return session, nil
+}
+
+// reconcileMultipartSession - session of an upload as recorded next to the object, reconciled
+// with the active session after a crash left the two apart. A session file missing under the
+// active session is rebuilt out of it, and a session file of an upload which is not active is
+// taken up again, as on startup. Uploads known to neither, or to another upload of the object,
+// are invalid. Caller holds the lock.
+func (fs Filesystem) reconcileMultipartSession(bucket, object, uploadID string) (*MultipartSession, *probe.Error) {
+	active, isActive := fs.multiparts.ActiveSession[object]
+	if isActive && (active.UploadID != uploadID || (active.Bucket != "" && active.Bucket != bucket)) {
+		return nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+	}
+	sessionPath := filepath.Join(fs.path, bucket, fs.diskKey(object)) + "$multiparts"
+	session, err := readMultipartSession(sessionPath)
+	if err == nil {
+		if session.UploadID != uploadID {
+			return nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+		}
+		if !isActive {
+			session.Bucket = bucket
+			fs.multiparts.ActiveSession[object] = session
+			if err := SaveMultipartsSession(fs.multiparts); err != nil {
+				return nil, err.Trace()
+			}
+		}
+		return session, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, probe.NewError(err)
+	}
+	if !isActive {
+		return nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+	}
+	sessionFile, err := os.OpenFile(sessionPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.modes.file)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	defer sessionFile.Close()
+	active.Version = multipartSessionVersion
+	active.Bucket = bucket
+	if err := json.NewEncoder(sessionFile).Encode(active); err != nil {
+		return nil, probe.NewError(err)
+	}
+	return active, nil
+}
+
+// quarantineMultipartSession - move session file and all of its parts under the quarantine directory
+func (fs Filesystem) quarantineMultipartSession(bucket, object string) *probe.Error {
+	objectPath := filepath.Join(fs.path, bucket, object)
+	quarantinePath := filepath.Join(fs.path, quarantineDir, bucket, object)
+	if err := fs.modes.mkdirAll(filepath.Dir(quarantinePath)); err != nil {
+		return probe.NewError(err)
+	}
+	if err := os.Rename(objectPath+"$multiparts", quarantinePath+"$multiparts"); err != nil {
+		return probe.NewError(err)
+	}
+	names, err := readDirUnsortedNames(filepath.Dir(objectPath))
+	if err != nil {
+		return probe.NewError(err)
+	}
+	partPrefix := filepath.Base(objectPath) + "$"
+	for _, name := range names {
+		if !strings.HasPrefix(name, partPrefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, partPrefix)); err != nil {
+			continue
+		}
+		partPath := filepath.Join(filepath.Dir(objectPath), name)
+		if err := os.Rename(partPath, filepath.Join(filepath.Dir(quarantinePath), name)); err != nil {
+			return probe.NewError(err)
+		}
+	}
+	return nil
+}
+
+// RecoverMultipartSessions - scan root path for multipart session files, rebuild active
+// sessions out of valid ones and quarantine the ones which cannot be parsed along with
+// their parts. Returns the list of quarantined session files.
+func (fs Filesystem) RecoverMultipartSessions() ([]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	type sessionKey struct {
+		bucket, object string
+	}
+	activeSessions := make(map[string]*MultipartSession)
+	var corruptedSessions []sessionKey
+	findSessions := func(fp string, fl os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fl.IsDir() && fl.Name() == quarantineDir {
+			return ErrSkipDir
+		}
+		if !fl.Mode().IsRegular() || !strings.HasSuffix(fp, "$multiparts") {
+			return nil
+		}
+		relPath, err := filepath.Rel(fs.path, strings.TrimSuffix(fp, "$multiparts"))
+		if err != nil {
+			return err
+		}
+		splits := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+		if len(splits) != 2 {
+			// not inside a bucket, ignore
+			return nil
+		}
+		bucket, object := splits[0], splits[1]
+		session, err := readMultipartSession(fp)
+		if _, ok := err.(UnsupportedMultipartSession); ok {
+			// left for the later server which wrote it, its parts are kept along with it
+			return nil
+		}
+		if err != nil {
+			corruptedSessions = append(corruptedSessions, sessionKey{bucket, object})
+			return nil
+		}
+		// sessions written before they named their bucket are told apart by their path
+		session.Bucket = bucket
+		if object, ok := fs.keyOfDiskName(object); ok {
+			activeSessions[object] = session
+		}
+		return nil
+	}
+	if err := WalkUnsorted(fs.path, findSessions); err != nil {
+		return nil, probe.NewError(err)
+	}
+
+	var quarantined []string
+	for _, corrupted := range corruptedSessions {
+		if err := fs.quarantineMultipartSession(corrupted.bucket, corrupted.object); err != nil {
+			return nil, err.Trace(corrupted.bucket, corrupted.object)
+		}
+		quarantined = append(quarantined, filepath.Join(fs.path, corrupted.bucket, corrupted.object)+"$multiparts")
+	}
+	fs.multiparts.ActiveSession = activeSessions
+	if err := SaveMultipartsSession(fs.multiparts); err != nil {
+		return nil, err.Trace()
+	}
+	return quarantined, nil
+}
+
+// RemoveOrphanParts - remove part files older than age no multipart session knows of, as left
+// behind by crashes between writing a part and recording it or by sessions which were lost. Parts
+// of an object with neither an active session nor a session file are orphans altogether, parts of
+// an active session only if the session does not record them. Returns the list of removed parts.
+func (fs Filesystem) RemoveOrphanParts(age time.Duration) ([]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var orphanParts []string
+	findOrphanParts := func(fp string, fl os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fl.IsDir() && fl.Name() == quarantineDir {
+			return ErrSkipDir
+		}
+		if !fl.Mode().IsRegular() || time.Since(fl.ModTime()) < age {
+			return nil
+		}
+		i := strings.LastIndex(fp, "$")
+		if i < 0 {
+			return nil
+		}
+		partNumber, err := strconv.Atoi(fp[i+1:])
+		if err != nil || fp[i+1:] != strconv.Itoa(partNumber) {
+			return nil
+		}
+		relPath, err := filepath.Rel(fs.path, fp[:i])
+		if err != nil {
+			return err
+		}
+		splits := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+		if len(splits) != 2 {
+			// not inside a bucket, ignore
+			return nil
+		}
+		object, ok := fs.keyOfDiskName(splits[1])
+		if session, live := fs.multiparts.ActiveSession[object]; ok && live && session.Bucket == splits[0] {
+			for _, part := range session.Parts {
+				if part.PartNumber == partNumber {
+					return nil
+				}
+			}
+			orphanParts = append(orphanParts, fp)
+			return nil
+		}
+		// a session file without an active session is still to be recovered
+		if _, err := os.Stat(fp[:i] + "$multiparts"); !os.IsNotExist(err) {
+			return nil
+		}
+		orphanParts = append(orphanParts, fp)
+		return nil
+	}
+	if err := WalkUnsorted(fs.path, findOrphanParts); err != nil {
+		return nil, probe.NewError(err)
+	}
+
+	var removed []string
+	for _, partPath := range orphanParts {
+		if err := os.Remove(partPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return removed, probe.NewError(err)
+		}
+		removed = append(removed, partPath)
+	}
+	return removed, nil
+}
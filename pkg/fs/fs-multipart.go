@@ -18,6 +18,7 @@ package fs
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
@@ -35,13 +36,15 @@ import (
 	"strings"
 	"time"
 
-	"github.com/minio/minio-xl/pkg/atomic"
 	"github.com/minio/minio-xl/pkg/crypto/sha256"
 	"github.com/minio/minio-xl/pkg/crypto/sha512"
 	"github.com/minio/minio-xl/pkg/probe"
-	"github.com/minio/minio/pkg/disk"
 )
 
+// maxCompleteMultipartUploadSize - maximum size of complete multipart upload request body,
+// plenty for the XML of DefaultMaxParts parts
+const maxCompleteMultipartUploadSize = 5 * 1024 * 1024
+
 func (fs Filesystem) isValidUploadID(object, uploadID string) bool {
 	s, ok := fs.multiparts.ActiveSession[object]
 	if !ok {
@@ -69,86 +72,314 @@ func (fs Filesystem) ListMultipartUploads(bucket string, resources BucketMultipa
 	if err != nil {
 		return BucketMultipartResourcesMetadata{}, probe.NewError(InternalError{})
 	}
-	var uploads []*UploadMetadata
+	bucketSessions := make(map[string]*MultipartSession)
 	for object, session := range fs.multiparts.ActiveSession {
+		if session.Bucket == bucket {
+			bucketSessions[object] = session
+		}
+	}
+	return listUploads(bucketSessions, resources), nil
+}
+
+// listUploads - page of uploads out of sessions keyed by their object, ordered by key and
+// upload id. A page starts after the upload named by the key and upload id markers, or after
+// every upload of the key marker if no upload id marker is given, and ends after MaxUploads
+// uploads and common prefixes. The next markers of a truncated page name its last entry, so
+// that paging with them neither skips nor repeats a session.
+func listUploads(sessions map[string]*MultipartSession, resources BucketMultipartResourcesMetadata) BucketMultipartResourcesMetadata {
+	if resources.Order == UploadsByInitiated {
+		return listUploadsByInitiated(sessions, resources)
+	}
+	var objects []string
+	for object := range sessions {
 		if strings.HasPrefix(object, resources.Prefix) {
-			if len(uploads) > resources.MaxUploads {
-				sort.Sort(byUploadMetadataKey(uploads))
-				resources.Upload = uploads
-				resources.NextKeyMarker = object
-				resources.NextUploadIDMarker = session.UploadID
-				resources.IsTruncated = true
-				return resources, nil
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+
+	var uploads []*UploadMetadata
+	var commonPrefixes []string
+	for _, object := range objects {
+		session := sessions[object]
+		// uploadIDMarker is ignored if KeyMarker is empty
+		switch {
+		case resources.KeyMarker != "" && resources.UploadIDMarker == "":
+			if object <= resources.KeyMarker {
+				continue
+			}
+		case resources.KeyMarker != "" && resources.UploadIDMarker != "":
+			if object < resources.KeyMarker || (object == resources.KeyMarker && session.UploadID <= resources.UploadIDMarker) {
+				continue
 			}
-			// uploadIDMarker is ignored if KeyMarker is empty
-			switch {
-			case resources.KeyMarker != "" && resources.UploadIDMarker == "":
-				if object > resources.KeyMarker {
-					upload := new(UploadMetadata)
-					upload.Object = object
-					upload.UploadID = session.UploadID
-					upload.Initiated = session.Initiated
-					uploads = append(uploads, upload)
+		}
+		// keys with delimiter after the prefix roll up into common prefixes, listed once
+		commonPrefix := ""
+		if resources.Delimiter != "" {
+			if i := strings.Index(object[len(resources.Prefix):], resources.Delimiter); i >= 0 {
+				commonPrefix = object[:len(resources.Prefix)+i+len(resources.Delimiter)]
+				if commonPrefix <= resources.KeyMarker {
+					continue
 				}
-			case resources.KeyMarker != "" && resources.UploadIDMarker != "":
-				if session.UploadID > resources.UploadIDMarker {
-					if object >= resources.KeyMarker {
-						upload := new(UploadMetadata)
-						upload.Object = object
-						upload.UploadID = session.UploadID
-						upload.Initiated = session.Initiated
-						uploads = append(uploads, upload)
-					}
+				if len(commonPrefixes) > 0 && commonPrefixes[len(commonPrefixes)-1] == commonPrefix {
+					continue
 				}
-			default:
-				upload := new(UploadMetadata)
-				upload.Object = object
-				upload.UploadID = session.UploadID
-				upload.Initiated = session.Initiated
-				uploads = append(uploads, upload)
 			}
 		}
+		if len(uploads)+len(commonPrefixes) >= resources.MaxUploads {
+			resources.IsTruncated = true
+			break
+		}
+		if commonPrefix != "" {
+			commonPrefixes = append(commonPrefixes, commonPrefix)
+			resources.NextKeyMarker = commonPrefix
+			resources.NextUploadIDMarker = ""
+			continue
+		}
+		uploads = append(uploads, &UploadMetadata{
+			Object:    object,
+			UploadID:  session.UploadID,
+			Initiated: session.Initiated,
+		})
+		resources.NextKeyMarker = object
+		resources.NextUploadIDMarker = session.UploadID
+	}
+	if !resources.IsTruncated {
+		resources.NextKeyMarker = ""
+		resources.NextUploadIDMarker = ""
 	}
-	sort.Sort(byUploadMetadataKey(uploads))
 	resources.Upload = uploads
-	return resources, nil
+	resources.CommonPrefixes = commonPrefixes
+	return resources
 }
 
-func (fs Filesystem) concatParts(parts *CompleteMultipartUpload, objectPath string, mw io.Writer) *probe.Error {
-	for _, part := range parts.Part {
-		recvMD5 := part.ETag
-		partFile, err := os.OpenFile(objectPath+fmt.Sprintf("$%d", part.PartNumber), os.O_RDONLY, 0600)
-		defer partFile.Close()
-		if err != nil {
-			return probe.NewError(err)
+// listUploadsByInitiated - page of uploads out of sessions keyed by their object, oldest first.
+// A page starts after the upload named by the initiated, key and upload id markers and ends
+// after MaxUploads uploads, the delimiter is ignored as uploads of a common prefix are not
+// initiated together. The next markers of a truncated page name its last upload.
+func listUploadsByInitiated(sessions map[string]*MultipartSession, resources BucketMultipartResourcesMetadata) BucketMultipartResourcesMetadata {
+	var uploads []*UploadMetadata
+	for object, session := range sessions {
+		if strings.HasPrefix(object, resources.Prefix) {
+			uploads = append(uploads, &UploadMetadata{
+				Object:    object,
+				UploadID:  session.UploadID,
+				Initiated: session.Initiated,
+			})
 		}
-		obj, err := ioutil.ReadAll(partFile)
-		if err != nil {
-			return probe.NewError(err)
+	}
+	sort.Sort(byUploadMetadataInitiated(uploads))
+
+	marker := &UploadMetadata{
+		Object:    resources.KeyMarker,
+		UploadID:  resources.UploadIDMarker,
+		Initiated: resources.InitiatedMarker,
+	}
+	uploads = uploads[sort.Search(len(uploads), func(i int) bool { return initiatedBefore(marker, uploads[i]) }):]
+	resources.IsTruncated = len(uploads) > resources.MaxUploads
+	resources.NextKeyMarker = ""
+	resources.NextUploadIDMarker = ""
+	resources.NextInitiatedMarker = time.Time{}
+	if resources.IsTruncated {
+		uploads = uploads[:resources.MaxUploads]
+		if len(uploads) > 0 {
+			last := uploads[len(uploads)-1]
+			resources.NextKeyMarker = last.Object
+			resources.NextUploadIDMarker = last.UploadID
+			resources.NextInitiatedMarker = last.Initiated
 		}
-		calcMD5Bytes := md5.Sum(obj)
-		// complete multi part request header md5sum per part is hex encoded
-		recvMD5Bytes, err := hex.DecodeString(strings.Trim(recvMD5, "\""))
+	}
+	resources.Upload = uploads
+	resources.CommonPrefixes = nil
+	return resources
+}
+
+// sortedCommonPrefixes - sorted list of common prefixes out of a set
+func sortedCommonPrefixes(commonPrefixes map[string]bool) []string {
+	var prefixes []string
+	for prefix := range commonPrefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// contextReader - fails reads once the context is done, so that copies of abandoned uploads stop
+// right away instead of running to completion
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	default:
+	}
+	return r.reader.Read(p)
+}
+
+// partData - content of a part read ahead by concatParts
+type partData struct {
+	data []byte
+	err  *probe.Error
+}
+
+// readPart - read a part and verify it against the md5sum from complete multipart request,
+// parts which were not hashed are verified against their size and modification time only
+func readPart(partPath, recvMD5 string) ([]byte, *probe.Error) {
+	partFile, err := os.OpenFile(partPath, os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	defer partFile.Close()
+	obj, err := ioutil.ReadAll(partFile)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	if isUnhashedETag(recvMD5) {
+		etag, err := fileUnhashedETag(partPath)
 		if err != nil {
-			return probe.NewError(InvalidDigest{Md5: recvMD5})
+			return nil, probe.NewError(err)
 		}
-		if !bytes.Equal(recvMD5Bytes, calcMD5Bytes[:]) {
-			return probe.NewError(BadDigest{Md5: recvMD5})
+		if etag != strings.Trim(recvMD5, "\"") {
+			return nil, probe.NewError(BadDigest{Md5: recvMD5})
 		}
-		_, err = io.Copy(mw, bytes.NewBuffer(obj))
-		if err != nil {
-			return probe.NewError(err)
+		return obj, nil
+	}
+	calcMD5Bytes := md5.Sum(obj)
+	// complete multi part request header md5sum per part is hex encoded
+	recvMD5Bytes, err := hex.DecodeString(strings.Trim(recvMD5, "\""))
+	if err != nil {
+		return nil, probe.NewError(InvalidDigest{Md5: recvMD5})
+	}
+	if !bytes.Equal(recvMD5Bytes, calcMD5Bytes[:]) {
+		return nil, probe.NewError(BadDigest{Md5: recvMD5})
+	}
+	return obj, nil
+}
+
+// concatParts - write parts in order, up to concatConcurrency parts are read and verified
+// ahead while the current one is written. Stops as soon as the context is done.
+func (fs Filesystem) concatParts(ctx context.Context, parts *CompleteMultipartUpload, objectPath string, mw io.Writer) *probe.Error {
+	concurrency := fs.concatConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	done := make(chan struct{})
+	defer close(done)
+
+	results := make([]chan partData, len(parts.Part))
+	for i := range results {
+		results[i] = make(chan partData, 1)
+	}
+	// a slot is taken before a part is read and given back once it is written
+	slots := make(chan struct{}, concurrency)
+	go func() {
+		for i, part := range parts.Part {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func(result chan<- partData, part CompletePart) {
+				data, err := readPart(objectPath+fmt.Sprintf("$%d", part.PartNumber), part.ETag)
+				result <- partData{data: data, err: err}
+			}(results[i], part)
+		}
+	}()
+	for _, result := range results {
+		var part partData
+		select {
+		case part = <-result:
+		case <-ctx.Done():
+			return probe.NewError(ctx.Err())
+		}
+		if part.err != nil {
+			return part.err.Trace()
+		}
+		if _, err := mw.Write(part.data); err != nil {
+			return fs.diskError(err)
+		}
+		<-slots
+	}
+	return nil
+}
+
+// verifyPartFile - compare size of a part file with the one recorded in its session, a part
+// truncated or lost by a crash mid-write is reported as InvalidPart
+func verifyPartFile(objectPath string, part *PartMetadata) *probe.Error {
+	st, err := os.Stat(objectPath + fmt.Sprintf("$%d", part.PartNumber))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return probe.NewError(InvalidPart{})
+		}
+		return probe.NewError(err)
+	}
+	if st.Size() != part.Size {
+		return probe.NewError(InvalidPart{})
+	}
+	return nil
+}
+
+// verifyCompleteParts - verify every part to complete was uploaded in session with the given
+// ETag and its file still matches, contents are verified against the ETag while concatenating
+func verifyCompleteParts(parts *CompleteMultipartUpload, session *MultipartSession, objectPath string) *probe.Error {
+	uploadedParts := make(map[int]*PartMetadata)
+	for _, part := range session.Parts {
+		uploadedParts[part.PartNumber] = part
+	}
+	for _, part := range parts.Part {
+		uploadedPart, ok := uploadedParts[part.PartNumber]
+		if !ok || uploadedPart.ETag != strings.Trim(part.ETag, "\"") {
+			return probe.NewError(InvalidPart{})
+		}
+		if err := verifyPartFile(objectPath, uploadedPart); err != nil {
+			return err.Trace(objectPath)
 		}
 	}
 	return nil
 }
 
+// getPartsSize - total size of the object assembled out of parts
+func getPartsSize(parts *CompleteMultipartUpload, objectPath string) (int64, *probe.Error) {
+	var size int64
+	for _, part := range parts.Part {
+		st, err := os.Stat(objectPath + fmt.Sprintf("$%d", part.PartNumber))
+		if err != nil {
+			return 0, probe.NewError(err)
+		}
+		size += st.Size()
+	}
+	return size, nil
+}
+
+// newUploadID - random id of a new multipart session
+func newUploadID(bucket, object string) string {
+	id := []byte(strconv.FormatInt(rand.Int63(), 10) + bucket + object + time.Now().String())
+	uploadIDSum := sha512.Sum512(id)
+	return base64.URLEncoding.EncodeToString(uploadIDSum[:])[:47]
+}
+
+// countBucketSessions - number of active sessions of bucket, but for a session of object which
+// a new session replaces
+func countBucketSessions(activeSessions map[string]*MultipartSession, bucket, object string) int {
+	count := 0
+	for sessionObject, session := range activeSessions {
+		if session.Bucket == bucket && sessionObject != object {
+			count++
+		}
+	}
+	return count
+}
+
 // NewMultipartUpload - initiate a new multipart session
 func (fs Filesystem) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 
-	stfs, err := disk.Stat(fs.path)
+	stfs, err := fs.statDisk()
 	if err != nil {
 		return "", probe.NewError(err)
 	}
@@ -162,8 +393,8 @@ func (fs Filesystem) NewMultipartUpload(bucket, object string) (string, *probe.E
 	if !IsValidBucket(bucket) {
 		return "", probe.NewError(BucketNameInvalid{Bucket: bucket})
 	}
-	if !IsValidObjectName(object) {
-		return "", probe.NewError(ObjectNameInvalid{Object: object})
+	if err := validateObjectName(bucket, object); err != nil {
+		return "", err.Trace()
 	}
 
 	bucketPath := filepath.Join(fs.path, bucket)
@@ -175,26 +406,34 @@ func (fs Filesystem) NewMultipartUpload(bucket, object string) (string, *probe.E
 	if err != nil {
 		return "", probe.NewError(InternalError{})
 	}
-	objectPath := filepath.Join(bucketPath, object)
+	object = fs.objectKey(object)
+	if fs.maxSessions > 0 && countBucketSessions(fs.multiparts.ActiveSession, bucket, object) >= fs.maxSessions {
+		return "", probe.NewError(TooManyMultipartUploads{Bucket: bucket, Limit: fs.maxSessions})
+	}
+
+	objectPath := filepath.Join(bucketPath, fs.diskKey(object))
 	objectDir := filepath.Dir(objectPath)
 	if _, err = os.Stat(objectDir); os.IsNotExist(err) {
-		err = os.MkdirAll(objectDir, 0700)
+		err = fs.modes.mkdirAll(objectDir)
 		if err != nil {
 			return "", probe.NewError(err)
 		}
 	}
 
-	id := []byte(strconv.FormatInt(rand.Int63(), 10) + bucket + object + time.Now().String())
-	uploadIDSum := sha512.Sum512(id)
-	uploadID := base64.URLEncoding.EncodeToString(uploadIDSum[:])[:47]
+	uploadID := newUploadID(bucket, object)
 
-	multiPartfile, err := os.OpenFile(objectPath+"$multiparts", os.O_WRONLY|os.O_CREATE, 0600)
+	multiPartfile, err := os.OpenFile(objectPath+"$multiparts", os.O_WRONLY|os.O_CREATE, fs.modes.file)
 	if err != nil {
 		return "", probe.NewError(err)
 	}
 	defer multiPartfile.Close()
+	if err = multiPartfile.Chmod(fs.modes.file); err != nil {
+		return "", probe.NewError(err)
+	}
 
 	mpartSession := new(MultipartSession)
+	mpartSession.Version = multipartSessionVersion
+	mpartSession.Bucket = bucket
 	mpartSession.TotalParts = 0
 	mpartSession.UploadID = uploadID
 	mpartSession.Initiated = time.Now().UTC()
@@ -220,12 +459,68 @@ func (a partNumber) Len() int           { return len(a) }
 func (a partNumber) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a partNumber) Less(i, j int) bool { return a[i].PartNumber < a[j].PartNumber }
 
-// CreateObjectPart - create a part in a multipart session
-func (fs Filesystem) CreateObjectPart(bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *Signature) (string, *probe.Error) {
+// getUploadedPart - metadata of an already uploaded part in the active session of the object
+func (fs Filesystem) getUploadedPart(object string, partID int) *PartMetadata {
+	session, ok := fs.multiparts.ActiveSession[object]
+	if !ok {
+		return nil
+	}
+	for _, part := range session.Parts {
+		if part.PartNumber == partID {
+			return part
+		}
+	}
+	return nil
+}
+
+// isRetriedPart - whether an upload of size bytes is a retry of the uploaded part, as told by the
+// client's If-None-Match, parts without an md5sum ETag can not be verified and are always rewritten
+func isRetriedPart(part *PartMetadata, ifNoneMatch, expectedMD5Sum string, size int64) bool {
+	if strings.TrimSpace(ifNoneMatch) == "" || !matchETag(ifNoneMatch, part.ETag, true) {
+		return false
+	}
+	if isUnhashedETag(part.ETag) || part.Size != size {
+		return false
+	}
+	return expectedMD5Sum == "" || expectedMD5Sum == part.ETag
+}
+
+// verifyObjectPart - read the part payload without persisting it, verifying its md5sum and signature
+func verifyObjectPart(expectedMD5Sum string, size int64, data io.Reader, signature *Signature) *probe.Error {
+	h := md5.New()
+	sh := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(h, sh), data, size); err != nil {
+		return probe.NewError(err)
+	}
+	if err := isMD5SumEqual(expectedMD5Sum, hex.EncodeToString(h.Sum(nil))); err != nil {
+		return probe.NewError(BadDigest{Md5: expectedMD5Sum})
+	}
+	if signature != nil {
+		ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+		if err != nil {
+			return err.Trace()
+		}
+		if !ok {
+			return probe.NewError(SignatureDoesNotMatch{})
+		}
+	}
+	return nil
+}
+
+// CreateObjectPart - create a part in a multipart session, the part is purged if the context
+// is done before all of its data is written
+func (fs Filesystem) CreateObjectPart(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *Signature) (string, *probe.Error) {
+	return fs.CreateObjectPartIf(ctx, bucket, object, uploadID, expectedMD5Sum, partID, size, data, signature, "")
+}
+
+// CreateObjectPartIf - create a part in a multipart session unless an uploaded part of the same
+// number carries one of the ETags in ifNoneMatch, such a retried upload is only verified to be
+// identical to the uploaded part and its ETag returned without rewriting it
+func (fs Filesystem) CreateObjectPartIf(ctx context.Context, bucket, object, uploadID, expectedMD5Sum string, partID int, size int64, data io.Reader, signature *Signature, ifNoneMatch string) (string, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 
-	stfs, err := disk.Stat(fs.path)
+	stfs, err := fs.statDisk()
 	if err != nil {
 		return "", probe.NewError(err)
 	}
@@ -239,16 +534,20 @@ func (fs Filesystem) CreateObjectPart(bucket, object, uploadID, expectedMD5Sum s
 	if partID <= 0 {
 		return "", probe.NewError(errors.New("invalid part id, cannot be zero or less than zero"))
 	}
+	if partID > fs.maxParts {
+		return "", probe.NewError(InvalidPart{})
+	}
 	// check bucket name valid
 	if !IsValidBucket(bucket) {
 		return "", probe.NewError(BucketNameInvalid{Bucket: bucket})
 	}
 
 	// verify object path legal
-	if !IsValidObjectName(object) {
-		return "", probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	if err := validateObjectName(bucket, object); err != nil {
+		return "", err.Trace()
 	}
 
+	object = fs.objectKey(object)
 	if !fs.isValidUploadID(object, uploadID) {
 		return "", probe.NewError(InvalidUploadID{UploadID: uploadID})
 	}
@@ -274,41 +573,62 @@ func (fs Filesystem) CreateObjectPart(bucket, object, uploadID, expectedMD5Sum s
 		}
 	}
 
-	objectPath := filepath.Join(bucketPath, object)
+	objectPath := filepath.Join(bucketPath, fs.diskKey(object))
 	partPath := objectPath + fmt.Sprintf("$%d", partID)
-	partFile, err := atomic.FileCreate(partPath)
+	data = contextReader{ctx: ctx, reader: data}
+
+	// retried upload of an identical part, verify the payload but do not rewrite it
+	if part := fs.getUploadedPart(object, partID); part != nil && isRetriedPart(part, ifNoneMatch, expectedMD5Sum, size) {
+		if fi, err := os.Stat(partPath); err == nil && fi.Size() == part.Size {
+			if err := verifyObjectPart(part.ETag, size, data, signature); err != nil {
+				return "", err.Trace(bucket, object)
+			}
+			return part.ETag, nil
+		}
+	}
+
+	tempFiles := fs.newTempFiles()
+	defer tempFiles.purge()
+	partFile, err := tempFiles.create(partPath)
 	if err != nil {
 		return "", probe.NewError(err)
 	}
 	h := md5.New()
 	sh := sha256.New()
-	mw := io.MultiWriter(partFile, h, sh)
-	_, err = io.CopyN(mw, data, size)
+	partWriter := &diskFullWriter{fs: fs, ctx: ctx, writer: diskWriter(partFile.File)}
+	var mw io.Writer = io.MultiWriter(partWriter, h, sh)
+	skipMD5 := fs.skipsPartMD5(expectedMD5Sum, size, signature)
+	if skipMD5 {
+		mw = partWriter
+	}
+	_, err = fs.copyBuffered(mw, data, size)
 	if err != nil {
-		partFile.CloseAndPurge()
-		return "", probe.NewError(err)
+		return "", fs.diskError(err).Trace(bucket, object)
+	}
+	// the upload may have been completed or aborted while waiting for space
+	if partWriter.waited && !fs.isValidUploadID(object, uploadID) {
+		return "", probe.NewError(InvalidUploadID{UploadID: uploadID})
 	}
 	md5sum := hex.EncodeToString(h.Sum(nil))
 	// Verify if the written object is equal to what is expected, only if it is requested as such
 	if strings.TrimSpace(expectedMD5Sum) != "" {
 		if err := isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), md5sum); err != nil {
-			partFile.CloseAndPurge()
 			return "", probe.NewError(BadDigest{Md5: expectedMD5Sum, Bucket: bucket, Object: object})
 		}
 	}
 	if signature != nil {
-		ok, perr := signature.DoesSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+		ok, perr := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
 		if perr != nil {
-			partFile.CloseAndPurge()
 			return "", perr.Trace()
 		}
 		if !ok {
-			partFile.CloseAndPurge()
 			return "", probe.NewError(SignatureDoesNotMatch{})
 		}
 	}
 	partFile.File.Sync()
-	partFile.Close()
+	if err := tempFiles.commit(partFile); err != nil {
+		return "", fs.diskError(err).Trace(bucket, object)
+	}
 
 	fi, err := os.Stat(partPath)
 	if err != nil {
@@ -316,37 +636,54 @@ func (fs Filesystem) CreateObjectPart(bucket, object, uploadID, expectedMD5Sum s
 	}
 	partMetadata := PartMetadata{}
 	partMetadata.ETag = md5sum
+	if skipMD5 {
+		partMetadata.ETag = unhashedETag(fi.Size(), fi.ModTime())
+	}
 	partMetadata.PartNumber = partID
 	partMetadata.Size = fi.Size()
-	partMetadata.LastModified = fi.ModTime()
+	partMetadata.LastModified = fi.ModTime().UTC()
 
-	multiPartfile, err := os.OpenFile(objectPath+"$multiparts", os.O_RDWR|os.O_APPEND, 0600)
+	// every update appends the whole session, the latest one carries all the parts so far
+	deserializedMultipartSession, perr := fs.reconcileMultipartSession(bucket, object, uploadID)
+	if perr != nil {
+		return "", perr.Trace(bucket, object)
+	}
+	multiPartfile, err := os.OpenFile(objectPath+"$multiparts", os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
 		return "", probe.NewError(err)
 	}
 	defer multiPartfile.Close()
 
-	var deserializedMultipartSession MultipartSession
-	decoder := json.NewDecoder(multiPartfile)
-	err = decoder.Decode(&deserializedMultipartSession)
-	if err != nil {
-		return "", probe.NewError(err)
+	// a part uploaded again replaces the previous one
+	replaced := false
+	for i, part := range deserializedMultipartSession.Parts {
+		if part.PartNumber == partID {
+			deserializedMultipartSession.Parts[i] = &partMetadata
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		deserializedMultipartSession.Parts = append(deserializedMultipartSession.Parts, &partMetadata)
+		deserializedMultipartSession.TotalParts++
 	}
-	deserializedMultipartSession.Parts = append(deserializedMultipartSession.Parts, &partMetadata)
-	deserializedMultipartSession.TotalParts++
-	fs.multiparts.ActiveSession[object] = &deserializedMultipartSession
+	fs.multiparts.ActiveSession[object] = deserializedMultipartSession
 
 	sort.Sort(partNumber(deserializedMultipartSession.Parts))
+	// sessions of earlier versions are appended in the layout of this server, later ones are rejected on read
+	deserializedMultipartSession.Version = multipartSessionVersion
+	deserializedMultipartSession.Bucket = bucket
 	encoder := json.NewEncoder(multiPartfile)
-	err = encoder.Encode(&deserializedMultipartSession)
+	err = encoder.Encode(deserializedMultipartSession)
 	if err != nil {
 		return "", probe.NewError(err)
 	}
 	return partMetadata.ETag, nil
 }
 
-// CompleteMultipartUpload - complete a multipart upload and persist the data
-func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, data io.Reader, signature *Signature) (ObjectMetadata, *probe.Error) {
+// CompleteMultipartUpload - complete a multipart upload and persist the data, the object is
+// purged and the session left as is if the context is done before all parts are written
+func (fs Filesystem) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, data io.Reader, signature *Signature) (ObjectMetadata, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 
@@ -356,10 +693,11 @@ func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, da
 	}
 
 	// verify object path legal
-	if !IsValidObjectName(object) {
-		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	if err := validateObjectName(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
 	}
 
+	object = fs.objectKey(object)
 	if !fs.isValidUploadID(object, uploadID) {
 		return ObjectMetadata{}, probe.NewError(InvalidUploadID{UploadID: uploadID})
 	}
@@ -373,44 +711,99 @@ func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, da
 		return ObjectMetadata{}, probe.NewError(InternalError{})
 	}
 
-	objectPath := filepath.Join(bucketPath, object)
-	file, err := atomic.FileCreate(objectPath)
+	objectPath := filepath.Join(bucketPath, fs.diskKey(object))
+	// locked objects may not be replaced
+	if err := checkObjectRetention(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	tempFiles := fs.newTempFiles()
+	defer tempFiles.purge()
+	file, err := tempFiles.create(objectPath)
 	if err != nil {
 		return ObjectMetadata{}, probe.NewError(err)
 	}
+	// encryption takes precedence, sealed data does not compress
+	var objectWriter io.Writer = file
+	var encrypted *encryptedWriter
+	var compressed *compressedWriter
+	if fs.bucketEncryption(bucket) == EncryptionAES256 {
+		encrypted, err = newEncryptedWriter(file)
+		if err != nil {
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+		objectWriter = encrypted
+	} else if algorithm := fs.bucketCompression(bucket); algorithm != "" {
+		compressed, err = newCompressedWriter(file, algorithm)
+		if err != nil {
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+		objectWriter = compressed
+	}
 	h := md5.New()
-	mw := io.MultiWriter(file, h)
 
-	partBytes, err := ioutil.ReadAll(data)
+	// never read more than maxCompleteMultipartUploadSize, one byte more tells the body is too large
+	partBytes, err := ioutil.ReadAll(io.LimitReader(contextReader{ctx: ctx, reader: data}, maxCompleteMultipartUploadSize+1))
 	if err != nil {
-		file.CloseAndPurge()
 		return ObjectMetadata{}, probe.NewError(err)
 	}
+	if int64(len(partBytes)) > maxCompleteMultipartUploadSize {
+		return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(int64(len(partBytes)), 10),
+			MaxSize:            strconv.FormatInt(maxCompleteMultipartUploadSize, 10),
+		})
+	}
 	if signature != nil {
 		sh := sha256.New()
 		sh.Write(partBytes)
-		ok, perr := signature.DoesSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
+		ok, perr := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sh.Sum(nil)))
 		if perr != nil {
-			file.CloseAndPurge()
 			return ObjectMetadata{}, probe.NewError(err)
 		}
 		if !ok {
-			file.CloseAndPurge()
 			return ObjectMetadata{}, probe.NewError(SignatureDoesNotMatch{})
 		}
 	}
 	parts := &CompleteMultipartUpload{}
 	if err := xml.Unmarshal(partBytes, parts); err != nil {
-		file.CloseAndPurge()
 		return ObjectMetadata{}, probe.NewError(MalformedXML{})
 	}
 	if !sort.IsSorted(completedParts(parts.Part)) {
-		file.CloseAndPurge()
 		return ObjectMetadata{}, probe.NewError(InvalidPartOrder{})
 	}
+	session, perr := fs.reconcileMultipartSession(bucket, object, uploadID)
+	if perr != nil {
+		return ObjectMetadata{}, perr.Trace(bucket, object)
+	}
+	if err := verifyCompleteParts(parts, session, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
 
-	if err := fs.concatParts(parts, objectPath, mw); err != nil {
-		file.CloseAndPurge()
+	if fs.maxObjectSize > 0 {
+		objectSize, err := getPartsSize(parts, objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+		if fs.isObjectTooLarge(objectSize) {
+			return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+				GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+				Size:               strconv.FormatInt(objectSize, 10),
+				MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+			})
+		}
+	}
+
+	// objects stored as is out of parts which were not hashed are not hashed either
+	skipMD5 := encrypted == nil && compressed == nil && fs.skipsObjectMD5(parts)
+	var mw io.Writer = io.MultiWriter(objectWriter, h)
+	if skipMD5 {
+		mw = objectWriter
+	}
+	usage := objectUsage(objectPath)
+	if err := fs.concatParts(ctx, parts, objectPath, mw); err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
 
@@ -418,33 +811,94 @@ func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, da
 	for _, part := range parts.Part {
 		err = os.Remove(objectPath + fmt.Sprintf("$%d", part.PartNumber))
 		if err != nil {
-			file.CloseAndPurge()
 			return ObjectMetadata{}, probe.NewError(err)
 		}
 	}
 	if err := os.Remove(objectPath + "$multiparts"); err != nil {
-		file.CloseAndPurge()
 		return ObjectMetadata{}, probe.NewError(err)
 	}
 	if err := SaveMultipartsSession(fs.multiparts); err != nil {
-		file.CloseAndPurge()
 		return ObjectMetadata{}, err.Trace()
 	}
+	if compressed != nil {
+		if err := compressed.Close(); err != nil {
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+	}
+	// the object replaced is kept as a previous version in versioned buckets
+	versionID, perr := fs.newObjectVersionID(bucket)
+	if perr != nil {
+		return ObjectMetadata{}, perr.Trace(bucket, object)
+	}
+	if _, err := fs.keepObjectVersion(bucket, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := fs.finishObjectEncryption(encrypted, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	md5Sum := hex.EncodeToString(h.Sum(nil))
+	if skipMD5 {
+		// the file keeps its modification time once committed
+		st, err := file.File.Stat()
+		if err != nil {
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+		md5Sum = unhashedETag(st.Size(), st.ModTime())
+	}
+	if err := finishObjectCompression(compressed, objectPath, md5Sum, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := finishObjectChecksum(objectPath, md5Sum, encrypted == nil && compressed == nil, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
 	file.File.Sync()
-	file.Close()
+	if err := tempFiles.commit(file); err != nil {
+		return ObjectMetadata{}, probe.NewError(err)
+	}
+	fs.usage.update(bucket, usage, objectUsage(objectPath))
+	// a new object is of the standard storage class and carries no tags until tagged otherwise
+	if err := removeObjectStorageClass(objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := removeObjectTags(objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := writeObjectVersionID(objectPath, versionID, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := fs.lockNewObject(bucket, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	fs.markImmutable(objectPath)
 
 	st, err := os.Stat(objectPath)
 	if err != nil {
 		return ObjectMetadata{}, probe.NewError(err)
 	}
 	newObject := ObjectMetadata{
-		Bucket:      bucket,
-		Object:      object,
-		Created:     st.ModTime(),
-		Size:        st.Size(),
-		ContentType: "application/octet-stream",
-		Md5:         hex.EncodeToString(h.Sum(nil)),
-	}
+		Bucket:       bucket,
+		Object:       object,
+		Created:      st.ModTime(),
+		Size:         st.Size(),
+		ContentType:  "application/octet-stream",
+		Md5:          md5Sum,
+		StorageClass: StorageClassStandard,
+		VersionID:    versionID,
+	}
+	if compressed != nil {
+		newObject.Size = compressed.size
+		newObject.Compression = compressed.algorithm
+	}
+	if encrypted != nil {
+		newObject.Size = encryptedObjectSize(st.Size())
+		newObject.Md5 = encrypted.ETag()
+		newObject.Encryption = EncryptionAES256
+	}
+	retainUntil, perr := readObjectRetention(objectPath)
+	if perr != nil {
+		return ObjectMetadata{}, perr.Trace(bucket, object)
+	}
+	newObject.RetainUntil = retainUntil
 	return newObject, nil
 }
 
@@ -463,9 +917,7 @@ func (fs Filesystem) ListObjectParts(bucket, object string, resources ObjectReso
 		return ObjectResourcesMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
 	}
 
-	if !fs.isValidUploadID(object, resources.UploadID) {
-		return ObjectResourcesMetadata{}, probe.NewError(InvalidUploadID{UploadID: resources.UploadID})
-	}
+	object = fs.objectKey(object)
 
 	objectResourcesMetadata := resources
 	objectResourcesMetadata.Bucket = bucket
@@ -488,31 +940,35 @@ func (fs Filesystem) ListObjectParts(bucket, object string, resources ObjectReso
 		return ObjectResourcesMetadata{}, probe.NewError(InternalError{})
 	}
 
-	objectPath := filepath.Join(bucketPath, object)
-	multiPartfile, err := os.OpenFile(objectPath+"$multiparts", os.O_RDONLY, 0600)
-	if err != nil {
-		return ObjectResourcesMetadata{}, probe.NewError(err)
-	}
-	defer multiPartfile.Close()
-
-	var deserializedMultipartSession MultipartSession
-	decoder := json.NewDecoder(multiPartfile)
-	err = decoder.Decode(&deserializedMultipartSession)
-	if err != nil {
-		return ObjectResourcesMetadata{}, probe.NewError(err)
+	objectPath := filepath.Join(bucketPath, fs.diskKey(object))
+	deserializedMultipartSession, perr := fs.reconcileMultipartSession(bucket, object, resources.UploadID)
+	if perr != nil {
+		return ObjectResourcesMetadata{}, perr.Trace(bucket, object)
 	}
+	sort.Sort(partNumber(deserializedMultipartSession.Parts))
 	var parts []*PartMetadata
-	for i := startPartNumber; i <= deserializedMultipartSession.TotalParts; i++ {
-		if len(parts) > objectResourcesMetadata.MaxParts {
-			sort.Sort(partNumber(parts))
+	for _, part := range deserializedMultipartSession.Parts {
+		if part.PartNumber < startPartNumber {
+			continue
+		}
+		if objectResourcesMetadata.MaxParts > 0 && len(parts) >= objectResourcesMetadata.MaxParts {
 			objectResourcesMetadata.IsTruncated = true
-			objectResourcesMetadata.Part = parts
-			objectResourcesMetadata.NextPartNumberMarker = i
-			return objectResourcesMetadata, nil
+			objectResourcesMetadata.NextPartNumberMarker = part.PartNumber
+			break
+		}
+		if err := verifyPartFile(objectPath, part); err != nil {
+			return ObjectResourcesMetadata{}, err.Trace(bucket, object)
+		}
+		// sessions written by older versions may lack the timestamp, clients fail to parse a zero one
+		if part.LastModified.IsZero() {
+			fi, err := os.Stat(objectPath + fmt.Sprintf("$%d", part.PartNumber))
+			if err != nil {
+				return ObjectResourcesMetadata{}, probe.NewError(err)
+			}
+			part.LastModified = fi.ModTime().UTC()
 		}
-		parts = append(parts, deserializedMultipartSession.Parts[i-1])
+		parts = append(parts, part)
 	}
-	sort.Sort(partNumber(parts))
 	objectResourcesMetadata.Part = parts
 	return objectResourcesMetadata, nil
 }
@@ -532,6 +988,7 @@ func (fs Filesystem) AbortMultipartUpload(bucket, object, uploadID string) *prob
 		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
 	}
 
+	object = fs.objectKey(object)
 	if !fs.isValidUploadID(object, uploadID) {
 		return probe.NewError(InvalidUploadID{UploadID: uploadID})
 	}
@@ -546,7 +1003,7 @@ func (fs Filesystem) AbortMultipartUpload(bucket, object, uploadID string) *prob
 		return probe.NewError(InternalError{})
 	}
 
-	objectPath := filepath.Join(bucketPath, object)
+	objectPath := filepath.Join(bucketPath, fs.diskKey(object))
 	for _, part := range fs.multiparts.ActiveSession[object].Parts {
 		err = os.RemoveAll(objectPath + fmt.Sprintf("$%d", part.PartNumber))
 		if err != nil {
@@ -21,18 +21,19 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"hash"
 	"runtime"
+	"time"
 
-	"github.com/minio/minio-xl/pkg/atomic"
 	"github.com/minio/minio-xl/pkg/crypto/sha256"
 	"github.com/minio/minio-xl/pkg/probe"
-	"github.com/minio/minio/pkg/disk"
 )
 
 /// Object Operations
@@ -52,7 +53,13 @@ func (fs Filesystem) GetObject(w io.Writer, bucket, object string, start, length
 		return 0, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
 	}
 
-	objectPath := filepath.Join(fs.path, bucket, object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	return fs.readObject(w, bucket, object, objectPath, start, length)
+}
+
+// readObject - write length bytes of the object at objectPath from start on to w, all of them if
+// length is not positive, caller holds the lock
+func (fs Filesystem) readObject(w io.Writer, bucket, object, objectPath string, start, length int64) (int64, *probe.Error) {
 	filestat, err := os.Stat(objectPath)
 	switch err := err.(type) {
 	case nil:
@@ -70,24 +77,62 @@ func (fs Filesystem) GetObject(w io.Writer, bucket, object string, start, length
 		return 0, probe.NewError(err)
 	}
 	defer file.Close()
+	if fs.sequentialHint {
+		hintSequentialRead(file)
+	}
 
-	_, err = file.Seek(start, os.SEEK_SET)
+	encryption, perr := readObjectEncryption(objectPath)
+	if perr != nil {
+		return 0, perr.Trace(bucket, object)
+	}
+	var reader io.Reader
+	if encryption != nil {
+		dataKey, perr := openDataKey(fs.masterKey, encryption.SealedKey)
+		if perr != nil {
+			return 0, perr.Trace(bucket, object)
+		}
+		reader, err = newDecryptedReader(file, dataKey, start)
+	} else {
+		compression, perr := readObjectCompression(objectPath)
+		if perr != nil {
+			return 0, perr.Trace(bucket, object)
+		}
+		reader, err = newObjectReader(file, start, compression)
+	}
 	if err != nil {
 		return 0, probe.NewError(err)
 	}
 
+	// full reads of objects stored as is are verified against their md5sum, compressed
+	// and encrypted objects are verified by gzip and GCM themselves. Objects which were not
+	// hashed have no md5sum to verify against.
+	var checksum hash.Hash
+	var expectedMD5Sum string
+	if r, ok := reader.(*os.File); ok && r == file && start == 0 && (length <= 0 || length == filestat.Size()) {
+		if expectedMD5Sum, perr = readObjectChecksum(objectPath); perr != nil {
+			return 0, perr.Trace(bucket, object)
+		}
+		if expectedMD5Sum != "" && !isUnhashedETag(expectedMD5Sum) {
+			checksum = md5.New()
+			reader = io.TeeReader(reader, checksum)
+		}
+	}
+
 	var count int64
 	if length > 0 {
-		count, err = io.CopyN(w, file, length)
+		count, err = io.CopyN(w, reader, length)
 		if err != nil {
 			return count, probe.NewError(err)
 		}
 	} else {
-		count, err = io.Copy(w, file)
+		count, err = io.Copy(w, reader)
 		if err != nil {
 			return count, probe.NewError(err)
 		}
 	}
+	if checksum != nil && expectedMD5Sum != hex.EncodeToString(checksum.Sum(nil)) {
+		return count, probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
 	return count, nil
 }
 
@@ -104,13 +149,140 @@ func (fs Filesystem) GetObjectMetadata(bucket, object string) (ObjectMetadata, *
 		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: bucket})
 	}
 
-	metadata, err := getMetadata(fs.path, bucket, object)
+	metadata, err := fs.getObjectMetadata(bucket, object)
 	if err != nil {
 		return ObjectMetadata{}, err.Trace(bucket, object)
 	}
 	if metadata.Mode.IsDir() {
 		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
 	}
+	if fs.bucketVersioning(bucket) != "" {
+		objectPath := filepath.Join(fs.path, bucket, fs.diskKey(fs.objectKey(object)))
+		if metadata.VersionID, err = readObjectVersionID(objectPath); err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+	}
+	return metadata, nil
+}
+
+// CheckPreconditions - verify conditional GET headers against object metadata, returns
+// true if the object is not modified and the request may be answered with '304 Not Modified'.
+// If-None-Match takes precedence over If-Modified-Since, as per RFC 7232.
+func CheckPreconditions(meta ObjectMetadata, ifNoneMatch string, ifModifiedSince time.Time) (notModified bool) {
+	if strings.TrimSpace(ifNoneMatch) != "" {
+		for _, etag := range strings.Split(ifNoneMatch, ",") {
+			etag = strings.TrimSpace(etag)
+			if etag == "*" {
+				return true
+			}
+			// weak comparison, ignore the weak validator prefix
+			etag = strings.Trim(strings.TrimPrefix(etag, "W/"), "\"")
+			if etag != "" && etag == meta.Md5 {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince.IsZero() {
+		return false
+	}
+	// http dates carry only second precision
+	return !meta.Created.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// WritePreconditions - conditions on the current object a write only succeeds under, as requested
+// by If-Match and If-None-Match headers. Empty conditions always hold.
+type WritePreconditions struct {
+	IfMatch     string // comma separated ETags one of which the object has to carry, '*' if it has to exist
+	IfNoneMatch string // comma separated ETags none of which the object may carry, '*' if it may not exist
+}
+
+// hold - whether the conditions hold for the current object, exists is false if there is none
+func (p WritePreconditions) hold(etag string, exists bool) bool {
+	if strings.TrimSpace(p.IfMatch) != "" && (!exists || !matchETag(p.IfMatch, etag, false)) {
+		return false
+	}
+	if strings.TrimSpace(p.IfNoneMatch) != "" && exists && matchETag(p.IfNoneMatch, etag, true) {
+		return false
+	}
+	return true
+}
+
+// matchETag - whether one of a comma separated list of ETags or '*' matches etag, weak ETags only
+// match when compared weakly
+func matchETag(etags, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(etags, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		candidate = strings.Trim(candidate, "\"")
+		if candidate != "" && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// objectETag - ETag of the object at objectPath, exists is false if there is none. Objects written
+// before their md5sum was recorded have it computed.
+func objectETag(objectPath string) (etag string, exists bool, err *probe.Error) {
+	st, e := os.Stat(objectPath)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return "", false, nil
+		}
+		return "", false, probe.NewError(e)
+	}
+	if !st.Mode().IsRegular() {
+		return "", false, nil
+	}
+	encryption, err := readObjectEncryption(objectPath)
+	if err != nil {
+		return "", false, err.Trace(objectPath)
+	}
+	if encryption != nil {
+		return encryption.ETag, true, nil
+	}
+	compression, err := readObjectCompression(objectPath)
+	if err != nil {
+		return "", false, err.Trace(objectPath)
+	}
+	if compression != nil {
+		return compression.MD5Sum, true, nil
+	}
+	md5Sum, err := readObjectChecksum(objectPath)
+	if err != nil {
+		return "", false, err.Trace(objectPath)
+	}
+	if md5Sum == "" {
+		if md5Sum, e = fileMD5Sum(objectPath); e != nil {
+			return "", false, probe.NewError(e)
+		}
+	}
+	return md5Sum, true, nil
+}
+
+// getObjectMetadata - metadata of an object by its key, as opposed to its name on disk
+func (fs Filesystem) getObjectMetadata(bucket, object string) (ObjectMetadata, *probe.Error) {
+	if !fs.portableKeys {
+		return getMetadata(fs.path, bucket, object)
+	}
+	object = fs.objectKey(object)
+	metadata, err := getMetadata(fs.path, bucket, fs.diskKey(object))
+	if err != nil {
+		if _, ok := err.ToGoError().(ObjectNotFound); ok {
+			return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	metadata.Object = object
 	return metadata, nil
 }
 
@@ -143,6 +315,47 @@ func getMetadata(rootPath, bucket, object string) (ObjectMetadata, *probe.Error)
 		ContentType: contentType,
 		Mode:        stat.Mode(),
 	}
+	if stat.Mode().IsRegular() {
+		encryption, err := readObjectEncryption(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		compression, err := readObjectCompression(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		if encryption != nil {
+			metadata.Size = encryptedObjectSize(stat.Size())
+			metadata.Md5 = encryption.ETag
+			metadata.Encryption = encryption.Algorithm
+		} else if compression != nil {
+			metadata.Size = compression.Size
+			metadata.Md5 = compression.MD5Sum
+			metadata.Compression = compression.Algorithm
+		} else {
+			// ETag of objects stored as is, clients send it back on conditional requests
+			md5Sum, err := readObjectChecksum(objectPath)
+			if err != nil {
+				return ObjectMetadata{}, err.Trace(bucket, object)
+			}
+			metadata.Md5 = md5Sum
+		}
+		retainUntil, err := readObjectRetention(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		metadata.RetainUntil = retainUntil
+		storageClass, err := readObjectStorageClass(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		metadata.StorageClass = storageClass
+		tags, err := readObjectTags(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		metadata.TagCount = len(tags)
+	}
 	return metadata, nil
 }
 
@@ -167,10 +380,16 @@ func isMD5SumEqual(expectedMD5Sum, actualMD5Sum string) *probe.Error {
 
 // CreateObject - PUT object
 func (fs Filesystem) CreateObject(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature) (ObjectMetadata, *probe.Error) {
+	return fs.CreateObjectIf(bucket, object, expectedMD5Sum, size, data, signature, WritePreconditions{})
+}
+
+// CreateObjectIf - PUT object only if the current object meets preconditions, PreconditionFailed
+// is returned otherwise before any data is read
+func (fs Filesystem) CreateObjectIf(bucket, object, expectedMD5Sum string, size int64, data io.Reader, signature *Signature, preconditions WritePreconditions) (ObjectMetadata, *probe.Error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 
-	stfs, err := disk.Stat(fs.path)
+	stfs, err := fs.statDisk()
 	if err != nil {
 		return ObjectMetadata{}, probe.NewError(err)
 	}
@@ -185,17 +404,25 @@ func (fs Filesystem) CreateObject(bucket, object, expectedMD5Sum string, size in
 	if !IsValidBucket(bucket) {
 		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
 	}
+	if fs.isObjectTooLarge(size) {
+		return ObjectMetadata{}, probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(size, 10),
+			MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+		})
+	}
 	// check bucket exists
 	if _, err = os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(err) {
 		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
 	}
 	// verify object path legal
-	if !IsValidObjectName(object) {
-		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	if err := validateObjectName(bucket, object); err != nil {
+		return ObjectMetadata{}, err.Trace()
 	}
 
 	// get object path
-	objectPath := filepath.Join(fs.path, bucket, object)
+	object = fs.objectKey(object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
 	if strings.TrimSpace(expectedMD5Sum) != "" {
 		var expectedMD5SumBytes []byte
 		expectedMD5SumBytes, err = base64.StdEncoding.DecodeString(strings.TrimSpace(expectedMD5Sum))
@@ -205,28 +432,70 @@ func (fs Filesystem) CreateObject(bucket, object, expectedMD5Sum string, size in
 		}
 		expectedMD5Sum = hex.EncodeToString(expectedMD5SumBytes)
 	}
+	// objects ending in a slash are folders, filepath.Join stripped the slash off objectPath
+	if isFolderObject(object) {
+		return fs.createFolderObject(bucket, object, objectPath, expectedMD5Sum, size, signature, preconditions)
+	}
+	// locked objects may not be replaced
+	if err := checkObjectRetention(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// the object cannot change until the new one is committed, writes hold the lock throughout
+	if preconditions != (WritePreconditions{}) {
+		etag, exists, err := objectETag(objectPath)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace(bucket, object)
+		}
+		if !preconditions.hold(etag, exists) {
+			return ObjectMetadata{}, probe.NewError(PreconditionFailed{Bucket: bucket, Object: object})
+		}
+	}
 
 	// write object
-	file, err := atomic.FileCreate(objectPath)
+	usage := objectUsage(objectPath)
+	file, err := createAtomicFile(objectPath, fs.modes)
 	if err != nil {
 		return ObjectMetadata{}, probe.NewError(err)
 	}
 
+	// encryption takes precedence, sealed data does not compress
+	var objectWriter io.Writer = file
+	var encrypted *encryptedWriter
+	var compressed *compressedWriter
+	if fs.bucketEncryption(bucket) == EncryptionAES256 {
+		encrypted, err = newEncryptedWriter(file)
+		if err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+		objectWriter = encrypted
+	} else if algorithm := fs.bucketCompression(bucket); algorithm != "" {
+		compressed, err = newCompressedWriter(file, algorithm)
+		if err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+		objectWriter = compressed
+	}
+
 	h := md5.New()
 	sh := sha256.New()
-	mw := io.MultiWriter(file, h, sh)
+	mw := io.MultiWriter(objectWriter, h, sh)
 
 	if size > 0 {
-		_, err = io.CopyN(mw, data, size)
+		_, err = fs.copyBuffered(mw, data, size)
 		if err != nil {
 			file.CloseAndPurge()
-			return ObjectMetadata{}, probe.NewError(err)
+			return ObjectMetadata{}, fs.diskError(err).Trace(bucket, object)
 		}
 	} else {
-		_, err = io.Copy(mw, data)
+		_, err = fs.copyBuffered(mw, data, -1)
 		if err != nil {
 			file.CloseAndPurge()
-			return ObjectMetadata{}, probe.NewError(err)
+			return ObjectMetadata{}, fs.diskError(err).Trace(bucket, object)
 		}
 	}
 
@@ -240,7 +509,7 @@ func (fs Filesystem) CreateObject(bucket, object, expectedMD5Sum string, size in
 	}
 	sha256Sum := hex.EncodeToString(sh.Sum(nil))
 	if signature != nil {
-		ok, perr := signature.DoesSignatureMatch(sha256Sum)
+		ok, perr := signature.DoesPayloadSignatureMatch(sha256Sum)
 		if perr != nil {
 			file.CloseAndPurge()
 			return ObjectMetadata{}, perr.Trace()
@@ -250,24 +519,319 @@ func (fs Filesystem) CreateObject(bucket, object, expectedMD5Sum string, size in
 			return ObjectMetadata{}, probe.NewError(SignatureDoesNotMatch{})
 		}
 	}
+	if compressed != nil {
+		if err := compressed.Close(); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, probe.NewError(err)
+		}
+	}
+	// the object replaced is kept as a previous version in versioned buckets, before the files
+	// next to it are replaced
+	versionID, perr := fs.newObjectVersionID(bucket)
+	if perr != nil {
+		file.CloseAndPurge()
+		return ObjectMetadata{}, perr.Trace(bucket, object)
+	}
+	if _, perr := fs.keepObjectVersion(bucket, objectPath); perr != nil {
+		file.CloseAndPurge()
+		return ObjectMetadata{}, perr.Trace(bucket, object)
+	}
+	if err := fs.finishObjectEncryption(encrypted, objectPath); err != nil {
+		file.CloseAndPurge()
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := finishObjectCompression(compressed, objectPath, md5Sum, fs.modes); err != nil {
+		file.CloseAndPurge()
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := finishObjectChecksum(objectPath, md5Sum, encrypted == nil && compressed == nil, fs.modes); err != nil {
+		file.CloseAndPurge()
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
 	file.File.Sync()
 	file.Close()
+	fs.usage.update(bucket, usage, objectUsage(objectPath))
+	// a new object is of the standard storage class and carries no tags until tagged otherwise
+	if err := removeObjectStorageClass(objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := removeObjectTags(objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := writeObjectVersionID(objectPath, versionID, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if err := fs.lockNewObject(bucket, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
 
 	st, err := os.Stat(objectPath)
 	if err != nil {
 		return ObjectMetadata{}, probe.NewError(err)
 	}
 	newObject := ObjectMetadata{
-		Bucket:      bucket,
-		Object:      object,
-		Created:     st.ModTime(),
-		Size:        st.Size(),
-		ContentType: "application/octet-stream",
-		Md5:         md5Sum,
+		Bucket:       bucket,
+		Object:       object,
+		Created:      st.ModTime(),
+		Size:         st.Size(),
+		ContentType:  "application/octet-stream",
+		Md5:          md5Sum,
+		StorageClass: StorageClassStandard,
+		VersionID:    versionID,
+	}
+	if compressed != nil {
+		newObject.Size = compressed.size
+		newObject.Compression = compressed.algorithm
+	}
+	if encrypted != nil {
+		newObject.Size = encryptedObjectSize(st.Size())
+		newObject.Md5 = encrypted.ETag()
+		newObject.Encryption = EncryptionAES256
+	}
+	retainUntil, perr := readObjectRetention(objectPath)
+	if perr != nil {
+		return ObjectMetadata{}, perr.Trace(bucket, object)
 	}
+	newObject.RetainUntil = retainUntil
 	return newObject, nil
 }
 
+// AppendObject - append data to an object at offset, which has to be the current size of the
+// object so that concurrent appends do not interleave. Objects stored as is are appended to in
+// place, the data already there is only read back to hash it. Compressed and encrypted objects
+// are rewritten atomically in the form they are stored in, as are objects of versioned buckets
+// which are kept as a previous version. Appended objects keep their storage class and tags.
+func (fs Filesystem) AppendObject(bucket, object string, data io.Reader, offset int64) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	stfs, e := fs.statDisk()
+	if e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	// Remove 5% from total space for cumulative disk space used for journalling, inodes etc.
+	availableDiskSpace := (float64(stfs.Free) / (float64(stfs.Total) - (0.05 * float64(stfs.Total)))) * 100
+	if int64(availableDiskSpace) <= fs.minFreeDisk {
+		return ObjectMetadata{}, probe.NewError(RootPathFull{Path: fs.path})
+	}
+
+	// check bucket name valid
+	if !IsValidBucket(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	// check bucket exists
+	if _, e := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(e) {
+		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	// verify object path legal
+	if !IsValidObjectName(object) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+
+	object = fs.objectKey(object)
+	metadata, err := fs.getObjectMetadata(bucket, object)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	if metadata.Mode.IsDir() {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	if offset != metadata.Size {
+		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+	}
+
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	// locked objects may not be appended to
+	if err := checkObjectRetention(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	// the appended object is a new version, the object appended to is kept as a previous one
+	versionID, err := fs.newObjectVersionID(bucket)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+
+	usage := objectUsage(objectPath)
+	if metadata.Compression == "" && metadata.Encryption == "" && fs.bucketVersioning(bucket) == "" {
+		err = fs.appendObjectFile(bucket, object, objectPath, data, offset)
+	} else {
+		err = fs.rewriteAppendedObject(bucket, object, objectPath, metadata, data, offset)
+	}
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	fs.usage.update(bucket, usage, objectUsage(objectPath))
+	if err := writeObjectVersionID(objectPath, versionID, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	// appended objects are locked anew as per the bucket retention
+	if err := fs.lockNewObject(bucket, objectPath); err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+
+	metadata, err = fs.getObjectMetadata(bucket, object)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object)
+	}
+	metadata.VersionID = versionID
+	return metadata, nil
+}
+
+// appendObjectFile - append data in place to an object stored as is, along with its new md5sum.
+// A failed append truncates the object back to offset. Caller holds the lock.
+func (fs Filesystem) appendObjectFile(bucket, object, objectPath string, data io.Reader, offset int64) *probe.Error {
+	file, e := os.OpenFile(objectPath, os.O_RDWR|os.O_APPEND, fs.modes.file)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, e := fs.copyBuffered(h, file, offset); e != nil {
+		return probe.NewError(e)
+	}
+	appended, e := fs.copyBuffered(io.MultiWriter(file, h), data, -1)
+	if e != nil {
+		file.Truncate(offset)
+		return fs.diskError(e).Trace(bucket, object)
+	}
+	if fs.isObjectTooLarge(offset + appended) {
+		file.Truncate(offset)
+		return probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(offset+appended, 10),
+			MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+		})
+	}
+	if e := file.Sync(); e != nil {
+		file.Truncate(offset)
+		return probe.NewError(e)
+	}
+	if err := finishObjectChecksum(objectPath, hex.EncodeToString(h.Sum(nil)), true, fs.modes); err != nil {
+		file.Truncate(offset)
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
+
+// rewriteAppendedObject - rewrite an object along with data appended to it atomically, in the
+// form it is stored in. The object appended to is kept as a previous version in versioned
+// buckets. Caller holds the lock.
+func (fs Filesystem) rewriteAppendedObject(bucket, object, objectPath string, metadata ObjectMetadata, data io.Reader, offset int64) *probe.Error {
+	srcFile, e := os.Open(objectPath)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer srcFile.Close()
+	var reader io.Reader
+	encryption, err := readObjectEncryption(objectPath)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	if encryption != nil {
+		dataKey, err := openDataKey(fs.masterKey, encryption.SealedKey)
+		if err != nil {
+			return err.Trace(bucket, object)
+		}
+		reader, e = newDecryptedReader(srcFile, dataKey, 0)
+	} else {
+		compression, err := readObjectCompression(objectPath)
+		if err != nil {
+			return err.Trace(bucket, object)
+		}
+		reader, e = newObjectReader(srcFile, 0, compression)
+	}
+	if e != nil {
+		return probe.NewError(e)
+	}
+	// storage class and tags go along with a kept version, the appended object keeps them as well
+	storageClass, err := readObjectStorageClass(objectPath)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	tags, err := readObjectTags(objectPath)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+
+	file, e := createAtomicFile(objectPath, fs.modes)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	var objectWriter io.Writer = file
+	var encrypted *encryptedWriter
+	var compressed *compressedWriter
+	if metadata.Encryption != "" {
+		if encrypted, e = newEncryptedWriter(file); e != nil {
+			file.CloseAndPurge()
+			return probe.NewError(e)
+		}
+		objectWriter = encrypted
+	} else if metadata.Compression != "" {
+		if compressed, e = newCompressedWriter(file, metadata.Compression); e != nil {
+			file.CloseAndPurge()
+			return probe.NewError(e)
+		}
+		objectWriter = compressed
+	}
+	h := md5.New()
+	mw := io.MultiWriter(objectWriter, h)
+	if _, e := fs.copyBuffered(mw, reader, offset); e != nil {
+		file.CloseAndPurge()
+		return fs.diskError(e).Trace(bucket, object)
+	}
+	appended, e := fs.copyBuffered(mw, data, -1)
+	if e != nil {
+		file.CloseAndPurge()
+		return fs.diskError(e).Trace(bucket, object)
+	}
+	if fs.isObjectTooLarge(offset + appended) {
+		file.CloseAndPurge()
+		return probe.NewError(EntityTooLarge{
+			GenericObjectError: GenericObjectError{Bucket: bucket, Object: object},
+			Size:               strconv.FormatInt(offset+appended, 10),
+			MaxSize:            strconv.FormatInt(fs.maxObjectSize, 10),
+		})
+	}
+	if compressed != nil {
+		if e := compressed.Close(); e != nil {
+			file.CloseAndPurge()
+			return probe.NewError(e)
+		}
+	}
+	// the object appended to is moved away as a previous version, it is not read any further
+	srcFile.Close()
+	if _, err := fs.keepObjectVersion(bucket, objectPath); err != nil {
+		file.CloseAndPurge()
+		return err.Trace(bucket, object)
+	}
+	md5Sum := hex.EncodeToString(h.Sum(nil))
+	if err := fs.finishObjectEncryption(encrypted, objectPath); err != nil {
+		file.CloseAndPurge()
+		return err.Trace(bucket, object)
+	}
+	if err := finishObjectCompression(compressed, objectPath, md5Sum, fs.modes); err != nil {
+		file.CloseAndPurge()
+		return err.Trace(bucket, object)
+	}
+	if err := finishObjectChecksum(objectPath, md5Sum, encrypted == nil && compressed == nil, fs.modes); err != nil {
+		file.CloseAndPurge()
+		return err.Trace(bucket, object)
+	}
+	file.File.Sync()
+	file.Close()
+	if err := writeObjectStorageClass(objectPath, storageClass, fs.modes); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := writeObjectTags(objectPath, tags, fs.modes); err != nil {
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
+
 func deleteObjectPath(basePath, deletePath, bucket, object string) *probe.Error {
 	if basePath == deletePath {
 		return nil
@@ -322,11 +886,57 @@ func (fs Filesystem) DeleteObject(bucket, object string) *probe.Error {
 
 	// Do not use filepath.Join() since filepath.Join strips off any object names with '/', use them as is
 	// in a static manner so that we can send a proper 'ObjectNotFound' reply back upon os.Stat()
+	object = fs.objectKey(object)
 	var objectPath string
 	if runtime.GOOS == "windows" {
-		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + fs.diskKey(object)
 	} else {
-		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + fs.diskKey(object)
+	}
+	// folders are the directory without the slash, only removed if empty
+	if isFolderObject(object) {
+		objectPath = strings.TrimSuffix(objectPath, folderSuffix)
+	}
+	// locked objects may not be removed
+	if err := checkObjectRetention(bucket, object, objectPath); err != nil {
+		return err.Trace()
+	}
+	if err := fs.checkImmutable(bucket, object, objectPath); err != nil {
+		return err.Trace()
+	}
+	usage := objectUsage(objectPath)
+	// the object removed is kept as a previous version in versioned buckets
+	kept, perr := fs.keepObjectVersion(bucket, objectPath)
+	if perr != nil {
+		return perr.Trace(bucket, object)
+	}
+	if kept {
+		fs.usage.update(bucket, usage, BucketUsage{})
+		return nil
+	}
+	// encryption details go first, object directory would not be empty otherwise
+	if err := removeObjectEncryption(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectCompression(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectChecksum(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectStorageClass(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectTags(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectVersionID(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if isFolderObject(object) {
+		if err := removeFolderMarker(objectPath); err != nil {
+			return err.Trace(bucket, object)
+		}
 	}
 	err := deleteObjectPath(bucketPath, objectPath, bucket, object)
 	if os.IsNotExist(err.ToGoError()) {
@@ -335,5 +945,185 @@ func (fs Filesystem) DeleteObject(bucket, object string) *probe.Error {
 	if err != nil {
 		return err.Trace()
 	}
+	fs.usage.update(bucket, usage, BucketUsage{})
 	return nil
 }
+
+// CopyObject - copy object, metadataDirective is either "COPY" (default) to preserve metadata
+// of the source object or "REPLACE" to replace it, the copy is then of the standard storage class
+// without tags for the caller to apply the requested ones. Copying an object to itself only
+// updates its metadata and hence is allowed only with "REPLACE".
+func (fs Filesystem) CopyObject(destBucket, destObject, srcBucket, srcObject string, metadataDirective string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if metadataDirective == "" {
+		metadataDirective = "COPY"
+	}
+	if metadataDirective != "COPY" && metadataDirective != "REPLACE" {
+		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+	}
+	for _, bucket := range []string{srcBucket, destBucket} {
+		// check bucket name valid
+		if !IsValidBucket(bucket) {
+			return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		}
+		// check bucket exists
+		if _, err := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(err) {
+			return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+	}
+	// verify object path legal
+	if !IsValidObjectName(srcObject) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Bucket: srcBucket, Object: srcObject})
+	}
+	if err := validateObjectName(destBucket, destObject); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+
+	srcObject, destObject = fs.objectKey(srcObject), fs.objectKey(destObject)
+	srcMetadata, err := fs.getObjectMetadata(srcBucket, srcObject)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(srcBucket, srcObject)
+	}
+	if srcMetadata.Mode.IsDir() {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Bucket: srcBucket, Object: srcObject})
+	}
+
+	srcPath := filepath.Join(fs.path, srcBucket, fs.diskKey(srcObject))
+	destPath := filepath.Join(fs.path, destBucket, fs.diskKey(destObject))
+	// locked objects may not be replaced, not even their metadata
+	if err := checkObjectRetention(destBucket, destObject, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := fs.checkImmutable(destBucket, destObject, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	srcFile, e := os.Open(srcPath)
+	if e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	defer srcFile.Close()
+
+	h := md5.New()
+	// a metadata only update keeps the version of the object
+	versionID := srcMetadata.VersionID
+	if srcPath == destPath {
+		if metadataDirective != "REPLACE" {
+			return ObjectMetadata{}, probe.NewError(InvalidCopyRequest{Bucket: destBucket, Object: destObject})
+		}
+		// metadata only update, data stays as is
+		if _, e := io.Copy(h, srcFile); e != nil {
+			return ObjectMetadata{}, probe.NewError(e)
+		}
+		now := time.Now().UTC()
+		if e := os.Chtimes(destPath, now, now); e != nil {
+			return ObjectMetadata{}, probe.NewError(e)
+		}
+	} else {
+		stfs, e := fs.statDisk()
+		if e != nil {
+			return ObjectMetadata{}, probe.NewError(e)
+		}
+		// Remove 5% from total space for cumulative disk space used for journalling, inodes etc.
+		availableDiskSpace := (float64(stfs.Free) / (float64(stfs.Total) - (0.05 * float64(stfs.Total)))) * 100
+		if int64(availableDiskSpace) <= fs.minFreeDisk {
+			return ObjectMetadata{}, probe.NewError(RootPathFull{Path: fs.path})
+		}
+
+		usage := objectUsage(destPath)
+		file, e := createAtomicFile(destPath, fs.modes)
+		if e != nil {
+			return ObjectMetadata{}, probe.NewError(e)
+		}
+		if _, e := fs.copyBuffered(io.MultiWriter(file, h), srcFile, -1); e != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, fs.diskError(e).Trace(destBucket, destObject)
+		}
+		// the object replaced is kept as a previous version in versioned buckets
+		if versionID, err = fs.newObjectVersionID(destBucket); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(destBucket, destObject)
+		}
+		if _, err := fs.keepObjectVersion(destBucket, destPath); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(destBucket, destObject)
+		}
+		// sealed data is copied as is, it stays sealed with the same data key
+		if err := copyObjectEncryption(srcPath, destPath, fs.modes); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(destBucket, destObject)
+		}
+		// compressed data is copied as is, along with its compression details
+		compression, err := readObjectCompression(srcPath)
+		if err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(srcBucket, srcObject)
+		}
+		if err := writeObjectCompression(destPath, compression, fs.modes); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(destBucket, destObject)
+		}
+		storedAsIs := srcMetadata.Compression == "" && srcMetadata.Encryption == ""
+		if err := finishObjectChecksum(destPath, hex.EncodeToString(h.Sum(nil)), storedAsIs, fs.modes); err != nil {
+			file.CloseAndPurge()
+			return ObjectMetadata{}, err.Trace(destBucket, destObject)
+		}
+		file.File.Sync()
+		file.Close()
+		fs.usage.update(destBucket, usage, objectUsage(destPath))
+	}
+	// the copy keeps the storage class and the tags of its source unless they are replaced
+	storageClass, tags := StorageClassStandard, map[string]string{}
+	if metadataDirective == "COPY" {
+		storageClass = srcMetadata.StorageClass
+		if tags, err = readObjectTags(srcPath); err != nil {
+			return ObjectMetadata{}, err.Trace(srcBucket, srcObject)
+		}
+	}
+	if err := writeObjectStorageClass(destPath, storageClass, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	if err := writeObjectTags(destPath, tags, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	if err := writeObjectVersionID(destPath, versionID, fs.modes); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	if err := fs.lockNewObject(destBucket, destPath); err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+
+	st, e := os.Stat(destPath)
+	if e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	newObject := ObjectMetadata{
+		Bucket:       destBucket,
+		Object:       destObject,
+		Created:      st.ModTime(),
+		Size:         st.Size(),
+		ContentType:  srcMetadata.ContentType,
+		Md5:          hex.EncodeToString(h.Sum(nil)),
+		StorageClass: storageClass,
+		TagCount:     len(tags),
+		VersionID:    versionID,
+	}
+	// compressed data is copied as is, along with original size and md5sum
+	if srcMetadata.Compression != "" {
+		newObject.Size = srcMetadata.Size
+		newObject.Md5 = srcMetadata.Md5
+		newObject.Compression = srcMetadata.Compression
+	}
+	if srcMetadata.Encryption != "" {
+		newObject.Size = srcMetadata.Size
+		newObject.Md5 = srcMetadata.Md5
+		newObject.Encryption = srcMetadata.Encryption
+	}
+	retainUntil, err := readObjectRetention(destPath)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(destBucket, destObject)
+	}
+	newObject.RetainUntil = retainUntil
+	return newObject, nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// removeFile - remove a temp file, replaced in tests to simulate failures
+var removeFile = os.Remove
+
+// PurgeLogger - reports a temp file which could not be removed, the removal is retried by PurgeTempFiles
+type PurgeLogger func(tempPath string, err *probe.Error)
+
+// pendingPurges - temp files whose removal failed, waiting for PurgeTempFiles
+type pendingPurges struct {
+	lock  *sync.Mutex
+	paths map[string]struct{}
+}
+
+func newPendingPurges() *pendingPurges {
+	return &pendingPurges{
+		lock:  new(sync.Mutex),
+		paths: make(map[string]struct{}),
+	}
+}
+
+// tempFiles - atomic files created during a single operation, the ones not committed by the
+// time the operation returns are purged
+type tempFiles struct {
+	fs    Filesystem
+	files []*atomicFile
+}
+
+// newTempFiles - registry of atomic files of an operation, purge has to be deferred right away
+func (fs Filesystem) newTempFiles() *tempFiles {
+	return &tempFiles{fs: fs}
+}
+
+// create - create an atomic file for filePath, purged on operation exit unless committed
+func (t *tempFiles) create(filePath string) (*atomicFile, error) {
+	file, err := createAtomicFile(filePath, t.fs.modes)
+	if err != nil {
+		return nil, err
+	}
+	t.files = append(t.files, file)
+	return file, nil
+}
+
+// commit - move file to its destination, a file failing to commit is purged on operation exit
+func (t *tempFiles) commit(file *atomicFile) error {
+	if err := file.Close(); err != nil {
+		return err
+	}
+	for i, f := range t.files {
+		if f == file {
+			t.files = append(t.files[:i], t.files[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// purge - remove every file not committed, failures are logged and kept for PurgeTempFiles to retry
+func (t *tempFiles) purge() {
+	for _, file := range t.files {
+		// a failed commit has closed the file already
+		file.File.Close()
+		if err := removeFile(file.Name()); err != nil && !os.IsNotExist(err) {
+			t.fs.purgeFailed(file.Name(), probe.NewError(err))
+		}
+	}
+	t.files = nil
+}
+
+// purgeFailed - remember a temp file which could not be removed and log it
+func (fs Filesystem) purgeFailed(tempPath string, err *probe.Error) {
+	if fs.purges != nil {
+		fs.purges.lock.Lock()
+		fs.purges.paths[tempPath] = struct{}{}
+		fs.purges.lock.Unlock()
+	}
+	if fs.purgeLogger != nil {
+		fs.purgeLogger(tempPath, err.Trace(tempPath))
+	}
+}
+
+// SetPurgeLogger - set logger of temp files which could not be removed
+func (fs *Filesystem) SetPurgeLogger(logger PurgeLogger) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.purgeLogger = logger
+}
+
+// PurgeTempFiles - retry removing temp files which failed to be removed when their operation
+// returned, files failing again are kept for the next retry. Returns the list of removed files.
+func (fs Filesystem) PurgeTempFiles() ([]string, *probe.Error) {
+	if fs.purges == nil {
+		return nil, nil
+	}
+	fs.purges.lock.Lock()
+	defer fs.purges.lock.Unlock()
+
+	var purged []string
+	var purgeErr *probe.Error
+	for tempPath := range fs.purges.paths {
+		if err := removeFile(tempPath); err != nil && !os.IsNotExist(err) {
+			if purgeErr == nil {
+				purgeErr = probe.NewError(err).Trace(tempPath)
+			}
+			continue
+		}
+		delete(fs.purges.paths, tempPath)
+		purged = append(purged, tempPath)
+	}
+	sort.Strings(purged)
+	return purged, purgeErr
+}
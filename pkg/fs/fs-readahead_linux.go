@@ -0,0 +1,35 @@
+// +build linux,amd64 linux,arm64
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// posixFadvSequential - POSIX_FADV_SEQUENTIAL of fcntl.h, doubles the readahead window of the
+// file on Linux
+const posixFadvSequential = 2
+
+// hintSequentialRead - advise the kernel the file is about to be read sequentially. The hint is
+// advisory only, filesystems not supporting it are read as usual and failures are ignored.
+func hintSequentialRead(file *os.File) {
+	// offset and length of zero cover the whole file
+	syscall.Syscall6(syscall.SYS_FADVISE64, file.Fd(), 0, 0, posixFadvSequential, 0, 0)
+}
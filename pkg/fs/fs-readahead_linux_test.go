@@ -0,0 +1,97 @@
+// +build linux,amd64 linux,arm64
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// posixFadvDontNeed - POSIX_FADV_DONTNEED of fcntl.h, drops clean pages of the file from the
+// page cache
+const posixFadvDontNeed = 4
+
+// dropPageCache - flush the file and drop it from the page cache, so that it is read from disk
+func dropPageCache(b *testing.B, name string) {
+	file, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Sync(); err != nil {
+		b.Fatal(err)
+	}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_FADVISE64, file.Fd(), 0, 0, posixFadvDontNeed, 0, 0); errno != 0 {
+		b.Fatal(errno)
+	}
+}
+
+// benchmarkGetObject - read a 256MB object evicted from the page cache beforehand, with or
+// without hinting the read as sequential
+func benchmarkGetObject(b *testing.B, sequentialHint bool) {
+	configPath, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(configPath)
+	path, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	SetFSMultipartsConfigPath(filepath.Join(configPath, "multiparts-session.json"))
+	SetFSBucketsConfigPath(filepath.Join(configPath, "buckets.json"))
+	fs, perr := New()
+	if perr != nil {
+		b.Fatal(perr)
+	}
+	fs.SetRootPath(path)
+	fs.SetMinFreeDisk(0)
+	fs.SetSequentialReadHint(sequentialHint)
+	if perr := fs.MakeBucket("bucket", ""); perr != nil {
+		b.Fatal(perr)
+	}
+	data := bytes.Repeat([]byte("a"), 256*1024*1024)
+	if _, perr := fs.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil); perr != nil {
+		b.Fatal(perr)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dropPageCache(b, filepath.Join(path, "bucket", "object"))
+		b.StartTimer()
+		if _, perr := fs.GetObject(ioutil.Discard, "bucket", "object", 0, 0); perr != nil {
+			b.Fatal(perr)
+		}
+	}
+}
+
+func BenchmarkGetObjectWithoutHint(b *testing.B) {
+	benchmarkGetObject(b, false)
+}
+
+func BenchmarkGetObjectSequentialHint(b *testing.B) {
+	benchmarkGetObject(b, true)
+}
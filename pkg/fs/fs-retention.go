@@ -0,0 +1,211 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// retentionSuffix - suffix of the file next to a locked object carrying its retention
+const retentionSuffix = "$lock"
+
+// objectRetention - content of the file next to a locked object
+type objectRetention struct {
+	RetainUntil time.Time `json:"retainUntil"`
+}
+
+// isRetentionFile - true if the file carries the retention of an object
+func isRetentionFile(name string) bool {
+	return strings.HasSuffix(name, retentionSuffix)
+}
+
+// readObjectRetention - time until which an object may not be replaced or removed, zero
+// for objects which were never locked
+func readObjectRetention(objectPath string) (time.Time, *probe.Error) {
+	retentionBytes, err := ioutil.ReadFile(objectPath + retentionSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, probe.NewError(err)
+	}
+	retention := &objectRetention{}
+	if err := json.Unmarshal(retentionBytes, retention); err != nil {
+		return time.Time{}, probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	return retention.RetainUntil, nil
+}
+
+// lockObject - make an object read-only until the given time, retention of an object already
+// locked is only ever extended
+func lockObject(objectPath string, retainUntil time.Time, modes fileModes) *probe.Error {
+	current, err := readObjectRetention(objectPath)
+	if err != nil {
+		return err.Trace(objectPath)
+	}
+	if current.After(retainUntil) {
+		retainUntil = current
+	}
+	retentionBytes, e := json.Marshal(objectRetention{RetainUntil: retainUntil.UTC()})
+	if e != nil {
+		return probe.NewError(e)
+	}
+	file, e := createAtomicFile(objectPath+retentionSuffix, modes)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e := file.Write(retentionBytes); e != nil {
+		file.CloseAndPurge()
+		return probe.NewError(e)
+	}
+	if e := file.Close(); e != nil {
+		return probe.NewError(e)
+	}
+	st, e := os.Stat(objectPath)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e := os.Chmod(objectPath, st.Mode()&^0222); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// releaseObject - make an object with an expired retention writable again
+func releaseObject(objectPath string) *probe.Error {
+	st, err := os.Stat(objectPath)
+	if err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	if err == nil {
+		if err := os.Chmod(objectPath, st.Mode()|0200); err != nil {
+			return probe.NewError(err)
+		}
+	}
+	if err := os.Remove(objectPath + retentionSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// checkObjectRetention - fail with ObjectLocked while an object is locked, an object whose
+// retention has passed is released so that it may be replaced or removed
+func checkObjectRetention(bucket, object, objectPath string) *probe.Error {
+	retainUntil, err := readObjectRetention(objectPath)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	if retainUntil.IsZero() {
+		return nil
+	}
+	if time.Now().UTC().Before(retainUntil) {
+		return probe.NewError(ObjectLocked{Bucket: bucket, Object: object, RetainUntil: retainUntil})
+	}
+	return releaseObject(objectPath)
+}
+
+// errLockedObjectFound - stops the walk of checkBucketRetention at the first locked object
+var errLockedObjectFound = errors.New("locked object found")
+
+// checkBucketRetention - fail with ObjectLocked while any object or version under bucketDir is
+// locked, objects whose retention has passed are released so that they may be removed
+func checkBucketRetention(bucket, bucketDir string) *probe.Error {
+	var locked *probe.Error
+	err := filepath.Walk(bucketDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isRetentionFile(info.Name()) {
+			return nil
+		}
+		objectPath := strings.TrimSuffix(path, retentionSuffix)
+		object, err := filepath.Rel(bucketDir, objectPath)
+		if err != nil {
+			return err
+		}
+		if perr := checkObjectRetention(bucket, filepath.ToSlash(object), objectPath); perr != nil {
+			locked = perr
+			return errLockedObjectFound
+		}
+		return nil
+	})
+	if err == errLockedObjectFound {
+		return locked.Trace(bucket)
+	}
+	if err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// lockNewObject - lock an object just written for the default retention of its bucket
+func (fs Filesystem) lockNewObject(bucket, objectPath string) *probe.Error {
+	retention := fs.bucketRetention(bucket)
+	if retention <= 0 {
+		return nil
+	}
+	return lockObject(objectPath, time.Now().UTC().Add(retention), fs.modes)
+}
+
+// bucketRetention - default retention of new objects in a bucket, caller holds the lock
+func (fs Filesystem) bucketRetention(bucket string) time.Duration {
+	if bucketMetadata, ok := fs.buckets.Metadata[bucket]; ok && bucketMetadata != nil {
+		return bucketMetadata.Retention
+	}
+	return 0
+}
+
+// SetObjectRetention - lock an object until the given time, it may neither be replaced nor
+// removed until then. Retention of an object already locked is only ever extended.
+func (fs Filesystem) SetObjectRetention(bucket, object string, retainUntil time.Time) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !retainUntil.After(time.Now().UTC()) {
+		return probe.NewError(InvalidArgument{})
+	}
+	object = fs.objectKey(object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	st, err := os.Stat(objectPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return probe.NewError(err)
+	}
+	if st.IsDir() {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	if err := lockObject(objectPath, retainUntil, fs.modes); err != nil {
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// StorageClassStandard - storage class of objects nobody asked another one for
+const StorageClassStandard = "STANDARD"
+
+// storageClassSuffix - suffix of the file next to an object carrying its storage class, objects
+// of the standard storage class have none
+const storageClassSuffix = "$class"
+
+// validStorageClasses - storage classes objects may be tagged with, all of them are stored alike
+var validStorageClasses = map[string]struct{}{
+	StorageClassStandard: {},
+	"REDUCED_REDUNDANCY": {},
+	"STANDARD_IA":        {},
+	"ONEZONE_IA":         {},
+}
+
+// IsValidStorageClass - verify storage class name
+func IsValidStorageClass(storageClass string) bool {
+	_, ok := validStorageClasses[storageClass]
+	return ok
+}
+
+// isStorageClassFile - true if the file carries the storage class of an object
+func isStorageClassFile(name string) bool {
+	return strings.HasSuffix(name, storageClassSuffix)
+}
+
+// readObjectStorageClass - storage class of an object, standard if it was never set
+func readObjectStorageClass(objectPath string) (string, *probe.Error) {
+	storageClassBytes, err := ioutil.ReadFile(objectPath + storageClassSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return StorageClassStandard, nil
+		}
+		return "", probe.NewError(err)
+	}
+	storageClass := strings.TrimSpace(string(storageClassBytes))
+	if !IsValidStorageClass(storageClass) {
+		return "", probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	return storageClass, nil
+}
+
+// writeObjectStorageClass - tag an object with a storage class, the standard one removes the tag
+func writeObjectStorageClass(objectPath, storageClass string, modes fileModes) *probe.Error {
+	if storageClass == StorageClassStandard {
+		return removeObjectStorageClass(objectPath)
+	}
+	file, err := createAtomicFile(objectPath+storageClassSuffix, modes)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if _, err := file.Write([]byte(storageClass)); err != nil {
+		file.CloseAndPurge()
+		return probe.NewError(err)
+	}
+	if err := file.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// removeObjectStorageClass - remove the storage class tag of an object, if there is any
+func removeObjectStorageClass(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + storageClassSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// SetObjectStorageClass - tag an object with the storage class it was requested with
+func (fs Filesystem) SetObjectStorageClass(bucket, object, storageClass string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !IsValidStorageClass(storageClass) {
+		return probe.NewError(InvalidArgument{})
+	}
+	object = fs.objectKey(object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	st, err := os.Stat(objectPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return probe.NewError(err)
+	}
+	if st.IsDir() {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	if err := writeObjectStorageClass(objectPath, storageClass, fs.modes); err != nil {
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
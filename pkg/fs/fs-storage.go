@@ -0,0 +1,59 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// GetStorageInfo - get disk usage of root path along with bucket and object counts
+func (fs Filesystem) GetStorageInfo() (StorageInfo, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	stfs, err := fs.statDisk()
+	if err != nil {
+		return StorageInfo{}, probe.NewError(err)
+	}
+	storageInfo := StorageInfo{
+		Total: stfs.Total,
+		Free:  stfs.Free,
+		Used:  stfs.Total - stfs.Free,
+	}
+
+	files, err := ioutil.ReadDir(fs.path)
+	if err != nil {
+		return StorageInfo{}, probe.NewError(err)
+	}
+	// objects are counted by the usage cache rather than by walking every bucket
+	if err := fs.seedUsage(); err != nil {
+		return StorageInfo{}, err.Trace()
+	}
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	for _, file := range files {
+		// only directories with valid bucket names are buckets
+		if !file.IsDir() || !IsValidBucket(file.Name()) {
+			continue
+		}
+		storageInfo.Buckets++
+		storageInfo.Objects += fs.usage.buckets[file.Name()].Objects
+	}
+	return storageInfo, nil
+}
@@ -0,0 +1,191 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// MaxObjectTags, MaxTagKeyLength, MaxTagValueLength - limits of the tags of an object as
+// enforced by S3, lengths are in unicode characters
+const (
+	MaxObjectTags     = 10
+	MaxTagKeyLength   = 128
+	MaxTagValueLength = 256
+)
+
+// taggingSuffix - suffix of the file next to an object carrying its tags, objects without
+// tags have none
+const taggingSuffix = "$tags"
+
+// objectTagging - content of the file next to a tagged object
+type objectTagging struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// isTaggingFile - true if the file carries the tags of an object
+func isTaggingFile(name string) bool {
+	return strings.HasSuffix(name, taggingSuffix)
+}
+
+// CheckObjectTags - verify tags against the limits of S3, keys prefixed with aws: are reserved
+func CheckObjectTags(tags map[string]string) *probe.Error {
+	if len(tags) > MaxObjectTags {
+		return probe.NewError(InvalidTag{Reason: "Object tags cannot be greater than 10."})
+	}
+	for key, value := range tags {
+		if key == "" || utf8.RuneCountInString(key) > MaxTagKeyLength || strings.HasPrefix(key, "aws:") {
+			return probe.NewError(InvalidTag{Reason: "The TagKey you have provided is invalid."})
+		}
+		if utf8.RuneCountInString(value) > MaxTagValueLength {
+			return probe.NewError(InvalidTag{Reason: "The TagValue you have provided is invalid."})
+		}
+	}
+	return nil
+}
+
+// readObjectTags - tags of an object, none if it was never tagged
+func readObjectTags(objectPath string) (map[string]string, *probe.Error) {
+	taggingBytes, err := ioutil.ReadFile(objectPath + taggingSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, probe.NewError(err)
+	}
+	tagging := &objectTagging{}
+	if err := json.Unmarshal(taggingBytes, tagging); err != nil {
+		return nil, probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	if tagging.Tags == nil {
+		tagging.Tags = map[string]string{}
+	}
+	return tagging.Tags, nil
+}
+
+// writeObjectTags - replace the tags of an object, no tags remove the file carrying them
+func writeObjectTags(objectPath string, tags map[string]string, modes fileModes) *probe.Error {
+	if len(tags) == 0 {
+		return removeObjectTags(objectPath)
+	}
+	taggingBytes, e := json.Marshal(objectTagging{Tags: tags})
+	if e != nil {
+		return probe.NewError(e)
+	}
+	file, e := createAtomicFile(objectPath+taggingSuffix, modes)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e := file.Write(taggingBytes); e != nil {
+		file.CloseAndPurge()
+		return probe.NewError(e)
+	}
+	if e := file.Close(); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// removeObjectTags - remove the tags of an object, if there are any
+func removeObjectTags(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + taggingSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// taggedObjectPath - path of an existing object whose tags are accessed, caller holds the lock
+func (fs Filesystem) taggedObjectPath(bucket, object string) (string, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return "", probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return "", probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if _, err := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(err) {
+		return "", probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	object = fs.objectKey(object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	st, err := os.Stat(objectPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return "", probe.NewError(err)
+	}
+	if st.IsDir() {
+		return "", probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	return objectPath, nil
+}
+
+// PutObjectTagging - replace all the tags of an object, tags may be changed on locked objects
+func (fs Filesystem) PutObjectTagging(bucket, object string, tags map[string]string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := CheckObjectTags(tags); err != nil {
+		return err.Trace(bucket, object)
+	}
+	objectPath, err := fs.taggedObjectPath(bucket, object)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := writeObjectTags(objectPath, tags, fs.modes); err != nil {
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
+
+// GetObjectTagging - tags of an object, empty if it has none
+func (fs Filesystem) GetObjectTagging(bucket, object string) (map[string]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	objectPath, err := fs.taggedObjectPath(bucket, object)
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	tags, err := readObjectTags(objectPath)
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	return tags, nil
+}
+
+// DeleteObjectTagging - remove all the tags of an object
+func (fs Filesystem) DeleteObjectTagging(bucket, object string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	objectPath, err := fs.taggedObjectPath(bucket, object)
+	if err != nil {
+		return err.Trace(bucket, object)
+	}
+	if err := removeObjectTags(objectPath); err != nil {
+		return err.Trace(bucket, object)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// DefaultUsageSaveInterval, DefaultUsageReconcileInterval - cached bucket usage is persisted
+// every minute, and corrected by a full walk every hour for changes made behind its back
+const (
+	DefaultUsageSaveInterval      = time.Minute
+	DefaultUsageReconcileInterval = time.Hour
+)
+
+// Usage - usage of every bucket of a root path, as persisted across restarts
+type Usage struct {
+	Version string                 `json:"version"`
+	Path    string                 `json:"path"`
+	Buckets map[string]BucketUsage `json:"buckets"`
+}
+
+// usageCache - usage of every bucket, seeded by a walk of the root path and updated by every
+// write and delete of an object from then on. Updates are made under the filesystem lock, the
+// mutex only guards against concurrent saves.
+type usageCache struct {
+	mutex   *sync.Mutex
+	seeded  bool
+	dirty   bool
+	buckets map[string]BucketUsage
+}
+
+func newUsageCache() *usageCache {
+	return &usageCache{
+		mutex:   &sync.Mutex{},
+		buckets: make(map[string]BucketUsage),
+	}
+}
+
+// set - replace the usage of every bucket
+func (u *usageCache) set(buckets map[string]BucketUsage) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.buckets = buckets
+	u.seeded = true
+	u.dirty = true
+}
+
+// update - account for an object going from before to after, nothing is accounted for until
+// the cache is seeded
+func (u *usageCache) update(bucket string, before, after BucketUsage) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if !u.seeded || before == after {
+		return
+	}
+	usage := u.buckets[bucket]
+	usage.Objects += after.Objects - before.Objects
+	usage.Size += after.Size - before.Size
+	u.buckets[bucket] = usage
+	u.dirty = true
+}
+
+// remove - forget the usage of a removed bucket
+func (u *usageCache) remove(bucket string) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if _, ok := u.buckets[bucket]; ok {
+		delete(u.buckets, bucket)
+		u.dirty = true
+	}
+}
+
+// isObjectFile - true if name is the data of an object, as opposed to the files kept next to it
+func isObjectFile(name string) bool {
+	return !isSidecarFile(name)
+}
+
+// objectUsage - usage of the object at objectPath, zero if there is none
+func objectUsage(objectPath string) BucketUsage {
+	st, err := os.Stat(objectPath)
+	if err != nil || !st.Mode().IsRegular() {
+		return BucketUsage{}
+	}
+	return BucketUsage{Objects: 1, Size: st.Size()}
+}
+
+// walkUsage - usage of every bucket of the root path as found on disk
+func (fs Filesystem) walkUsage() (map[string]BucketUsage, *probe.Error) {
+	files, err := ioutil.ReadDir(fs.path)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	buckets := make(map[string]BucketUsage)
+	for _, file := range files {
+		// only directories with valid bucket names are buckets
+		if !file.IsDir() || !IsValidBucket(file.Name()) {
+			continue
+		}
+		var usage BucketUsage
+		countObject := func(fp string, fl os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// previous versions of objects are not counted
+			if fl.IsDir() && isVersionsDir(fl.Name()) {
+				return ErrSkipDir
+			}
+			if fl.Mode().IsRegular() && isObjectFile(fl.Name()) {
+				usage.Objects++
+				usage.Size += fl.Size()
+			}
+			return nil
+		}
+		if err := WalkUnsorted(filepath.Join(fs.path, file.Name()), countObject); err != nil {
+			return nil, probe.NewError(err)
+		}
+		buckets[file.Name()] = usage
+	}
+	return buckets, nil
+}
+
+// seedUsage - walk the root path for the usage of every bucket unless it is cached already,
+// has to be called under the filesystem lock
+func (fs Filesystem) seedUsage() *probe.Error {
+	fs.usage.mutex.Lock()
+	seeded := fs.usage.seeded
+	fs.usage.mutex.Unlock()
+	if seeded {
+		return nil
+	}
+	buckets, err := fs.walkUsage()
+	if err != nil {
+		return err.Trace()
+	}
+	fs.usage.set(buckets)
+	return nil
+}
+
+// InitUsage - seed the usage of every bucket from the usage saved last, so that restarts need
+// no walk of the root path. The root path is walked if no usage was saved for it, or if the
+// saved usage cannot be read as it is a cache only.
+func (fs Filesystem) InitUsage() *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	usage, err := loadUsage()
+	if err == nil && usage.Path == fs.path {
+		buckets := usage.Buckets
+		if buckets == nil {
+			buckets = make(map[string]BucketUsage)
+		}
+		fs.usage.set(buckets)
+		return nil
+	}
+	return fs.seedUsage()
+}
+
+// GetBucketUsage - objects and bytes stored in a bucket, without walking it once cached
+func (fs Filesystem) GetBucketUsage(bucket string) (BucketUsage, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return BucketUsage{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if _, err := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(err) {
+		return BucketUsage{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	if err := fs.seedUsage(); err != nil {
+		return BucketUsage{}, err.Trace()
+	}
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	return fs.usage.buckets[bucket], nil
+}
+
+// SaveUsage - persist the usage of every bucket if it changed since saved last
+func (fs Filesystem) SaveUsage() *probe.Error {
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	if !fs.usage.seeded || !fs.usage.dirty {
+		return nil
+	}
+	usage := &Usage{
+		Version: "1",
+		Path:    fs.path,
+		Buckets: fs.usage.buckets,
+	}
+	if err := saveUsage(usage); err != nil {
+		return err.Trace()
+	}
+	fs.usage.dirty = false
+	return nil
+}
+
+// ReconcileUsage - replace the cached usage of every bucket by a walk of the root path, which
+// blocks writes while in progress. Returns the buckets whose cached usage was off.
+func (fs Filesystem) ReconcileUsage() ([]string, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	buckets, err := fs.walkUsage()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	fs.usage.mutex.Lock()
+	var drifted []string
+	if fs.usage.seeded {
+		// buckets missing on either side are empty
+		for bucket, usage := range buckets {
+			if fs.usage.buckets[bucket] != usage {
+				drifted = append(drifted, bucket)
+			}
+		}
+		for bucket, cached := range fs.usage.buckets {
+			if _, ok := buckets[bucket]; !ok && cached != (BucketUsage{}) {
+				drifted = append(drifted, bucket)
+			}
+		}
+	}
+	fs.usage.mutex.Unlock()
+	fs.usage.set(buckets)
+	sort.Strings(drifted)
+	return drifted, nil
+}
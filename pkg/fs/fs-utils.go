@@ -22,6 +22,8 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/minio/minio-xl/pkg/probe"
 )
@@ -188,3 +190,36 @@ func walk(path string, info os.FileInfo, walkFn WalkFunc) error {
 	}
 	return nil
 }
+
+// isSidecarFile - true if the file is kept next to an object rather than being its data: multipart
+// sessions and parts, data keys, compression, checksums, retention, storage classes, tags, version
+// ids and folder markers
+func isSidecarFile(name string) bool {
+	return isMultipartFile(name) || isEncryptionFile(name) || isCompressionFile(name) || isChecksumFile(name) || isRetentionFile(name) ||
+		isStorageClassFile(name) || isTaggingFile(name) || isVersionIDFile(name) || isFolderMarkerFile(name)
+}
+
+// isMultipartFile - is the file name a multipart session or a part file.
+func isMultipartFile(name string) bool {
+	if strings.HasSuffix(name, "$multiparts") {
+		return true
+	}
+	i := strings.LastIndex(name, "$")
+	if i < 0 {
+		return false
+	}
+	_, err := strconv.Atoi(name[i+1:])
+	return err == nil
+}
+
+// validateObjectName - ObjectNameTooLong for the name of an object written which is longer than
+// MaxObjectNameLength bytes, ObjectNameInvalid for any other name IsValidObjectName rejects
+func validateObjectName(bucket, object string) *probe.Error {
+	if len(object) > MaxObjectNameLength {
+		return probe.NewError(ObjectNameTooLong{Bucket: bucket, Object: object})
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	return nil
+}
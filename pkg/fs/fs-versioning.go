@@ -0,0 +1,339 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// VersioningEnabled, VersioningSuspended - versioning states of a bucket as in S3, buckets
+// versioning was never enabled on have none
+const (
+	VersioningEnabled   = "Enabled"
+	VersioningSuspended = "Suspended"
+)
+
+// NullVersionID - version id of objects written while versioning was not enabled on their bucket
+const NullVersionID = "null"
+
+const (
+	// versionsSuffix - suffix of the directory next to an object keeping its previous versions,
+	// each named by its version id along with the files next to it
+	versionsSuffix = "$versions"
+	// versionIDSuffix - suffix of the file next to an object carrying its version id, objects
+	// without one are the null version
+	versionIDSuffix = "$vid"
+)
+
+// versionedSuffixes - suffixes of the files next to an object which go along with it when it
+// becomes a previous version
+var versionedSuffixes = []string{encryptionSuffix, compressionSuffix, checksumSuffix, retentionSuffix, storageClassSuffix, taggingSuffix, versionIDSuffix}
+
+// isVersionsDir - true if the directory keeps the previous versions of an object
+func isVersionsDir(name string) bool {
+	return strings.HasSuffix(name, versionsSuffix)
+}
+
+// isVersionIDFile - true if the file carries the version id of an object
+func isVersionIDFile(name string) bool {
+	return strings.HasSuffix(name, versionIDSuffix)
+}
+
+// IsValidVersioning - verify versioning state a bucket is put in, versioning cannot be disabled
+// once enabled, only suspended
+func IsValidVersioning(status string) bool {
+	return status == VersioningEnabled || status == VersioningSuspended
+}
+
+// IsValidVersionID - verify version id, ids are never paths
+func IsValidVersionID(versionID string) bool {
+	if versionID == NullVersionID {
+		return true
+	}
+	if len(versionID) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(versionID)
+	return err == nil
+}
+
+// newVersionID - unique version id, ids of later versions sort after those of earlier ones
+func newVersionID() (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016x", time.Now().UnixNano()) + hex.EncodeToString(random), nil
+}
+
+// versionPath - path of a previous version of the object at objectPath
+func versionPath(objectPath, versionID string) string {
+	return filepath.Join(objectPath+versionsSuffix, versionID)
+}
+
+// readObjectVersionID - version id of the object at objectPath, the null version if it has none
+func readObjectVersionID(objectPath string) (string, *probe.Error) {
+	versionIDBytes, err := ioutil.ReadFile(objectPath + versionIDSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NullVersionID, nil
+		}
+		return "", probe.NewError(err)
+	}
+	versionID := strings.TrimSpace(string(versionIDBytes))
+	if !IsValidVersionID(versionID) {
+		return "", probe.NewError(ObjectCorrupted{Object: objectPath})
+	}
+	return versionID, nil
+}
+
+// writeObjectVersionID - record the version id of an object just written, the null version and
+// objects of buckets versioning was never enabled on have none
+func writeObjectVersionID(objectPath, versionID string, modes fileModes) *probe.Error {
+	if versionID == "" || versionID == NullVersionID {
+		return removeObjectVersionID(objectPath)
+	}
+	file, e := createAtomicFile(objectPath+versionIDSuffix, modes)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e := file.Write([]byte(versionID)); e != nil {
+		file.CloseAndPurge()
+		return probe.NewError(e)
+	}
+	if e := file.Close(); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// removeObjectVersionID - remove the version id of an object, if it has one
+func removeObjectVersionID(objectPath string) *probe.Error {
+	if err := os.Remove(objectPath + versionIDSuffix); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// removeVersionFiles - remove a version along with the files next to it, if there is one
+func removeVersionFiles(versionPath string) *probe.Error {
+	for _, suffix := range append([]string{""}, versionedSuffixes...) {
+		if err := os.Remove(versionPath + suffix); err != nil && !os.IsNotExist(err) {
+			return probe.NewError(err)
+		}
+	}
+	return nil
+}
+
+// bucketVersioning - versioning state of a bucket, empty if versioning was never enabled on it,
+// caller holds the lock
+func (fs Filesystem) bucketVersioning(bucket string) string {
+	if bucketMetadata, ok := fs.buckets.Metadata[bucket]; ok && bucketMetadata != nil {
+		return bucketMetadata.Versioning
+	}
+	return ""
+}
+
+// newObjectVersionID - version id of an object about to be written, a new one while versioning
+// is enabled, the null version while it is suspended and empty if it was never enabled
+func (fs Filesystem) newObjectVersionID(bucket string) (string, *probe.Error) {
+	switch fs.bucketVersioning(bucket) {
+	case VersioningEnabled:
+		versionID, e := newVersionID()
+		if e != nil {
+			return "", probe.NewError(e)
+		}
+		return versionID, nil
+	case VersioningSuspended:
+		return NullVersionID, nil
+	}
+	return "", nil
+}
+
+// keepObjectVersion - keep the object at objectPath as a previous version before it is replaced
+// or removed, returns false if it was not kept. Objects are not kept in buckets versioning was
+// never enabled on, and the null version is not kept while versioning is suspended as the
+// object replacing it is the null version once more.
+func (fs Filesystem) keepObjectVersion(bucket, objectPath string) (bool, *probe.Error) {
+	versioning := fs.bucketVersioning(bucket)
+	if versioning == "" {
+		return false, nil
+	}
+	if versioning == VersioningSuspended {
+		if err := removeVersionFiles(versionPath(objectPath, NullVersionID)); err != nil {
+			return false, err.Trace(objectPath)
+		}
+	}
+	st, e := os.Stat(objectPath)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return false, nil
+		}
+		return false, probe.NewError(e)
+	}
+	if !st.Mode().IsRegular() {
+		return false, nil
+	}
+	versionID, err := readObjectVersionID(objectPath)
+	if err != nil {
+		return false, err.Trace(objectPath)
+	}
+	if versioning == VersioningSuspended && versionID == NullVersionID {
+		return false, nil
+	}
+	if e := fs.modes.mkdirAll(objectPath + versionsSuffix); e != nil {
+		return false, probe.NewError(e)
+	}
+	keptPath := versionPath(objectPath, versionID)
+	// files next to the object go first, an earlier null version must not keep any of its own
+	for _, suffix := range versionedSuffixes {
+		if _, e := os.Stat(objectPath + suffix); os.IsNotExist(e) {
+			if e := os.Remove(keptPath + suffix); e != nil && !os.IsNotExist(e) {
+				return false, probe.NewError(e)
+			}
+			continue
+		}
+		if e := fs.moveFile(objectPath+suffix, keptPath+suffix); e != nil {
+			return false, fs.diskError(e).Trace(objectPath)
+		}
+	}
+	if e := fs.moveFile(objectPath, keptPath); e != nil {
+		return false, fs.diskError(e).Trace(objectPath)
+	}
+	return true, nil
+}
+
+// objectVersionPath - path of a version of an object, the current object if it is the version
+// asked for, caller holds the lock
+func (fs Filesystem) objectVersionPath(bucket, object, versionID string) (string, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return "", probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return "", probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	}
+	if !IsValidVersionID(versionID) {
+		return "", probe.NewError(InvalidArgument{})
+	}
+	if _, e := os.Stat(filepath.Join(fs.path, bucket)); os.IsNotExist(e) {
+		return "", probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	object = fs.objectKey(object)
+	objectPath := filepath.Join(fs.path, bucket, fs.diskKey(object))
+	if st, e := os.Stat(objectPath); e == nil && st.Mode().IsRegular() {
+		currentID, err := readObjectVersionID(objectPath)
+		if err != nil {
+			return "", err.Trace(bucket, object)
+		}
+		if currentID == versionID {
+			return objectPath, nil
+		}
+	}
+	keptPath := versionPath(objectPath, versionID)
+	st, e := os.Stat(keptPath)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return "", probe.NewError(ObjectVersionNotFound{Bucket: bucket, Object: object, VersionID: versionID})
+		}
+		return "", probe.NewError(e)
+	}
+	if !st.Mode().IsRegular() {
+		return "", probe.NewError(ObjectVersionNotFound{Bucket: bucket, Object: object, VersionID: versionID})
+	}
+	return keptPath, nil
+}
+
+// SetBucketVersioning - enable or suspend versioning of a bucket. Objects replaced or removed
+// while versioning is enabled are kept as previous versions, objects written while it is
+// suspended are the null version and replace the null version kept before.
+func (fs Filesystem) SetBucketVersioning(bucket, status string) *probe.Error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidVersioning(status) {
+		return probe.NewError(InvalidArgument{})
+	}
+	bucketDir := filepath.Join(fs.path, bucket)
+	fi, err := os.Stat(bucketDir)
+	if err != nil {
+		// check if bucket exists
+		if os.IsNotExist(err) {
+			return probe.NewError(BucketNotFound{Bucket: bucket})
+		}
+		return probe.NewError(err)
+	}
+	bucketMetadata, ok := fs.buckets.Metadata[bucket]
+	if !ok {
+		bucketMetadata = &BucketMetadata{}
+		bucketMetadata.Name = fi.Name()
+		bucketMetadata.Created = fi.ModTime()
+		bucketMetadata.ACL = BucketACL("private")
+	}
+	bucketMetadata.Versioning = status
+	fs.buckets.Metadata[bucket] = bucketMetadata
+	if err := SaveBucketsMetadata(fs.buckets); err != nil {
+		return err.Trace(bucket)
+	}
+	return nil
+}
+
+// GetObjectVersion - GET a version of an object, the current object or a previous version
+func (fs Filesystem) GetObjectVersion(w io.Writer, bucket, object, versionID string, start, length int64) (int64, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	objectPath, err := fs.objectVersionPath(bucket, object, versionID)
+	if err != nil {
+		return 0, err.Trace(bucket, object, versionID)
+	}
+	return fs.readObject(w, bucket, object, objectPath, start, length)
+}
+
+// GetObjectVersionMetadata - HEAD a version of an object, the current object or a previous version
+func (fs Filesystem) GetObjectVersionMetadata(bucket, object, versionID string) (ObjectMetadata, *probe.Error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	objectPath, err := fs.objectVersionPath(bucket, object, versionID)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object, versionID)
+	}
+	// metadata is read by the path relative to the bucket, versions have no key of their own
+	relPath, e := filepath.Rel(filepath.Join(fs.path, bucket), objectPath)
+	if e != nil {
+		return ObjectMetadata{}, probe.NewError(e)
+	}
+	metadata, err := getMetadata(fs.path, bucket, relPath)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace(bucket, object, versionID)
+	}
+	metadata.Object = fs.objectKey(object)
+	metadata.VersionID = versionID
+	return metadata, nil
+}
@@ -17,7 +17,9 @@
 package fs
 
 import (
+	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -26,13 +28,52 @@ import (
 
 // Filesystem - local variables
 type Filesystem struct {
-	path        string
-	minFreeDisk int64
-	lock        *sync.Mutex
-	multiparts  *Multiparts
-	buckets     *Buckets
+	path              string
+	minFreeDisk       int64
+	maxParts          int
+	maxSessions       int           // active multipart sessions allowed per bucket, unlimited if zero
+	maxObjectSize     int64         // maximum size of an object, unlimited if zero
+	masterKey         []byte        // seals data keys of encrypted objects
+	diskRetries       int           // retries of a disk stat failing with a transient error
+	diskRetryBackoff  time.Duration // wait before the first retry
+	diskFullWait      time.Duration // parts writing to a full disk wait for space this long, failing right away if zero
+	concatConcurrency int           // parts read ahead while completing a multipart upload
+	writeBufferSize   int           // size of the buffer objects and parts are written through
+	purgeLogger       PurgeLogger   // reports temp files which could not be removed
+	modes             fileModes     // permissions of created files and directories
+	portableKeys      bool          // object keys are normalized and encoded on disk, see fs-keys.go
+	sequentialHint    bool          // objects are read with a sequential readahead hint, see fs-readahead_linux.go
+	folderObjects     bool          // objects ending in a slash are kept as directories, see fs-folders.go
+	skipPartMD5       bool          // parts uploaded unverified are not hashed, see fs-etag.go
+	immutableWindow   time.Duration // objects completed by multipart uploads are immutable this long, see fs-immutable.go
+	purges            *pendingPurges
+	usage             *usageCache // usage of every bucket, see fs-usage.go
+	recentUploads     *recentUploads
+	lock              *sync.Mutex
+	multiparts        *Multiparts
+	buckets           *Buckets
 }
 
+// DefaultMaxParts - maximum part number allowed in a multipart upload, as capped by S3
+const DefaultMaxParts = 10000
+
+// DefaultMaxMultipartSessions - active multipart sessions allowed per bucket, every session
+// holds a session file and its parts on disk until completed or aborted
+const DefaultMaxMultipartSessions = 10000
+
+// DefaultConcatConcurrency - parts read ahead while completing a multipart upload
+const DefaultConcatConcurrency = 4
+
+// DefaultWriteBufferSize - size of the buffer objects and parts are written through, as io.Copy uses
+const DefaultWriteBufferSize = 32 * 1024
+
+// MinWriteBufferSize, MaxWriteBufferSize - bounds of the write buffer size, every write in flight
+// holds a buffer of its own
+const (
+	MinWriteBufferSize = 4 * 1024
+	MaxWriteBufferSize = 16 * 1024 * 1024
+)
+
 // Buckets holds acl information
 type Buckets struct {
 	Version  string `json:"version"`
@@ -41,6 +82,8 @@ type Buckets struct {
 
 // MultipartSession holds active session information
 type MultipartSession struct {
+	Version    string
+	Bucket     string
 	TotalParts int
 	UploadID   string
 	Initiated  time.Time
@@ -88,6 +131,17 @@ func New() (Filesystem, *probe.Error) {
 		}
 	}
 	a := Filesystem{lock: new(sync.Mutex)}
+	a.maxParts = DefaultMaxParts
+	a.maxSessions = DefaultMaxMultipartSessions
+	a.diskRetries = DefaultDiskRetries
+	a.diskRetryBackoff = DefaultDiskRetryBackoff
+	a.concatConcurrency = DefaultConcatConcurrency
+	a.writeBufferSize = DefaultWriteBufferSize
+	a.modes = defaultFileModes
+	a.folderObjects = true
+	a.purges = newPendingPurges()
+	a.usage = newUsageCache()
+	a.recentUploads = newRecentUploads()
 	a.multiparts = multiparts
 	a.buckets = buckets
 	return a, nil
@@ -98,6 +152,7 @@ func (fs *Filesystem) SetRootPath(path string) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
 	fs.path = path
+	fs.modes.tempDir = filepath.Join(path, atomicTempDir)
 }
 
 // SetMinFreeDisk - set min free disk
@@ -106,3 +161,141 @@ func (fs *Filesystem) SetMinFreeDisk(minFreeDisk int64) {
 	defer fs.lock.Unlock()
 	fs.minFreeDisk = minFreeDisk
 }
+
+// SetMaxParts - set maximum part number allowed per multipart upload
+func (fs *Filesystem) SetMaxParts(maxParts int) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxParts = maxParts
+}
+
+// SetMaxMultipartSessions - set active multipart sessions allowed per bucket, zero disables the limit
+func (fs *Filesystem) SetMaxMultipartSessions(maxSessions int) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxSessions = maxSessions
+}
+
+// SetMaxObjectSize - set maximum size of an object, zero disables the limit
+func (fs *Filesystem) SetMaxObjectSize(maxObjectSize int64) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.maxObjectSize = maxObjectSize
+}
+
+// SetConcatConcurrency - set number of parts read ahead while completing a multipart upload,
+// every part read ahead is held in memory until written
+func (fs *Filesystem) SetConcatConcurrency(concurrency int) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.concatConcurrency = concurrency
+}
+
+// SetWriteBufferSize - set size of the buffer objects and parts are written through, larger
+// buffers suit large sequential writes on fast disks
+func (fs *Filesystem) SetWriteBufferSize(size int) *probe.Error {
+	if size < MinWriteBufferSize || size > MaxWriteBufferSize {
+		return probe.NewError(InvalidWriteBufferSize{Size: size})
+	}
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.writeBufferSize = size
+	return nil
+}
+
+// SetFileModes - set permissions of created objects, parts and their directories, applied
+// regardless of the process umask
+func (fs *Filesystem) SetFileModes(fileMode, dirMode os.FileMode) *probe.Error {
+	if err := validateFileModes(fileMode, dirMode); err != nil {
+		return err.Trace()
+	}
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.modes = fileModes{file: fileMode, dir: dirMode, tempDir: fs.modes.tempDir}
+	return nil
+}
+
+// SetPortableKeys - normalize object keys and encode them on disk, such that keys differing
+// in case only do not collide on case-insensitive filesystems. Objects stored without portable
+// keys whose keys encode differently are not found once they are enabled, and the other way round.
+func (fs *Filesystem) SetPortableKeys(portable bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.portableKeys = portable
+}
+
+// SetSequentialReadHint - advise the kernel objects are read sequentially before streaming them,
+// which widens readahead for large objects on Linux. Other platforms ignore the hint.
+func (fs *Filesystem) SetSequentialReadHint(enabled bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.sequentialHint = enabled
+}
+
+// SetFolderObjects - keep objects ending in a slash as directories, such that the folders tools
+// create as empty objects list as common prefixes. Such objects are rejected if disabled.
+func (fs *Filesystem) SetFolderObjects(enabled bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.folderObjects = enabled
+}
+
+// SetSkipPartMD5 - skip md5sums of parts uploaded without Content-MD5 nor a signed payload, and
+// of objects completed out of such parts. Their ETags are not md5sums then, which breaks clients
+// verifying ETags against the data, see fs-etag.go.
+func (fs *Filesystem) SetSkipPartMD5(enabled bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.skipPartMD5 = enabled
+}
+
+// SetDiskRetry - set retries and initial backoff of disk stats failing with a transient error,
+// zero retries fail right away
+func (fs *Filesystem) SetDiskRetry(retries int, backoff time.Duration) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.diskRetries = retries
+	fs.diskRetryBackoff = backoff
+}
+
+// SetDiskFullWait - set how long a part upload finding the disk full waits for space to be
+// reclaimed before failing, zero fails right away
+func (fs *Filesystem) SetDiskFullWait(wait time.Duration) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.diskFullWait = wait
+}
+
+// SetMasterKey - set master key sealing data keys of encrypted objects
+func (fs *Filesystem) SetMasterKey(masterKey []byte) *probe.Error {
+	if len(masterKey) != MasterKeySize {
+		return probe.NewError(InvalidMasterKey{})
+	}
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.masterKey = masterKey
+	return nil
+}
+
+// isObjectTooLarge - verify size against configured maximum object size
+func (fs Filesystem) isObjectTooLarge(size int64) bool {
+	return fs.maxObjectSize > 0 && size > fs.maxObjectSize
+}
+
+// copyBuffered - copy size bytes from data to w through a buffer of the configured write buffer
+// size, all of data if size is negative. Like io.CopyN fewer bytes than size return io.EOF
+func (fs Filesystem) copyBuffered(w io.Writer, data io.Reader, size int64) (int64, error) {
+	bufferSize := fs.writeBufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultWriteBufferSize
+	}
+	buffer := make([]byte, bufferSize)
+	if size < 0 {
+		return io.CopyBuffer(w, data, buffer)
+	}
+	written, err := io.CopyBuffer(w, io.LimitReader(data, size), buffer)
+	if written < size && err == nil {
+		err = io.EOF
+	}
+	return written, err
+}
@@ -0,0 +1,278 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// StreamingPayload - x-amz-content-sha256 value for aws-chunked uploads
+	StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	// StreamingPayloadTrailer - x-amz-content-sha256 value for aws-chunked uploads followed by
+	// signed trailing headers
+	StreamingPayloadTrailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	// StreamingUnsignedPayloadTrailer - x-amz-content-sha256 value for aws-chunked uploads of
+	// unsigned chunks followed by trailing headers
+	StreamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	chunkAuthPrefix                 = "AWS4-HMAC-SHA256-PAYLOAD"
+	trailerAuthPrefix               = "AWS4-HMAC-SHA256-TRAILER"
+	// trailing header carrying the signature of the trailing headers before it
+	trailerSignatureHeader = "x-amz-trailer-signature"
+	// maximum size of a single chunk, chunks are buffered for verification
+	maxChunkSize = 16 * 1024 * 1024
+)
+
+// trailerChecksums - checksums which may be declared in x-amz-trailer, values are base64 encoded
+var trailerChecksums = map[string]func() hash.Hash{
+	"x-amz-checksum-crc32":  func() hash.Hash { return crc32.NewIEEE() },
+	"x-amz-checksum-crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"x-amz-checksum-sha1":   sha1.New,
+	"x-amz-checksum-sha256": sha256.New,
+}
+
+// getChunkSignature - signature of a single chunk chained to the previous signature
+//   - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+func (r Signature) getChunkSignature(t time.Time, previousSignature string, chunk []byte) string {
+	stringToSign := strings.Join([]string{
+		chunkAuthPrefix,
+		t.Format(iso8601Format),
+		r.getScope(t),
+		previousSignature,
+		hex.EncodeToString(sha256.Sum256([]byte(""))),
+		hex.EncodeToString(sha256.Sum256(chunk)),
+	}, "\n")
+	return r.getSignature(r.getSigningKey(t), stringToSign)
+}
+
+// getTrailerSignature - signature of the trailing headers chained to the signature of the final chunk,
+// trailers are signed as 'name:value\n' lines
+//   - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html
+func (r Signature) getTrailerSignature(t time.Time, previousSignature string, trailers []byte) string {
+	stringToSign := strings.Join([]string{
+		trailerAuthPrefix,
+		t.Format(iso8601Format),
+		r.getScope(t),
+		previousSignature,
+		hex.EncodeToString(sha256.Sum256(trailers)),
+	}, "\n")
+	return r.getSignature(r.getSigningKey(t), stringToSign)
+}
+
+// chunkedReader - decodes aws-chunked framing, verifying chunk signatures if a signature is set
+// and the checksum declared in x-amz-trailer against the one trailing the final chunk
+type chunkedReader struct {
+	reader            *bufio.Reader
+	signature         *Signature
+	date              time.Time
+	previousSignature string
+	buffer            []byte
+	eof               bool
+	trailers          bool      // final chunk is followed by trailing headers
+	trailer           string    // trailing header carrying the checksum, empty if none was declared
+	checksum          hash.Hash // checksum of the decoded payload
+}
+
+// NewChunkedReader - returns a reader decoding aws-chunked payload of the request body, trailer is
+// the checksum header declared in x-amz-trailer if any. If signature is not nil the seed signature
+// of the request is verified upfront and signed chunks later on while reading.
+func NewChunkedReader(data io.Reader, trailer string, signature *Signature) (io.Reader, *probe.Error) {
+	chunked := &chunkedReader{
+		reader: bufio.NewReader(data),
+	}
+	if trailer = strings.ToLower(strings.TrimSpace(trailer)); trailer != "" {
+		newChecksum, ok := trailerChecksums[trailer]
+		if !ok {
+			return nil, probe.NewError(InvalidTrailer{Trailer: trailer})
+		}
+		chunked.trailers = true
+		chunked.trailer = trailer
+		chunked.checksum = newChecksum()
+	}
+	if signature == nil {
+		return chunked, nil
+	}
+	payload := signature.Request.Header.Get(http.CanonicalHeaderKey("x-amz-content-sha256"))
+	switch payload {
+	case StreamingPayloadTrailer, StreamingUnsignedPayloadTrailer:
+		chunked.trailers = true
+	default:
+		payload = StreamingPayload
+	}
+	ok, err := signature.DoesSignatureMatch(payload)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if !ok {
+		return nil, probe.NewError(SignatureDoesNotMatch{})
+	}
+	if payload == StreamingUnsignedPayloadTrailer {
+		return chunked, nil
+	}
+	var date string
+	if date = signature.Request.Header.Get(http.CanonicalHeaderKey("x-amz-date")); date == "" {
+		if date = signature.Request.Header.Get("Date"); date == "" {
+			return nil, probe.NewError(MissingDateHeader{})
+		}
+	}
+	t, e := time.Parse(iso8601Format, date)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	chunked.signature = signature
+	chunked.date = t
+	chunked.previousSignature = signature.Signature
+	return chunked, nil
+}
+
+// readChunk - read next chunk of style
+//
+//	<hex size>;chunk-signature=<signature>\r\n
+//	<data>\r\n
+//
+// the signature is left out for unsigned chunks, trailing headers take the place of the data
+// of the final chunk if declared
+func (c *chunkedReader) readChunk() error {
+	header, err := c.reader.ReadString('\n')
+	if err != nil {
+		return IncompleteBody{}
+	}
+	header = strings.TrimSuffix(header, "\r\n")
+	splits := strings.SplitN(header, ";", 2)
+	var chunkSignature string
+	if len(splits) == 2 {
+		if !strings.HasPrefix(splits[1], "chunk-signature=") {
+			return IncompleteBody{}
+		}
+		chunkSignature = strings.TrimPrefix(splits[1], "chunk-signature=")
+	} else if c.signature != nil {
+		return IncompleteBody{}
+	}
+	size, err := strconv.ParseInt(splits[0], 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return IncompleteBody{}
+	}
+	var chunk []byte
+	if size > 0 || !c.trailers {
+		chunk = make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, chunk); err != nil {
+			return IncompleteBody{}
+		}
+		if !bytes.HasSuffix(chunk, []byte("\r\n")) {
+			return IncompleteBody{}
+		}
+		chunk = chunk[:size]
+	}
+	if c.signature != nil {
+		if c.signature.getChunkSignature(c.date, c.previousSignature, chunk) != chunkSignature {
+			return SignatureDoesNotMatch{}
+		}
+		c.previousSignature = chunkSignature
+	}
+	if c.checksum != nil {
+		c.checksum.Write(chunk)
+	}
+	if size == 0 {
+		if c.trailers {
+			if err := c.readTrailers(); err != nil {
+				return err
+			}
+		}
+		c.eof = true
+	}
+	c.buffer = chunk
+	return nil
+}
+
+// readTrailers - read trailing headers up to an empty line, verifying their signature for signed
+// chunks and the declared checksum against the decoded payload
+func (c *chunkedReader) readTrailers() error {
+	var trailers bytes.Buffer
+	var checksum, trailerSignature string
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return IncompleteBody{}
+		}
+		line = strings.TrimSuffix(line, "\r\n")
+		if line == "" {
+			break
+		}
+		splits := strings.SplitN(line, ":", 2)
+		if len(splits) != 2 {
+			return IncompleteBody{}
+		}
+		name, value := strings.ToLower(strings.TrimSpace(splits[0])), strings.TrimSpace(splits[1])
+		if name == trailerSignatureHeader {
+			trailerSignature = value
+			continue
+		}
+		if name == c.trailer {
+			checksum = value
+		}
+		trailers.WriteString(name + ":" + value + "\n")
+	}
+	if c.signature != nil {
+		if c.signature.getTrailerSignature(c.date, c.previousSignature, trailers.Bytes()) != trailerSignature {
+			return SignatureDoesNotMatch{}
+		}
+	}
+	if c.checksum != nil {
+		if checksum == "" {
+			return IncompleteBody{}
+		}
+		if base64.StdEncoding.EncodeToString(c.checksum.Sum(nil)) != checksum {
+			return BadDigest{}
+		}
+	}
+	return nil
+}
+
+func (c *chunkedReader) Read(p []byte) (n int, err error) {
+	for len(c.buffer) == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		if err := c.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, c.buffer)
+	c.buffer = c.buffer[n:]
+	// read ahead once the chunk is drained, so that the final chunk along with its trailing
+	// headers is verified even by readers which stop at the decoded length
+	if len(c.buffer) == 0 && !c.eof {
+		if err := c.readChunk(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fs
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSigningKeyTTL - default time signing keys are reused for after being derived
+const DefaultSigningKeyTTL = 15 * time.Minute
+
+// signingKeyScope - credential scope a signing key is derived for
+type signingKeyScope struct {
+	accessKeyID string
+	date        string
+	region      string
+}
+
+// signingKeyEntry - signing key derived from secretAccessKey, reused until expires
+type signingKeyEntry struct {
+	secretAccessKey string
+	signingKey      []byte
+	expires         time.Time
+}
+
+// SigningKeyCache - signing keys derived for signatures V4, reused across requests of the same
+// access key, date and region so that every part of a multipart upload does not go through the
+// chain of HMACs again. Keys are only reused with the secret key they were derived from, and
+// all of them are dropped on Purge when credentials change.
+type SigningKeyCache struct {
+	mutex   *sync.Mutex
+	ttl     time.Duration
+	entries map[signingKeyScope]signingKeyEntry
+}
+
+// NewSigningKeyCache - signing key cache reusing keys for ttl, keys are derived on every
+// request if ttl is not positive
+func NewSigningKeyCache(ttl time.Duration) *SigningKeyCache {
+	return &SigningKeyCache{
+		mutex:   &sync.Mutex{},
+		ttl:     ttl,
+		entries: make(map[signingKeyScope]signingKeyEntry),
+	}
+}
+
+// SetTTL - reuse keys for ttl from now on, keys are derived on every request if ttl is not
+// positive
+func (c *SigningKeyCache) SetTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ttl = ttl
+	c.entries = make(map[signingKeyScope]signingKeyEntry)
+}
+
+// Purge - drop every signing key, those of rotated credentials may not verify requests anymore
+func (c *SigningKeyCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[signingKeyScope]signingKeyEntry)
+}
+
+// signingKey - signing key of scope for secretAccessKey, derived if none was cached for it
+func (c *SigningKeyCache) signingKey(scope signingKeyScope, secretAccessKey string, derive func() []byte) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.ttl <= 0 {
+		return derive()
+	}
+	now := time.Now()
+	if entry, ok := c.entries[scope]; ok && entry.secretAccessKey == secretAccessKey && now.Before(entry.expires) {
+		return entry.signingKey
+	}
+	// scopes are few, a day and a region or two per access key, expired ones are dropped as
+	// new ones come in
+	for cachedScope, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, cachedScope)
+		}
+	}
+	signingKey := derive()
+	c.entries[scope] = signingKeyEntry{
+		secretAccessKey: secretAccessKey,
+		signingKey:      signingKey,
+		expires:         now.Add(c.ttl),
+	}
+	return signingKey
+}
@@ -41,6 +41,9 @@ type Signature struct {
 	PresignedPolicy string
 	SignedHeaders   []string
 	Signature       string
+	ClockSkew       time.Duration    // allowed request time skew, DefaultClockSkew if not set
+	Region          string           // region of the credential scope, DefaultRegion if not set
+	SigningKeys     *SigningKeyCache // signing keys reused across requests, derived every time if nil
 	Request         *http.Request
 }
 
@@ -50,6 +53,16 @@ const (
 	yyyymmdd         = "20060102"
 )
 
+// DefaultClockSkew - default allowed difference between request time and server time
+const DefaultClockSkew = 15 * time.Minute
+
+// DefaultRegion - region of the credential scope unless the signature names another
+const DefaultRegion = "milkyway"
+
+// UnsignedPayload - x-amz-content-sha256 value of requests whose payload is not part of the
+// signature, presigned requests are always signed that way
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
 // sumHMAC calculate hmac between two input byte array
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
@@ -201,16 +214,24 @@ func (r Signature) getPresignedCanonicalRequest(presignedQuery string) string {
 		rawQuery,
 		r.getCanonicalHeaders(r.extractSignedHeaders()),
 		r.getSignedHeaders(r.extractSignedHeaders()),
-		"UNSIGNED-PAYLOAD",
+		UnsignedPayload,
 	}, "\n")
 	return canonicalRequest
 }
 
+// getRegion - region of the credential scope
+func (r Signature) getRegion() string {
+	if r.Region == "" {
+		return DefaultRegion
+	}
+	return r.Region
+}
+
 // getScope generate a string of a specific date, an AWS region, and a service
 func (r Signature) getScope(t time.Time) string {
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
-		"milkyway",
+		r.getRegion(),
 		"s3",
 		"aws4_request",
 	}, "/")
@@ -227,19 +248,66 @@ func (r Signature) getStringToSign(canonicalRequest string, t time.Time) string
 
 // getSigningKey hmac seed to calculate final signature
 func (r Signature) getSigningKey(t time.Time) []byte {
+	if r.SigningKeys == nil {
+		return r.deriveSigningKey(t)
+	}
+	scope := signingKeyScope{accessKeyID: r.AccessKeyID, date: t.Format(yyyymmdd), region: r.getRegion()}
+	return r.SigningKeys.signingKey(scope, r.SecretAccessKey, func() []byte {
+		return r.deriveSigningKey(t)
+	})
+}
+
+// deriveSigningKey - derive the signing key of the day of t through the chain of HMACs
+func (r Signature) deriveSigningKey(t time.Time) []byte {
 	secret := r.SecretAccessKey
 	date := sumHMAC([]byte("AWS4"+secret), []byte(t.Format(yyyymmdd)))
-	region := sumHMAC(date, []byte("milkyway"))
+	region := sumHMAC(date, []byte(r.getRegion()))
 	service := sumHMAC(region, []byte("s3"))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 	return signingKey
 }
 
+// isWithinClockSkew - is request time within the allowed skew of server time
+func (r Signature) isWithinClockSkew(t time.Time) bool {
+	clockSkew := r.ClockSkew
+	if clockSkew <= 0 {
+		clockSkew = DefaultClockSkew
+	}
+	skew := time.Now().UTC().Sub(t)
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew <= clockSkew
+}
+
 // getSignature final signature in hexadecimal form
 func (r Signature) getSignature(signingKey []byte, stringToSign string) string {
 	return hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
 }
 
+// SignRequest - sign request with signature v4 using AccessKeyID and SecretAccessKey, all
+// request headers are signed along with host. Sets Authorization header on the request.
+func (r *Signature) SignRequest(hashedPayload string, t time.Time) {
+	r.Request.Header.Set("X-Amz-Date", t.UTC().Format(iso8601Format))
+	r.Request.Header.Set("X-Amz-Content-Sha256", hashedPayload)
+	r.Request.Header.Del("Authorization")
+
+	r.SignedHeaders = nil
+	for k := range r.Request.Header {
+		r.SignedHeaders = append(r.SignedHeaders, strings.ToLower(k))
+	}
+	canonicalRequest := r.getCanonicalRequest()
+	stringToSign := r.getStringToSign(canonicalRequest, t.UTC())
+	signingKey := r.getSigningKey(t.UTC())
+	r.Signature = r.getSignature(signingKey, stringToSign)
+
+	r.Request.Header.Set("Authorization", strings.Join([]string{
+		authHeaderPrefix + " Credential=" + r.AccessKeyID + "/" + r.getScope(t.UTC()),
+		"SignedHeaders=" + r.getSignedHeaders(r.extractSignedHeaders()),
+		"Signature=" + r.Signature,
+	}, ", "))
+}
+
 // DoesPolicySignatureMatch - Verify query headers with post policy
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
@@ -297,6 +365,16 @@ func (r *Signature) DoesPresignedSignatureMatch() (bool, *probe.Error) {
 	return true, nil
 }
 
+// DoesPayloadSignatureMatch - Verify authorization header of a request whose payload hashes to
+// hashedPayload. Requests declaring an UNSIGNED-PAYLOAD have every other part of the request
+// verified, as the payload is not covered by their signature.
+func (r *Signature) DoesPayloadSignatureMatch(hashedPayload string) (bool, *probe.Error) {
+	if r.Request.Header.Get("X-Amz-Content-Sha256") == UnsignedPayload {
+		return r.DoesSignatureMatch(UnsignedPayload)
+	}
+	return r.DoesSignatureMatch(hashedPayload)
+}
+
 // DoesSignatureMatch - Verify authorization header with calculated header in accordance with
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
@@ -315,6 +393,9 @@ func (r *Signature) DoesSignatureMatch(hashedPayload string) (bool, *probe.Error
 	if err != nil {
 		return false, probe.NewError(err)
 	}
+	if !r.isWithinClockSkew(t) {
+		return false, probe.NewError(RequestTimeTooSkewed{})
+	}
 	canonicalRequest := r.getCanonicalRequest()
 	stringToSign := r.getStringToSign(canonicalRequest, t)
 	signingKey := r.getSigningKey(t)
@@ -18,21 +18,54 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	router "github.com/gorilla/mux"
+	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio/pkg/fs"
 )
 
 // CloudStorageAPI container for API and also carries OP (operation) channel
 type CloudStorageAPI struct {
-	Filesystem fs.Filesystem
-	Anonymous  bool // do not checking for incoming signatures, allow all requests
-	AccessLog  bool // if true log all incoming request
+	ObjectAPI       ObjectLayer
+	Anonymous       bool                // deprecated, do not checking for incoming signatures, allow all requests
+	AnonymousRead   anonymousReadPolicy // buckets readable without signature
+	IPFilter        *ipFilter           // source addresses requests are accepted from, every one if nil
+	TrustedProxies  trustedProxies      // proxies whose forwarded client addresses are trusted
+	AccessLog       bool                // if true log all incoming request
+	AccessLogSample float64             // fraction of successful requests logged, failed ones are always logged
+	Notifier        *eventNotifier      // posts object events to bucket webhooks, disabled if nil
+	StorageClass    string              // storage class of new objects requested with none, standard if empty
+	Bandwidth       *bandwidthLimiter   // throttles object uploads and downloads, disabled if nil
+	Uploads         *uploadLimiter      // turns away uploads beyond the ones allowed in flight, disabled if nil
+	Browser         bool                // serve the bucket browser, if compiled in
+	MaxBodyBytes    int64               // bytes of request bodies other than parts accepted, unlimited if zero
+	Chaos           *chaosConfig        // faults injected into requests for testing clients, disabled if nil
+	ContentTypes    contentTypes        // content types of objects by extension, the defaults if nil
 }
 
 // registerCloudStorageAPI - register all the handlers to their respective paths
 func registerCloudStorageAPI(mux *router.Router, a CloudStorageAPI) {
 	root := mux.NewRoute().PathPrefix("/").Subrouter()
+	// admin routes go first, bucket routes would match them otherwise
+	admin := root.PathPrefix(adminPathPrefix).Subrouter()
+	admin.Methods("GET").Path("/storage").HandlerFunc(a.StorageInfoHandler)
+	admin.Methods("GET").Path("/maintenance").HandlerFunc(a.MaintenanceModeHandler)
+	admin.Methods("PUT").Path("/maintenance").HandlerFunc(a.MaintenanceModeHandler)
+	admin.Methods("DELETE").Path("/maintenance").HandlerFunc(a.MaintenanceModeHandler)
+	admin.Methods("GET").Path("/compression/{bucket}").HandlerFunc(a.BucketCompressionHandler)
+	admin.Methods("PUT").Path("/compression/{bucket}").HandlerFunc(a.BucketCompressionHandler)
+	admin.Methods("DELETE").Path("/compression/{bucket}").HandlerFunc(a.BucketCompressionHandler)
+	admin.Methods("GET").Path("/encryption/{bucket}").HandlerFunc(a.BucketEncryptionHandler)
+	admin.Methods("PUT").Path("/encryption/{bucket}").HandlerFunc(a.BucketEncryptionHandler)
+	admin.Methods("DELETE").Path("/encryption/{bucket}").HandlerFunc(a.BucketEncryptionHandler)
+	admin.Methods("GET").Path("/retention/{bucket}").HandlerFunc(a.BucketRetentionHandler)
+	admin.Methods("PUT").Path("/retention/{bucket}").HandlerFunc(a.BucketRetentionHandler)
+	admin.Methods("DELETE").Path("/retention/{bucket}").HandlerFunc(a.BucketRetentionHandler)
+	admin.Methods("GET").Path("/notification/{bucket}").HandlerFunc(a.BucketNotificationHandler)
+	admin.Methods("PUT").Path("/notification/{bucket}").HandlerFunc(a.BucketNotificationHandler)
+	admin.Methods("DELETE").Path("/notification/{bucket}").HandlerFunc(a.BucketNotificationHandler)
+
 	bucket := root.PathPrefix("/{bucket}").Subrouter()
 
 	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(a.HeadObjectHandler)
@@ -41,17 +74,29 @@ func registerCloudStorageAPI(mux *router.Router, a CloudStorageAPI) {
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.NewMultipartUploadHandler).Queries("uploads", "")
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.AbortMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.GetObjectTaggingHandler).Queries("tagging", "")
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectTaggingHandler).Queries("tagging", "")
+	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.DeleteObjectTaggingHandler).Queries("tagging", "")
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.GetObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").Headers("X-Amz-Copy-Source", "").HandlerFunc(a.CopyObjectHandler)
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectHandler)
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.DeleteObjectHandler)
 
 	bucket.Methods("GET").HandlerFunc(a.GetBucketACLHandler).Queries("acl", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketVersioningHandler).Queries("versioning", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketEncryptionHandler).Queries("encryption", "")
 	bucket.Methods("GET").HandlerFunc(a.ListMultipartUploadsHandler).Queries("uploads", "")
 	bucket.Methods("GET").HandlerFunc(a.ListObjectsHandler)
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketACLHandler).Queries("acl", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketVersioningHandler).Queries("versioning", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketEncryptionHandler).Queries("encryption", "")
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketHandler)
 	bucket.Methods("HEAD").HandlerFunc(a.HeadBucketHandler)
 	bucket.Methods("POST").HandlerFunc(a.PostPolicyBucketHandler)
+	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketEncryptionHandler).Queries("encryption", "")
 	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketHandler)
 
 	root.Methods("GET").HandlerFunc(a.ListBucketsHandler)
@@ -64,18 +109,150 @@ func getNewCloudStorageAPI(conf cloudServerConfig) CloudStorageAPI {
 
 	fs.SetRootPath(conf.Path)
 	fs.SetMinFreeDisk(conf.MinFreeDisk)
+	if conf.MaxParts > 0 {
+		fs.SetMaxParts(conf.MaxParts)
+	}
+	if conf.MaxSessions > 0 {
+		fs.SetMaxMultipartSessions(conf.MaxSessions)
+	}
+	if conf.MaxObjectSize > 0 {
+		fs.SetMaxObjectSize(conf.MaxObjectSize)
+	}
+	if conf.PartReadahead > 0 {
+		fs.SetConcatConcurrency(conf.PartReadahead)
+	}
+	if conf.WriteBuffer > 0 {
+		err = fs.SetWriteBufferSize(conf.WriteBuffer)
+		fatalIf(err.Trace(), "Setting write buffer size failed.", nil)
+	}
+	if conf.FileMode != 0 || conf.DirMode != 0 {
+		err = fs.SetFileModes(conf.FileMode, conf.DirMode)
+		fatalIf(err.Trace(), "Setting file modes failed.", nil)
+	}
+	fs.SetPortableKeys(conf.PortableKeys)
+	fs.SetSequentialReadHint(conf.ReadHint)
+	fs.SetFolderObjects(!conf.NoFolders)
+	fs.SetSkipPartMD5(conf.SkipPartMD5)
+	if conf.DiskBackoff > 0 {
+		fs.SetDiskRetry(conf.DiskRetries, conf.DiskBackoff)
+	}
+	if conf.DiskFullWait > 0 {
+		fs.SetDiskFullWait(conf.DiskFullWait)
+	}
+	if conf.Immutable > 0 {
+		fs.SetImmutableWindow(conf.Immutable)
+	}
+	if conf.MasterKey != nil {
+		err = fs.SetMasterKey(conf.MasterKey)
+		fatalIf(err.Trace(), "Setting master key failed.", nil)
+	}
+
+	fs.SetPurgeLogger(func(tempPath string, err *probe.Error) {
+		errorIf(err, "Removing temporary file failed, retrying later.", map[string]interface{}{"path": tempPath})
+	})
+
+	if conf.TempFileAge > 0 {
+		removed, err := fs.RemoveAtomicTempFiles(conf.TempFileAge)
+		errorIf(err.Trace(conf.Path), "Removing temporary files of interrupted writes failed.", nil)
+		if len(removed) > 0 {
+			log.WithFields(map[string]interface{}{"count": len(removed)}).Info("Temporary files of interrupted writes removed.")
+		}
+	}
+
+	quarantined, err := fs.RecoverMultipartSessions()
+	fatalIf(err.Trace(conf.Path), "Recovering multipart sessions failed.", nil)
+	for _, sessionPath := range quarantined {
+		log.WithFields(map[string]interface{}{"path": sessionPath}).Warn("Corrupted multipart session quarantined.")
+	}
+	go removeOrphanPartsThread(fs)
+	err = fs.InitUsage()
+	fatalIf(err.Trace(conf.Path), "Loading bucket usage failed.", nil)
+	go usageThread(fs)
+	if len(conf.Regions) > 0 {
+		globalRegions = conf.Regions
+	}
+	switch {
+	case conf.NoSigningKeyCache:
+		globalSigningKeys.SetTTL(0)
+	case conf.SigningKeyTTL > 0:
+		globalSigningKeys.SetTTL(conf.SigningKeyTTL)
+	}
 	if conf.Expiry > 0 {
 		go fs.AutoExpiryThread(conf.Expiry)
 	}
+	chaos, err := loadChaosConfig()
+	fatalIf(err.Trace(), "Invalid fault injection configured.", nil)
+	if chaos != nil {
+		log.WithFields(map[string]interface{}{
+			"delayPercent": chaos.DelayPercent,
+			"delay":        chaos.Delay.String(),
+			"jitter":       chaos.Jitter.String(),
+			"errorPercent": chaos.ErrorPercent,
+		}).Warn("Fault injection is enabled, requests are delayed and failed at random.")
+	}
 	return CloudStorageAPI{
-		Filesystem: fs,
-		Anonymous:  conf.Anonymous,
-		AccessLog:  conf.AccessLog,
+		ObjectAPI:       fs,
+		Anonymous:       conf.Anonymous,
+		AnonymousRead:   conf.AnonymousRead,
+		IPFilter:        conf.IPFilter,
+		TrustedProxies:  conf.TrustedProxies,
+		AccessLog:       conf.AccessLog,
+		AccessLogSample: conf.AccessLogSample,
+		Notifier:        newEventNotifier(defaultEventQueueSize),
+		StorageClass:    conf.StorageClass,
+		Bandwidth:       newBandwidthLimiter(conf.RequestBandwidth, conf.ClientBandwidth),
+		Uploads:         newUploadLimiter(conf.MaxUploads),
+		Browser:         conf.Browser,
+		MaxBodyBytes:    conf.MaxRequestBodyBytes,
+		Chaos:           chaos,
+		ContentTypes:    conf.ContentTypes,
+	}
+}
+
+// removeOrphanPartsThread - remove parts their multipart sessions do not know of and temp files
+// which failed to be removed every hour, logging every removal
+func removeOrphanPartsThread(filesystem fs.Filesystem) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		removed, err := filesystem.RemoveOrphanParts(fs.DefaultOrphanPartAge)
+		errorIf(err.Trace(), "Removing orphaned multipart parts failed.", nil)
+		for _, partPath := range removed {
+			log.WithFields(map[string]interface{}{"path": partPath}).Info("Orphaned multipart part removed.")
+		}
+		purged, err := filesystem.PurgeTempFiles()
+		errorIf(err.Trace(), "Removing temporary files failed.", nil)
+		for _, tempPath := range purged {
+			log.WithFields(map[string]interface{}{"path": tempPath}).Info("Temporary file removed.")
+		}
+		<-ticker.C
+	}
+}
+
+// usageThread - persist bucket usage every minute so that restarts need no walk of the export
+// path, and correct it by a walk every hour for changes made behind the server's back
+func usageThread(filesystem fs.Filesystem) {
+	saveTicker := time.NewTicker(fs.DefaultUsageSaveInterval)
+	defer saveTicker.Stop()
+	reconcileTicker := time.NewTicker(fs.DefaultUsageReconcileInterval)
+	defer reconcileTicker.Stop()
+	for {
+		select {
+		case <-saveTicker.C:
+			errorIf(filesystem.SaveUsage().Trace(), "Saving bucket usage failed.", nil)
+		case <-reconcileTicker.C:
+			drifted, err := filesystem.ReconcileUsage()
+			errorIf(err.Trace(), "Reconciling bucket usage failed.", nil)
+			for _, bucket := range drifted {
+				log.WithFields(map[string]interface{}{"bucket": bucket}).Warn("Cached bucket usage was off, corrected by a walk.")
+			}
+		}
 	}
 }
 
 func getCloudStorageAPIHandler(api CloudStorageAPI) http.Handler {
 	var mwHandlers = []MiddlewareHandler{
+		MaintenanceModeHandler,
 		TimeValidityHandler,
 		IgnoreResourcesHandler,
 		CorsHandler,
@@ -83,9 +260,28 @@ func getCloudStorageAPIHandler(api CloudStorageAPI) http.Handler {
 	if !api.Anonymous {
 		mwHandlers = append(mwHandlers, SignatureHandler)
 	}
+	// the browser logs in on its own, it is dispatched to ahead of signature verification
+	mwHandlers = append(mwHandlers, BrowserHandler(api))
+	// oversized bodies are turned away before signatures are verified against them
+	if api.MaxBodyBytes > 0 {
+		mwHandlers = append(mwHandlers, RequestBodyLimitHandler(api.MaxBodyBytes))
+	}
+	// disallowed source addresses are turned away before anything else is looked at, yet logged
+	if api.IPFilter != nil {
+		mwHandlers = append(mwHandlers, IPFilterHandler(api.IPFilter))
+	}
 	if api.AccessLog {
-		mwHandlers = append(mwHandlers, AccessLogHandler)
+		mwHandlers = append(mwHandlers, AccessLogHandler(api.AccessLogSample))
+	}
+	if api.Chaos != nil {
+		mwHandlers = append(mwHandlers, ChaosHandler(*api.Chaos))
+	}
+	// client addresses forwarded by trusted proxies are what every other handler sees
+	if len(api.TrustedProxies) > 0 {
+		mwHandlers = append(mwHandlers, ProxyHeadersHandler(api.TrustedProxies))
 	}
+	// request id is assigned first, so that every other handler can refer to it
+	mwHandlers = append(mwHandlers, RequestIDHandler)
 	mux := router.NewRouter()
 	registerCloudStorageAPI(mux, api)
 	return registerCustomMiddleware(mux, mwHandlers...)
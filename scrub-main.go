@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+var scrubCmd = cli.Command{
+	Name:   "scrub",
+	Usage:  "Verify checksums of all objects under a path.",
+	Action: mainScrub,
+	CustomHelpTemplate: `NAME:
+   minio {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio {{.Name}} PATH [quarantine]
+
+OPTION = quarantine   Move corrupted objects under '.minio.quarantine' of PATH.
+
+EXAMPLES:
+   1. Report corrupted objects.
+      $ minio {{.Name}} /home/shared
+
+   2. Report and quarantine corrupted objects.
+      $ minio {{.Name}} /home/shared quarantine
+`,
+}
+
+func mainScrub(ctx *cli.Context) {
+	args := ctx.Args()
+	if !args.Present() || args.First() == "help" || len(args) > 2 {
+		cli.ShowCommandHelpAndExit(ctx, "scrub", 1) // last argument is exit code
+	}
+	quarantine := false
+	if len(args) == 2 {
+		if args.Get(1) != "quarantine" {
+			cli.ShowCommandHelpAndExit(ctx, "scrub", 1) // last argument is exit code
+		}
+		quarantine = true
+	}
+	corrupted, err := runScrub(args.First(), quarantine)
+	fatalIf(err.Trace(args.First()), "Scrubbing objects failed.", nil)
+	if len(corrupted) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runScrub - verify all objects under path, prints every corrupted object and a summary
+func runScrub(path string, quarantine bool) ([]string, *probe.Error) {
+	filesystem, err := fs.New()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	filesystem.SetRootPath(path)
+	corrupted, err := filesystem.ScrubObjects(quarantine)
+	for _, objectPath := range corrupted {
+		if quarantine {
+			Println("QUARANTINED: " + objectPath)
+		} else {
+			Println("CORRUPTED: " + objectPath)
+		}
+	}
+	if err != nil {
+		return corrupted, err.Trace(path)
+	}
+	Println("Scrubbed " + path + ", " + strconv.Itoa(len(corrupted)) + " corrupted object(s) found.")
+	return corrupted, nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
+)
+
+var selfTestCmd = cli.Command{
+	Name:   "selftest",
+	Usage:  "Exercise a PUT/GET/DELETE cycle against a running server.",
+	Action: mainSelfTest,
+	CustomHelpTemplate: `NAME:
+   minio {{.Name}} - {{.Usage}}
+
+USAGE:
+   minio {{.Name}} ADDRESS ACCESSKEY SECRETKEY
+
+EXAMPLES:
+   1. Smoke test a local server.
+      $ minio {{.Name}} http://localhost:9000 WLGDGYAQYIGI833EV05A BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF
+`,
+}
+
+// selfTestPayload - object written and read back by selftest
+var selfTestPayload = []byte("minio selftest payload")
+
+func mainSelfTest(ctx *cli.Context) {
+	if len(ctx.Args()) != 3 || ctx.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(ctx, "selftest", 1) // last argument is exit code
+	}
+	err := runSelfTest(ctx.Args().Get(0), ctx.Args().Get(1), ctx.Args().Get(2))
+	fatalIf(err.Trace(), "Selftest failed.", nil)
+}
+
+// selfTestClient - minimal signature v4 client for selftest
+type selfTestClient struct {
+	endpoint        string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+// do - sign and send a request, verify response status
+func (s selfTestClient) do(method, path string, body []byte, expectedStatus int) ([]byte, *probe.Error) {
+	req, e := http.NewRequest(method, s.endpoint+path, bytes.NewReader(body))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	req.ContentLength = int64(len(body))
+	signature := &fs.Signature{
+		AccessKeyID:     s.accessKeyID,
+		SecretAccessKey: s.secretAccessKey,
+		Request:         req,
+	}
+	signature.SignRequest(hex.EncodeToString(sha256.Sum256(body)), time.Now().UTC())
+
+	resp, e := s.client.Do(req)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	defer resp.Body.Close()
+	respBody, e := ioutil.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if resp.StatusCode != expectedStatus {
+		return nil, probe.NewError(fmt.Errorf("unexpected response %s: %s", resp.Status, strings.TrimSpace(string(respBody))))
+	}
+	return respBody, nil
+}
+
+// runSelfTest - create a temporary bucket, put, get and verify an object, then remove both.
+// Prints pass/fail per step, stops at the first failure.
+func runSelfTest(endpoint, accessKeyID, secretAccessKey string) *probe.Error {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+	client := selfTestClient{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+	suffix := make([]byte, 8)
+	if _, e := rand.Read(suffix); e != nil {
+		return probe.NewError(e)
+	}
+	bucket := "minio-selftest-" + hex.EncodeToString(suffix)
+	object := bucket + "/selftest-object"
+
+	steps := []struct {
+		name string
+		run  func() *probe.Error
+	}{
+		{"MakeBucket", func() *probe.Error {
+			_, err := client.do("PUT", "/"+bucket, nil, http.StatusOK)
+			return err
+		}},
+		{"PutObject", func() *probe.Error {
+			_, err := client.do("PUT", "/"+object, selfTestPayload, http.StatusOK)
+			return err
+		}},
+		{"GetObject", func() *probe.Error {
+			data, err := client.do("GET", "/"+object, nil, http.StatusOK)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(data, selfTestPayload) {
+				return probe.NewError(errSelfTestMismatch)
+			}
+			return nil
+		}},
+		{"DeleteObject", func() *probe.Error {
+			_, err := client.do("DELETE", "/"+object, nil, http.StatusNoContent)
+			return err
+		}},
+		{"DeleteBucket", func() *probe.Error {
+			_, err := client.do("DELETE", "/"+bucket, nil, http.StatusNoContent)
+			return err
+		}},
+	}
+	for i, step := range steps {
+		if err := step.run(); err != nil {
+			Println("FAIL: " + step.name + ": " + err.ToGoError().Error())
+			// best effort removal of what was created so far, last two steps clean up
+			if i > 0 {
+				for j := len(steps) - 2; j < len(steps); j++ {
+					if j > i {
+						steps[j].run()
+					}
+				}
+			}
+			return err.Trace(step.name)
+		}
+		Println("PASS: " + step.name)
+	}
+	return nil
+}
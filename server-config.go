@@ -17,6 +17,7 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio-xl/pkg/quick"
+	"github.com/minio/minio/pkg/fs"
 )
 
 // configV1
@@ -42,17 +44,28 @@ type configV2 struct {
 		AccessKeyID     string `json:"accessKeyId"`
 		SecretAccessKey string `json:"secretAccessKey"`
 	} `json:"credentials"`
-	MongoLogger struct {
-		Addr       string `json:"addr"`
-		DB         string `json:"db"`
-		Collection string `json:"collection"`
+	MasterKey    string            `json:"masterKey"`              // hex encoded master key for server side encryption
+	ContentTypes map[string]string `json:"contentTypes,omitempty"` // content types by extension overriding the defaults, empty unmaps one
+	MongoLogger  struct {
+		Addr          string `json:"addr"`
+		DB            string `json:"db"`
+		Collection    string `json:"collection"`
+		BufferSize    int    `json:"bufferSize"`
+		FlushInterval string `json:"flushInterval"`
 	} `json:"mongoLogger"`
 	SyslogLogger struct {
-		Network string `json:"network"`
-		Addr    string `json:"addr"`
+		Network  string `json:"network"`
+		Addr     string `json:"addr"`
+		CAFile   string `json:"caFile"`
+		CertFile string `json:"certFile"`
+		KeyFile  string `json:"keyFile"`
 	} `json:"syslogLogger"`
 	FileLogger struct {
-		Filename string `json:"filename"`
+		Filename   string `json:"filename"`
+		MaxSize    int    `json:"maxSize"`
+		MaxAge     string `json:"maxAge"`
+		MaxBackups int    `json:"maxBackups"`
+		Compress   bool   `json:"compress"`
 	} `json:"fileLogger"`
 }
 
@@ -97,16 +110,25 @@ func (c *configV2) String() string {
 func (c *configV2) JSON() string {
 	type logger struct {
 		MongoLogger struct {
-			Addr       string `json:"addr"`
-			DB         string `json:"db"`
-			Collection string `json:"collection"`
+			Addr          string `json:"addr"`
+			DB            string `json:"db"`
+			Collection    string `json:"collection"`
+			BufferSize    int    `json:"bufferSize"`
+			FlushInterval string `json:"flushInterval"`
 		} `json:"mongoLogger"`
 		SyslogLogger struct {
-			Network string `json:"network"`
-			Addr    string `json:"addr"`
+			Network  string `json:"network"`
+			Addr     string `json:"addr"`
+			CAFile   string `json:"caFile"`
+			CertFile string `json:"certFile"`
+			KeyFile  string `json:"keyFile"`
 		} `json:"syslogLogger"`
 		FileLogger struct {
-			Filename string `json:"filename"`
+			Filename   string `json:"filename"`
+			MaxSize    int    `json:"maxSize"`
+			MaxAge     string `json:"maxAge"`
+			MaxBackups int    `json:"maxBackups"`
+			Compress   bool   `json:"compress"`
 		} `json:"fileLogger"`
 	}
 	loggerBytes, err := json.Marshal(logger{
@@ -123,6 +145,9 @@ func getConfigPath() (string, *probe.Error) {
 	if customConfigPath != "" {
 		return customConfigPath, nil
 	}
+	if configPath := os.Getenv("MINIO_CONFIG_DIR"); configPath != "" {
+		return configPath, nil
+	}
 	u, err := userCurrent()
 	if err != nil {
 		return "", err.Trace()
@@ -172,7 +197,7 @@ func getConfigFile() (string, *probe.Error) {
 	return filepath.Join(configPath, "config.json"), nil
 }
 
-// configPath for custom config path only for testing purposes
+// customConfigPath set via --config-dir, takes precedence over MINIO_CONFIG_DIR
 var customConfigPath string
 
 // saveConfig save config
@@ -209,7 +234,53 @@ func loadConfigV2() (*configV2, *probe.Error) {
 	if err := qc.Load(configFile); err != nil {
 		return nil, err.Trace()
 	}
-	return qc.Data().(*configV2), nil
+	config := qc.Data().(*configV2)
+	// credentials from environment always override the ones on disk
+	accessKeyID, secretAccessKey, err := getCredentialsFromEnv()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if accessKeyID != "" {
+		config.Credentials.AccessKeyID = accessKeyID
+		config.Credentials.SecretAccessKey = secretAccessKey
+	}
+	return config, nil
+}
+
+// getCredentialsFromEnv get credentials from MINIO_ACCESS_KEY and MINIO_SECRET_KEY, empty if not set
+func getCredentialsFromEnv() (string, string, *probe.Error) {
+	accessKeyID := os.Getenv("MINIO_ACCESS_KEY")
+	secretAccessKey := os.Getenv("MINIO_SECRET_KEY")
+	if accessKeyID == "" && secretAccessKey == "" {
+		return "", "", nil
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return "", "", probe.NewError(errInvalidCredentialsEnv)
+	}
+	if !isValidAccessKey(accessKeyID) {
+		return "", "", probe.NewError(errInvalidAccessKeyEnv)
+	}
+	if !isValidSecretKey(secretAccessKey) {
+		return "", "", probe.NewError(errInvalidSecretKeyEnv)
+	}
+	return accessKeyID, secretAccessKey, nil
+}
+
+// getMasterKey get master key for server side encryption, MINIO_MASTER_KEY overrides the one in
+// config. Both are hex encoded, nil is returned if none is set.
+func getMasterKey(conf *configV2) ([]byte, *probe.Error) {
+	masterKey := conf.MasterKey
+	if key := os.Getenv("MINIO_MASTER_KEY"); key != "" {
+		masterKey = key
+	}
+	if masterKey == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(masterKey)
+	if err != nil || len(key) != fs.MasterKeySize {
+		return nil, probe.NewError(errInvalidMasterKey)
+	}
+	return key, nil
 }
 
 // loadConfigV1 load config
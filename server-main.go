@@ -18,20 +18,29 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/Sirupsen/logrus"
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
 	"github.com/minio/minio-xl/pkg/minhttp"
 	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio/pkg/fs"
 )
 
 var serverCmd = cli.Command{
@@ -44,8 +53,35 @@ var serverCmd = cli.Command{
 USAGE:
   minio {{.Name}} [OPTION VALUE] PATH
 
-  OPTION = expiry        VALUE = NN[h|m|s] [DEFAULT=Unlimited]
-  OPTION = min-free-disk VALUE = NN% [DEFAULT: 10%]
+  OPTION = expiry          VALUE = NN[h|m|s] [DEFAULT=Unlimited]
+  OPTION = min-free-disk   VALUE = NN% [DEFAULT: 10%]
+  OPTION = max-parts       VALUE = NN [DEFAULT: 10000]
+  OPTION = max-sessions    VALUE = NN [DEFAULT: 10000]
+  OPTION = max-uploads     VALUE = NN [DEFAULT=Unlimited]
+  OPTION = clock-skew      VALUE = NN[h|m|s] [DEFAULT: 15m]
+  OPTION = max-object-size VALUE = NN[KB|MB|GB|TB] [DEFAULT=Unlimited]
+  OPTION = keep-alive      VALUE = NN[h|m|s]|off [DEFAULT: 15s]
+  OPTION = disk-retries    VALUE = NN [DEFAULT: 3]
+  OPTION = disk-backoff    VALUE = NN[h|m|s] [DEFAULT: 100ms]
+  OPTION = part-readahead  VALUE = NN [DEFAULT: 4]
+  OPTION = write-buffer    VALUE = NN[KB|MB] [DEFAULT: 32KB]
+  OPTION = region          VALUE = REGION[,REGION...] [DEFAULT: milkyway,us-east-1]
+  OPTION = temp-file-age   VALUE = NN[h|m|s] [DEFAULT: 1h]
+  OPTION = storage-class   VALUE = STANDARD|REDUCED_REDUNDANCY|STANDARD_IA|ONEZONE_IA [DEFAULT: STANDARD]
+  OPTION = bandwidth       VALUE = NN[KB|MB|GB] per second [DEFAULT=Unlimited]
+  OPTION = client-bandwidth VALUE = NN[KB|MB|GB] per second [DEFAULT=Unlimited]
+  OPTION = file-mode       VALUE = NNNN [DEFAULT: 0600]
+  OPTION = dir-mode        VALUE = NNNN [DEFAULT: 0700]
+  OPTION = portable-keys   VALUE = on|off [DEFAULT: off]
+  OPTION = max-header-size VALUE = NN[KB|MB] [DEFAULT: 1MB]
+  OPTION = disk-full-wait  VALUE = NN[h|m|s] [DEFAULT: 0s]
+  OPTION = sequential-read VALUE = on|off [DEFAULT: off]
+  OPTION = folder-objects  VALUE = on|off [DEFAULT: on]
+  OPTION = max-body-size   VALUE = NN[KB|MB|GB|TB] [DEFAULT=Unlimited]
+  OPTION = skip-part-md5   VALUE = on|off [DEFAULT: off]
+  OPTION = signing-key-ttl VALUE = NN[h|m|s]|off [DEFAULT: 15m]
+  OPTION = immutable-window VALUE = NN[h|m|s] [DEFAULT: 0s]
+  OPTION = listen-timeout  VALUE = NN[h|m|s] [DEFAULT: 0s]
 
 EXAMPLES:
   1. Start minio server on Linux.
@@ -63,94 +99,512 @@ EXAMPLES:
   5. Start minio server with minimum free disk threshold to 15% with auto expiration set to 1h
       $ minio {{.Name}} min-free-disk 15% expiry 1h /home/shared/Documents
 
+  6. Start minio server allowing at most 1000 parts per multipart upload
+      $ minio {{.Name}} max-parts 1000 /home/shared/Videos
+
+  7. Start minio server accepting requests signed at most 5 minutes apart from server time
+      $ minio {{.Name}} clock-skew 5m /home/shared/Music
+
+  8. Start minio server rejecting objects larger than 100GB
+      $ minio {{.Name}} max-object-size 100GB /home/shared/Backups
+
+  9. Start minio server closing connections after every request, behind a load balancer
+      $ minio {{.Name}} keep-alive off /home/shared/Projects
+
+  10. Start minio server on an NFS mount retrying transient disk errors 5 times starting at 500ms apart
+      $ minio {{.Name}} disk-retries 5 disk-backoff 500ms /mnt/nfs/shared
+
+  11. Start minio server reading up to 16 parts ahead while completing multipart uploads
+      $ minio {{.Name}} part-readahead 16 /home/shared/Archives
+
+  12. Start minio server writing objects through a 1MB buffer, for large uploads on fast disks
+      $ minio {{.Name}} write-buffer 1MB /home/shared/Media
+
+  13. Start minio server on a unix domain socket accessible to its owner only, behind a local reverse proxy
+      $ minio --address unix:/run/minio.sock --socket-mode 0600 {{.Name}} /home/shared
+
+  14. Start minio server accepting only requests signed for region eu-west-1
+      $ minio {{.Name}} region eu-west-1 /home/shared
+
+  15. Start minio server serving profiles for go tool pprof on the loopback interface only
+      $ minio --profile localhost:6060 {{.Name}} /home/shared
+
+  16. Start minio server removing temporary files of writes interrupted at least 10 minutes ago
+      $ minio {{.Name}} temp-file-age 10m /home/shared
+
+  17. Start minio server tagging objects uploaded without a storage class as reduced redundancy
+      $ minio {{.Name}} storage-class REDUCED_REDUNDANCY /home/shared
+
+  18. Start minio server allowing at most 100 multipart uploads in progress per bucket
+      $ minio {{.Name}} max-sessions 100 /home/shared
+
+  19. Start minio server limiting every upload and download to 10MB/s and all of those of a client to 50MB/s
+      $ minio {{.Name}} bandwidth 10MB client-bandwidth 50MB /home/shared
+
+  20. Start minio server creating objects and buckets readable by the group of the server user
+      $ minio {{.Name}} file-mode 0640 dir-mode 0750 /home/shared
+
+  21. Reload credentials and loggers of a running minio server after changing its config
+      $ kill -HUP $(pidof minio)
+
+  22. Start minio server on a public and a private network at once, when you have split networks.
+      $ minio --address 192.168.1.101:9000,10.0.0.5:9000 {{.Name}} /home/shared
+
+  23. Start minio server negotiating HTTP/2 with TLS clients, to multiplex many small object requests.
+      $ minio --cert public.crt --key private.key --http2 {{.Name}} /home/shared
+
+  24. Start minio server on a case-insensitive filesystem, keeping keys differing in case only apart.
+      $ minio {{.Name}} portable-keys on /Volumes/shared
+
+  25. Start minio server accepting up to 4MB of request line and headers, for objects with many user metadata.
+      $ minio {{.Name}} max-header-size 4MB /home/shared
+
+  26. Start minio server pausing part uploads for up to 10 minutes when the disk is full, until space is freed.
+      $ minio {{.Name}} disk-full-wait 10m /home/shared
+
+  27. Start minio server on Linux widening readahead of object downloads, for large objects on spinning disks.
+      $ minio {{.Name}} sequential-read on /home/shared
+
+  28. Start minio server handling at most 64 uploads at once, turning away further ones with 503 Slow Down.
+      $ minio {{.Name}} max-uploads 64 /home/shared
+
+  29. Start minio server rejecting objects ending in a slash instead of keeping them as empty folders.
+      $ minio {{.Name}} folder-objects off /home/shared
+
+  30. Start minio server rejecting request bodies other than parts larger than 64MB with 413 before reading them.
+      $ minio {{.Name}} max-body-size 64MB /home/shared
+
+  31. Start minio server skipping md5sums of parts uploaded without Content-MD5 nor a signed payload, for ingest
+      of clients which do not verify ETags. ETags of such parts and of objects made of them are not md5sums.
+      $ minio {{.Name}} skip-part-md5 on /home/shared
+
+  32. Start minio server deriving signing keys for every request, instead of reusing them for 15 minutes.
+      $ minio {{.Name}} signing-key-ttl off /home/shared
+
+  33. Start minio server accepting requests from the office network only, behind a load balancer at 10.0.0.5
+      forwarding client addresses in X-Forwarded-For.
+      $ minio --allow-ips 192.168.1.0/24 --trusted-proxies 10.0.0.5 {{.Name}} /home/shared
+
+  34. Start minio server refusing to replace or remove objects for 30 seconds after their multipart upload
+      completes, with 409 OperationAborted, so that producers racing for a key do not overwrite each other.
+      $ minio {{.Name}} immutable-window 30s /home/shared
+
+  35. Start minio server waiting up to a minute for the previous server to release the port, failing with
+      "Address already in use" afterwards. Without a listen timeout the start fails right away.
+      $ minio {{.Name}} listen-timeout 1m /home/shared
+
 `,
 }
 
 // cloudServerConfig - http server config
 type cloudServerConfig struct {
 	/// HTTP server options
-	Address   string // Address:Port listening
-	AccessLog bool   // Enable access log handler
-	Anonymous bool   // No signature turn off
+	Address         string              // Comma separated Address:Port listening, or unix:PATH of a unix domain socket
+	SocketMode      os.FileMode         // Permissions of the unix domain socket file
+	ProfileAddress  string              // Address:Port profiles are served on, disabled if empty
+	AccessLog       bool                // Enable access log handler
+	AccessLogSample float64             // Fraction of successful requests logged, failed ones are always logged
+	Anonymous       bool                // No signature turn off, deprecated in favour of AnonymousRead
+	AnonymousRead   anonymousReadPolicy // Buckets readable without signature
+	IPFilter        *ipFilter           // Source addresses requests are accepted from, every one if nil
+	TrustedProxies  trustedProxies      // Proxies whose forwarded client addresses are trusted
+	Browser         bool                // Serve the bucket browser, if compiled in
+	ContentTypes    contentTypes        // Content types of objects by extension, the defaults if nil
 
 	/// FS options
-	Path        string        // Path to export for cloud storage
-	MinFreeDisk int64         // Minimum free disk space for filesystem
-	Expiry      time.Duration // Set auto expiry for filesystem
+	Path          string        // Path to export for cloud storage
+	MinFreeDisk   int64         // Minimum free disk space for filesystem
+	Expiry        time.Duration // Set auto expiry for filesystem
+	MaxParts      int           // Maximum part number per multipart upload
+	MaxSessions   int           // Maximum active multipart sessions per bucket
+	MaxUploads    int           // Uploads in flight at once, objects, parts and completions, unlimited if zero
+	MaxObjectSize int64         // Maximum object size, unlimited if zero
+	ClockSkew     time.Duration // Allowed difference between request and server time
+	Regions       []string      // Regions signatures may be scoped to, the first one is reported
+	MasterKey     []byte        // Master key for server side encryption, disabled if nil
+	DiskRetries   int           // Retries of disk stats failing with a transient error
+	DiskBackoff   time.Duration // Wait before the first disk stat retry, doubled on every further retry
+	DiskFullWait  time.Duration // Wait of part uploads for space on a full disk, failing right away if zero
+	PartReadahead int           // Parts read ahead while completing a multipart upload
+	WriteBuffer   int           // Size of the buffer objects and parts are written through
+	TempFileAge   time.Duration // Temp files of interrupted writes older than this are removed on start
+	StorageClass  string        // Storage class of objects uploaded without one
+	FileMode      os.FileMode   // Permissions of created objects and parts
+	DirMode       os.FileMode   // Permissions of created buckets and object directories
+	PortableKeys  bool          // Normalize keys and encode them on disk for case-insensitive filesystems
+	ReadHint      bool          // Hint sequential reads of objects to the kernel, widening readahead on Linux
+	NoFolders     bool          // Reject objects ending in a slash instead of keeping them as folders
+	SkipPartMD5   bool          // Skip md5sums of unverified parts, their ETags are not md5sums then
+	Immutable     time.Duration // Objects completed by multipart uploads may be neither replaced nor removed this long
+
+	/// Bandwidth options
+	RequestBandwidth int64 // Bytes per second of an object upload or download, unlimited if zero
+	ClientBandwidth  int64 // Bytes per second of all uploads and downloads of a client address, unlimited if zero
+
+	/// Signature options
+	SigningKeyTTL     time.Duration // Reuse of signing keys derived for a credential scope, fs.DefaultSigningKeyTTL if zero
+	NoSigningKeyCache bool          // Derive signing keys on every request
 
 	// TLS service
-	TLS      bool   // TLS on when certs are specified
-	CertFile string // Domain certificate
-	KeyFile  string // Domain key
+	TLS        bool   // TLS on when certs are specified
+	CertFile   string // Domain certificate
+	KeyFile    string // Domain key
+	StrictCert bool   // Fail instead of warning on certificate outside of its validity period
+	HTTP2      bool   // Negotiate HTTP/2 over TLS
 
 	/// Advanced HTTP server options
-	RateLimit int // Ratelimited server of incoming connections
+	RateLimit           int           // Ratelimited server of incoming connections
+	MaxHeaderBytes      int           // Bytes of request line and headers accepted, 1MB if zero
+	MaxRequestBodyBytes int64         // Bytes of request bodies other than parts accepted, unlimited if zero
+	KeepAlivePeriod     time.Duration // TCP keep-alive period, system default if zero
+	ListenTimeout       time.Duration // Wait for addresses in use by another process to be released, failing right away if zero
+	DisableKeepAlives   bool          // Close connections after every request
 }
 
-// configureAPIServer configure a new server instance
-func configureAPIServer(conf cloudServerConfig) (*http.Server, *probe.Error) {
-	// Minio server config
-	apiServer := &http.Server{
-		Addr:           conf.Address,
-		Handler:        getCloudStorageAPIHandler(getNewCloudStorageAPI(conf)),
-		MaxHeaderBytes: 1 << 20,
+// maxHeaderBytesLimit - largest maximum header size accepted, request headers are held in memory
+// while being read
+const maxHeaderBytesLimit = 64 << 20
+
+// serverAddresses - addresses listened on, address is a comma separated list of them
+func serverAddresses(address string) []string {
+	addresses := strings.Split(address, ",")
+	for i := range addresses {
+		addresses[i] = strings.TrimSpace(addresses[i])
 	}
+	return addresses
+}
 
+// configureAPIServer configure a new server instance per address, all of them serving the same handler
+func configureAPIServer(conf cloudServerConfig) ([]*http.Server, *probe.Error) {
+	handler := getCloudStorageAPIHandler(getNewCloudStorageAPI(conf))
+	var tlsConfig *tls.Config
 	if conf.TLS {
-		var err error
-		apiServer.TLSConfig = &tls.Config{}
-		apiServer.TLSConfig.Certificates = make([]tls.Certificate, 1)
-		apiServer.TLSConfig.Certificates[0], err = tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		cert, err := loadServerCertificate(conf)
 		if err != nil {
-			return nil, probe.NewError(err)
+			return nil, err.Trace(conf.CertFile, conf.KeyFile)
+		}
+		tlsConfig = &tls.Config{}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		// listeners are wrapped by TLS before being served, protocols are hence negotiated
+		// as advertised here and not as http.Server would set them up on its own
+		if conf.HTTP2 {
+			tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		} else {
+			tlsConfig.NextProtos = []string{"http/1.1"}
 		}
 	}
 
-	host, port, err := net.SplitHostPort(conf.Address)
-	if err != nil {
-		return nil, probe.NewError(err)
+	var apiServers []*http.Server
+	for _, address := range serverAddresses(conf.Address) {
+		// Minio server config
+		apiServer := &http.Server{
+			Addr:           address,
+			Handler:        handler,
+			MaxHeaderBytes: conf.MaxHeaderBytes,
+			TLSConfig:      tlsConfig,
+		}
+		if !conf.HTTP2 {
+			// an empty, non-nil map keeps net/http from enabling HTTP/2
+			apiServer.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		}
+		setKeepAlive(apiServer, conf)
+		apiServers = append(apiServers, apiServer)
 	}
 
-	var hosts []string
-	switch {
-	case host != "":
-		hosts = append(hosts, host)
-	default:
-		addrs, err := net.InterfaceAddrs()
-		if err != nil {
-			return nil, probe.NewError(err)
+	if socketPath, ok := unixSocketPath(conf.Address); ok {
+		Println("Starting minio server:")
+		if conf.TLS {
+			Printf("Listening on https+unix://%s\n", socketPath)
+		} else {
+			Printf("Listening on http+unix://%s\n", socketPath)
+		}
+		return apiServers, nil
+	}
+
+	Println("Starting minio server:")
+	for _, apiServer := range apiServers {
+		host, port, perr := splitListenAddress(apiServer.Addr)
+		if perr != nil {
+			return nil, perr.Trace()
 		}
-		for _, addr := range addrs {
-			if addr.Network() == "ip+net" {
-				host := strings.Split(addr.String(), "/")[0]
-				if ip := net.ParseIP(host); ip.To4() != nil {
-					hosts = append(hosts, host)
+
+		var hosts []string
+		switch {
+		case host != "" && !net.ParseIP(host).IsUnspecified():
+			hosts = append(hosts, host)
+		default:
+			addrs, err := net.InterfaceAddrs()
+			if err != nil {
+				return nil, probe.NewError(err)
+			}
+			for _, addr := range addrs {
+				if addr.Network() == "ip+net" {
+					host := strings.Split(addr.String(), "/")[0]
+					if ip := net.ParseIP(host); ip.To4() != nil {
+						hosts = append(hosts, host)
+					}
 				}
 			}
 		}
+
+		for _, host := range hosts {
+			if conf.TLS {
+				Printf("Listening on https://%s:%s\n", host, port)
+			} else {
+				Printf("Listening on http://%s:%s\n", host, port)
+			}
+		}
 	}
+	return apiServers, nil
+}
 
-	Println("Starting minio server:")
-	for _, host := range hosts {
-		if conf.TLS {
-			Printf("Listening on https://%s:%s\n", host, port)
-		} else {
-			Printf("Listening on http://%s:%s\n", host, port)
+// keepAliveConn - connections supporting TCP keep-alive tuning
+type keepAliveConn interface {
+	SetKeepAlive(keepalive bool) error
+	SetKeepAlivePeriod(d time.Duration) error
+}
+
+// setKeepAlive apply keep-alive settings to the server, TCP keep-alive period is set on every new
+// connection. Rate limited connections hide the underlying TCP connection and keep system default.
+func setKeepAlive(server *http.Server, conf cloudServerConfig) {
+	if conf.DisableKeepAlives {
+		server.SetKeepAlivesEnabled(false)
+		return
+	}
+	if conf.KeepAlivePeriod <= 0 {
+		return
+	}
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state != http.StateNew {
+			return
+		}
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			conn = tlsConn.NetConn()
+		}
+		if tcpConn, ok := conn.(keepAliveConn); ok {
+			tcpConn.SetKeepAlive(true)
+			tcpConn.SetKeepAlivePeriod(conf.KeepAlivePeriod)
+		}
+	}
+}
+
+// validateServerConfig validates server config without starting the server
+func validateServerConfig(conf cloudServerConfig) *probe.Error {
+	st, err := os.Stat(conf.Path)
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if !st.IsDir() {
+		return probe.NewError(errInvalidArgument).Trace(conf.Path)
+	}
+	if socketPath, ok := unixSocketPath(conf.Address); ok && len(serverAddresses(conf.Address)) == 1 {
+		if socketPath == "" {
+			return probe.NewError(errInvalidArgument).Trace(conf.Address)
+		}
+		// the socket file is created on start, its directory has to be there already
+		st, err := os.Stat(filepath.Dir(socketPath))
+		if err != nil {
+			return probe.NewError(err)
+		}
+		if !st.IsDir() {
+			return probe.NewError(errInvalidArgument).Trace(conf.Address)
+		}
+	}
+	if err := validateServerAddresses(conf.Address); err != nil {
+		return err.Trace()
+	}
+	if conf.ProfileAddress != "" {
+		if err := validateProfileAddress(conf); err != nil {
+			return err.Trace()
+		}
+	}
+	if conf.TLS {
+		if _, err := loadServerCertificate(conf); err != nil {
+			return err.Trace(conf.CertFile, conf.KeyFile)
 		}
 	}
-	return apiServer, nil
+	return nil
+}
+
+// splitListenAddress - host and port of a TCP address to listen on, an empty host listens on all
+// interfaces. Unlike net.SplitHostPort the port has to be a number, and malformed addresses are
+// told apart by what is wrong with them.
+func splitListenAddress(address string) (string, string, *probe.Error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		switch {
+		case address != "" && strings.Trim(address, "0123456789") == "":
+			return "", "", probe.NewError(errAddressBarePort).Trace(address)
+		case strings.Count(address, ":") > 1 && !strings.HasPrefix(address, "["):
+			return "", "", probe.NewError(errAddressIPv6Brackets).Trace(address)
+		}
+		return "", "", probe.NewError(errAddressNotHostPort).Trace(address)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", probe.NewError(errAddressInvalidPort).Trace(address)
+	}
+	return host, port, nil
+}
+
+// validateServerAddresses - every address listened on has to be an Address:Port, a unix domain
+// socket is only listened on by itself
+func validateServerAddresses(address string) *probe.Error {
+	addresses := serverAddresses(address)
+	if len(addresses) == 1 {
+		if _, ok := unixSocketPath(address); ok {
+			return nil
+		}
+	}
+	for _, address := range addresses {
+		if _, ok := unixSocketPath(address); ok {
+			return probe.NewError(errInvalidArgument).Trace(address)
+		}
+		if _, _, err := splitListenAddress(address); err != nil {
+			return err.Trace()
+		}
+	}
+	return nil
+}
+
+// listenRetryInterval - wait between checks of an address in use during the listen timeout
+const listenRetryInterval = 250 * time.Millisecond
+
+// isAddressInUse - true if listening failed for another socket being bound to the address
+func isAddressInUse(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && errno == syscall.EADDRINUSE
+}
+
+// checkServerAddresses - fail with errAddressInUse on the first TCP address another process is
+// listening on once the listen timeout passes, along with that address. Addresses are only bound
+// for the check, so that nothing is printed about a server which is going to fail to listen.
+// Listeners inherited on a graceful restart are still held by the parent and are not checked.
+func checkServerAddresses(conf cloudServerConfig) (string, *probe.Error) {
+	if _, ok := unixSocketPath(conf.Address); ok || os.Getenv("LISTEN_FDS") != "" {
+		return "", nil
+	}
+	deadline := time.Now().Add(conf.ListenTimeout)
+	for _, address := range serverAddresses(conf.Address) {
+		for {
+			listener, err := net.Listen("tcp", address)
+			if err == nil {
+				listener.Close()
+				break
+			}
+			if !isAddressInUse(err) {
+				return address, probe.NewError(err)
+			}
+			if !time.Now().Before(deadline) {
+				return address, probe.NewError(errAddressInUse)
+			}
+			time.Sleep(listenRetryInterval)
+		}
+	}
+	return "", nil
+}
+
+// loadCertificate loads certificate and its key, verifies if they match and if the
+// certificate is within its validity period
+func loadCertificate(certFile, keyFile string) (tls.Certificate, *probe.Error) {
+	// fails if private key does not match public key of the certificate
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, probe.NewError(err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, probe.NewError(err)
+	}
+	now := time.Now()
+	if now.Before(cert.Leaf.NotBefore) {
+		return cert, probe.NewError(errCertNotYetValid)
+	}
+	if now.After(cert.Leaf.NotAfter) {
+		return cert, probe.NewError(errCertExpired)
+	}
+	return cert, nil
+}
+
+// loadServerCertificate loads server certificate, certificate outside of its validity period
+// is only warned about unless strict certificate checking is requested
+func loadServerCertificate(conf cloudServerConfig) (tls.Certificate, *probe.Error) {
+	cert, err := loadCertificate(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		switch err.ToGoError() {
+		case errCertExpired, errCertNotYetValid:
+			if conf.StrictCert {
+				return tls.Certificate{}, err.Trace()
+			}
+			log.WithFields(map[string]interface{}{
+				"cert":      conf.CertFile,
+				"notBefore": cert.Leaf.NotBefore,
+				"notAfter":  cert.Leaf.NotAfter,
+			}).Warn(err.ToGoError().Error() + ", TLS handshakes will fail.")
+		default:
+			return tls.Certificate{}, err.Trace()
+		}
+	}
+	return cert, nil
+}
+
+// serverEventFields - fields of the start and shutdown events of the server, carrying the build
+// along with what is served so that restarts can be correlated in aggregated logs
+func serverEventFields(event string, conf cloudServerConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"event":     event,
+		"version":   minioVersion,
+		"commitID":  minioCommitID,
+		"addresses": serverAddresses(conf.Address),
+		"path":      conf.Path,
+	}
+}
+
+// logServerStart - log the start of the server to the console and the loggers enabled in config
+func logServerStart(conf cloudServerConfig) {
+	log.WithFields(serverEventFields("start", conf)).Info("Minio server starting.")
+}
+
+// logServerShutdown - log a graceful shutdown of the server, once every request has been served
+func logServerShutdown(conf cloudServerConfig) {
+	log.WithFields(serverEventFields("shutdown", conf)).Info("Minio server shut down.")
 }
 
 // startServer starts an s3 compatible cloud storage server
 func startServer(conf cloudServerConfig) *probe.Error {
-	apiServer, err := configureAPIServer(conf)
+	apiServers, err := configureAPIServer(conf)
 	if err != nil {
 		return err.Trace()
 	}
+	logServerStart(conf)
+	if conf.ProfileAddress != "" {
+		profileListener, err := startProfileServer(conf)
+		if err != nil {
+			return err.Trace(conf.ProfileAddress)
+		}
+		defer profileListener.Close()
+	}
+	if _, ok := unixSocketPath(conf.Address); ok {
+		if conf.RateLimit > 0 {
+			log.WithFields(map[string]interface{}{"address": conf.Address}).Warn("Rate limit is not applied to unix domain socket connections.")
+		}
+		listener, err := listenUnix(conf, apiServers[0].TLSConfig)
+		if err != nil {
+			return err.Trace(conf.Address)
+		}
+		defer listener.Close()
+		// SIGTERM shuts down gracefully, as it does for addresses served by minhttp
+		if err := serveUnix(apiServers[0], listener); err != nil {
+			return probe.NewError(err)
+		}
+		logServerShutdown(conf)
+		return nil
+	}
+	// startup fails unless every address could be listened on, SIGTERM shuts down gracefully
 	rateLimit := conf.RateLimit
-	if err := minhttp.ListenAndServeLimited(rateLimit, apiServer); err != nil {
-		return err.Trace()
+	if err := minhttp.ListenAndServeLimited(rateLimit, apiServers...); err != nil {
+		return err.Trace(conf.Address)
 	}
+	logServerShutdown(conf)
 	return nil
 }
 
@@ -171,21 +625,68 @@ func parsePercentToInt(s string, bitSize int) (int64, *probe.Error) {
 	}
 	return p, nil
 }
+
+// setLogFormat - log in JSON if conf enables any logger, called once on start before the server
+// logs concurrently. Loggers enabled by a later reload keep the format chosen on start.
+func setLogFormat(conf *configV2) {
+	if conf.IsMongoLoggingEnabled() || conf.IsSysloggingEnabled() || conf.IsFileLoggingEnabled() {
+		log.Formatter = &logrus.JSONFormatter{} // JSON formatted log.
+		log.Level = logrus.InfoLevel            // Minimum log level.
+	}
+}
+
+// setLogger - enable the loggers of conf in place of the ones enabled so far, which are kept
+// if any of the new ones fails
 func setLogger(conf *configV2) *probe.Error {
+	hooks := make(logrus.LevelHooks)
+	if err := addLoggerHooks(hooks, conf); err != nil {
+		closeHooks(hooks)
+		return err.Trace()
+	}
+	configuredHooks.replace(hooks)
+	return nil
+}
+
+// addLoggerHooks - add hooks of the loggers enabled in conf
+func addLoggerHooks(hooks logrus.LevelHooks, conf *configV2) *probe.Error {
 	if conf.IsMongoLoggingEnabled() {
-		err := log2Mongo(conf.MongoLogger.Addr, conf.MongoLogger.DB, conf.MongoLogger.Collection)
+		var flushInterval time.Duration
+		if conf.MongoLogger.FlushInterval != "" {
+			var e error
+			flushInterval, e = time.ParseDuration(conf.MongoLogger.FlushInterval)
+			if e != nil {
+				return probe.NewError(e)
+			}
+		}
+		err := log2Mongo(hooks, conf.MongoLogger.Addr, conf.MongoLogger.DB, conf.MongoLogger.Collection,
+			conf.MongoLogger.BufferSize, flushInterval)
 		if err != nil {
 			return err.Trace()
 		}
 	}
 	if conf.IsSysloggingEnabled() {
-		err := log2Syslog(conf.SyslogLogger.Network, conf.SyslogLogger.Addr)
+		var err *probe.Error
+		if conf.SyslogLogger.Network == syslogTLSNetwork {
+			err = log2SyslogTLS(hooks, conf.SyslogLogger.Addr, conf.SyslogLogger.CAFile, conf.SyslogLogger.CertFile, conf.SyslogLogger.KeyFile)
+		} else {
+			err = log2Syslog(hooks, conf.SyslogLogger.Network, conf.SyslogLogger.Addr)
+		}
 		if err != nil {
 			return err.Trace()
 		}
 	}
 	if conf.IsFileLoggingEnabled() {
-		err := log2File(conf.FileLogger.Filename)
+		var maxAge time.Duration
+		if conf.FileLogger.MaxAge != "" {
+			var e error
+			maxAge, e = time.ParseDuration(conf.FileLogger.MaxAge)
+			if e != nil {
+				return probe.NewError(e)
+			}
+		}
+		// maxSize is configured in megabytes
+		err := log2File(hooks, conf.FileLogger.Filename, int64(conf.FileLogger.MaxSize)*1024*1024, maxAge,
+			conf.FileLogger.MaxBackups, conf.FileLogger.Compress)
 		if err != nil {
 			return err.Trace()
 		}
@@ -206,6 +707,15 @@ func getConfig() (*configV2, *probe.Error) {
 			config.Version = "2"
 			config.Credentials.AccessKeyID = string(mustGenerateAccessKeyID())
 			config.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+			// seed with credentials from environment if set
+			accessKeyID, secretAccessKey, err := getCredentialsFromEnv()
+			if err != nil {
+				return nil, err.Trace()
+			}
+			if accessKeyID != "" {
+				config.Credentials.AccessKeyID = accessKeyID
+				config.Credentials.SecretAccessKey = secretAccessKey
+			}
 			if err := saveConfig(config); err != nil {
 				return nil, err.Trace()
 			}
@@ -218,49 +728,86 @@ func getConfig() (*configV2, *probe.Error) {
 
 type accessKeys struct {
 	*configV2
+	credentialsFile string // secret key is not printed when written to this file
 }
 
 func (a accessKeys) String() string {
 	magenta := color.New(color.FgMagenta, color.Bold).SprintFunc()
 	white := color.New(color.FgWhite, color.Bold).SprintfFunc()
+	if a.credentialsFile != "" {
+		return fmt.Sprint(magenta("AccessKey: ") + white(a.Credentials.AccessKeyID) + "  " + magenta("SecretKey: ") + white("written to %s", a.credentialsFile))
+	}
 	return fmt.Sprint(magenta("AccessKey: ") + white(a.Credentials.AccessKeyID) + "  " + magenta("SecretKey: ") + white(a.Credentials.SecretAccessKey))
 }
 
 // JSON - json formatted output
 func (a accessKeys) JSON() string {
+	if a.credentialsFile != "" {
+		b, err := json.Marshal(struct {
+			AccessKeyID     string `json:"accessKeyId"`
+			CredentialsFile string `json:"credentialsFile"`
+		}{a.Credentials.AccessKeyID, a.credentialsFile})
+		errorIf(probe.NewError(err), "Unable to marshal json", nil)
+		return string(b)
+	}
 	b, err := json.Marshal(a)
 	errorIf(probe.NewError(err), "Unable to marshal json", nil)
 	return string(b)
 }
 
+// saveCredentials write access keys to a file readable only by the owner
+func saveCredentials(credentialsFile string, conf *configV2) *probe.Error {
+	credentialsBytes, err := json.MarshalIndent(conf.Credentials, "", "\t")
+	if err != nil {
+		return probe.NewError(err)
+	}
+	if err := ioutil.WriteFile(credentialsFile, credentialsBytes, 0600); err != nil {
+		return probe.NewError(err)
+	}
+	// WriteFile does not change permissions of an already existing file
+	if err := os.Chmod(credentialsFile, 0600); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
 // initServer initialize server
-func initServer() *probe.Error {
+func initServer(credentialsFile string) *probe.Error {
 	conf, err := getConfig()
 	if err != nil {
 		return err.Trace()
 	}
+	setLogFormat(conf)
 	if err := setLogger(conf); err != nil {
 		return err.Trace()
 	}
-	if conf != nil {
+	secretAccessKey := conf.Credentials.SecretAccessKey
+	if credentialsFile != "" {
+		if err := saveCredentials(credentialsFile, conf); err != nil {
+			return err.Trace(credentialsFile)
+		}
+		secretAccessKey = "SECRET-KEY"
+	}
+	// keys are already available in credentials file, in quiet mode do not print them
+	if conf != nil && !(globalQuietFlag && credentialsFile != "") {
 		if globalJSONFlag {
-			Println(accessKeys{conf}.JSON())
+			Println(accessKeys{conf, credentialsFile}.JSON())
 		} else {
 			Println()
-			Println(accessKeys{conf})
+			Println(accessKeys{conf, credentialsFile})
 		}
 	}
-	if !globalJSONFlag {
+	if !globalJSONFlag && !globalQuietFlag {
 		Println("\nTo configure Minio Client.")
 		if runtime.GOOS == "windows" {
 			Println("\n\tDownload https://dl.minio.io:9000/updates/2015/Oct/" + runtime.GOOS + "-" + runtime.GOARCH + "/mc.exe")
-			Println("\t$ mc.exe config host add localhost:9000 " + conf.Credentials.AccessKeyID + " " + conf.Credentials.SecretAccessKey)
+			Println("\t$ mc.exe config host add localhost:9000 " + conf.Credentials.AccessKeyID + " " + secretAccessKey)
 			Println("\t$ mc.exe mb localhost/photobucket")
 			Println("\t$ mc.exe cp C:\\Photos... localhost/photobucket")
 		} else {
 			Println("\n\t$ wget https://dl.minio.io:9000/updates/2015/Oct/" + runtime.GOOS + "-" + runtime.GOARCH + "/mc")
 			Println("\t$ chmod 755 mc")
-			Println("\t$ ./mc config host add localhost:9000 " + conf.Credentials.AccessKeyID + " " + conf.Credentials.SecretAccessKey)
+			Println("\t$ ./mc config host add localhost:9000 " + conf.Credentials.AccessKeyID + " " + secretAccessKey)
 			Println("\t$ ./mc mb localhost/photobucket")
 			Println("\t$ ./mc cp ~/Photos... localhost/photobucket")
 		}
@@ -273,9 +820,6 @@ func checkServerSyntax(c *cli.Context) {
 	if !c.Args().Present() || c.Args().First() == "help" {
 		cli.ShowCommandHelpAndExit(c, "server", 1)
 	}
-	if len(c.Args()) > 5 {
-		fatalIf(probe.NewError(errInvalidArgument), "Unnecessary arguments passed. Please refer ‘mc server help’", nil)
-	}
 	path := strings.TrimSpace(c.Args().Last())
 	if path == "" {
 		fatalIf(probe.NewError(errInvalidArgument), "Path argument cannot be empty.", nil)
@@ -285,7 +829,7 @@ func checkServerSyntax(c *cli.Context) {
 func serverMain(c *cli.Context) {
 	checkServerSyntax(c)
 
-	perr := initServer()
+	perr := initServer(c.GlobalString("credentials-file"))
 	fatalIf(perr.Trace(), "Failed to read config for minio.", nil)
 
 	certFile := c.GlobalString("cert")
@@ -302,6 +846,70 @@ func serverMain(c *cli.Context) {
 	var expiration time.Duration
 	expirationSet := false
 
+	var maxParts int
+	maxPartsSet := false
+
+	var maxSessions int
+	maxSessionsSet := false
+
+	var maxUploads int
+	maxUploadsSet := false
+
+	clockSkew := fs.DefaultClockSkew
+	clockSkewSet := false
+
+	var maxObjectSize int64
+	maxObjectSizeSet := false
+
+	var keepAlivePeriod time.Duration
+	var listenTimeout time.Duration
+	listenTimeoutSet := false
+	disableKeepAlives := false
+	keepAliveSet := false
+
+	diskRetries := fs.DefaultDiskRetries
+	diskRetriesSet := false
+
+	diskBackoff := fs.DefaultDiskRetryBackoff
+	diskBackoffSet := false
+
+	var diskFullWait time.Duration
+	diskFullWaitSet := false
+
+	partReadahead := fs.DefaultConcatConcurrency
+	partReadaheadSet := false
+
+	writeBuffer := fs.DefaultWriteBufferSize
+	writeBufferSet := false
+
+	regions := defaultRegions
+	regionsSet := false
+
+	tempFileAge := fs.DefaultAtomicTempAge
+	tempFileAgeSet := false
+
+	storageClass := fs.StorageClassStandard
+	storageClassSet := false
+
+	var requestBandwidth, clientBandwidth int64
+	requestBandwidthSet, clientBandwidthSet := false, false
+
+	fileMode, dirMode := fs.DefaultFileMode, fs.DefaultDirMode
+	fileModeSet, dirModeSet := false, false
+	portableKeys, portableKeysSet := false, false
+	sequentialRead, sequentialReadSet := false, false
+	folderObjects, folderObjectsSet := true, false
+	skipPartMD5, skipPartMD5Set := false, false
+	signingKeyTTL := fs.DefaultSigningKeyTTL
+	noSigningKeyCache, signingKeyTTLSet := false, false
+	var immutableWindow time.Duration
+	immutableWindowSet := false
+
+	var maxHeaderBytes int
+	maxHeaderBytesSet := false
+	var maxRequestBodyBytes int64
+	maxRequestBodyBytesSet := false
+
 	args := c.Args()
 	for len(args) >= 2 {
 		switch args.First() {
@@ -325,29 +933,487 @@ func serverMain(c *cli.Context) {
 			fatalIf(probe.NewError(err), "Invalid expiration time "+args.First()+" passed.", nil)
 			args = args.Tail()
 			expirationSet = true
+		case "max-parts":
+			if maxPartsSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum parts should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			maxParts, err = strconv.Atoi(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum parts "+args.First()+" passed.", nil)
+			if maxParts <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum parts should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			maxPartsSet = true
+		case "max-sessions":
+			if maxSessionsSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum sessions should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			maxSessions, err = strconv.Atoi(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum sessions "+args.First()+" passed.", nil)
+			if maxSessions <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum sessions should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			maxSessionsSet = true
+		case "max-uploads":
+			if maxUploadsSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum uploads should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			maxUploads, err = strconv.Atoi(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum uploads "+args.First()+" passed.", nil)
+			if maxUploads <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum uploads should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			maxUploadsSet = true
+		case "clock-skew":
+			if clockSkewSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Clock skew should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			clockSkew, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid clock skew "+args.First()+" passed.", nil)
+			if clockSkew <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Clock skew should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			clockSkewSet = true
+		case "max-object-size":
+			if maxObjectSizeSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum object size should be set only once.", nil)
+			}
+			args = args.Tail()
+			size, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum object size "+args.First()+" passed.", nil)
+			if size == 0 || size > math.MaxInt64 {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum object size should be greater than zero.", nil)
+			}
+			maxObjectSize = int64(size)
+			args = args.Tail()
+			maxObjectSizeSet = true
+		case "keep-alive":
+			if keepAliveSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Keep-alive should be set only once.", nil)
+			}
+			args = args.Tail()
+			if args.First() == "off" {
+				disableKeepAlives = true
+			} else {
+				var err error
+				keepAlivePeriod, err = time.ParseDuration(args.First())
+				fatalIf(probe.NewError(err), "Invalid keep-alive period "+args.First()+" passed.", nil)
+				if keepAlivePeriod <= 0 {
+					fatalIf(probe.NewError(errInvalidArgument), "Keep-alive period should be greater than zero.", nil)
+				}
+			}
+			args = args.Tail()
+			keepAliveSet = true
+		case "listen-timeout":
+			if listenTimeoutSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Listen timeout should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			listenTimeout, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid listen timeout "+args.First()+" passed.", nil)
+			if listenTimeout < 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Listen timeout should not be negative.", nil)
+			}
+			args = args.Tail()
+			listenTimeoutSet = true
+		case "disk-retries":
+			if diskRetriesSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk retries should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			diskRetries, err = strconv.Atoi(args.First())
+			fatalIf(probe.NewError(err), "Invalid disk retries "+args.First()+" passed.", nil)
+			if diskRetries < 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk retries should not be negative.", nil)
+			}
+			args = args.Tail()
+			diskRetriesSet = true
+		case "disk-backoff":
+			if diskBackoffSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk backoff should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			diskBackoff, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid disk backoff "+args.First()+" passed.", nil)
+			if diskBackoff <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk backoff should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			diskBackoffSet = true
+		case "disk-full-wait":
+			if diskFullWaitSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk full wait should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			diskFullWait, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid disk full wait "+args.First()+" passed.", nil)
+			if diskFullWait < 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Disk full wait should not be negative.", nil)
+			}
+			args = args.Tail()
+			diskFullWaitSet = true
+		case "part-readahead":
+			if partReadaheadSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Part readahead should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			partReadahead, err = strconv.Atoi(args.First())
+			fatalIf(probe.NewError(err), "Invalid part readahead "+args.First()+" passed.", nil)
+			if partReadahead <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Part readahead should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			partReadaheadSet = true
+		case "write-buffer":
+			if writeBufferSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Write buffer size should be set only once.", nil)
+			}
+			args = args.Tail()
+			size, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid write buffer size "+args.First()+" passed.", nil)
+			if size < fs.MinWriteBufferSize || size > fs.MaxWriteBufferSize {
+				fatalIf(probe.NewError(fs.InvalidWriteBufferSize{Size: int(size)}), "Invalid write buffer size "+args.First()+" passed.", nil)
+			}
+			writeBuffer = int(size)
+			args = args.Tail()
+			writeBufferSet = true
+		case "region":
+			if regionsSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Region should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err *probe.Error
+			regions, err = parseRegions(args.First())
+			fatalIf(err.Trace(args.First()), "Invalid region "+args.First()+" passed.", nil)
+			args = args.Tail()
+			regionsSet = true
+		case "temp-file-age":
+			if tempFileAgeSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Temporary file age should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			tempFileAge, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid temporary file age "+args.First()+" passed.", nil)
+			if tempFileAge <= 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Temporary file age should be greater than zero.", nil)
+			}
+			args = args.Tail()
+			tempFileAgeSet = true
+		case "storage-class":
+			if storageClassSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Storage class should be set only once.", nil)
+			}
+			args = args.Tail()
+			storageClass = args.First()
+			if !fs.IsValidStorageClass(storageClass) {
+				fatalIf(probe.NewError(errInvalidArgument), "Invalid storage class "+args.First()+" passed.", nil)
+			}
+			args = args.Tail()
+			storageClassSet = true
+		case "bandwidth":
+			if requestBandwidthSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Bandwidth should be set only once.", nil)
+			}
+			args = args.Tail()
+			rate, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid bandwidth "+args.First()+" passed.", nil)
+			if rate == 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Bandwidth should be greater than zero.", nil)
+			}
+			requestBandwidth = int64(rate)
+			args = args.Tail()
+			requestBandwidthSet = true
+		case "client-bandwidth":
+			if clientBandwidthSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Client bandwidth should be set only once.", nil)
+			}
+			args = args.Tail()
+			rate, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid client bandwidth "+args.First()+" passed.", nil)
+			if rate == 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Client bandwidth should be greater than zero.", nil)
+			}
+			clientBandwidth = int64(rate)
+			args = args.Tail()
+			clientBandwidthSet = true
+		case "file-mode":
+			if fileModeSet {
+				fatalIf(probe.NewError(errInvalidArgument), "File mode should be set only once.", nil)
+			}
+			args = args.Tail()
+			mode, err := strconv.ParseUint(args.First(), 8, 32)
+			fatalIf(probe.NewError(err), "Invalid file mode "+args.First()+" passed.", nil)
+			fileMode = os.FileMode(mode)
+			args = args.Tail()
+			fileModeSet = true
+		case "dir-mode":
+			if dirModeSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Directory mode should be set only once.", nil)
+			}
+			args = args.Tail()
+			mode, err := strconv.ParseUint(args.First(), 8, 32)
+			fatalIf(probe.NewError(err), "Invalid directory mode "+args.First()+" passed.", nil)
+			dirMode = os.FileMode(mode)
+			args = args.Tail()
+			dirModeSet = true
+		case "portable-keys":
+			if portableKeysSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Portable keys should be set only once.", nil)
+			}
+			args = args.Tail()
+			switch args.First() {
+			case "on":
+				portableKeys = true
+			case "off":
+				portableKeys = false
+			default:
+				fatalIf(probe.NewError(errInvalidArgument), "Invalid portable keys "+args.First()+" passed, should be on or off.", nil)
+			}
+			args = args.Tail()
+			portableKeysSet = true
+		case "sequential-read":
+			if sequentialReadSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Sequential read should be set only once.", nil)
+			}
+			args = args.Tail()
+			switch args.First() {
+			case "on":
+				sequentialRead = true
+			case "off":
+				sequentialRead = false
+			default:
+				fatalIf(probe.NewError(errInvalidArgument), "Invalid sequential read "+args.First()+" passed, should be on or off.", nil)
+			}
+			args = args.Tail()
+			sequentialReadSet = true
+		case "folder-objects":
+			if folderObjectsSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Folder objects should be set only once.", nil)
+			}
+			args = args.Tail()
+			switch args.First() {
+			case "on":
+				folderObjects = true
+			case "off":
+				folderObjects = false
+			default:
+				fatalIf(probe.NewError(errInvalidArgument), "Invalid folder objects "+args.First()+" passed, should be on or off.", nil)
+			}
+			args = args.Tail()
+			folderObjectsSet = true
+		case "skip-part-md5":
+			if skipPartMD5Set {
+				fatalIf(probe.NewError(errInvalidArgument), "Skip part md5 should be set only once.", nil)
+			}
+			args = args.Tail()
+			switch args.First() {
+			case "on":
+				skipPartMD5 = true
+			case "off":
+				skipPartMD5 = false
+			default:
+				fatalIf(probe.NewError(errInvalidArgument), "Invalid skip part md5 "+args.First()+" passed, should be on or off.", nil)
+			}
+			args = args.Tail()
+			skipPartMD5Set = true
+		case "signing-key-ttl":
+			if signingKeyTTLSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Signing key TTL should be set only once.", nil)
+			}
+			args = args.Tail()
+			if args.First() == "off" {
+				noSigningKeyCache = true
+			} else {
+				var err error
+				signingKeyTTL, err = time.ParseDuration(args.First())
+				fatalIf(probe.NewError(err), "Invalid signing key TTL "+args.First()+" passed.", nil)
+				if signingKeyTTL <= 0 {
+					fatalIf(probe.NewError(errInvalidArgument), "Signing key TTL should be greater than zero.", nil)
+				}
+			}
+			args = args.Tail()
+			signingKeyTTLSet = true
+		case "immutable-window":
+			if immutableWindowSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Immutable window should be set only once.", nil)
+			}
+			args = args.Tail()
+			var err error
+			immutableWindow, err = time.ParseDuration(args.First())
+			fatalIf(probe.NewError(err), "Invalid immutable window "+args.First()+" passed.", nil)
+			if immutableWindow < 0 {
+				fatalIf(probe.NewError(errInvalidArgument), "Immutable window should not be negative.", nil)
+			}
+			args = args.Tail()
+			immutableWindowSet = true
+		case "max-header-size":
+			if maxHeaderBytesSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum header size should be set only once.", nil)
+			}
+			args = args.Tail()
+			size, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum header size "+args.First()+" passed.", nil)
+			if size == 0 || size > maxHeaderBytesLimit {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum header size should be greater than zero and at most 64MB.", nil)
+			}
+			maxHeaderBytes = int(size)
+			args = args.Tail()
+			maxHeaderBytesSet = true
+		case "max-body-size":
+			if maxRequestBodyBytesSet {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum request body size should be set only once.", nil)
+			}
+			args = args.Tail()
+			size, err := humanize.ParseBytes(args.First())
+			fatalIf(probe.NewError(err), "Invalid maximum request body size "+args.First()+" passed.", nil)
+			if size == 0 || size > math.MaxInt64 {
+				fatalIf(probe.NewError(errInvalidArgument), "Maximum request body size should be greater than zero.", nil)
+			}
+			maxRequestBodyBytes = int64(size)
+			args = args.Tail()
+			maxRequestBodyBytesSet = true
 		default:
 			cli.ShowCommandHelpAndExit(c, "server", 1) // last argument is exit code
 		}
 	}
 
+	perr = validateServerAddresses(c.GlobalString("address"))
+	fatalIf(perr.Trace(), "Invalid address "+c.GlobalString("address")+" passed.", nil)
+
+	socketMode, err := strconv.ParseUint(c.GlobalString("socket-mode"), 8, 32)
+	fatalIf(probe.NewError(err), "Invalid socket mode "+c.GlobalString("socket-mode")+" passed.", nil)
+	if os.FileMode(socketMode)&^os.ModePerm != 0 {
+		fatalIf(probe.NewError(errInvalidArgument), "Socket mode should only carry permission bits.", nil)
+	}
+
+	accessLogSample, err := strconv.ParseFloat(c.GlobalString("accesslog-sample"), 64)
+	fatalIf(probe.NewError(err), "Invalid access log sample "+c.GlobalString("accesslog-sample")+" passed.", nil)
+	if accessLogSample <= 0 || accessLogSample > 1 {
+		fatalIf(probe.NewError(errInvalidArgument), "Access log sample should be greater than zero and at most 1.", nil)
+	}
+
+	anonymousRead, perr := parseAnonymousReadPolicy(c.GlobalString("anonymous-read"))
+	fatalIf(perr.Trace(), "Invalid anonymous read buckets "+c.GlobalString("anonymous-read")+" passed.", nil)
+	sourceFilter, perr := parseIPFilter(c.GlobalString("allow-ips"), c.GlobalString("deny-ips"))
+	fatalIf(perr.Trace(), "Invalid networks to allow or deny requests from passed.", nil)
+	proxies, perr := parseTrustedProxies(c.GlobalString("trusted-proxies"))
+	fatalIf(perr.Trace(), "Invalid networks of trusted proxies "+c.GlobalString("trusted-proxies")+" passed.", nil)
+	if c.GlobalBool("anonymous") {
+		log.Warn("Anonymous mode is deprecated and accepts every request without signature, use --anonymous-read instead.")
+	}
+
 	path := strings.TrimSpace(c.Args().Last())
 	// Last argument is always path
 	if _, err := os.Stat(path); err != nil {
 		fatalIf(probe.NewError(err), "Unable to validate the path", nil)
 	}
+	conf, perr := getConfig()
+	fatalIf(perr.Trace(), "Failed to read config for minio.", nil)
+	masterKey, perr := getMasterKey(conf)
+	fatalIf(perr.Trace(), "Invalid master key for server side encryption.", nil)
+	contentTypes, perr := newContentTypes(conf.ContentTypes)
+	fatalIf(perr.Trace(), "Invalid content types in config.", nil)
+
 	tls := (certFile != "" && keyFile != "")
+	if c.GlobalBool("http2") && !tls {
+		log.Warn("HTTP/2 is only negotiated over TLS, --http2 is ignored without --cert and --key.")
+	}
+	if c.GlobalBool("browser") && !browserBuild {
+		log.Warn("The browser is not compiled in, --browser is ignored.")
+	}
 	apiServerConfig := cloudServerConfig{
-		Address:     c.GlobalString("address"),
-		AccessLog:   c.GlobalBool("enable-accesslog"),
-		Anonymous:   c.GlobalBool("anonymous"),
-		Path:        path,
-		MinFreeDisk: minFreeDisk,
-		Expiry:      expiration,
-		TLS:         tls,
-		CertFile:    certFile,
-		KeyFile:     keyFile,
-		RateLimit:   c.GlobalInt("ratelimit"),
+		Address:             c.GlobalString("address"),
+		SocketMode:          os.FileMode(socketMode),
+		ProfileAddress:      c.GlobalString("profile"),
+		AccessLog:           c.GlobalBool("enable-accesslog"),
+		AccessLogSample:     accessLogSample,
+		Anonymous:           c.GlobalBool("anonymous"),
+		AnonymousRead:       anonymousRead,
+		IPFilter:            sourceFilter,
+		TrustedProxies:      proxies,
+		Browser:             c.GlobalBool("browser"),
+		ContentTypes:        contentTypes,
+		Path:                path,
+		MinFreeDisk:         minFreeDisk,
+		Expiry:              expiration,
+		MaxParts:            maxParts,
+		MaxSessions:         maxSessions,
+		MaxUploads:          maxUploads,
+		MaxObjectSize:       maxObjectSize,
+		ClockSkew:           clockSkew,
+		Regions:             regions,
+		MasterKey:           masterKey,
+		DiskRetries:         diskRetries,
+		DiskBackoff:         diskBackoff,
+		DiskFullWait:        diskFullWait,
+		PartReadahead:       partReadahead,
+		WriteBuffer:         writeBuffer,
+		TempFileAge:         tempFileAge,
+		StorageClass:        storageClass,
+		FileMode:            fileMode,
+		DirMode:             dirMode,
+		PortableKeys:        portableKeys,
+		ReadHint:            sequentialRead,
+		NoFolders:           !folderObjects,
+		SkipPartMD5:         skipPartMD5,
+		Immutable:           immutableWindow,
+		SigningKeyTTL:       signingKeyTTL,
+		NoSigningKeyCache:   noSigningKeyCache,
+		RequestBandwidth:    requestBandwidth,
+		ClientBandwidth:     clientBandwidth,
+		TLS:                 tls,
+		CertFile:            certFile,
+		KeyFile:             keyFile,
+		StrictCert:          c.GlobalBool("strict-cert"),
+		HTTP2:               c.GlobalBool("http2"),
+		RateLimit:           c.GlobalInt("ratelimit"),
+		MaxHeaderBytes:      maxHeaderBytes,
+		MaxRequestBodyBytes: maxRequestBodyBytes,
+		KeepAlivePeriod:     keepAlivePeriod,
+		ListenTimeout:       listenTimeout,
+		DisableKeepAlives:   disableKeepAlives,
+	}
+	if c.GlobalBool("validate") {
+		// initServer has already verified config and logger targets by now
+		perr = validateServerConfig(apiServerConfig)
+		fatalIf(perr.Trace(), "Server configuration is invalid.", nil)
+		Println("Server configuration is valid.")
+		Printf("Path: %s\n", apiServerConfig.Path)
+		Printf("Address: %s\n", apiServerConfig.Address)
+		Printf("TLS: %t\n", apiServerConfig.TLS)
+		return
+	}
+	// signed requests are rejected once their date is further off the server time than this
+	globalClockSkew = apiServerConfig.ClockSkew
+	// requests are verified against credentials of conf until config is reloaded on SIGHUP
+	setServerConfig(conf)
+	stopReload := reloadOnSignal()
+	defer stopReload()
+
+	// addresses in use fail the start before the server is announced
+	if address, perr := checkServerAddresses(apiServerConfig); perr != nil {
+		if perr.ToGoError() == errAddressInUse {
+			fatalIf(perr.Trace(address), "Address already in use: "+address+".", nil)
+		}
+		fatalIf(perr.Trace(address), "Unable to listen on "+address+".", nil)
 	}
 	perr = startServer(apiServerConfig)
-	errorIf(perr.Trace(), "Failed to start the minio server.", nil)
+	fatalIf(perr.Trace(), "Failed to start the minio server.", nil)
 }
@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// profileMaxHeaderBytes - bytes of request line and headers accepted by the profile server, which
+// serves anyone reaching it and needs no more than a short request line
+const profileMaxHeaderBytes = 8 << 10
+
+// getProfileHandler - net/http/pprof endpoints under /debug/pprof/, served apart from the cloud storage API
+func getProfileHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// validateProfileAddress - profiles are only served on a TCP address of their own
+func validateProfileAddress(conf cloudServerConfig) *probe.Error {
+	for _, address := range serverAddresses(conf.Address) {
+		if conf.ProfileAddress == address {
+			return probe.NewError(errInvalidArgument).Trace(conf.ProfileAddress)
+		}
+	}
+	if _, _, err := splitListenAddress(conf.ProfileAddress); err != nil {
+		return err.Trace()
+	}
+	return nil
+}
+
+// startProfileServer - serve profiles on the profile address in the background, closing the
+// returned listener stops serving
+func startProfileServer(conf cloudServerConfig) (net.Listener, *probe.Error) {
+	if err := validateProfileAddress(conf); err != nil {
+		return nil, err.Trace()
+	}
+	listener, err := net.Listen("tcp", conf.ProfileAddress)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	if host, _, _ := net.SplitHostPort(conf.ProfileAddress); host == "" || net.ParseIP(host).IsUnspecified() {
+		log.WithFields(map[string]interface{}{"address": conf.ProfileAddress}).Warn("Profiles are served on all interfaces, bind them to a private address instead.")
+	}
+	profileServer := &http.Server{
+		Handler:        getProfileHandler(),
+		MaxHeaderBytes: profileMaxHeaderBytes,
+	}
+	if conf.MaxHeaderBytes > 0 && conf.MaxHeaderBytes < profileMaxHeaderBytes {
+		profileServer.MaxHeaderBytes = conf.MaxHeaderBytes
+	}
+	go func() {
+		err := profileServer.Serve(listener)
+		errorIf(probe.NewError(err), "Serving profiles stopped.", map[string]interface{}{"address": conf.ProfileAddress})
+	}()
+	return listener, nil
+}
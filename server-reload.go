@@ -0,0 +1,79 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// validateCredentials - requests are only verified against well formed credentials
+func validateCredentials(conf *configV2) *probe.Error {
+	if conf.Credentials.AccessKeyID == "" || !isValidAccessKey(conf.Credentials.AccessKeyID) {
+		return probe.NewError(errInvalidCredentials)
+	}
+	if !isValidSecretKey(conf.Credentials.SecretAccessKey) {
+		return probe.NewError(errInvalidCredentials)
+	}
+	return nil
+}
+
+// reloadServerConfig - re-read config from disk, re-initialize its loggers and swap in its
+// credentials. Config which fails to load, carries malformed credentials or any logger which
+// fails to start is rejected, the running config is kept as is.
+func reloadServerConfig() *probe.Error {
+	conf, err := loadConfigV2()
+	if err != nil {
+		return err.Trace()
+	}
+	if err := validateCredentials(conf); err != nil {
+		return err.Trace()
+	}
+	if err := setLogger(conf); err != nil {
+		return err.Trace()
+	}
+	setServerConfig(conf)
+	return nil
+}
+
+// reloadOnSignal - reload config every time the server receives SIGHUP, until stopped
+func reloadOnSignal() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if err := reloadServerConfig(); err != nil {
+					errorIf(err.Trace(), "Reloading config failed, running config is kept.", nil)
+					continue
+				}
+				log.Info("Config reloaded.")
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
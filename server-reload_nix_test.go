@@ -0,0 +1,120 @@
+// +build !windows
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+type ServerReloadSuite struct{}
+
+var _ = Suite(&ServerReloadSuite{})
+
+func (s *ServerReloadSuite) TestReloadOnSignal(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-reload-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	savedConfigPath := customConfigPath
+	customConfigPath = root
+	defer func() { customConfigPath = savedConfigPath }()
+
+	conf := newConfigV2()
+	conf.Credentials.AccessKeyID = string(mustGenerateAccessKeyID())
+	conf.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+	c.Assert(saveConfig(conf), IsNil)
+	setServerConfig(conf)
+	defer setServerConfig(nil)
+	stopReload := reloadOnSignal()
+	defer stopReload()
+
+	// changes on disk are only picked up on SIGHUP
+	changed := newConfigV2()
+	changed.Credentials.AccessKeyID = conf.Credentials.AccessKeyID
+	changed.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+	c.Assert(saveConfig(changed), IsNil)
+	running, perr := getServerConfig()
+	c.Assert(perr, IsNil)
+	c.Assert(running.Credentials.SecretAccessKey, Equals, conf.Credentials.SecretAccessKey)
+
+	c.Assert(syscall.Kill(os.Getpid(), syscall.SIGHUP), IsNil)
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Credentials.SecretAccessKey != changed.Credentials.SecretAccessKey && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		running, perr = getServerConfig()
+		c.Assert(perr, IsNil)
+	}
+	c.Assert(running.Credentials.SecretAccessKey, Equals, changed.Credentials.SecretAccessKey)
+	c.Assert(running.Credentials.AccessKeyID, Equals, conf.Credentials.AccessKeyID)
+
+	// invalid config is rejected, the running one is kept
+	invalid := newConfigV2()
+	invalid.Credentials.AccessKeyID = conf.Credentials.AccessKeyID
+	invalid.Credentials.SecretAccessKey = "short"
+	c.Assert(saveConfig(invalid), IsNil)
+	perr = reloadServerConfig()
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), Equals, errInvalidCredentials)
+
+	configFile, perr := getConfigFile()
+	c.Assert(perr, IsNil)
+	c.Assert(ioutil.WriteFile(configFile, []byte("{"), 0600), IsNil)
+	c.Assert(reloadServerConfig(), Not(IsNil))
+
+	running, perr = getServerConfig()
+	c.Assert(perr, IsNil)
+	c.Assert(running.Credentials.SecretAccessKey, Equals, changed.Credentials.SecretAccessKey)
+}
+
+func (s *ServerReloadSuite) TestReloadKeepsLogFormat(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minio-reload-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(root)
+
+	savedConfigPath := customConfigPath
+	customConfigPath = root
+	defer func() { customConfigPath = savedConfigPath }()
+	formatter, level := log.Formatter, log.Level
+	defer func() { log.Formatter, log.Level = formatter, level }()
+	defer configuredHooks.replace(make(logrus.LevelHooks))
+
+	// a logger enabled by a reload swaps in its hook, the format chosen on start is kept
+	conf := newConfigV2()
+	conf.Credentials.AccessKeyID = string(mustGenerateAccessKeyID())
+	conf.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+	conf.FileLogger.Filename = filepath.Join(root, "minio.log")
+	c.Assert(saveConfig(conf), IsNil)
+	defer setServerConfig(nil)
+	c.Assert(reloadServerConfig(), IsNil)
+	c.Assert(log.Formatter, Equals, formatter)
+	c.Assert(log.Level, Equals, level)
+	c.Assert(len(configuredHooks.hooks), Not(Equals), 0)
+
+	// the format is chosen by the loggers enabled on start
+	setLogFormat(conf)
+	_, ok := log.Formatter.(*logrus.JSONFormatter)
+	c.Assert(ok, Equals, true)
+}
@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// unixAddressPrefix - prefix of an address naming a unix domain socket file, as in 'unix:/run/minio.sock'
+const unixAddressPrefix = "unix:"
+
+// defaultSocketMode - permissions of the unix domain socket file, owner and group may connect
+const defaultSocketMode os.FileMode = 0660
+
+// unixSocketPath - path of the socket file if address names a unix domain socket
+func unixSocketPath(address string) (string, bool) {
+	if !strings.HasPrefix(address, unixAddressPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(address, unixAddressPrefix), true
+}
+
+// removeStaleSocket - remove a socket file left behind by a server which did not shut down
+// cleanly, refuses to remove anything which is not a socket or a socket still accepting connections
+func removeStaleSocket(socketPath string) *probe.Error {
+	st, err := os.Lstat(socketPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return probe.NewError(err)
+	}
+	if st.Mode()&os.ModeSocket == 0 {
+		return probe.NewError(errNotASocket).Trace(socketPath)
+	}
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return probe.NewError(errSocketInUse).Trace(socketPath)
+	}
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// listenUnix - listen on the unix domain socket named by the server address with the configured
+// permissions, wrapped in TLS if certificates are configured
+func listenUnix(conf cloudServerConfig, tlsConfig *tls.Config) (net.Listener, *probe.Error) {
+	socketPath, ok := unixSocketPath(conf.Address)
+	if !ok || socketPath == "" {
+		return nil, probe.NewError(errInvalidArgument).Trace(conf.Address)
+	}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, err.Trace()
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	socketMode := conf.SocketMode
+	if socketMode == 0 {
+		socketMode = defaultSocketMode
+	}
+	if err := os.Chmod(socketPath, socketMode); err != nil {
+		// closing the listener removes the socket file as well
+		listener.Close()
+		return nil, probe.NewError(err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	return listener, nil
+}
+
+// serveUnix - serve on the unix domain socket until the server receives SIGTERM, which shuts it
+// down gracefully
+func serveUnix(server *http.Server, listener net.Listener) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	return serveUntilShutdown(server, listener, signals)
+}
+
+// serveUntilShutdown - serve until a signal is received on shutdown, then stop accepting
+// connections and return once every request has been served. Returns nil on a shutdown, the
+// error Serve failed with otherwise.
+func serveUntilShutdown(server *http.Server, listener net.Listener, shutdown <-chan os.Signal) error {
+	done := make(chan struct{})
+	defer close(done)
+	shutdownErr := make(chan error, 1)
+	go func() {
+		select {
+		case <-shutdown:
+			shutdownErr <- server.Shutdown(context.Background())
+		case <-done:
+		}
+	}()
+	// Serve returns as soon as shutdown starts, requests in flight are still being served
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return <-shutdownErr
+}
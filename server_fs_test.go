@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -27,9 +28,11 @@ import (
 	"time"
 
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
 	"github.com/minio/minio/pkg/fs"
 	. "gopkg.in/check.v1"
@@ -90,10 +93,13 @@ func (s *MyAPIFSCacheSuite) SetUpSuite(c *C) {
 	perr = saveConfig(conf)
 	c.Assert(perr, IsNil)
 
+	anonymousRead, perr := parseAnonymousReadPolicy("anonymousreadable")
+	c.Assert(perr, IsNil)
 	cloudServer := cloudServerConfig{
-		Path:        fsroot,
-		MinFreeDisk: 0,
-		Anonymous:   false,
+		Path:          fsroot,
+		MinFreeDisk:   0,
+		Anonymous:     false,
+		AnonymousRead: anonymousRead,
 	}
 	cloudStorageAPI := getNewCloudStorageAPI(cloudServer)
 	httpHandler := getCloudStorageAPIHandler(cloudStorageAPI)
@@ -141,7 +147,20 @@ var ignoredHeaders = map[string]bool{
 }
 
 func (s *MyAPIFSCacheSuite) newRequest(method, urlStr string, contentLength int64, body io.ReadSeeker) (*http.Request, error) {
-	t := time.Now().UTC()
+	return s.newRequestAt(time.Now().UTC(), method, urlStr, contentLength, body)
+}
+
+func (s *MyAPIFSCacheSuite) newRequestAt(t time.Time, method, urlStr string, contentLength int64, body io.ReadSeeker) (*http.Request, error) {
+	return s.newRequestInScope(t, "milkyway", "s3", method, urlStr, contentLength, body)
+}
+
+func (s *MyAPIFSCacheSuite) newRequestInScope(t time.Time, region, service, method, urlStr string, contentLength int64, body io.ReadSeeker) (*http.Request, error) {
+	return s.newRequestWithPayload(t, region, service, method, urlStr, contentLength, body, "")
+}
+
+// newRequestWithPayload - signed request claiming hashedPayload in x-amz-content-sha256, the
+// hash of body if empty
+func (s *MyAPIFSCacheSuite) newRequestWithPayload(t time.Time, region, service, method, urlStr string, contentLength int64, body io.ReadSeeker, hashedPayload string) (*http.Request, error) {
 	req, err := http.NewRequest(method, urlStr, nil)
 	if err != nil {
 		return nil, err
@@ -173,7 +192,9 @@ func (s *MyAPIFSCacheSuite) newRequest(method, urlStr string, contentLength int6
 			return hex.EncodeToString(sum256Bytes)
 		}
 	}
-	hashedPayload := hash()
+	if hashedPayload == "" {
+		hashedPayload = hash()
+	}
 	req.Header.Set("x-amz-content-sha256", hashedPayload)
 
 	var headers []string
@@ -234,8 +255,8 @@ func (s *MyAPIFSCacheSuite) newRequest(method, urlStr string, contentLength int6
 
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
-		"milkyway",
-		"s3",
+		region,
+		service,
 		"aws4_request",
 	}, "/")
 
@@ -244,9 +265,9 @@ func (s *MyAPIFSCacheSuite) newRequest(method, urlStr string, contentLength int6
 	stringToSign = stringToSign + hex.EncodeToString(sum256([]byte(canonicalRequest)))
 
 	date := sumHMAC([]byte("AWS4"+s.secretAccessKey), []byte(t.Format(yyyymmdd)))
-	region := sumHMAC(date, []byte("milkyway"))
-	service := sumHMAC(region, []byte("s3"))
-	signingKey := sumHMAC(service, []byte("aws4_request"))
+	regionKey := sumHMAC(date, []byte(region))
+	serviceKey := sumHMAC(regionKey, []byte(service))
+	signingKey := sumHMAC(serviceKey, []byte("aws4_request"))
 
 	signature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
 
@@ -262,6 +283,26 @@ func (s *MyAPIFSCacheSuite) newRequest(method, urlStr string, contentLength int6
 	return req, nil
 }
 
+func (s *MyAPIFSCacheSuite) TestSelfTest(c *C) {
+	var output bytes.Buffer
+	savedPrintln := Println
+	Println = func(a ...interface{}) { fmt.Fprintln(&output, a...) }
+	defer func() { Println = savedPrintln }()
+
+	perr := runSelfTest(testAPIFSCacheServer.URL, s.accessKeyID, s.secretAccessKey)
+	c.Assert(perr, IsNil)
+	for _, step := range []string{"MakeBucket", "PutObject", "GetObject", "DeleteObject", "DeleteBucket"} {
+		c.Assert(strings.Contains(output.String(), "PASS: "+step), Equals, true)
+	}
+
+	// wrong credentials, server address without scheme
+	output.Reset()
+	perr = runSelfTest(strings.TrimPrefix(testAPIFSCacheServer.URL, "http://"), s.accessKeyID, "invalid-secret-access-key")
+	c.Assert(perr, Not(IsNil))
+	c.Assert(strings.Contains(output.String(), "FAIL: "), Equals, true)
+	c.Assert(strings.Contains(output.String(), "PASS: GetObject"), Equals, false)
+}
+
 func (s *MyAPIFSCacheSuite) TestAuth(c *C) {
 	secretID, err := generateSecretAccessKey()
 	c.Assert(err, IsNil)
@@ -316,49 +357,215 @@ func (s *MyAPIFSCacheSuite) TestDeleteObject(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
 }
 
-func (s *MyAPIFSCacheSuite) TestNonExistantBucket(c *C) {
-	request, err := s.newRequest("HEAD", testAPIFSCacheServer.URL+"/nonexistantbucket", 0, nil)
+func (s *MyAPIFSCacheSuite) TestAnonymousGetObjectACL(c *C) {
+	for _, bucket := range []string{"anonymousprivate", "anonymouspublicread"} {
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/"+bucket, 0, nil)
+		c.Assert(err, IsNil)
+		if bucket == "anonymouspublicread" {
+			request.Header.Set("x-amz-acl", "public-read")
+		}
+
+		client := http.Client{}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/"+bucket+"/object", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	// anonymous request, no signature
+	request, err := http.NewRequest("GET", testAPIFSCacheServer.URL+"/anonymousprivate/object", nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	request, err = http.NewRequest("GET", testAPIFSCacheServer.URL+"/anonymouspublicread/object", nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	// anonymous writes are denied on public-read buckets
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = http.NewRequest("PUT", testAPIFSCacheServer.URL+"/anonymouspublicread/object", buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
 }
 
-func (s *MyAPIFSCacheSuite) TestEmptyObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/emptyobject", 0, nil)
+func (s *MyAPIFSCacheSuite) TestResponseOverrides(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/responseoverrides", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Set("x-amz-acl", "public-read")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/emptyobject/object", 0, nil)
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/responseoverrides/object", int64(buffer.Len()), buffer)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/emptyobject/object", 0, nil)
+	query := url.Values{}
+	query.Set("response-content-disposition", "attachment")
+	query.Set("response-content-type", "text/plain")
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/responseoverrides/object?"+query.Encode(), 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Disposition"), Equals, "attachment")
+	c.Assert(response.Header.Get("Content-Type"), Equals, "text/plain")
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
 
-	var buffer bytes.Buffer
+	// ranges keep the overrides
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/responseoverrides/object?"+query.Encode(), 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("Range", "bytes=6-")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	c.Assert(response.Header.Get("Content-Disposition"), Equals, "attachment")
+
+	// values which would inject headers of their own are rejected
+	query = url.Values{}
+	query.Set("response-content-disposition", "attachment\r\nSet-Cookie: session=stolen")
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/responseoverrides/object?"+query.Encode(), 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Response header overrides should be valid values of their header.", http.StatusBadRequest)
+
+	query = url.Values{}
+	query.Set("response-expires", "tomorrow")
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/responseoverrides/object?"+query.Encode(), 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Response header overrides should be valid values of their header.", http.StatusBadRequest)
+
+	// anonymous reads may not override headers, even on public buckets
+	request, err = http.NewRequest("GET", testAPIFSCacheServer.URL+"/responseoverrides/object?response-content-disposition=attachment", nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRequest", "Request specific response headers cannot be used for anonymous GET requests.", http.StatusBadRequest)
+}
+
+func (s *MyAPIFSCacheSuite) TestAnonymousReadPolicy(c *C) {
+	for _, bucket := range []string{"anonymousreadable", "anonymousnotreadable"} {
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/"+bucket, 0, nil)
+		c.Assert(err, IsNil)
+
+		client := http.Client{}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/"+bucket+"/object", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	// anonymous reads are allowed on the private bucket named by the policy only
+	client := http.Client{}
+	request, err := http.NewRequest("GET", testAPIFSCacheServer.URL+"/anonymousreadable/object", nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 	responseBody, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, buffer.Bytes()))
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	request, err = http.NewRequest("HEAD", testAPIFSCacheServer.URL+"/anonymousreadable/object", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = http.NewRequest("GET", testAPIFSCacheServer.URL+"/anonymousreadable", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = http.NewRequest("GET", testAPIFSCacheServer.URL+"/anonymousnotreadable/object", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// anonymous writes still need a signature
+	buffer := bytes.NewReader([]byte("hello anonymous"))
+	request, err = http.NewRequest("PUT", testAPIFSCacheServer.URL+"/anonymousreadable/object", buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	request, err = http.NewRequest("DELETE", testAPIFSCacheServer.URL+"/anonymousreadable/object", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// the object is left as written
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/anonymousreadable/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	// policy parsing
+	policy, perr := parseAnonymousReadPolicy(" anonymousreadable , other-bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(policy.allowsRead("anonymousreadable"), Equals, true)
+	c.Assert(policy.allowsRead("other-bucket"), Equals, true)
+	c.Assert(policy.allowsRead("anonymousnotreadable"), Equals, false)
+	policy, perr = parseAnonymousReadPolicy("*")
+	c.Assert(perr, IsNil)
+	c.Assert(policy.allowsRead("anonymousnotreadable"), Equals, true)
+	_, perr = parseAnonymousReadPolicy("anonymousreadable,In_valid")
+	c.Assert(perr, Not(IsNil))
 }
 
-func (s *MyAPIFSCacheSuite) TestBucket(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucket", 0, nil)
+func (s *MyAPIFSCacheSuite) TestStorageInfo(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageinfo", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -366,18 +573,43 @@ func (s *MyAPIFSCacheSuite) TestBucket(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/bucket", 0, nil)
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageinfo/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/_minio/admin/storage", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "application/json")
+
+	var storageInfo StorageInfoResponse
+	c.Assert(json.NewDecoder(response.Body).Decode(&storageInfo), IsNil)
+	c.Assert(storageInfo.Total > 0, Equals, true)
+	c.Assert(storageInfo.Free > 0, Equals, true)
+	c.Assert(storageInfo.Used, Equals, storageInfo.Total-storageInfo.Free)
+	c.Assert(storageInfo.Buckets > 0, Equals, true)
+	c.Assert(storageInfo.Objects > 0, Equals, true)
+
+	// anonymous request, no signature
+	request, err = http.NewRequest("GET", testAPIFSCacheServer.URL+"/_minio/admin/storage", nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
 }
 
-func (s *MyAPIFSCacheSuite) TestObject(c *C) {
-	buffer := bytes.NewReader([]byte("hello world"))
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/testobject", 0, nil)
+func (s *MyAPIFSCacheSuite) TestMaintenanceMode(c *C) {
+	defer setMaintenanceMode(false)
+
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/maintenance", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -385,30 +617,104 @@ func (s *MyAPIFSCacheSuite) TestObject(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/testobject/object", int64(buffer.Len()), buffer)
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/maintenance/object", int64(buffer.Len()), buffer)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/testobject/object", 0, nil)
+	// enable maintenance mode
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/_minio/admin/maintenance", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
+	var maintenanceMode MaintenanceModeResponse
+	c.Assert(json.NewDecoder(response.Body).Decode(&maintenanceMode), IsNil)
+	c.Assert(maintenanceMode.Enabled, Equals, true)
+
+	// writes are rejected
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/maintenance/object1", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "ServiceUnavailable", "Server is in maintenance mode, retry later.", http.StatusServiceUnavailable)
+
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/maintenance/object1?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusServiceUnavailable)
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/maintenance/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusServiceUnavailable)
+
+	// reads keep working
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/maintenance/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 	responseBody, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
 	c.Assert(responseBody, DeepEquals, []byte("hello world"))
 
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/maintenance/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/maintenance", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// disable maintenance mode, writes are accepted again
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/_minio/admin/maintenance", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(json.NewDecoder(response.Body).Decode(&maintenanceMode), IsNil)
+	c.Assert(maintenanceMode.Enabled, Equals, false)
+
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/maintenance/object1", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// anonymous request, no signature
+	request, err = http.NewRequest("PUT", testAPIFSCacheServer.URL+"/_minio/admin/maintenance", nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+	c.Assert(isMaintenanceMode(), Equals, false)
 }
 
-func (s *MyAPIFSCacheSuite) TestMultipleObjects(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects", 0, nil)
+func (s *MyAPIFSCacheSuite) TestObjectLock(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectlock", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -416,194 +722,1375 @@ func (s *MyAPIFSCacheSuite) TestMultipleObjects(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object", 0, nil)
+	// retention in the past is rejected
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectlock/object", int64(buffer.Len()), buffer)
 	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", time.Now().UTC().Add(-time.Hour).Format(time.RFC3339))
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+	verifyError(c, response, "InvalidArgument", "Object lock retention should be a time in the future or a positive duration.", http.StatusBadRequest)
 
-	//// test object 1
+	retainUntil := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectlock/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", retainUntil.Format(time.RFC3339))
 
-	// get object
-	buffer1 := bytes.NewReader([]byte("hello one"))
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object1", int64(buffer1.Len()), buffer1)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/objectlock/object", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Object-Lock-Retain-Until-Date"), Equals, retainUntil.Format(time.RFC3339))
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object1", 0, nil)
+	// locked object may neither be removed nor replaced
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/objectlock/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	buffer = bytes.NewReader([]byte("hello"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectlock/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// bucket default retention
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/_minio/admin/retention/objectlock?retention=24h", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	// verify response data
-	responseBody, err := ioutil.ReadAll(response.Body)
+	var bucketRetention BucketRetentionResponse
+	c.Assert(json.NewDecoder(response.Body).Decode(&bucketRetention), IsNil)
+	c.Assert(bucketRetention.Retention, Equals, "24h0m0s")
+
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectlock/object1", int64(buffer.Len()), buffer)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello one")))
 
-	buffer2 := bytes.NewReader([]byte("hello two"))
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object2", int64(buffer2.Len()), buffer2)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/objectlock/object1", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/_minio/admin/retention/objectlock", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object2", 0, nil)
+	bucketRetention = BucketRetentionResponse{}
+	c.Assert(json.NewDecoder(response.Body).Decode(&bucketRetention), IsNil)
+	c.Assert(bucketRetention.Retention, Equals, "")
+}
+
+func (s *MyAPIFSCacheSuite) TestBucketNotification(c *C) {
+	events := make(chan NotificationEvent, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var event NotificationEvent
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		events <- event
+	}))
+	defer webhook.Close()
+
+	receiveEvent := func() NotificationEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			c.Fatal("event notification not delivered")
+		}
+		return NotificationEvent{}
+	}
+
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketnotification", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// only absolute http urls are accepted
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/_minio/admin/notification/bucketnotification?webhook="+url.QueryEscape("ftp://localhost/events"), 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Notification webhook should be an absolute http or https url.", http.StatusBadRequest)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/_minio/admin/notification/bucketnotification?webhook="+url.QueryEscape(webhook.URL), 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var bucketNotification BucketNotificationResponse
+	c.Assert(json.NewDecoder(response.Body).Decode(&bucketNotification), IsNil)
+	c.Assert(bucketNotification.Webhook, Equals, webhook.URL)
+
+	// multipart upload notifies once completed
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/bucketnotification/object?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	newResponse := &InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
+	uploadID := newResponse.UploadID
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketnotification/object?uploadId="+uploadID+"&partNumber=1", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	completeBytes, err := xml.Marshal(&fs.CompleteMultipartUpload{
+		Part: []fs.CompletePart{{PartNumber: 1, ETag: response.Header.Get("ETag")}},
+	})
+	c.Assert(err, IsNil)
+
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/bucketnotification/object?uploadId="+uploadID, int64(len(completeBytes)), bytes.NewReader(completeBytes))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	event := receiveEvent()
+	c.Assert(len(event.Records), Equals, 1)
+	c.Assert(event.Records[0].EventName, Equals, "s3:ObjectCreated:CompleteMultipartUpload")
+	c.Assert(event.Records[0].S3.Bucket.Name, Equals, "bucketnotification")
+	c.Assert(event.Records[0].S3.Object.Key, Equals, "object")
+	c.Assert(event.Records[0].S3.Object.Size, Equals, int64(len("hello world")))
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/bucketnotification/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	event = receiveEvent()
+	c.Assert(event.Records[0].EventName, Equals, "s3:ObjectRemoved:Delete")
+	c.Assert(event.Records[0].S3.Object.Key, Equals, "object")
+
+	// no more events once disabled
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/_minio/admin/notification/bucketnotification", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	bucketNotification = BucketNotificationResponse{}
+	c.Assert(json.NewDecoder(response.Body).Decode(&bucketNotification), IsNil)
+	c.Assert(bucketNotification.Webhook, Equals, "")
+
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketnotification/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	select {
+	case event := <-events:
+		c.Fatalf("unexpected event notification %s", event.Records[0].EventName)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *MyAPIFSCacheSuite) TestRequestTimeSkew(c *C) {
+	// within the default clock skew
+	request, err := s.newRequestAt(time.Now().UTC().Add(-10*time.Minute), "PUT", testAPIFSCacheServer.URL+"/timeskewinwindow", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// too far in the past
+	request, err = s.newRequestAt(time.Now().UTC().Add(-20*time.Minute), "PUT", testAPIFSCacheServer.URL+"/timeskewpast", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden)
+
+	// too far in the future
+	request, err = s.newRequestAt(time.Now().UTC().Add(20*time.Minute), "PUT", testAPIFSCacheServer.URL+"/timeskewfuture", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden)
+}
+
+func (s *MyAPIFSCacheSuite) TestSignatureScope(c *C) {
+	client := http.Client{}
+
+	// default regions
+	for _, region := range []string{"milkyway", "us-east-1"} {
+		request, err := s.newRequestInScope(time.Now().UTC(), region, "s3", "GET", testAPIFSCacheServer.URL+"/", 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	request, err := s.newRequestInScope(time.Now().UTC(), "eu-west-1", "s3", "GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AuthorizationHeaderMalformed", "The authorization header is malformed; the region is wrong.", http.StatusBadRequest)
+
+	request, err = s.newRequestInScope(time.Now().UTC(), "milkyway", "ec2", "GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+
+	// configured regions replace the default ones, also for requests with a payload
+	regions := globalRegions
+	globalRegions = []string{"eu-west-1"}
+	defer func() { globalRegions = regions }()
+
+	request, err = s.newRequestInScope(time.Now().UTC(), "eu-west-1", "s3", "GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequestInScope(time.Now().UTC(), "us-east-1", "s3", "PUT", testAPIFSCacheServer.URL+"/signaturescope", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AuthorizationHeaderMalformed", "The authorization header is malformed; the region is wrong.", http.StatusBadRequest)
+}
+
+func (s *MyAPIFSCacheSuite) TestSigningKeyRotation(c *C) {
+	conf, perr := loadConfigV2()
+	c.Assert(perr, IsNil)
+	setServerConfig(conf)
+	defer setServerConfig(nil)
+
+	// the signing key derived for the first request is reused by the second one
+	client := selfTestClient{
+		endpoint:        testAPIFSCacheServer.URL,
+		accessKeyID:     s.accessKeyID,
+		secretAccessKey: s.secretAccessKey,
+		client:          http.DefaultClient,
+	}
+	for i := 0; i < 2; i++ {
+		_, perr = client.do("GET", "/", nil, http.StatusOK)
+		c.Assert(perr, IsNil)
+	}
+
+	// keys of the previous secret key no longer verify requests once it is rotated
+	rotated := *conf
+	rotated.Credentials.SecretAccessKey = string(mustGenerateSecretAccessKey())
+	setServerConfig(&rotated)
+	_, perr = client.do("GET", "/", nil, http.StatusForbidden)
+	c.Assert(perr, IsNil)
+	client.secretAccessKey = rotated.Credentials.SecretAccessKey
+	_, perr = client.do("GET", "/", nil, http.StatusOK)
+	c.Assert(perr, IsNil)
+}
+
+// bodyReadTracker - request body recording whether the client sent it
+type bodyReadTracker struct {
+	io.Reader
+	read bool
+}
+
+func (b *bodyReadTracker) Read(p []byte) (int, error) {
+	b.read = true
+	return b.Reader.Read(p)
+}
+
+func (s *MyAPIFSCacheSuite) TestExpectContinue(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/expectcontinue", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/expectcontinue/object?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	newResponse := &InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
+	partURL := testAPIFSCacheServer.URL + "/expectcontinue/object?uploadId=" + newResponse.UploadID + "&partNumber=1"
+
+	// the client waits for 100-continue before sending the body
+	expectClient := http.Client{
+		Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second},
+	}
+	sendPart := func(request *http.Request) (*http.Response, bool) {
+		body := &bodyReadTracker{Reader: strings.NewReader("hello world")}
+		request.Body = ioutil.NopCloser(body)
+		request.ContentLength = int64(len("hello world"))
+		request.Header.Set("Expect", "100-continue")
+		response, err := expectClient.Do(request)
+		c.Assert(err, IsNil)
+		return response, body.read
+	}
+
+	// unsigned
+	request, err = http.NewRequest("PUT", partURL, nil)
+	c.Assert(err, IsNil)
+	response, sent := sendPart(request)
+	c.Assert(sent, Equals, false)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// signed with a wrong secret key
+	secretAccessKey := s.secretAccessKey
+	s.secretAccessKey = "wrongsecretaccesskey"
+	request, err = s.newRequest("PUT", partURL, int64(len("hello world")), strings.NewReader("hello world"))
+	s.secretAccessKey = secretAccessKey
+	c.Assert(err, IsNil)
+	response, sent = sendPart(request)
+	c.Assert(sent, Equals, false)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+
+	request, err = s.newRequest("PUT", partURL, int64(len("hello world")), strings.NewReader("hello world"))
+	c.Assert(err, IsNil)
+	response, sent = sendPart(request)
+	c.Assert(sent, Equals, true)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIFSCacheSuite) TestStorageClass(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass/invalid", int64(len("hello world")), strings.NewReader("hello world"))
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Storage-Class", "GLACIER")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidStorageClass", "The storage class you specified is not valid.", http.StatusBadRequest)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass/reduced", int64(len("hello world")), strings.NewReader("hello world"))
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Storage-Class", "REDUCED_REDUNDANCY")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass/standard", int64(len("hello world")), strings.NewReader("hello world"))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, method := range []string{"HEAD", "GET"} {
+		request, err = s.newRequest(method, testAPIFSCacheServer.URL+"/storageclass/reduced", 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("X-Amz-Storage-Class"), Equals, "REDUCED_REDUNDANCY")
+
+		request, err = s.newRequest(method, testAPIFSCacheServer.URL+"/storageclass/standard", 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("X-Amz-Storage-Class"), Equals, "STANDARD")
+	}
+
+	// copies keep the storage class of their source unless asked for another one
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass/reduced-copy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/storageclass/reduced")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/storageclass/standard-copy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/storageclass/reduced")
+	request.Header.Set("X-Amz-Storage-Class", "STANDARD")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/storageclass", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	listResponse := &ListObjectsResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(listResponse), IsNil)
+	storageClasses := make(map[string]string)
+	for _, object := range listResponse.Contents {
+		storageClasses[object.Key] = object.StorageClass
+	}
+	c.Assert(storageClasses, DeepEquals, map[string]string{
+		"reduced":       "REDUCED_REDUNDANCY",
+		"reduced-copy":  "REDUCED_REDUNDANCY",
+		"standard":      "STANDARD",
+		"standard-copy": "STANDARD",
+	})
+}
+
+func (s *MyAPIFSCacheSuite) TestFolderObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/folderobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// empty objects ending in a slash are folders, as created by the AWS console
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/folderobject/folder/", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Storage-Class", "REDUCED_REDUNDANCY")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, "\"d41d8cd98f00b204e9800998ecf8427e\"")
+
+	listFolders := func() []string {
+		request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/folderobject?delimiter=/", 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		listResponse := &ListObjectsResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(listResponse), IsNil)
+		c.Assert(len(listResponse.Contents), Equals, 0)
+		var folders []string
+		for _, commonPrefix := range listResponse.CommonPrefixes {
+			folders = append(folders, commonPrefix.Prefix)
+		}
+		return folders
+	}
+	c.Assert(listFolders(), DeepEquals, []string{"folder/"})
+
+	// folders have no data
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/folderobject/data/", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Objects ending in a slash are kept as empty folders, which cannot have data or replace an object of the same name.", http.StatusBadRequest)
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/folderobject/folder/", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	c.Assert(listFolders(), IsNil)
+}
+
+func (s *MyAPIFSCacheSuite) TestCopyObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/copyobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/copyobject/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/copyobject/object-copy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/copyobject/object")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	copyObjectResponse := &CopyObjectResponse{}
+	decoder := xml.NewDecoder(response.Body)
+	c.Assert(decoder.Decode(copyObjectResponse), IsNil)
+	c.Assert(copyObjectResponse.ETag, Equals, "\"5eb63bbbe01eeed093cb22bb8f5acdc3\"")
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/copyobject/object-copy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	// the copy source is path escaped, a plus is part of the key
+	buffer = bytes.NewReader([]byte("hello plus"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/copyobject/a+b", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// replaced metadata carries the requested tags
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/copyobject/plus-copy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/copyobject/a+b")
+	request.Header.Set("X-Amz-Metadata-Directive", "REPLACE")
+	request.Header.Set("X-Amz-Tagging", "project=minio")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/copyobject/plus-copy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("x-amz-tagging-count"), Equals, "1")
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello plus"))
+}
+
+func (s *MyAPIFSCacheSuite) TestNonExistantBucket(c *C) {
+	request, err := s.newRequest("HEAD", testAPIFSCacheServer.URL+"/nonexistantbucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MyAPIFSCacheSuite) TestRequestID(c *C) {
+	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/nonexistantbucket/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	requestID := response.Header.Get("X-Amz-Request-Id")
+	c.Assert(len(requestID), Equals, 16)
+
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	errorResponse := APIErrorResponse{}
+	c.Assert(xml.Unmarshal(data, &errorResponse), IsNil)
+	c.Assert(errorResponse.RequestID, Equals, requestID)
+
+	// same id has to show up in the access log
+	accessLogFile, err := ioutil.TempFile("", "access.log")
+	c.Assert(err, IsNil)
+	defer os.Remove(accessLogFile.Name())
+	defer accessLogFile.Close()
+
+	handler := RequestIDHandler(&accessLogHandler{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		}),
+		accessLogFile: accessLogFile,
+	})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, &http.Request{Method: "GET", URL: request.URL, Header: http.Header{}})
+	requestID = recorder.Header().Get("X-Amz-Request-Id")
+	c.Assert(len(requestID), Equals, 16)
+
+	logData, err := ioutil.ReadFile(accessLogFile.Name())
+	c.Assert(err, IsNil)
+	var logMessage LogMessage
+	c.Assert(json.Unmarshal(logData, &logMessage), IsNil)
+	c.Assert(logMessage.RequestID, Equals, requestID)
+
+	errorResponse = APIErrorResponse{}
+	c.Assert(xml.Unmarshal(recorder.Body.Bytes(), &errorResponse), IsNil)
+	c.Assert(errorResponse.RequestID, Equals, requestID)
+}
+
+func (s *MyAPIFSCacheSuite) TestEmptyObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/emptyobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/emptyobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/emptyobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var buffer bytes.Buffer
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, buffer.Bytes()))
+}
+
+func (s *MyAPIFSCacheSuite) TestBucket(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/bucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIFSCacheSuite) TestObject(c *C) {
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/testobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/testobject/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/testobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+}
+
+func (s *MyAPIFSCacheSuite) TestMultipleObjects(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+
+	//// test object 1
+
+	// get object
+	buffer1 := bytes.NewReader([]byte("hello one"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object1", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object1", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify response data
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello one")))
+
+	buffer2 := bytes.NewReader([]byte("hello two"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object2", int64(buffer2.Len()), buffer2)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object2", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify response data
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello two")))
+
+	buffer3 := bytes.NewReader([]byte("hello three"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object3", int64(buffer3.Len()), buffer3)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object3", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify object
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello three")))
+}
+
+func (s *MyAPIFSCacheSuite) TestNotImplemented(c *C) {
+	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/bucket/object?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
+}
+
+func (s *MyAPIFSCacheSuite) TestHeader(c *C) {
+	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/bucket/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+}
+
+func (s *MyAPIFSCacheSuite) TestPutBucket(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-bucket", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-bucket-slash/", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIFSCacheSuite) TestPutObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-object/object", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIFSCacheSuite) TestListBuckets(c *C) {
+	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var results ListBucketsResponse
+	decoder := xml.NewDecoder(response.Body)
+	err = decoder.Decode(&results)
+	c.Assert(err, IsNil)
+}
+
+func (s *MyAPIFSCacheSuite) TestNotBeAbleToCreateObjectInNonexistantBucket(c *C) {
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/innonexistantbucket/object", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+}
+
+func (s *MyAPIFSCacheSuite) TestHeadOnObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/headonobject", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/headonobject/object1", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/headonobject/object1", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIFSCacheSuite) TestConditionalGetObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/conditionalgetobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	lastModified := response.Header.Get("Last-Modified")
+	lastModifiedTime, err := http.ParseTime(lastModified)
+	c.Assert(err, IsNil)
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Modified-Since", lastModified)
 
-	// verify response data
-	responseBody, err = ioutil.ReadAll(response.Body)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello two")))
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(response.Header.Get("Last-Modified"), Equals, lastModified)
 
-	buffer3 := bytes.NewReader([]byte("hello three"))
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects/object3", int64(buffer3.Len()), buffer3)
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Set("If-Modified-Since", lastModified)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
 
-	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/multipleobjects/object3", 0, nil)
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Set("If-Modified-Since", lastModifiedTime.Add(-time.Hour).Format(http.TimeFormat))
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-
-	// verify object
-	responseBody, err = ioutil.ReadAll(response.Body)
+	responseBody, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello three")))
-}
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
 
-func (s *MyAPIFSCacheSuite) TestNotImplemented(c *C) {
-	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/bucket/object?policy", 0, nil)
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/conditionalgetobject/object1", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Set("If-None-Match", "*")
 
-	client := http.Client{}
-	response, err := client.Do(request)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
 }
 
-func (s *MyAPIFSCacheSuite) TestHeader(c *C) {
-	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/bucket/object", 0, nil)
+func (s *MyAPIFSCacheSuite) TestConditionalPutObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/conditionalputobject", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
-}
+	put := func(data string, header, value string) *http.Response {
+		buffer := bytes.NewReader([]byte(data))
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/conditionalputobject/object", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		request.Header.Set(header, value)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
 
-func (s *MyAPIFSCacheSuite) TestPutBucket(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-bucket", 0, nil)
-	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
+	// create if absent
+	response = put("first", "If-None-Match", "*")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	firstETag := response.Header.Get("ETag")
+	response = put("second", "If-None-Match", "*")
+	verifyError(c, response, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold.", http.StatusPreconditionFailed)
 
-	client := http.Client{}
-	response, err := client.Do(request)
+	// replace the object as last seen only
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/conditionalputobject/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("ETag"), Equals, firstETag)
+	response = put("second", "If-Match", firstETag)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = put("third", "If-Match", firstETag)
+	verifyError(c, response, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold.", http.StatusPreconditionFailed)
 
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-bucket-slash/", 0, nil)
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/conditionalputobject/object", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
-
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("second"))
 }
 
-func (s *MyAPIFSCacheSuite) TestPutObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-object", 0, nil)
+func (s *MyAPIFSCacheSuite) TestObjectTagging(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objecttagging", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/put-object/object", int64(buffer1.Len()), buffer1)
+	// tags captured at write time are counted on HEAD
+	buffer := bytes.NewReader([]byte("tagged"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objecttagging/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("x-amz-tagging", "project=minio&cost%20center=42")
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/objecttagging/object", 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("x-amz-tagging-count"), Equals, "2")
+
+	getTags := func() ObjectTaggingResponse {
+		request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/objecttagging/object?tagging", 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		tagging := ObjectTaggingResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(&tagging), IsNil)
+		return tagging
+	}
+	c.Assert(getTags().TagSet.Tag, DeepEquals, []Tag{{Key: "cost center", Value: "42"}, {Key: "project", Value: "minio"}})
+
+	putTags := func(body string) *http.Response {
+		buffer := bytes.NewReader([]byte(body))
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objecttagging/object?tagging", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	response = putTags("<Tagging><TagSet><Tag><Key>owner</Key><Value>ops</Value></Tag></TagSet></Tagging>")
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-}
+	c.Assert(getTags().TagSet.Tag, DeepEquals, []Tag{{Key: "owner", Value: "ops"}})
+
+	// keys are unique and limited in length, the tags stay as they were
+	invalidTag := "The tag provided was not a valid tag. Objects carry at most 10 tags, with keys of up to 128 and values of up to 256 characters."
+	response = putTags("<Tagging><TagSet><Tag><Key>a</Key><Value>1</Value></Tag><Tag><Key>a</Key><Value>2</Value></Tag></TagSet></Tagging>")
+	verifyError(c, response, "InvalidTag", invalidTag, http.StatusBadRequest)
+	response = putTags("<Tagging><TagSet><Tag><Key>" + strings.Repeat("k", 129) + "</Key><Value>v</Value></Tag></TagSet></Tagging>")
+	verifyError(c, response, "InvalidTag", invalidTag, http.StatusBadRequest)
+	response = putTags("<Tagging><TagSet>")
+	verifyError(c, response, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest)
+	c.Assert(getTags().TagSet.Tag, DeepEquals, []Tag{{Key: "owner", Value: "ops"}})
+
+	buffer = bytes.NewReader([]byte("tagged"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/objecttagging/invalid", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("x-amz-tagging", "a=1&a=2")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidTag", invalidTag, http.StatusBadRequest)
 
-func (s *MyAPIFSCacheSuite) TestListBuckets(c *C) {
-	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/objecttagging/object?tagging", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	c.Assert(len(getTags().TagSet.Tag), Equals, 0)
 
-	client := http.Client{}
-	response, err := client.Do(request)
+	// the object itself is untouched
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/objecttagging/object", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("x-amz-tagging-count"), Equals, "")
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("tagged"))
 
-	var results ListBucketsResponse
-	decoder := xml.NewDecoder(response.Body)
-	err = decoder.Decode(&results)
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/objecttagging/missing?tagging", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
 }
 
-func (s *MyAPIFSCacheSuite) TestNotBeAbleToCreateObjectInNonexistantBucket(c *C) {
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/innonexistantbucket/object", int64(buffer1.Len()), buffer1)
+func (s *MyAPIFSCacheSuite) TestObjectVersioning(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectversioning", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
-}
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-func (s *MyAPIFSCacheSuite) TestHeadOnObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/headonobject", 0, nil)
+	getVersioning := func() VersioningConfigurationResponse {
+		request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/objectversioning?versioning", 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		configuration := VersioningConfigurationResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(&configuration), IsNil)
+		return configuration
+	}
+	putVersioning := func(body string) *http.Response {
+		buffer := bytes.NewReader([]byte(body))
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectversioning?versioning", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	c.Assert(getVersioning().Status, Equals, "")
+	response = putVersioning("<VersioningConfiguration><Status>Disabled</Status></VersioningConfiguration>")
+	verifyError(c, response, "IllegalVersioningConfigurationException", "The versioning configuration specified in the request is invalid, the status should be Enabled or Suspended.", http.StatusBadRequest)
+	response = putVersioning("<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(getVersioning().Status, Equals, "Enabled")
+
+	putObject := func(data string) string {
+		buffer := bytes.NewReader([]byte(data))
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectversioning/object", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		return response.Header.Get("x-amz-version-id")
+	}
+	getObject := func(query string) *http.Response {
+		request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/objectversioning/object"+query, 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	// an overwrite creates a second version, the first one is still fetched by its id
+	first := putObject("first")
+	second := putObject("second")
+	c.Assert(first, Not(Equals), "")
+	c.Assert(second, Not(Equals), first)
+
+	response = getObject("")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("x-amz-version-id"), Equals, second)
+	responseBody, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
+	c.Assert(string(responseBody), Equals, "second")
 
+	response = getObject("?versionId=" + first)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("x-amz-version-id"), Equals, first)
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "first")
+
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/objectversioning/object?versionId="+first, 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.ContentLength, Equals, int64(len("first")))
+
+	response = getObject("?versionId=" + strings.Repeat("0", 32))
+	verifyError(c, response, "NoSuchVersion", "The specified version does not exist.", http.StatusNotFound)
+	response = getObject("?versionId=invalid")
+	verifyError(c, response, "InvalidArgument", "Invalid version id specified", http.StatusBadRequest)
+}
+
+func (s *MyAPIFSCacheSuite) TestBucketPolicy(c *C) {
 	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketpolicy", 0, nil)
+	c.Assert(err, IsNil)
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/headonobject/object1", int64(buffer1.Len()), buffer1)
+	for _, object := range []string{"public/object", "public/secret"} {
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketpolicy/"+object, int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/bucketpolicy?policy", 0, nil)
 	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucketPolicy", "The bucket policy does not exist.", http.StatusNotFound)
+
+	putPolicy := func(policy string) *http.Response {
+		buffer := bytes.NewReader([]byte(policy))
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketpolicy?policy", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	anonymousGet := func(object string) *http.Response {
+		request, err := http.NewRequest("GET", testAPIFSCacheServer.URL+"/bucketpolicy/"+object, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	verifyError(c, anonymousGet("public/object"), "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// public read of a private bucket
+	response = putPolicy(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Sid": "PublicRead",
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": "s3:GetObject",
+			"Resource": "arn:aws:s3:::bucketpolicy/public/*"
+		}]
+	}`)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	response = anonymousGet("public/object")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+	c.Assert(anonymousGet("public/secret").StatusCode, Equals, http.StatusOK)
 
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/bucketpolicy?policy", 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	policy := fs.BucketPolicy{}
+	c.Assert(json.NewDecoder(response.Body).Decode(&policy), IsNil)
+	c.Assert(len(policy.Statement), Equals, 1)
+	c.Assert(policy.Statement[0].Sid, Equals, "PublicRead")
 
-	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/headonobject/object1", 0, nil)
+	// writes are still denied
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = http.NewRequest("PUT", testAPIFSCacheServer.URL+"/bucketpolicy/public/object", buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// an explicit deny overrides the allow, for signed requests as well
+	response = putPolicy(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": {"AWS": ["*"]},
+			"Action": ["s3:GetObject"],
+			"Resource": ["arn:aws:s3:::bucketpolicy/*"]
+		}, {
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:Get*",
+			"Resource": "arn:aws:s3:::bucketpolicy/public/secret"
+		}]
+	}`)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	c.Assert(anonymousGet("public/object").StatusCode, Equals, http.StatusOK)
+	verifyError(c, anonymousGet("public/secret"), "AccessDenied", "Access Denied.", http.StatusForbidden)
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/bucketpolicy/public/secret", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// copies and multipart uploads are subject to the policy as well
+	response = putPolicy(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:GetObject",
+			"Resource": "arn:aws:s3:::bucketpolicy/public/secret"
+		}, {
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:PutObject",
+			"Resource": "arn:aws:s3:::bucketpolicy/readonly/*"
+		}]
+	}`)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	copyObject := func(source, destination string) *http.Response {
+		request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/bucketpolicy/"+destination, 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("X-Amz-Copy-Source", "/bucketpolicy/"+source)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	verifyError(c, copyObject("public/secret", "public/copy"), "AccessDenied", "Access Denied.", http.StatusForbidden)
+	verifyError(c, copyObject("public/object", "readonly/copy"), "AccessDenied", "Access Denied.", http.StatusForbidden)
+	c.Assert(copyObject("public/object", "public/copy").StatusCode, Equals, http.StatusOK)
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/bucketpolicy/readonly/object?uploads", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	// unsupported elements and resources of other buckets are rejected, the policy stays as it was
+	malformedPolicy := "Policy has invalid or unsupported elements. Statements allow or deny s3:GetObject and s3:PutObject on objects of the bucket, without conditions."
+	for _, policy := range []string{
+		`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:ListBucket", "Resource": "arn:aws:s3:::bucketpolicy/*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::otherbucket/*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucketpolicy/*", "Condition": {}}]}`,
+		`{"Statement": [`,
+	} {
+		verifyError(c, putPolicy(policy), "MalformedPolicy", malformedPolicy, http.StatusBadRequest)
+	}
+	verifyError(c, anonymousGet("public/secret"), "AccessDenied", "Access Denied.", http.StatusForbidden)
 
+	// without a policy access is left to the ACL again
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/bucketpolicy?policy", 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	verifyError(c, anonymousGet("public/object"), "AccessDenied", "Access Denied.", http.StatusForbidden)
 }
 
 func (s *MyAPIFSCacheSuite) TestHeadOnBucket(c *C) {
@@ -723,6 +2210,44 @@ func (s *MyAPIFSCacheSuite) TestContentTypePersists(c *C) {
 	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
 }
 
+func (s *MyAPIFSCacheSuite) TestContentTypeByExtension(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/contenttype-extension", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/contenttype-extension/logo.SVG", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	delete(request.Header, "Content-Type")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, method := range []string{"HEAD", "GET"} {
+		request, err = s.newRequest(method, testAPIFSCacheServer.URL+"/contenttype-extension/logo.SVG", 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("Content-Type"), Equals, "image/svg+xml")
+	}
+
+	// response-content-type still wins
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/contenttype-extension/logo.SVG?response-content-type=text/plain", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "text/plain")
+}
+
 func (s *MyAPIFSCacheSuite) TestPartialContent(c *C) {
 	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/partial-content", 0, nil)
 	c.Assert(err, IsNil)
@@ -1111,6 +2636,141 @@ func (s *MyAPIFSCacheSuite) TestObjectMultipart(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
 
+func (s *MyAPIFSCacheSuite) TestZeroByteObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/zerobyte", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/zerobyte/put", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, "\"d41d8cd98f00b204e9800998ecf8427e\"")
+
+	// multipart upload out of a single empty part
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/zerobyte/multipart?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	initiated := &InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(initiated), IsNil)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/zerobyte/multipart?uploadId="+initiated.UploadID+"&partNumber=1", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, "\"d41d8cd98f00b204e9800998ecf8427e\"")
+
+	completeBytes, err := xml.Marshal(&fs.CompleteMultipartUpload{
+		Part: []fs.CompletePart{{PartNumber: 1, ETag: response.Header.Get("ETag")}},
+	})
+	c.Assert(err, IsNil)
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/zerobyte/multipart?uploadId="+initiated.UploadID, int64(len(completeBytes)), bytes.NewReader(completeBytes))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	completed := &CompleteMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(completed), IsNil)
+	c.Assert(strings.Trim(completed.ETag, "\""), Equals, "d41d8cd98f00b204e9800998ecf8427e")
+
+	for _, object := range []string{"put", "multipart"} {
+		request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/zerobyte/"+object, 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("Content-Length"), Equals, "0")
+		c.Assert(response.Header.Get("ETag"), Equals, "\"d41d8cd98f00b204e9800998ecf8427e\"")
+
+		request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/zerobyte/"+object, 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		responseBody, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		c.Assert(len(responseBody), Equals, 0)
+		c.Assert(response.Header.Get("ETag"), Equals, "\"d41d8cd98f00b204e9800998ecf8427e\"")
+	}
+}
+
+func (s *MyAPIFSCacheSuite) TestObjectUnsignedPayload(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/unsignedpayload", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	newRequest := func(method, urlStr string, body []byte, hashedPayload string) *http.Request {
+		request, err := s.newRequestWithPayload(time.Now().UTC(), "milkyway", "s3", method, urlStr, int64(len(body)), bytes.NewReader(body), hashedPayload)
+		c.Assert(err, IsNil)
+		return request
+	}
+
+	// the payload is not hashed into the signature
+	response, err = client.Do(newRequest("PUT", testAPIFSCacheServer.URL+"/unsignedpayload/object", []byte("hello world"), fs.UnsignedPayload))
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	response, err = client.Do(newRequest("GET", testAPIFSCacheServer.URL+"/unsignedpayload/object", nil, ""))
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello world")
+
+	// signed payloads are still verified
+	response, err = client.Do(newRequest("PUT", testAPIFSCacheServer.URL+"/unsignedpayload/object", []byte("hello world"), hex.EncodeToString(sum256([]byte("hello earth")))))
+	c.Assert(err, IsNil)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+
+	response, err = client.Do(newRequest("POST", testAPIFSCacheServer.URL+"/unsignedpayload/object?uploads", nil, ""))
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	newResponse := &InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
+	uploadID := newResponse.UploadID
+
+	var etag string
+	for _, hashedPayload := range []string{"", fs.UnsignedPayload} {
+		response, err = client.Do(newRequest("PUT", testAPIFSCacheServer.URL+"/unsignedpayload/object?uploadId="+uploadID+"&partNumber=1", []byte("hello world"), hashedPayload))
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		etag = response.Header.Get("ETag")
+	}
+
+	// unsigned payloads still need a valid signature
+	request = newRequest("PUT", testAPIFSCacheServer.URL+"/unsignedpayload/object?uploadId="+uploadID+"&partNumber=2", []byte("hello world"), fs.UnsignedPayload)
+	request.URL.RawQuery = "uploadId=" + uploadID + "&partNumber=3"
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+
+	completeBytes, err := xml.Marshal(&fs.CompleteMultipartUpload{
+		Part: []fs.CompletePart{{PartNumber: 1, ETag: etag}},
+	})
+	c.Assert(err, IsNil)
+	response, err = client.Do(newRequest("POST", testAPIFSCacheServer.URL+"/unsignedpayload/object?uploadId="+uploadID, completeBytes, fs.UnsignedPayload))
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
 func verifyError(c *C, response *http.Response, code, description string, statusCode int) {
 	data, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
@@ -1121,3 +2781,36 @@ func verifyError(c *C, response *http.Response, code, description string, status
 	c.Assert(errorResponse.Message, Equals, description)
 	c.Assert(response.StatusCode, Equals, statusCode)
 }
+
+func (s *MyAPIFSCacheSuite) TestObjectNameTooLong(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/longnames", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	longest := strings.Repeat(strings.Repeat("a", 200)+"/", 5) + strings.Repeat("b", 19)
+	c.Assert(len(longest), Equals, fs.MaxObjectNameLength)
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/longnames/"+longest, int64(len("longest")), bytes.NewReader([]byte("longest")))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/longnames/"+longest+"b", int64(len("too long")), bytes.NewReader([]byte("too long")))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "KeyTooLongError", "Your key is too long.", http.StatusBadRequest)
+
+	request, err = s.newRequest("POST", testAPIFSCacheServer.URL+"/longnames/"+longest+"b?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "KeyTooLongError", "Your key is too long.", http.StatusBadRequest)
+}
@@ -58,6 +58,28 @@ func isRequestPostPolicySignatureV4(req *http.Request) bool {
 	return false
 }
 
+func isRequestChunkedV4(req *http.Request) bool {
+	for _, encoding := range strings.Split(req.Header.Get("Content-Encoding"), ",") {
+		if strings.TrimSpace(encoding) == "aws-chunked" {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureV4ErrorCode - API error of an authorization header which failed to initialize signature verification
+func signatureV4ErrorCode(err *probe.Error) int {
+	switch err.ToGoError() {
+	case errInvalidRegion:
+		return AuthorizationHeaderMalformed
+	case errInvalidService:
+		return SignatureDoesNotMatch
+	case errAccessKeyIDInvalid:
+		return InvalidAccessKeyID
+	}
+	return InternalError
+}
+
 func isRequestRequiresACLCheck(req *http.Request) bool {
 	if isRequestSignatureV4(req) || isRequestPresignedSignatureV4(req) || isRequestPostPolicySignatureV4(req) {
 		return false
@@ -84,6 +106,10 @@ func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 					errorIf(err.Trace(), "Unknown region in authorization header.", nil)
 					writeErrorResponse(w, r, AuthorizationHeaderMalformed, r.URL.Path)
 					return
+				case errInvalidService:
+					errorIf(err.Trace(), "Unknown service in authorization header.", nil)
+					writeErrorResponse(w, r, SignatureDoesNotMatch, r.URL.Path)
+					return
 				case errAccessKeyIDInvalid:
 					errorIf(err.Trace(), "Invalid access key id.", nil)
 					writeErrorResponse(w, r, InvalidAccessKeyID, r.URL.Path)
@@ -94,11 +120,17 @@ func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 			}
-			ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256.Sum256([]byte(""))))
+			ok, err := signature.DoesPayloadSignatureMatch(hex.EncodeToString(sha256.Sum256([]byte(""))))
 			if err != nil {
-				errorIf(err.Trace(), "Unable to verify signature.", nil)
-				writeErrorResponse(w, r, InternalError, r.URL.Path)
-				return
+				switch err.ToGoError().(type) {
+				case fs.RequestTimeTooSkewed:
+					writeErrorResponse(w, r, RequestTimeTooSkewed, r.URL.Path)
+					return
+				default:
+					errorIf(err.Trace(), "Unable to verify signature.", nil)
+					writeErrorResponse(w, r, InternalError, r.URL.Path)
+					return
+				}
 			}
 			if !ok {
 				writeErrorResponse(w, r, SignatureDoesNotMatch, r.URL.Path)
@@ -113,6 +145,14 @@ func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		signature, err = initPresignedSignatureV4(r)
 		if err != nil {
 			switch err.ToGoError() {
+			case errInvalidRegion:
+				errorIf(err.Trace(), "Unknown region in credential query.", nil)
+				writeErrorResponse(w, r, AuthorizationQueryParametersError, r.URL.Path)
+				return
+			case errInvalidService:
+				errorIf(err.Trace(), "Unknown service in credential query.", nil)
+				writeErrorResponse(w, r, SignatureDoesNotMatch, r.URL.Path)
+				return
 			case errAccessKeyIDInvalid:
 				errorIf(err.Trace(), "Invalid access key id requested.", nil)
 				writeErrorResponse(w, r, InvalidAccessKeyID, r.URL.Path)
@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// trustedProxies - networks of proxies whose forwarded client addresses are trusted, headers
+// forwarding addresses are ignored on requests from anywhere else
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies - parse comma separated networks of trusted proxies
+func parseTrustedProxies(value string) (trustedProxies, *probe.Error) {
+	networks, err := parseNetworks(value)
+	if err != nil {
+		return nil, err.Trace(value)
+	}
+	return trustedProxies(networks), nil
+}
+
+// clientIP - address a request from remote is coming from, remote unless it is a trusted proxy.
+// X-Forwarded-For is walked from the nearest hop on, up to the first address which is not a
+// trusted proxy, earlier ones may be made up by the client. A malformed hop ends the walk at
+// the proxy which added it. X-Real-IP is taken as is from proxies not sending X-Forwarded-For.
+func (p trustedProxies) clientIP(remote net.IP, req *http.Request) net.IP {
+	if !containsIP(p, remote) {
+		return remote
+	}
+	ip := remote
+	if forwarded := req.Header["X-Forwarded-For"]; len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0 && containsIP(p, ip); i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				break
+			}
+			ip = hop
+		}
+		return ip
+	}
+	if realIP := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP
+	}
+	return ip
+}
+
+type proxyHeadersHandler struct {
+	handler http.Handler
+	proxies trustedProxies
+}
+
+// ProxyHeadersHandler replaces the remote address of requests from trusted proxies with the
+// client address they forward, which access logs, client bandwidth limits and source address
+// filters see from then on
+func ProxyHeadersHandler(proxies trustedProxies) MiddlewareHandler {
+	return func(h http.Handler) http.Handler {
+		return proxyHeadersHandler{handler: h, proxies: proxies}
+	}
+}
+
+func (h proxyHeadersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// requests over a unix domain socket have no address
+	if remote := net.ParseIP(clientAddress(r)); remote != nil {
+		if ip := h.proxies.clientIP(remote, r); !ip.Equal(remote) {
+			r.RemoteAddr = ip.String()
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+type TrustedProxiesSuite struct{}
+
+var _ = Suite(&TrustedProxiesSuite{})
+
+func (s *TrustedProxiesSuite) TestParseTrustedProxies(c *C) {
+	proxies, perr := parseTrustedProxies("")
+	c.Assert(perr, IsNil)
+	c.Assert(len(proxies), Equals, 0)
+
+	proxies, perr = parseTrustedProxies("192.168.0.1, fd00::/8")
+	c.Assert(perr, IsNil)
+	c.Assert(len(proxies), Equals, 2)
+	c.Assert(proxies[0].String(), Equals, "192.168.0.1/32")
+
+	for _, networks := range []string{"10.0.0.0/8,*", "proxy.local", "10.0.0.0/33"} {
+		_, perr = parseTrustedProxies(networks)
+		c.Assert(perr, Not(IsNil))
+		c.Assert(perr.ToGoError(), Equals, errInvalidCIDR)
+	}
+}
+
+func (s *TrustedProxiesSuite) TestClientIP(c *C) {
+	proxies, perr := parseTrustedProxies("192.168.0.0/24,fd00::/8")
+	c.Assert(perr, IsNil)
+
+	testCases := []struct {
+		remote       string
+		forwardedFor []string
+		realIP       string
+		client       string
+	}{
+		// headers sent by peers which are not trusted proxies are ignored
+		{"172.16.0.1", nil, "", "172.16.0.1"},
+		{"172.16.0.1", []string{"10.2.3.4"}, "", "172.16.0.1"},
+		{"172.16.0.1", nil, "10.2.3.4", "172.16.0.1"},
+		// trusted proxies forward the client address
+		{"192.168.0.1", nil, "", "192.168.0.1"},
+		{"192.168.0.1", []string{"10.2.3.4"}, "", "10.2.3.4"},
+		{"192.168.0.1", nil, " 10.2.3.4 ", "10.2.3.4"},
+		{"fd00::1", []string{"2001:db8::1"}, "", "2001:db8::1"},
+		// forwarded through several trusted proxies, in one header or several
+		{"192.168.0.1", []string{"10.2.3.4, 192.168.0.2"}, "", "10.2.3.4"},
+		{"192.168.0.1", []string{"10.2.3.4", "fd00::2"}, "", "10.2.3.4"},
+		// addresses ahead of the first untrusted one may be made up by the client
+		{"192.168.0.1", []string{"10.9.9.9, 172.16.0.1"}, "", "172.16.0.1"},
+		{"192.168.0.1", []string{"garbage, 10.2.3.4"}, "", "10.2.3.4"},
+		// a malformed address ends the walk at the proxy which added it
+		{"192.168.0.1", []string{"10.2.3.4, garbage"}, "", "192.168.0.1"},
+		{"192.168.0.1", []string{"10.2.3.4, garbage, 192.168.0.2"}, "", "192.168.0.2"},
+		{"192.168.0.1", nil, "garbage", "192.168.0.1"},
+		// X-Forwarded-For takes precedence over X-Real-IP
+		{"192.168.0.1", []string{"10.2.3.4"}, "10.5.6.7", "10.2.3.4"},
+	}
+	for i, testCase := range testCases {
+		request, err := http.NewRequest("GET", "http://localhost/", nil)
+		c.Assert(err, IsNil)
+		for _, hops := range testCase.forwardedFor {
+			request.Header.Add("X-Forwarded-For", hops)
+		}
+		if testCase.realIP != "" {
+			request.Header.Set("X-Real-IP", testCase.realIP)
+		}
+		client := proxies.clientIP(net.ParseIP(testCase.remote), request)
+		c.Assert(client.String(), Equals, testCase.client, Commentf("test case %d", i+1))
+	}
+}
+
+func (s *TrustedProxiesSuite) TestProxyHeadersHandler(c *C) {
+	proxies, perr := parseTrustedProxies("192.168.0.1")
+	c.Assert(perr, IsNil)
+	limiter := newBandwidthLimiter(0, 64*1024)
+	var accessLog bytes.Buffer
+	handler := ProxyHeadersHandler(proxies)(newAccessLogHandler(&accessLog, 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// requests stay with the limiter, so that its clients can be looked at
+		limiter.acquire(r)
+		w.Write([]byte("ok"))
+	})))
+
+	for _, remoteAddr := range []string{"192.168.0.1:5000", "172.16.0.1:5000", "@"} {
+		request, err := http.NewRequest("GET", "http://localhost/", nil)
+		c.Assert(err, IsNil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("X-Forwarded-For", "10.2.3.4")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	// the client behind the trusted proxy is logged and limited, the untrusted peer is itself
+	var logged []string
+	scanner := bufio.NewScanner(&accessLog)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message LogMessage
+		c.Assert(json.Unmarshal(scanner.Bytes(), &message), IsNil)
+		logged = append(logged, message.HTTP.Request.RemoteAddr)
+	}
+	c.Assert(scanner.Err(), IsNil)
+	c.Assert(logged, DeepEquals, []string{"10.2.3.4", "172.16.0.1:5000", "@"})
+	c.Assert(len(limiter.clients), Equals, 3)
+	for _, client := range []string{"10.2.3.4", "172.16.0.1", "@"} {
+		_, ok := limiter.clients[client]
+		c.Assert(ok, Equals, true, Commentf("client %s", client))
+	}
+}
@@ -53,6 +53,9 @@ var errCredentialTagMalformed = errors.New("Invalid credential tag malformed")
 // errInvalidRegion means that the region element from credential tag in Authorization header is invalid.
 var errInvalidRegion = errors.New("Invalid region")
 
+// errInvalidService means that the service or terminator element of a credential scope is not 's3' and 'aws4_request'.
+var errInvalidService = errors.New("Invalid service")
+
 // errAccessKeyIDInvalid means that the accessKeyID element from credential tag in Authorization header is invalid.
 var errAccessKeyIDInvalid = errors.New("AccessKeyID invalid")
 
@@ -64,3 +67,63 @@ var errPolicyAlreadyExpired = errors.New("Policy already expired")
 
 // errPolicyMissingFields means that form values and policy header have some fields missing.
 var errPolicyMissingFields = errors.New("Some fields are missing or do not match in policy")
+
+// errInvalidCredentialsEnv means that only one of MINIO_ACCESS_KEY and MINIO_SECRET_KEY is set.
+var errInvalidCredentialsEnv = errors.New("Both MINIO_ACCESS_KEY and MINIO_SECRET_KEY should be set")
+
+// errInvalidAccessKeyEnv means that MINIO_ACCESS_KEY is malformed.
+var errInvalidAccessKeyEnv = errors.New("MINIO_ACCESS_KEY should be 20 characters of A-Z, 0-9, '-', '.', '_' or '~'")
+
+// errInvalidSecretKeyEnv means that MINIO_SECRET_KEY is malformed.
+var errInvalidSecretKeyEnv = errors.New("MINIO_SECRET_KEY should be 40 characters long")
+
+// errInvalidCredentials means that credentials in config are malformed.
+var errInvalidCredentials = errors.New("Access key should be 20 characters of A-Z, 0-9, '-', '.', '_' or '~' and secret key 40 characters long")
+
+// errNotASocket means that the unix socket path is taken by a file which is not a socket.
+var errNotASocket = errors.New("Path exists and is not a unix domain socket")
+
+// errSocketInUse means that another server is still accepting connections on the unix socket.
+var errSocketInUse = errors.New("Unix domain socket is in use by another server")
+
+// errEventNotDelivered means that a bucket webhook replied with a status other than 2xx.
+var errEventNotDelivered = errors.New("Webhook did not accept the event notification")
+
+// errCertExpired means that the server certificate is past its validity period.
+var errCertExpired = errors.New("Certificate has expired")
+
+// errCertNotYetValid means that the server certificate validity period has not started yet.
+var errCertNotYetValid = errors.New("Certificate is not yet valid")
+
+// errInvalidSyslogCA means that the syslog CA file carries no PEM encoded certificates.
+var errInvalidSyslogCA = errors.New("No certificates found in syslog CA file")
+
+// errSyslogUnavailable means that the syslog server connection is lost and waiting to be re-established.
+var errSyslogUnavailable = errors.New("Syslog server unavailable, waiting to reconnect")
+
+// errSelfTestMismatch means that the object read back by selftest differs from the one written.
+var errSelfTestMismatch = errors.New("Object read back does not match the object written")
+
+// errInvalidMasterKey - master key is not a hex encoded 256 bit key
+var errInvalidMasterKey = errors.New("Master key should be 64 hex characters long")
+
+// errInvalidCIDR means that a network to allow or deny requests from is neither a CIDR nor an address.
+var errInvalidCIDR = errors.New("Network should be an IPv4 or IPv6 CIDR, or a single address")
+
+// errInvalidContentType - content type of the config is not mapped to an extension or is not a media type
+var errInvalidContentType = errors.New("Content types should map extensions starting with a dot to media types")
+
+// errAddressNotHostPort means that an address to listen on is not of the form host:port.
+var errAddressNotHostPort = errors.New("Address must be host:port, or :port to listen on all interfaces")
+
+// errAddressBarePort means that an address to listen on is a port without the colon before it.
+var errAddressBarePort = errors.New("Address must be host:port, a bare port is given as :port to listen on all interfaces")
+
+// errAddressInvalidPort means that the port of an address to listen on is not a port number.
+var errAddressInvalidPort = errors.New("Port must be a number from 0 to 65535")
+
+// errAddressInUse means that another process is listening on an address to listen on.
+var errAddressInUse = errors.New("Address already in use")
+
+// errAddressIPv6Brackets means that an IPv6 address to listen on is missing its brackets.
+var errAddressIPv6Brackets = errors.New("IPv6 addresses must be enclosed in brackets, as in [::1]:9000")
@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// uploadRetryAfter - time clients turned away for too many uploads in flight are asked to wait
+const uploadRetryAfter = time.Second
+
+// uploadLimiter - limits the uploads in flight, PUTs of objects and parts and completions of
+// multipart uploads, so that bursts are turned away instead of thrashing the disk. Unlike
+// the rate limit of the server this counts requests writing data, not connections. A nil
+// limiter limits nothing.
+type uploadLimiter struct {
+	slots chan struct{}
+}
+
+// newUploadLimiter - limiter of at most maxUploads uploads in flight, nil if unlimited
+func newUploadLimiter(maxUploads int) *uploadLimiter {
+	if maxUploads <= 0 {
+		return nil
+	}
+	return &uploadLimiter{slots: make(chan struct{}, maxUploads)}
+}
+
+// acquire - take a slot without waiting for one, false if all of them are taken. Release has
+// to be called once the upload is done if true.
+func (l *uploadLimiter) acquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release - give back a slot taken by acquire
+func (l *uploadLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// writeSlowDownResponse - turn away an upload as there are too many in flight
+func writeSlowDownResponse(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(uploadRetryAfter/time.Second)))
+	writeErrorResponse(w, req, SlowDown, req.URL.Path)
+}
@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio/pkg/fs"
+	. "gopkg.in/check.v1"
+)
+
+type UploadLimiterSuite struct{}
+
+var _ = Suite(&UploadLimiterSuite{})
+
+func (s *UploadLimiterSuite) TestMaxUploads(c *C) {
+	c.Assert(newUploadLimiter(0), IsNil)
+
+	const maxUploads = 2
+	api := CloudStorageAPI{
+		ObjectAPI: fs.NewMemoryFS(),
+		Anonymous: true,
+		Uploads:   newUploadLimiter(maxUploads),
+	}
+	server := httptest.NewServer(getCloudStorageAPIHandler(api))
+	defer server.Close()
+
+	do := func(method, path string, body io.Reader, size int64) *http.Response {
+		request, err := http.NewRequest(method, server.URL+path, body)
+		c.Assert(err, IsNil)
+		request.ContentLength = size
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	response := do("PUT", "/uploads", nil, 0)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = do("POST", "/uploads/multipart?uploads", nil, 0)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	newMultipartUpload := InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&newMultipartUpload), IsNil)
+	uploadID := newMultipartUpload.UploadID
+
+	// uploads held in flight until their bodies are written
+	var writers []*io.PipeWriter
+	responses := make(chan *http.Response, maxUploads)
+	for i := 0; i < maxUploads; i++ {
+		reader, writer := io.Pipe()
+		writers = append(writers, writer)
+		go func(path string) {
+			responses <- do("PUT", path, reader, int64(len("hello world")))
+		}("/uploads/object" + strconv.Itoa(i))
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(api.Uploads.slots) < maxUploads; {
+		c.Assert(time.Now().Before(deadline), Equals, true)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// objects, parts and completions beyond the limit are turned away
+	response = do("PUT", "/uploads/object", bytes.NewReader([]byte("hello world")), int64(len("hello world")))
+	c.Assert(response.StatusCode, Equals, http.StatusServiceUnavailable)
+	c.Assert(response.Header.Get("Retry-After"), Equals, "1")
+	errorResponse := APIErrorResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&errorResponse), IsNil)
+	c.Assert(errorResponse.Code, Equals, "SlowDown")
+	response = do("PUT", "/uploads/multipart?uploadId="+uploadID+"&partNumber=1", bytes.NewReader([]byte("hello world")), int64(len("hello world")))
+	c.Assert(response.StatusCode, Equals, http.StatusServiceUnavailable)
+	response = do("POST", "/uploads/multipart?uploadId="+uploadID, bytes.NewReader(nil), 0)
+	c.Assert(response.StatusCode, Equals, http.StatusServiceUnavailable)
+
+	// uploads in flight complete, and free their slots
+	for _, writer := range writers {
+		_, err := writer.Write([]byte("hello world"))
+		c.Assert(err, IsNil)
+		c.Assert(writer.Close(), IsNil)
+	}
+	for i := 0; i < maxUploads; i++ {
+		c.Assert((<-responses).StatusCode, Equals, http.StatusOK)
+	}
+	response = do("PUT", "/uploads/multipart?uploadId="+uploadID+"&partNumber=1", bytes.NewReader([]byte("hello world")), int64(len("hello world")))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}